@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -103,6 +104,11 @@ func (s *Server) uploadFile(c *gin.Context) {
 	commitHash := c.PostForm("commit_hash")
 	commitMessage := c.PostForm("commit_message")
 
+	declaredHash := c.PostForm("content_hash")
+	if declaredHash == "" {
+		declaredHash = c.GetHeader("X-Content-Hash")
+	}
+
 	// Create upload request
 	uploadReq := &fileops.UploadRequest{
 		ProjectID:     projectID,
@@ -113,6 +119,7 @@ func (s *Server) uploadFile(c *gin.Context) {
 		Content:       file,
 		CommitHash:    commitHash,
 		CommitMessage: commitMessage,
+		DeclaredHash:  declaredHash,
 		Metadata: map[string]string{
 			"filename":     header.Filename,
 			"content-type": header.Header.Get("Content-Type"),
@@ -134,6 +141,11 @@ func (s *Server) uploadFile(c *gin.Context) {
 	result, err := s.fileOps.UploadFile(uploadReq)
 	if err != nil {
 		fmt.Printf("DEBUG: Upload failed: %v\n", err)
+		var quarantined *fileops.QuarantinedUploadError
+		if errors.As(err, &quarantined) {
+			s.rejectQuarantinedUpload(c, quarantined)
+			return
+		}
 		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
@@ -178,10 +190,17 @@ func (s *Server) downloadFile(c *gin.Context) {
 
 	// Set headers
 	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Length", strconv.FormatInt(result.Size, 10))
 	c.Header("Content-Hash", result.ContentHash)
 
+	// Objects never change, so interrupted downloads can resume with a Range request
+	if seeker, ok := result.Content.(io.ReadSeeker); ok {
+		c.Header("ETag", fmt.Sprintf("\"%s\"", result.ContentHash))
+		http.ServeContent(c.Writer, c.Request, "", time.Time{}, seeker)
+		return
+	}
+
 	// Stream content
+	c.Header("Content-Length", strconv.FormatInt(result.Size, 10))
 	if _, err := io.Copy(c.Writer, result.Content); err != nil {
 		// Log error but can't return JSON at this point
 		fmt.Printf("Error streaming file: %v\n", err)
@@ -257,6 +276,7 @@ func (s *Server) finalizeUpload(c *gin.Context) {
 		UserName      string            `json:"user_name"`
 		CommitHash    string            `json:"commit_hash"`
 		CommitMessage string            `json:"commit_message"`
+		ContentHash   string            `json:"content_hash"`
 		Metadata      map[string]string `json:"metadata"`
 	}
 
@@ -282,11 +302,17 @@ func (s *Server) finalizeUpload(c *gin.Context) {
 		SessionID:     req.SessionID,
 		CommitHash:    req.CommitHash,
 		CommitMessage: req.CommitMessage,
+		DeclaredHash:  req.ContentHash,
 		Metadata:      req.Metadata,
 	}
 
 	result, err := s.fileOps.FinalizeChunkedUpload(req.SessionID, req.TotalChunks, req.Metadata, uploadReq)
 	if err != nil {
+		var quarantined *fileops.QuarantinedUploadError
+		if errors.As(err, &quarantined) {
+			s.rejectQuarantinedUpload(c, quarantined)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -542,6 +568,12 @@ func (s *Server) getDependencyGraph(c *gin.Context) {
 	projectID := c.Param("project")
 	assetPath := c.Query("asset_path")
 
+	// Graph-wide analysis modes run against the project's current tree
+	if mode := c.Query("mode"); mode != "" {
+		s.analyzeDependencyGraph(c, projectID, mode, assetPath)
+		return
+	}
+
 	if assetPath == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "asset_path parameter required"})
 		return
@@ -570,6 +602,169 @@ func (s *Server) getDependencyGraph(c *gin.Context) {
 	})
 }
 
+// analyzeDependencyGraph serves the cycles, closure and offenders dependency analysis modes
+func (s *Server) analyzeDependencyGraph(c *gin.Context, projectID, mode, assetPath string) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "read") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	if mode != "cycles" && mode != "closure" && mode != "offenders" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be one of: cycles, closure, offenders"})
+		return
+	}
+
+	tree := make(map[string]string, len(project.Files))
+	sizes := make(map[string]int64, len(project.Files))
+	for _, file := range project.Files {
+		tree[file.Path] = file.ContentHash
+		sizes[file.Path] = file.Size
+	}
+
+	graph := s.fileOps.BuildDependencyGraph(tree, sizes)
+
+	switch mode {
+	case "cycles":
+		c.JSON(http.StatusOK, gin.H{
+			"success":        true,
+			"project":        projectID,
+			"mode":           mode,
+			"package_count":  graph.NodeCount(),
+			"cycles":         graph.Cycles(),
+			"circular_edges": graph.CircularDependencies(),
+		})
+
+	case "closure":
+		if assetPath == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "asset_path parameter required"})
+			return
+		}
+
+		node, ok := graph.Lookup(assetPath)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "asset not found in project"})
+			return
+		}
+
+		chain, _ := graph.LoadChain(node.PackageName)
+		c.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"project":      projectID,
+			"mode":         mode,
+			"asset_path":   assetPath,
+			"load_chain":   chain,
+			"dependencies": node.Dependencies,
+		})
+
+	case "offenders":
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil || limit <= 0 {
+			limit = 20
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":       true,
+			"project":       projectID,
+			"mode":          mode,
+			"package_count": graph.NodeCount(),
+			"offenders":     graph.WorstOffenders(limit),
+		})
+	}
+}
+
+// ImpactRequest lists the changed files to run impact analysis for
+type ImpactRequest struct {
+	FilePaths []string `json:"file_paths" binding:"required"`
+}
+
+// analyzeChangeImpact reports which assets transitively reference a pending change set
+func (s *Server) analyzeChangeImpact(c *gin.Context) {
+	projectID := c.Param("project")
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	var req ImpactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "read") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	// Resolve last modifiers to user names from the project's members
+	userNames := make(map[string]string)
+	if project.Owner != nil {
+		userNames[project.Owner.ID] = project.Owner.Username
+	}
+	for _, member := range project.Members {
+		userNames[member.UserID] = member.User.Username
+	}
+
+	tree := make(map[string]string, len(project.Files))
+	sizes := make(map[string]int64, len(project.Files))
+	owners := make(map[string]string)
+	for _, file := range project.Files {
+		tree[file.Path] = file.ContentHash
+		sizes[file.Path] = file.Size
+		if file.LastModifiedBy != nil {
+			owner := userNames[*file.LastModifiedBy]
+			if owner == "" {
+				owner = *file.LastModifiedBy
+			}
+			owners[file.Path] = owner
+		}
+	}
+
+	locks, err := s.fileOps.ListProjectLocks(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := s.fileOps.AnalyzeImpact(tree, sizes, req.FilePaths, owners, locks, userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"project": projectID,
+		"impact":  report,
+	})
+}
+
 func (s *Server) getTeamInsights(c *gin.Context) {
 	projectID := c.Param("project")
 	daysStr := c.DefaultQuery("days", "30")
@@ -986,7 +1181,8 @@ func (s *Server) storeFileMetadata(req *fileops.BatchUploadRequest) error {
 			ContentHash:    contentHash,
 			Size:           objectInfo.Size,
 			MimeType:       "application/octet-stream", // Default MIME type
-			Branch:         "main",                     // Default branch
+			Metadata:       s.fileOps.VersionMetadata(filePath, contentHash),
+			Branch:         "main", // Default branch
 			LastModifiedBy: &req.UserID,
 			LastModifiedAt: time.Now(),
 		}
@@ -1000,6 +1196,98 @@ func (s *Server) storeFileMetadata(req *fileops.BatchUploadRequest) error {
 	return nil
 }
 
+// uploadObject streams one object, named by its hash, into the store. It's verified on
+// its way in and quarantined instead of stored if it fails.
+func (s *Server) uploadObject(c *gin.Context) {
+	hash := c.Param("hash")
+	projectID := c.Query("project")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project ID required"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "write") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	// Objects another project uploaded are received again, so the content proves the upload
+	if s.projectHasObject(project.ID, hash) && s.storage.Exists(hash) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "content_hash": hash, "skipped": true})
+		return
+	}
+
+	filePath := c.Query("path")
+	if filePath == "" {
+		filePath = hash
+	}
+
+	result, err := s.fileOps.StoreObject(&fileops.UploadRequest{
+		ProjectID:    project.ID,
+		FilePath:     filePath,
+		UserID:       userID,
+		UserName:     c.GetString("user_name"),
+		SessionID:    c.GetString("session_id"),
+		DeclaredHash: hash,
+	}, c.Request.Body)
+	if err != nil {
+		var quarantined *fileops.QuarantinedUploadError
+		if errors.As(err, &quarantined) {
+			s.rejectQuarantinedUpload(c, quarantined)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.db.DB.Save(&models.ProjectObject{
+		ProjectID:   project.ID,
+		ContentHash: result.ContentHash,
+		UploadedBy:  userID,
+		CreatedAt:   time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record object"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"content_hash": result.ContentHash,
+		"size":         result.Size,
+	})
+}
+
+// projectHasObject reports whether an object was uploaded to the project or is the
+// content of one of its files
+func (s *Server) projectHasObject(projectID, hash string) bool {
+	var count int64
+	if s.db.DB.Model(&models.ProjectObject{}).Where("project_id = ? AND content_hash = ?", projectID, hash).Count(&count); count > 0 {
+		return true
+	}
+	if s.db.DB.Model(&models.FileVersion{}).Where("project_id = ? AND content_hash = ?", projectID, hash).Count(&count); count > 0 {
+		return true
+	}
+	s.db.DB.Model(&models.File{}).Where("project_id = ? AND content_hash = ?", projectID, hash).Count(&count)
+	return count > 0
+}
+
 // PHASE 1: New file existence check handler
 func (s *Server) checkFileExists(c *gin.Context) {
 	hash := c.Param("hash")
@@ -1007,6 +1295,20 @@ func (s *Server) checkFileExists(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "hash required"})
 		return
 	}
+	// With a project, only objects the project may use count
+	if projectID := c.Query("project"); projectID != "" {
+		userID := c.GetString("user_id")
+		project, err := database.NewProjectRepository(s.db.DB).GetProjectByID(projectID, userID)
+		if err != nil || !project.HasPermission(userID, "read") {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		if !s.projectHasObject(project.ID, hash) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+	}
+
 	// Check if file exists in storage
 	exists := s.storage.Exists(hash)
 	if !exists {
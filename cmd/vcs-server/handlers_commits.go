@@ -3,6 +3,7 @@ package main
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Telerallc/gamedev-vcs/database"
+	fileops "github.com/Telerallc/gamedev-vcs/internal/fileOps"
 	"github.com/Telerallc/gamedev-vcs/internal/version"
 	"github.com/Telerallc/gamedev-vcs/models"
 	"github.com/gin-gonic/gin"
@@ -22,9 +24,27 @@ type CreateCommitRequest struct {
 	Message       string   `json:"message" binding:"required"`
 	Branch        string   `json:"branch"`
 	FilePaths     []string `json:"file_paths"`
+	DeletedPaths  []string `json:"deleted_paths"`
 	ParentCommits []string `json:"parent_commits"`
 }
 
+// CheckReferencesRequest is a change set to validate against a branch without recording it
+type CheckReferencesRequest struct {
+	Branch       string            `json:"branch"`
+	Changes      map[string]string `json:"changes"` // file path -> content hash
+	DeletedPaths []string          `json:"deleted_paths"`
+}
+
+// Reference validation modes, stored in the project's "reference_validation" setting
+const (
+	referenceValidationReject = "reject"
+	referenceValidationWarn   = "warn"
+	referenceValidationOff    = "off"
+)
+
+// maxHistoryPage caps how many commits one history request returns
+const maxHistoryPage = 500
+
 func (s *Server) createCommit(c *gin.Context) {
 	projectID := c.Param("project")
 	if projectID == "" {
@@ -69,7 +89,7 @@ func (s *Server) createCommit(c *gin.Context) {
 	// FIXED: Work with object store and file paths instead of database files
 	var files []models.File
 
-	if len(req.FilePaths) == 0 {
+	if len(req.FilePaths) == 0 && len(req.DeletedPaths) == 0 {
 		// If no specific files provided, try to get files from the working directory
 		// This is a fallback - normally CLI should provide file paths
 		fileRepo := database.NewFileRepository(s.db.DB)
@@ -108,7 +128,7 @@ func (s *Server) createCommit(c *gin.Context) {
 		}
 	}
 
-	if len(files) == 0 {
+	if len(files) == 0 && len(req.DeletedPaths) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":  "no valid files found to commit",
 			"detail": "files may not exist or may not be uploaded to the server",
@@ -116,11 +136,34 @@ func (s *Server) createCommit(c *gin.Context) {
 		return
 	}
 
+	// Keep source art metadata (texture size, FBX contents) on the new file versions
+	for i := range files {
+		if meta := s.fileOps.VersionMetadata(files[i].Path, files[i].ContentHash); meta != nil {
+			files[i].Metadata = meta
+		}
+	}
+
+	// Pre-receive check: the commit must not break hard asset references
+	changes := make(map[string]string, len(files))
+	for _, file := range files {
+		changes[file.Path] = file.ContentHash
+	}
+	referenceCheck, allowed := s.checkAssetReferences(c, project, req.Branch, changes, req.DeletedPaths)
+	if !allowed {
+		return
+	}
+
 	// Create commit service
 	commitService := version.NewCommitService(s.db.DB)
 
+	// Deleted paths are recorded in the commit as files without content
+	commitFiles := files
+	for _, path := range req.DeletedPaths {
+		commitFiles = append(commitFiles, models.File{Path: path})
+	}
+
 	// Create the commit
-	commit, err := commitService.CreateCommit(projectID, userID, req.Message, files, req.ParentCommits)
+	commit, err := commitService.CreateCommit(projectID, userID, req.Message, commitFiles, req.ParentCommits)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -132,6 +175,13 @@ func (s *Server) createCommit(c *gin.Context) {
 		fmt.Printf("Warning: failed to update branch HEAD: %v\n", err)
 	}
 
+	// Drop deleted files from the project's current tree
+	if len(req.DeletedPaths) > 0 {
+		if err := s.db.Where("project_id = ? AND path IN ?", projectID, req.DeletedPaths).Delete(&models.File{}).Error; err != nil {
+			fmt.Printf("Warning: failed to remove deleted files: %v\n", err)
+		}
+	}
+
 	// Log the commit event
 	s.logCommitEvent("commit_created", projectID, commit.ID, userID, c.GetString("user_name"), map[string]interface{}{
 		"message":    req.Message,
@@ -139,14 +189,21 @@ func (s *Server) createCommit(c *gin.Context) {
 		"file_count": len(files),
 	})
 
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"success":         true,
 		"commit":          commit,
 		"files_committed": len(files),
-	})
+	}
+	if referenceCheck.HasViolations() {
+		response["reference_warnings"] = referenceCheck.Violations
+	}
+
+	c.JSON(http.StatusCreated, response)
 }
 
-// getCommitHistory retrieves commit history for a project/branch
+// getCommitHistory retrieves commit history for a project/branch, optionally starting
+// from given commits and filtered by author, date, message and path. Pages continue
+// from the next_cursor of the previous response.
 func (s *Server) getCommitHistory(c *gin.Context) {
 	projectID := c.Param("project")
 	if projectID == "" {
@@ -181,24 +238,198 @@ func (s *Server) getCommitHistory(c *gin.Context) {
 	branch := c.DefaultQuery("branch", project.DefaultBranch)
 	limitStr := c.DefaultQuery("limit", "50")
 	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
+	if err != nil || limit <= 0 {
 		limit = 50
 	}
+	if limit > maxHistoryPage {
+		limit = maxHistoryPage
+	}
+
+	query := version.HistoryQuery{
+		Branch: branch,
+		From:   c.QueryArray("from"),
+		Author: c.Query("author"),
+		Grep:   c.Query("grep"),
+		Paths:  c.QueryArray("path"),
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+	}
+	if query.Since, err = parseHistoryTime(c.Query("since")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid since: %v", err)})
+		return
+	}
+	if query.Until, err = parseHistoryTime(c.Query("until")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid until: %v", err)})
+		return
+	}
 
 	// Create commit service and get history
 	commitService := version.NewCommitService(s.db.DB)
-	commits, err := commitService.GetCommitHistory(projectID, branch, limit)
+	page, err := commitService.QueryHistory(projectID, query)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"commits": commits,
-		"branch":  branch,
-		"total":   len(commits),
+		"success":     true,
+		"commits":     page.Commits,
+		"branch":      branch,
+		"total":       len(page.Commits),
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// parseHistoryTime accepts RFC 3339 timestamps and plain dates; empty means no bound
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// PickCommitRequest is the payload for reverting or cherry-picking a commit
+type PickCommitRequest struct {
+	Branch  string `json:"branch"`
+	Message string `json:"message"`
+}
+
+// revertCommit commits the inverse of a commit on a branch
+func (s *Server) revertCommit(c *gin.Context) {
+	s.pickCommit(c, true)
+}
+
+// cherryPickCommit commits a commit's changes on another branch
+func (s *Server) cherryPickCommit(c *gin.Context) {
+	s.pickCommit(c, false)
+}
+
+// pickCommit reverts or cherry-picks a commit onto a branch. Files the branch changed
+// independently are conflicts and nothing is committed.
+func (s *Server) pickCommit(c *gin.Context, revert bool) {
+	projectID := c.Param("project")
+	commitID := c.Param("commit")
+	if projectID == "" || commitID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project ID and commit ID required"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	var req PickCommitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify user has write access to the project
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "write") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	if req.Branch == "" {
+		req.Branch = project.DefaultBranch
+	}
+
+	commitService := version.NewCommitService(s.db.DB)
+	var plan *version.PickPlan
+	if revert {
+		plan, err = commitService.PlanRevert(projectID, commitID, req.Branch)
+	} else {
+		plan, err = commitService.PlanCherryPick(projectID, commitID, req.Branch)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, version.ErrMergeCommit), errors.Is(err, version.ErrNothingToPick):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "commit not found"), strings.HasPrefix(err.Error(), "branch not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if plan.HasConflicts() {
+		c.JSON(http.StatusConflict, gin.H{
+			"success":   false,
+			"error":     fmt.Sprintf("%d files were changed on %s since the commit", len(plan.Conflicts), req.Branch),
+			"conflicts": plan.Conflicts,
+		})
+		return
+	}
+	if req.Message != "" {
+		plan.Message = req.Message
+	}
+
+	// The new versions must not break hard asset references either
+	changes := make(map[string]string, len(plan.Files))
+	var deletions []string
+	for _, file := range plan.Files {
+		if file.ContentHash == "" {
+			deletions = append(deletions, file.Path)
+			continue
+		}
+		changes[file.Path] = file.ContentHash
+	}
+	referenceCheck, allowed := s.checkAssetReferences(c, project, req.Branch, changes, deletions)
+	if !allowed {
+		return
+	}
+
+	commit, err := commitService.ApplyPick(projectID, userID, plan)
+	if err != nil {
+		if errors.Is(err, version.ErrBranchMoved) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	eventType := "commit_cherry_picked"
+	if revert {
+		eventType = "commit_reverted"
+	}
+	s.logCommitEvent(eventType, projectID, commit.ID, userID, c.GetString("user_name"), map[string]interface{}{
+		"source_commit": plan.Source.ID,
+		"branch":        req.Branch,
+		"file_count":    len(plan.Files),
 	})
+
+	response := gin.H{
+		"success":         true,
+		"commit":          commit,
+		"branch":          req.Branch,
+		"files_committed": len(plan.Files),
+	}
+	if referenceCheck.HasViolations() {
+		response["reference_warnings"] = referenceCheck.Violations
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // getCommit retrieves a specific commit by ID
@@ -254,10 +485,62 @@ func (s *Server) getCommit(c *gin.Context) {
 	})
 }
 
+// getCommitTree returns every file visible at a commit, for checkouts and clones
+func (s *Server) getCommitTree(c *gin.Context) {
+	projectID := c.Param("project")
+	commitID := c.Param("commit")
+
+	if projectID == "" || commitID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project ID and commit ID required"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	// Verify user has read access to the project
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "read") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	if !s.commitExists(projectID, commitID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "commit not found in project"})
+		return
+	}
+
+	commitService := version.NewCommitService(s.db.DB)
+	files, err := commitService.GetTreeAtCommit(commitID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"commit_id": commitID,
+		"files":     files,
+	})
+}
+
 // getFileHistory retrieves the history of a specific file
 func (s *Server) getFileHistory(c *gin.Context) {
 	projectID := c.Param("project")
-	filePath := c.Param("file")
+	filePath := strings.TrimPrefix(c.Param("file"), "/") // wildcard params keep their leading slash
 
 	if projectID == "" || filePath == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "project ID and file path required"})
@@ -346,6 +629,11 @@ func (s *Server) diffCommits(c *gin.Context) {
 		return
 	}
 
+	if !s.commitExists(projectID, fromCommit) || !s.commitExists(projectID, toCommit) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "commit not found in project"})
+		return
+	}
+
 	// Get diff
 	commitService := version.NewCommitService(s.db.DB)
 	diff, err := commitService.DiffCommits(fromCommit, toCommit)
@@ -360,6 +648,101 @@ func (s *Server) diffCommits(c *gin.Context) {
 	})
 }
 
+// semanticDiff compares two revisions of a Blueprint by its variables, functions and graphs,
+// or of a DataTable/CurveTable row by row when kind=table.
+// When "to" is omitted the project's current version of the file is used.
+func (s *Server) semanticDiff(c *gin.Context) {
+	projectID := c.Param("project")
+	fromCommit := c.Query("from")
+	toCommit := c.Query("to")
+	filePath := c.Query("path")
+
+	if projectID == "" || fromCommit == "" || filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "project ID, from commit, and file path required",
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	// Verify user has read access to the project
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "read") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	fromHash, toHash := s.resolveRevisionHashes(project, fromCommit, toCommit, filePath)
+	if fromHash == "" && toHash == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found in either revision"})
+		return
+	}
+
+	var diff interface{ HasChanges() bool }
+	if c.Query("kind") == "table" {
+		diff, err = s.fileOps.DiffDataTable(filePath, fromHash, toHash)
+	} else {
+		diff, err = s.fileOps.DiffBlueprint(filePath, fromHash, toHash)
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"path":        filePath,
+		"from":        fromCommit,
+		"to":          toCommit,
+		"from_hash":   fromHash,
+		"to_hash":     toHash,
+		"has_changes": diff.HasChanges(),
+		"diff":        diff,
+	})
+}
+
+// resolveRevisionHashes returns the content hash of a file at two commits; an empty
+// toCommit selects the project's current version. Missing files resolve to "".
+func (s *Server) resolveRevisionHashes(project *models.Project, fromCommit, toCommit, filePath string) (string, string) {
+	commitService := version.NewCommitService(s.db.DB)
+
+	fromHash := ""
+	if fileVersion, err := commitService.GetFileAsOfCommit(fromCommit, filePath); err == nil {
+		fromHash = fileVersion.ContentHash
+	}
+
+	toHash := ""
+	if toCommit != "" {
+		if fileVersion, err := commitService.GetFileAsOfCommit(toCommit, filePath); err == nil {
+			toHash = fileVersion.ContentHash
+		}
+	} else {
+		for _, file := range project.Files {
+			if file.Path == filePath {
+				toHash = file.ContentHash
+				break
+			}
+		}
+	}
+
+	return fromHash, toHash
+}
+
 // Helper function to log commit events
 func (s *Server) logCommitEvent(eventType, projectID, commitID, userID, userName string, metadata map[string]interface{}) {
 	// Add standard metadata
@@ -372,6 +755,130 @@ func (s *Server) logCommitEvent(eventType, projectID, commitID, userID, userName
 	s.logFileEvent(eventType, projectID, "", userID, userName, metadata)
 }
 
+// checkReferences reports the hard asset references a change set would break on a branch.
+// Clients call it after committing locally so broken references show up before the push.
+func (s *Server) checkReferences(c *gin.Context) {
+	projectID := c.Param("project")
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	var req CheckReferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Branch == "" {
+		req.Branch = "main"
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "read") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	mode := referenceValidationMode(project)
+	if mode == referenceValidationOff {
+		c.JSON(http.StatusOK, gin.H{"success": true, "mode": mode})
+		return
+	}
+
+	currentTree, err := s.branchTree(project.ID, req.Branch)
+	if err != nil {
+		currentTree = make(map[string]string) // a branch that isn't on the server yet starts empty
+	}
+	result := s.fileOps.ValidateReferences(currentTree, req.Changes, req.DeletedPaths)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":            true,
+		"mode":               mode,
+		"reference_warnings": result.Violations,
+		"unverified":         result.Unverified,
+	})
+}
+
+// checkAssetReferences validates hard asset references of a change set against the
+// tree the target branch will have after the push. It writes an error response and
+// returns false when the project rejects broken references or the branch can't be read.
+func (s *Server) checkAssetReferences(c *gin.Context, project *models.Project, branch string, changes map[string]string, deletions []string) (*fileops.ReferenceCheckResult, bool) {
+	mode := referenceValidationMode(project)
+	if mode == referenceValidationOff {
+		return nil, true
+	}
+
+	currentTree, err := s.branchTree(project.ID, branch)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	result := s.fileOps.ValidateReferences(currentTree, changes, deletions)
+	if !result.HasViolations() {
+		return result, true
+	}
+
+	s.logFileEvent("reference_violation", project.ID, "", c.GetString("user_id"), c.GetString("user_name"), map[string]interface{}{
+		"mode":       mode,
+		"violations": len(result.Violations),
+	})
+
+	if mode == referenceValidationWarn {
+		return result, true
+	}
+
+	c.JSON(http.StatusConflict, gin.H{
+		"success":         false,
+		"error":           "push rejected: change set breaks hard asset references",
+		"reference_check": result,
+	})
+	return result, false
+}
+
+// branchTree maps every file visible at the head of a branch to its content hash
+func (s *Server) branchTree(projectID, branchName string) (map[string]string, error) {
+	var branch models.Branch
+	if err := s.db.Where("project_id = ? AND name = ?", projectID, branchName).First(&branch).Error; err != nil {
+		return nil, fmt.Errorf("branch not found: %s", branchName)
+	}
+
+	tree := make(map[string]string)
+	if branch.LastCommit == "" {
+		return tree, nil
+	}
+	files, err := version.NewCommitService(s.db.DB).GetTreeAtCommit(branch.LastCommit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the tree of %s: %w", branchName, err)
+	}
+	for _, file := range files {
+		tree[file.Path] = file.ContentHash
+	}
+	return tree, nil
+}
+
+// referenceValidationMode returns the project's reference validation mode (reject by default)
+func referenceValidationMode(project *models.Project) string {
+	if mode, ok := project.Settings["reference_validation"].(string); ok {
+		switch mode {
+		case referenceValidationReject, referenceValidationWarn, referenceValidationOff:
+			return mode
+		}
+	}
+	return referenceValidationReject
+}
+
 // Helper function to create a File model from a file path
 func (s *Server) createFileFromPath(projectID, filePath, userID string) (*models.File, error) {
 	// Try to find the file in the object store first
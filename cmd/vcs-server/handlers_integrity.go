@@ -0,0 +1,877 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Telerallc/gamedev-vcs/database"
+	fileops "github.com/Telerallc/gamedev-vcs/internal/fileOps"
+	"github.com/Telerallc/gamedev-vcs/internal/integrity"
+	"github.com/Telerallc/gamedev-vcs/internal/state"
+	"github.com/Telerallc/gamedev-vcs/internal/storage"
+	"github.com/Telerallc/gamedev-vcs/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ReportCorruptionRequest represents a corruption event reported by a client
+type ReportCorruptionRequest struct {
+	CorruptionType  string   `json:"corruption_type" binding:"required"`
+	Severity        string   `json:"severity" binding:"required"`
+	AffectedAssets  []string `json:"affected_assets" binding:"required"`
+	RootCause       string   `json:"root_cause"`
+	DetectionMethod string   `json:"detection_method"`
+	AutoRecovered   bool     `json:"auto_recovered"`
+	RecoveryMethod  string   `json:"recovery_method"`
+}
+
+// corruptionAlertSettings is the project's "corruption_alerts" setting. Webhook signing
+// secrets aren't part of it: settings are visible to every member, so secrets are kept as
+// project secrets and set through setWebhookSecret.
+type corruptionAlertSettings struct {
+	EventStream struct {
+		MinSeverity string   `json:"min_severity"`
+		Paths       []string `json:"paths"`
+	} `json:"event_stream"`
+	Webhooks []struct {
+		Name        string   `json:"name"`
+		URL         string   `json:"url"`
+		MinSeverity string   `json:"min_severity"`
+		Paths       []string `json:"paths"`
+	} `json:"webhooks"`
+}
+
+// Alert sinks every project has
+const (
+	alertSinkLog         = "log"
+	alertSinkEventStream = "event_stream"
+)
+
+func (s *Server) reportCorruption(c *gin.Context) {
+	projectID := c.Param("project")
+
+	var req ReportCorruptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	severity := integrity.CorruptionSeverity(req.Severity)
+	if integrity.SeverityRank(severity) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "severity must be one of: low, medium, high, critical"})
+		return
+	}
+	if len(req.AffectedAssets) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one affected asset required"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "write") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	event := &integrity.CorruptionEvent{
+		EventID:         fmt.Sprintf("corruption_%d", time.Now().UnixNano()),
+		Timestamp:       time.Now(),
+		CorruptionType:  integrity.CorruptionType(req.CorruptionType),
+		Severity:        severity,
+		AffectedAssets:  req.AffectedAssets,
+		RootCause:       req.RootCause,
+		DetectionMethod: req.DetectionMethod,
+		UserReported:    true,
+		AutoRecovered:   req.AutoRecovered,
+		RecoveryMethod:  req.RecoveryMethod,
+	}
+
+	if err := s.routeCorruptionAlert(project, event, userID, c.GetString("user_name")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "event": event})
+		return
+	}
+
+	// Event stream and webhook deliveries land in the alert log's delivered_to as they finish
+	c.JSON(http.StatusCreated, gin.H{
+		"success":      true,
+		"event":        event,
+		"delivered_to": event.TeamNotified,
+	})
+}
+
+func (s *Server) listCorruptionAlerts(c *gin.Context) {
+	projectID := c.Param("project")
+	severity := c.Query("severity")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "read") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	query := s.db.DB.Where("project_id = ?", project.ID)
+	if severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+
+	var alerts []models.CorruptionAlert
+	if err := query.Order("created_at DESC").Limit(limit).Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load corruption alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"alerts":  alerts,
+		"count":   len(alerts),
+	})
+}
+
+// routeCorruptionAlert delivers a corruption event through the project's alert routes:
+// every event is written to the alert log before this returns, while the collaboration
+// event stream (medium and above) and webhooks (whatever their severity and path filters
+// select) are delivered in the background so a slow receiver doesn't hold up the caller.
+func (s *Server) routeCorruptionAlert(project *models.Project, event *integrity.CorruptionEvent, userID, userName string) error {
+	if err := s.logCorruptionAlert(project, event, userID); err != nil {
+		return fmt.Errorf("failed to record corruption alert: %w", err)
+	}
+	event.TeamNotified = append(event.TeamNotified, alertSinkLog)
+
+	alerts := s.corruptionAlertSystem(project, userID, userName)
+	delivery := *event
+	delivery.TeamNotified = append([]string{}, event.TeamNotified...)
+	go func() {
+		if err := alerts.SendAlert(&delivery); err != nil {
+			fmt.Printf("Failed to deliver corruption alert %s: %v\n", delivery.EventID, err)
+		}
+
+		// Record where the event went once the background sinks have run
+		delivered := make([]interface{}, len(delivery.TeamNotified))
+		for i, sink := range delivery.TeamNotified {
+			delivered[i] = sink
+		}
+		if err := s.db.DB.Model(&models.CorruptionAlert{}).
+			Where("event_id = ?", delivery.EventID).
+			Update("delivered_to", models.JSON{"sinks": delivered}).Error; err != nil {
+			fmt.Printf("Failed to update corruption alert %s: %v\n", delivery.EventID, err)
+		}
+	}()
+
+	return nil
+}
+
+// logCorruptionAlert writes an event to the project's alert log
+func (s *Server) logCorruptionAlert(project *models.Project, event *integrity.CorruptionEvent, userID string) error {
+	assets := make([]interface{}, len(event.AffectedAssets))
+	for i, asset := range event.AffectedAssets {
+		assets[i] = asset
+	}
+	return s.db.DB.Create(&models.CorruptionAlert{
+		ID:             event.EventID,
+		ProjectID:      project.ID,
+		EventID:        event.EventID,
+		Severity:       string(event.Severity),
+		CorruptionType: string(event.CorruptionType),
+		AffectedAssets: models.JSON{"paths": assets},
+		Details: models.JSON{
+			"root_cause":       event.RootCause,
+			"detection_method": event.DetectionMethod,
+			"user_reported":    event.UserReported,
+			"auto_recovered":   event.AutoRecovered,
+			"recovery_method":  event.RecoveryMethod,
+		},
+		DeliveredTo: models.JSON{"sinks": []interface{}{alertSinkLog}},
+		ReportedBy:  userID,
+		CreatedAt:   event.Timestamp,
+	}).Error
+}
+
+// corruptionAlertSystem builds the background alert sinks and routes configured for a project
+func (s *Server) corruptionAlertSystem(project *models.Project, userID, userName string) *integrity.CorruptionAlertSystem {
+	settings := loadCorruptionAlertSettings(project)
+	alerts := integrity.NewCorruptionAlertSystem()
+
+	alerts.AddSink(alertSinkEventStream, integrity.AlertSinkFunc(func(event integrity.CorruptionEvent) error {
+		filePath := ""
+		if len(event.AffectedAssets) > 0 {
+			filePath = event.AffectedAssets[0]
+		}
+		return s.stateManager.PublishEvent(&state.CollaborationEvent{
+			EventID:   event.EventID,
+			Type:      state.EventCorruptionDetected,
+			UserID:    userID,
+			UserName:  userName,
+			ProjectID: project.ID,
+			FilePath:  filePath,
+			Timestamp: event.Timestamp,
+			Data: map[string]interface{}{
+				"severity":        event.Severity,
+				"corruption_type": event.CorruptionType,
+				"affected_assets": event.AffectedAssets,
+				"auto_recovered":  event.AutoRecovered,
+			},
+		})
+	}))
+	alerts.AddRoute(integrity.AlertRoute{
+		Sink:        alertSinkEventStream,
+		MinSeverity: severityOrDefault(settings.EventStream.MinSeverity, integrity.CorruptionSeverityMedium),
+		Paths:       settings.EventStream.Paths,
+	})
+
+	secrets := make(map[string]string)
+	if len(settings.Webhooks) > 0 {
+		var stored []models.ProjectSecret
+		if err := s.db.DB.Where("project_id = ?", project.ID).Find(&stored).Error; err != nil {
+			fmt.Printf("Failed to load secrets of project %s: %v\n", project.ID, err)
+		}
+		for _, secret := range stored {
+			secrets[secret.Name] = secret.Value
+		}
+	}
+
+	for i, webhook := range settings.Webhooks {
+		if webhook.URL == "" {
+			continue
+		}
+		name := models.WebhookSecretName(webhook.Name, i+1)
+
+		sink, err := integrity.NewWebhookSink(webhook.URL, secrets[name], project.ID)
+		if err != nil {
+			fmt.Printf("Skipping %s of project %s: %v\n", name, project.ID, err)
+			continue
+		}
+		alerts.AddSink(name, sink)
+		alerts.AddRoute(integrity.AlertRoute{
+			Sink:        name,
+			MinSeverity: severityOrDefault(webhook.MinSeverity, integrity.CorruptionSeverityHigh),
+			Paths:       webhook.Paths,
+		})
+	}
+
+	return alerts
+}
+
+// loadCorruptionAlertSettings decodes the project's "corruption_alerts" setting,
+// falling back to the defaults when it's absent or malformed
+func loadCorruptionAlertSettings(project *models.Project) corruptionAlertSettings {
+	var settings corruptionAlertSettings
+
+	raw, ok := project.Settings["corruption_alerts"]
+	if !ok || raw == nil {
+		return settings
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return settings
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		fmt.Printf("Invalid corruption_alerts settings for project %s: %v\n", project.ID, err)
+		return corruptionAlertSettings{}
+	}
+	return settings
+}
+
+// SetWebhookSecretRequest sets the signing secret of a corruption alert webhook
+type SetWebhookSecretRequest struct {
+	Secret string `json:"secret"` // empty removes the secret
+}
+
+// setWebhookSecret stores the signing secret of one of the project's corruption alert
+// webhooks, named as in its settings. Secrets are write-only: no response includes them.
+func (s *Server) setWebhookSecret(c *gin.Context) {
+	projectID := c.Param("project")
+	webhookName := c.Param("name")
+
+	var req SetWebhookSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	name := ""
+	for i, webhook := range loadCorruptionAlertSettings(project).Webhooks {
+		if candidate := models.WebhookSecretName(webhook.Name, i+1); candidate == models.WebhookSecretName(webhookName, 0) {
+			name = candidate
+			break
+		}
+	}
+	if name == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no corruption alert webhook named %s", webhookName)})
+		return
+	}
+
+	if req.Secret == "" {
+		err = s.db.DB.Where("project_id = ? AND name = ?", project.ID, name).Delete(&models.ProjectSecret{}).Error
+	} else {
+		err = s.db.DB.Save(&models.ProjectSecret{
+			ProjectID: project.ID,
+			Name:      name,
+			Value:     req.Secret,
+			UpdatedBy: userID,
+			UpdatedAt: time.Now(),
+		}).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store webhook secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"webhook": webhookName,
+		"secret":  req.Secret != "",
+	})
+}
+
+func severityOrDefault(value string, fallback integrity.CorruptionSeverity) integrity.CorruptionSeverity {
+	severity := integrity.CorruptionSeverity(value)
+	if integrity.SeverityRank(severity) == 0 {
+		return fallback
+	}
+	return severity
+}
+
+func (s *Server) runFsck(c *gin.Context) {
+	projectID := c.Param("project")
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	run := &models.FsckRun{
+		ID:        fmt.Sprintf("fsck_%d", time.Now().UnixNano()),
+		ProjectID: project.ID,
+		Status:    "running",
+		StartedBy: userID,
+		StartedAt: time.Now(),
+	}
+	if err := s.db.DB.Create(run).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start fsck"})
+		return
+	}
+
+	// Re-hashing a project's content can take a long time, so it runs off the request path
+	go s.executeFsck(run, project, userID, c.GetString("user_name"))
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"run":     run,
+	})
+}
+
+// getFsckRun returns the status and, once finished, the reports of an fsck run
+func (s *Server) getFsckRun(c *gin.Context) {
+	projectID := c.Param("project")
+	runID := c.Param("run")
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	var run models.FsckRun
+	if err := s.db.DB.Where("id = ? AND project_id = ?", runID, project.ID).First(&run).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fsck run not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"run":     run,
+	})
+}
+
+// executeFsck verifies the content a project references and walks its history, recording the result on the run
+func (s *Server) executeFsck(run *models.FsckRun, project *models.Project, userID, userName string) {
+	finish := func(fields map[string]interface{}) {
+		finishedAt := time.Now()
+		fields["finished_at"] = &finishedAt
+		if err := s.db.DB.Model(run).Updates(fields).Error; err != nil {
+			fmt.Printf("Failed to record fsck run %s: %v\n", run.ID, err)
+		}
+	}
+
+	hashes, err := s.projectContentHashes(project)
+	if err != nil {
+		finish(map[string]interface{}{"status": "failed", "error": err.Error()})
+		return
+	}
+
+	contentReport := s.storage.VerifyHashes(hashes)
+
+	corrupt := make(map[string]bool)
+	for _, issue := range contentReport.Issues {
+		if issue.Kind == storage.FsckCorruptObject && issue.Object != "" {
+			corrupt[issue.Object] = true
+		}
+	}
+
+	historyReport, err := s.checkProjectHistory(project, corrupt)
+	if err != nil {
+		finish(map[string]interface{}{"status": "failed", "error": err.Error()})
+		return
+	}
+
+	s.alertDamagedFiles(project, historyReport, userID, userName)
+
+	finish(map[string]interface{}{
+		"status":  "completed",
+		"healthy": !contentReport.HasErrors() && !historyReport.HasErrors(),
+		"reports": models.JSON{
+			"content": contentReport,
+			"history": historyReport,
+		},
+	})
+}
+
+// projectContentHashes returns the distinct content hashes referenced by a project's trees and current files
+func (s *Server) projectContentHashes(project *models.Project) ([]string, error) {
+	var trees []models.CommitTree
+	if err := s.db.DB.Where("project_id = ?", project.ID).Find(&trees).Error; err != nil {
+		return nil, fmt.Errorf("failed to load commit trees: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var hashes []string
+	add := func(hash string) {
+		if hash != "" && !seen[hash] {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+	for _, tree := range trees {
+		for _, file := range tree.Files {
+			if !file.IsDeletion() {
+				add(file.ContentHash)
+			}
+		}
+	}
+	for _, file := range project.Files {
+		add(file.ContentHash)
+	}
+	return hashes, nil
+}
+
+// checkProjectHistory walks every ref of a project through its commits and trees,
+// checking that each file version's content exists in the content store and isn't corrupt
+func (s *Server) checkProjectHistory(project *models.Project, corrupt map[string]bool) (*storage.FsckReport, error) {
+	startTime := time.Now()
+	report := storage.NewFsckReport()
+
+	var commits []models.Commit
+	if err := s.db.DB.Where("project_id = ?", project.ID).Find(&commits).Error; err != nil {
+		return nil, fmt.Errorf("failed to load commits: %w", err)
+	}
+	var trees []models.CommitTree
+	if err := s.db.DB.Where("project_id = ?", project.ID).Find(&trees).Error; err != nil {
+		return nil, fmt.Errorf("failed to load commit trees: %w", err)
+	}
+	var refs []models.Ref
+	if err := s.db.DB.Where("project_id = ?", project.ID).Find(&refs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load refs: %w", err)
+	}
+	var branches []models.Branch
+	if err := s.db.DB.Where("project_id = ?", project.ID).Find(&branches).Error; err != nil {
+		return nil, fmt.Errorf("failed to load branches: %w", err)
+	}
+
+	commitsByID := make(map[string]*models.Commit, len(commits))
+	for i := range commits {
+		commitsByID[commits[i].ID] = &commits[i]
+	}
+	treesByID := make(map[string]*models.CommitTree, len(trees))
+	for i := range trees {
+		treesByID[trees[i].ID] = &trees[i]
+	}
+
+	for _, ref := range refs {
+		if ref.CommitID != "" {
+			report.Refs[ref.Name] = ref.CommitID
+		}
+	}
+	for _, branch := range branches {
+		name := "refs/heads/" + branch.Name
+		if _, exists := report.Refs[name]; !exists && branch.LastCommit != "" {
+			report.Refs[name] = branch.LastCommit
+		}
+	}
+
+	checkContent := func(hash, filePath, referencedBy string) {
+		switch {
+		case corrupt[hash]:
+			report.Add(storage.FsckIssue{Kind: storage.FsckCorruptObject, Object: hash, Type: "blob", Path: filePath,
+				Detail: fmt.Sprintf("content of %s in %s is corrupt", filePath, referencedBy)})
+		case !s.storage.Exists(hash):
+			report.Add(storage.FsckIssue{Kind: storage.FsckMissingObject, Object: hash, Type: "blob", Path: filePath,
+				Detail: fmt.Sprintf("content of %s in %s is missing", filePath, referencedBy)})
+		}
+	}
+
+	type pending struct{ id, from string }
+	var queue []pending
+	for name, commitID := range report.Refs {
+		queue = append(queue, pending{commitID, name})
+	}
+
+	reachable := make(map[string]bool)
+	checkedTrees := make(map[string]bool)
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if reachable[next.id] {
+			continue
+		}
+		reachable[next.id] = true
+
+		commit, exists := commitsByID[next.id]
+		if !exists {
+			report.Add(storage.FsckIssue{Kind: storage.FsckMissingObject, Object: next.id, Type: "commit", Path: next.from,
+				Detail: fmt.Sprintf("commit referenced by %s is missing", next.from)})
+			continue
+		}
+		report.CommitsWalked++
+		for _, parent := range commit.ParentIDs {
+			queue = append(queue, pending{parent, "parent of " + commit.ID})
+		}
+
+		if checkedTrees[commit.TreeHash] {
+			continue
+		}
+		checkedTrees[commit.TreeHash] = true
+
+		tree, exists := treesByID[commit.TreeHash]
+		if !exists {
+			report.Add(storage.FsckIssue{Kind: storage.FsckMissingObject, Object: commit.TreeHash, Type: "tree", Path: commit.ID,
+				Detail: fmt.Sprintf("tree of commit %s is missing", commit.ID)})
+			continue
+		}
+		report.TreesWalked++
+		for _, file := range tree.Files {
+			if file.IsDeletion() {
+				continue
+			}
+			report.ObjectsChecked++
+			checkContent(file.ContentHash, file.Path, "commit "+commit.ID)
+		}
+	}
+
+	for _, file := range project.Files {
+		if file.ContentHash == "" {
+			continue
+		}
+		report.ObjectsChecked++
+		checkContent(file.ContentHash, file.Path, "the current project files")
+	}
+
+	for _, commit := range commits {
+		if !reachable[commit.ID] {
+			report.Add(storage.FsckIssue{Kind: storage.FsckDanglingObject, Object: commit.ID, Type: "commit",
+				Detail: "commit isn't reachable from any ref"})
+		}
+	}
+
+	report.Duration = time.Since(startTime)
+	return report, nil
+}
+
+// alertDamagedFiles raises a critical corruption alert for project files whose content fsck found corrupt or missing
+func (s *Server) alertDamagedFiles(project *models.Project, report *storage.FsckReport, userID, userName string) {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, issue := range report.Issues {
+		if issue.Type != "blob" || (issue.Kind != storage.FsckCorruptObject && issue.Kind != storage.FsckMissingObject) {
+			continue
+		}
+		if !seen[issue.Path] {
+			seen[issue.Path] = true
+			paths = append(paths, issue.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	event := &integrity.CorruptionEvent{
+		EventID:         fmt.Sprintf("fsck_%d", time.Now().UnixNano()),
+		Timestamp:       time.Now(),
+		CorruptionType:  integrity.CorruptionContent,
+		Severity:        integrity.CorruptionSeverityCritical,
+		AffectedAssets:  paths,
+		RootCause:       fmt.Sprintf("fsck found %d corrupt and %d missing objects in the server store", report.Count(storage.FsckCorruptObject), report.Count(storage.FsckMissingObject)),
+		DetectionMethod: "fsck",
+	}
+	if err := s.routeCorruptionAlert(project, event, userID, userName); err != nil {
+		fmt.Printf("Failed to deliver fsck corruption alert for project %s: %v\n", project.ID, err)
+	}
+}
+
+// rejectQuarantinedUpload answers an upload that failed verification and alerts the project
+func (s *Server) rejectQuarantinedUpload(c *gin.Context, quarantined *fileops.QuarantinedUploadError) {
+	s.alertQuarantinedUpload(quarantined.Record.ID, c.GetString("user_name"))
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":         "upload failed verification and was quarantined",
+		"quarantine_id": quarantined.Record.ID,
+		"file_path":     quarantined.Record.FilePath,
+		"declared_hash": quarantined.Record.DeclaredHash,
+		"actual_hash":   quarantined.Record.ActualHash,
+		"reasons":       quarantined.Record.Reasons,
+	})
+}
+
+// alertQuarantinedUpload routes a corruption alert for a quarantined upload
+func (s *Server) alertQuarantinedUpload(id, userName string) {
+	record, err := s.storage.GetQuarantined(id)
+	if err != nil {
+		fmt.Printf("Failed to load quarantine record %s: %v\n", id, err)
+		return
+	}
+
+	var project models.Project
+	if err := s.db.DB.Where("id = ?", record.ProjectID).First(&project).Error; err != nil {
+		fmt.Printf("Failed to load project %s for quarantine alert: %v\n", record.ProjectID, err)
+		return
+	}
+
+	event := &integrity.CorruptionEvent{
+		EventID:         fmt.Sprintf("quarantine_%s", record.ID),
+		Timestamp:       record.QuarantinedAt,
+		CorruptionType:  integrity.CorruptionContent,
+		Severity:        integrity.CorruptionSeverityHigh,
+		AffectedAssets:  []string{record.FilePath},
+		RootCause:       strings.Join(record.Reasons, "; "),
+		DetectionMethod: "upload_verification",
+	}
+	if err := s.routeCorruptionAlert(&project, event, record.UserID, userName); err != nil {
+		fmt.Printf("Failed to deliver quarantine alert for project %s: %v\n", project.ID, err)
+	}
+}
+
+// quarantineAdminProject loads the project and checks the caller may administer its quarantine
+func (s *Server) quarantineAdminProject(c *gin.Context) (*models.Project, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return nil, false
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(c.Param("project"), userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return nil, false
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return nil, false
+	}
+
+	if !project.HasPermission(userID, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return nil, false
+	}
+	return project, true
+}
+
+// quarantinedRecord loads a quarantine record belonging to the project
+func (s *Server) quarantinedRecord(c *gin.Context, project *models.Project) (*storage.QuarantineRecord, bool) {
+	record, err := s.storage.GetQuarantined(c.Param("id"))
+	if err != nil || record.ProjectID != project.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "quarantined upload not found"})
+		return nil, false
+	}
+	return record, true
+}
+
+func (s *Server) listQuarantine(c *gin.Context) {
+	project, ok := s.quarantineAdminProject(c)
+	if !ok {
+		return
+	}
+
+	records, err := s.storage.ListQuarantined(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"quarantined": records,
+		"count":       len(records),
+	})
+}
+
+func (s *Server) inspectQuarantine(c *gin.Context) {
+	project, ok := s.quarantineAdminProject(c)
+	if !ok {
+		return
+	}
+	record, ok := s.quarantinedRecord(c, project)
+	if !ok {
+		return
+	}
+
+	content, err := s.storage.ReadQuarantined(record.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	header := content
+	if len(header) > 64 {
+		header = header[:64]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"record":        record,
+		"header_hex":    hex.EncodeToString(header),
+		"already_live":  s.storage.Exists(record.ActualHash),
+		"declared_live": record.DeclaredHash != "" && s.storage.Exists(record.DeclaredHash),
+	})
+}
+
+func (s *Server) releaseQuarantine(c *gin.Context) {
+	project, ok := s.quarantineAdminProject(c)
+	if !ok {
+		return
+	}
+	record, ok := s.quarantinedRecord(c, project)
+	if !ok {
+		return
+	}
+
+	stats, err := s.storage.ReleaseQuarantined(record.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"released":     record.ID,
+		"file_path":    record.FilePath,
+		"content_hash": stats.Hash,
+		"size":         stats.Size,
+		"released_by":  c.GetString("user_id"),
+	})
+}
+
+func (s *Server) purgeQuarantine(c *gin.Context) {
+	project, ok := s.quarantineAdminProject(c)
+	if !ok {
+		return
+	}
+	record, ok := s.quarantinedRecord(c, project)
+	if !ok {
+		return
+	}
+
+	if err := s.storage.PurgeQuarantined(record.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"purged":  record.ID,
+	})
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Telerallc/gamedev-vcs/database"
+	"github.com/Telerallc/gamedev-vcs/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateShelfRequest represents a request to shelve uncommitted work. The objects of the
+// files must already be on the server, sent through the batch upload.
+type CreateShelfRequest struct {
+	Name       string             `json:"name"`
+	Branch     string             `json:"branch"`
+	BaseCommit string             `json:"base_commit"`
+	Files      []models.ShelfFile `json:"files" binding:"required"`
+}
+
+// shelfProject loads the project of a shelf request and checks the caller's permission
+func (s *Server) shelfProject(c *gin.Context, permission string) (*models.Project, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return nil, false
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(c.Param("project"), userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return nil, false
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return nil, false
+	}
+
+	if !project.HasPermission(userID, permission) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return nil, false
+	}
+	return project, true
+}
+
+// projectShelf loads a shelf of the project. Only its owner and project admins (leads
+// reviewing someone's work) may open or delete it.
+func (s *Server) projectShelf(c *gin.Context, project *models.Project) (*models.Shelf, bool) {
+	var shelf models.Shelf
+	if err := s.db.Preload("User").Where("id = ? AND project_id = ?", c.Param("shelf"), project.ID).First(&shelf).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shelf not found"})
+		return nil, false
+	}
+
+	userID := c.GetString("user_id")
+	if shelf.UserID != userID && !project.HasPermission(userID, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner of the shelf or a project admin can use it"})
+		return nil, false
+	}
+	return &shelf, true
+}
+
+// createShelf stores a snapshot of uncommitted files
+func (s *Server) createShelf(c *gin.Context) {
+	project, ok := s.shelfProject(c, "write")
+	if !ok {
+		return
+	}
+	userID := c.GetString("user_id")
+
+	var req CreateShelfRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no files to shelve"})
+		return
+	}
+
+	// A shelf is only useful if every version it holds can be downloaded again
+	var missing []string
+	seen := make(map[string]bool, len(req.Files))
+	for _, file := range req.Files {
+		if file.Path == "" || (!file.Deleted && file.ContentHash == "") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file path and content hash required"})
+			return
+		}
+		if seen[file.Path] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is listed twice", file.Path)})
+			return
+		}
+		seen[file.Path] = true
+
+		if !file.Deleted && (!s.projectHasObject(project.ID, file.ContentHash) || !s.storage.Exists(file.ContentHash)) {
+			missing = append(missing, file.ContentHash)
+		}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":           fmt.Sprintf("%d objects haven't been uploaded", len(missing)),
+			"missing_objects": missing,
+		})
+		return
+	}
+
+	if req.Name == "" {
+		req.Name = fmt.Sprintf("Shelved on %s", time.Now().Format("2006-01-02 15:04"))
+	}
+	if req.Branch == "" {
+		req.Branch = project.DefaultBranch
+	}
+
+	shelf := &models.Shelf{
+		ID:         fmt.Sprintf("shelf_%d", time.Now().UnixNano()),
+		ProjectID:  project.ID,
+		UserID:     userID,
+		Name:       req.Name,
+		Branch:     req.Branch,
+		BaseCommit: req.BaseCommit,
+		Files:      req.Files,
+	}
+	if err := s.db.Create(shelf).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create shelf"})
+		return
+	}
+
+	s.logFileEvent("shelf_created", project.ID, "", userID, c.GetString("user_name"), map[string]interface{}{
+		"shelf_id":    shelf.ID,
+		"name":        shelf.Name,
+		"branch":      shelf.Branch,
+		"base_commit": shelf.BaseCommit,
+		"file_count":  len(shelf.Files),
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"shelf":   shelf,
+	})
+}
+
+// listShelves returns the caller's shelves, newest first. With all=true project admins
+// see everyone's shelves, optionally narrowed down to one user.
+func (s *Server) listShelves(c *gin.Context) {
+	project, ok := s.shelfProject(c, "read")
+	if !ok {
+		return
+	}
+	userID := c.GetString("user_id")
+
+	query := s.db.Preload("User").Where("project_id = ?", project.ID)
+	if c.Query("all") == "true" {
+		if !project.HasPermission(userID, "admin") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin access required to list other users' shelves"})
+			return
+		}
+		if user := c.Query("user"); user != "" {
+			query = query.Where("user_id = ?", user)
+		}
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var shelves []models.Shelf
+	if err := query.Order("created_at DESC").Find(&shelves).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get shelves"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"shelves": shelves,
+		"count":   len(shelves),
+	})
+}
+
+// getShelf returns one shelf with its files
+func (s *Server) getShelf(c *gin.Context) {
+	project, ok := s.shelfProject(c, "read")
+	if !ok {
+		return
+	}
+	shelf, ok := s.projectShelf(c, project)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"shelf":   shelf,
+	})
+}
+
+// deleteShelf removes a shelf. Its objects stay in storage.
+func (s *Server) deleteShelf(c *gin.Context) {
+	project, ok := s.shelfProject(c, "read")
+	if !ok {
+		return
+	}
+	shelf, ok := s.projectShelf(c, project)
+	if !ok {
+		return
+	}
+
+	if err := s.db.Delete(&models.Shelf{}, "id = ?", shelf.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete shelf"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	s.logFileEvent("shelf_deleted", project.ID, "", userID, c.GetString("user_name"), map[string]interface{}{
+		"shelf_id": shelf.ID,
+		"name":     shelf.Name,
+		"owner_id": shelf.UserID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "shelf deleted",
+	})
+}
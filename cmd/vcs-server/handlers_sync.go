@@ -1,13 +1,20 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Telerallc/gamedev-vcs/database"
+	fileops "github.com/Telerallc/gamedev-vcs/internal/fileOps"
+	"github.com/Telerallc/gamedev-vcs/internal/storage"
 	"github.com/Telerallc/gamedev-vcs/internal/version"
 	"github.com/Telerallc/gamedev-vcs/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // PushRequest represents a push request from the client
@@ -16,6 +23,30 @@ type PushRequest struct {
 	LocalCommits  []string `json:"local_commits"`
 	RemoteCommits []string `json:"remote_commits"`
 	Files         []string `json:"files,omitempty"` // Optional: push specific files only
+
+	// Publishing offline commits: the client's branch head and the commits the server is
+	// missing (oldest first). The objects they need are uploaded beforehand.
+	Head    string                         `json:"head,omitempty"`
+	Force   bool                           `json:"force,omitempty"`
+	Commits []PushedCommit                 `json:"commits,omitempty"`
+	Objects map[string]*storage.ObjectInfo `json:"objects,omitempty"`
+}
+
+// PushedCommit is a commit the client created offline, with the files it changed
+type PushedCommit struct {
+	ID        string              `json:"id"`
+	Tree      string              `json:"tree"`
+	Parents   []string            `json:"parents"`
+	Message   string              `json:"message"`
+	Timestamp time.Time           `json:"timestamp"`
+	Files     []storage.TreeEntry `json:"files"`
+}
+
+// NegotiateRequest lists the commits and objects a client is about to push
+type NegotiateRequest struct {
+	Branch  string   `json:"branch"`
+	Commits []string `json:"commits"`
+	Objects []string `json:"objects"`
 }
 
 // PushResponse represents the server response to a push
@@ -26,6 +57,8 @@ type PushResponse struct {
 	Conflicts     []string `json:"conflicts,omitempty"`
 	RequiredPull  bool     `json:"required_pull"`
 	RemoteCommits []string `json:"remote_commits,omitempty"`
+
+	ReferenceWarnings []fileops.ReferenceViolation `json:"reference_warnings,omitempty"`
 }
 
 // PullRequest represents a pull request from the client
@@ -87,6 +120,11 @@ func (s *Server) pushChanges(c *gin.Context) {
 		req.Branch = project.DefaultBranch
 	}
 
+	if req.Head != "" {
+		s.publishCommits(c, project, userID, &req)
+		return
+	}
+
 	commitService := version.NewCommitService(s.db.DB)
 
 	// Get current remote commits for the branch
@@ -142,6 +180,36 @@ func (s *Server) pushChanges(c *gin.Context) {
 		return
 	}
 
+	// Pre-receive check: apply the pushed commits oldest first so newer trees win
+	changes := make(map[string]string)
+	deleted := make(map[string]bool)
+	for i := len(newCommits) - 1; i >= 0; i-- {
+		commit, err := commitService.GetCommitByID(newCommits[i])
+		if err != nil {
+			continue
+		}
+
+		var tree models.CommitTree
+		if err := s.db.Where("id = ? AND project_id = ?", commit.TreeHash, projectID).First(&tree).Error; err != nil {
+			continue
+		}
+
+		for _, treeFile := range tree.Files {
+			if treeFile.IsDeletion() {
+				delete(changes, treeFile.Path)
+				deleted[treeFile.Path] = true
+				continue
+			}
+			changes[treeFile.Path] = treeFile.ContentHash
+			delete(deleted, treeFile.Path)
+		}
+	}
+
+	referenceCheck, allowed := s.checkAssetReferences(c, project, req.Branch, changes, sortedPaths(deleted))
+	if !allowed {
+		return
+	}
+
 	// Update the branch HEAD to the latest local commit
 	if len(req.LocalCommits) > 0 {
 		latestCommit := req.LocalCommits[0] // Assuming first commit is the latest
@@ -158,13 +226,328 @@ func (s *Server) pushChanges(c *gin.Context) {
 		"new_commits": len(newCommits),
 	})
 
-	c.JSON(http.StatusOK, PushResponse{
+	response := PushResponse{
 		Success:    true,
 		Updated:    true,
 		NewCommits: newCommits,
+	}
+	if referenceCheck.HasViolations() {
+		response.ReferenceWarnings = referenceCheck.Violations
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// negotiatePush tells a client which of the commits and objects it wants to push the
+// server is missing, and where the branch head is now
+func (s *Server) negotiatePush(c *gin.Context) {
+	projectID := c.Param("project")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project ID required"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	var req NegotiateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	projectRepo := database.NewProjectRepository(s.db.DB)
+	project, err := projectRepo.GetProjectByID(projectID, userID)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	if !project.HasPermission(userID, "write") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	if req.Branch == "" {
+		req.Branch = project.DefaultBranch
+	}
+
+	var branch models.Branch
+	if err := s.db.Where("project_id = ? AND name = ?", projectID, req.Branch).First(&branch).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "branch not found"})
+		return
+	}
+
+	var existingCommits []string
+	if len(req.Commits) > 0 {
+		if err := s.db.Model(&models.Commit{}).Where("project_id = ? AND id IN ?", projectID, req.Commits).Pluck("id", &existingCommits).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up commits"})
+			return
+		}
+	}
+	known := make(map[string]bool, len(existingCommits))
+	for _, commitID := range existingCommits {
+		known[commitID] = true
+	}
+
+	missingCommits := []string{}
+	for _, commitID := range req.Commits {
+		if !known[commitID] {
+			missingCommits = append(missingCommits, commitID)
+		}
+	}
+
+	missingObjects := []string{}
+	for _, hash := range req.Objects {
+		if !s.storage.Exists(hash) {
+			missingObjects = append(missingObjects, hash)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"branch":          req.Branch,
+		"head_commit":     branch.LastCommit,
+		"missing_commits": missingCommits,
+		"missing_objects": missingObjects,
 	})
 }
 
+// publishCommits imports commits the client created offline, with the objects the server
+// was missing, and fast-forwards the branch to the client's head
+func (s *Server) publishCommits(c *gin.Context, project *models.Project, userID string, req *PushRequest) {
+	var branch models.Branch
+	if err := s.db.Where("project_id = ? AND name = ?", project.ID, req.Branch).First(&branch).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "branch not found"})
+		return
+	}
+
+	if branch.LastCommit == req.Head {
+		c.JSON(http.StatusOK, PushResponse{Success: true, Updated: false})
+		return
+	}
+
+	pushed := make(map[string]*PushedCommit, len(req.Commits))
+	for i := range req.Commits {
+		commit := &req.Commits[i]
+		if commit.ID == "" || commit.Tree == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pushed commits need an ID and a tree"})
+			return
+		}
+		// The tree is named by its entries, so a tree hash that doesn't match the files is refused
+		if treeHash, err := storage.TreeHash(commit.Files); err != nil || treeHash != commit.Tree {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("tree %s of commit %s doesn't match its files", commit.Tree, commit.ID)})
+			return
+		}
+		pushed[commit.ID] = commit
+	}
+
+	// Every parent must be pushed alongside or already on the server
+	for _, commit := range req.Commits {
+		for _, parent := range commit.Parents {
+			if pushed[parent] == nil && !s.commitExists(project.ID, parent) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("commit %s has unknown parent %s", commit.ID, parent)})
+				return
+			}
+		}
+	}
+	if pushed[req.Head] == nil && !s.commitExists(project.ID, req.Head) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("head commit %s was not pushed", req.Head)})
+		return
+	}
+
+	// Only fast-forwards unless forced: the branch head must be an ancestor of the new head
+	if branch.LastCommit != "" && !req.Force && !s.isAncestor(project.ID, branch.LastCommit, req.Head, pushed) {
+		c.JSON(http.StatusConflict, PushResponse{
+			Success:       false,
+			RequiredPull:  true,
+			RemoteCommits: []string{branch.LastCommit},
+		})
+		return
+	}
+
+	// Every file must already be stored; newer commits win the file map
+	batch := &fileops.BatchUploadRequest{
+		ProjectID: project.ID,
+		Objects:   make(map[string]*storage.ObjectInfo),
+		FileMap:   make(map[string]string),
+		UserID:    userID,
+		UserName:  c.GetString("user_name"),
+	}
+	var missing []string
+	deleted := make(map[string]bool)
+	for _, commit := range req.Commits {
+		for _, entry := range commit.Files {
+			if entry.IsDeletion() {
+				delete(batch.FileMap, entry.Name)
+				deleted[entry.Name] = true
+				continue
+			}
+			delete(deleted, entry.Name)
+			if !s.storage.Exists(entry.Hash) {
+				missing = append(missing, entry.Hash)
+				continue
+			}
+			info := req.Objects[entry.Hash]
+			if info == nil {
+				info = &storage.ObjectInfo{Hash: entry.Hash, Size: entry.Size}
+			}
+			batch.Objects[entry.Hash] = info
+			batch.FileMap[entry.Name] = entry.Hash
+		}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "push is missing objects", "missing_objects": missing})
+		return
+	}
+
+	// Pre-receive check against the combined change set of the pushed commits
+	deletions := sortedPaths(deleted)
+	referenceCheck, allowed := s.checkAssetReferences(c, project, req.Branch, batch.FileMap, deletions)
+	if !allowed {
+		return
+	}
+
+	if _, err := s.fileOps.ProcessObjectsBatch(batch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The commits and the branch move land together, and only if the branch is still where
+	// the fast-forward check saw it
+	newCommits := make([]string, 0, len(req.Commits))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		commitService := version.NewCommitService(tx)
+		for _, pushedCommit := range req.Commits {
+			files := make([]models.File, 0, len(pushedCommit.Files))
+			for _, entry := range pushedCommit.Files {
+				if entry.IsDeletion() {
+					files = append(files, models.File{Path: entry.Name})
+					continue
+				}
+				size := entry.Size
+				if info := batch.Objects[entry.Hash]; info != nil && info.Size > 0 {
+					size = info.Size
+				}
+				files = append(files, models.File{
+					Path:        entry.Name,
+					ContentHash: entry.Hash,
+					Size:        size,
+					MimeType:    s.detectMimeType(entry.Name),
+					Metadata:    s.fileOps.VersionMetadata(entry.Name, entry.Hash),
+				})
+			}
+
+			commit := &models.Commit{
+				ID:        pushedCommit.ID,
+				ProjectID: project.ID,
+				AuthorID:  userID,
+				Message:   pushedCommit.Message,
+				TreeHash:  pushedCommit.Tree,
+				ParentIDs: pushedCommit.Parents,
+				CreatedAt: pushedCommit.Timestamp,
+			}
+			if err := commitService.ImportCommit(commit, files); err != nil {
+				return err
+			}
+			newCommits = append(newCommits, pushedCommit.ID)
+		}
+		return commitService.MoveBranchHead(project.ID, req.Branch, branch.LastCommit, req.Head)
+	})
+	switch {
+	case errors.Is(err, version.ErrBranchMoved):
+		c.JSON(http.StatusConflict, PushResponse{Success: false, RequiredPull: true})
+		return
+	case errors.Is(err, version.ErrForeignCommit):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.storeFileMetadata(batch); err != nil {
+		fmt.Printf("Warning: failed to update files after push: %v\n", err)
+	}
+	if len(deletions) > 0 {
+		if err := s.db.Where("project_id = ? AND path IN ?", project.ID, deletions).Delete(&models.File{}).Error; err != nil {
+			fmt.Printf("Warning: failed to remove deleted files after push: %v\n", err)
+		}
+	}
+
+	s.logFileEvent("branch_pushed", project.ID, req.Branch, userID, c.GetString("user_name"), map[string]interface{}{
+		"branch":      req.Branch,
+		"new_commits": len(newCommits),
+		"head":        req.Head,
+		"forced":      req.Force,
+	})
+
+	response := PushResponse{
+		Success:    true,
+		Updated:    true,
+		NewCommits: newCommits,
+	}
+	if referenceCheck.HasViolations() {
+		response.ReferenceWarnings = referenceCheck.Violations
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// sortedPaths returns the paths of a set in order
+func sortedPaths(paths map[string]bool) []string {
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// commitExists reports whether a commit of the project is stored on the server
+func (s *Server) commitExists(projectID, commitID string) bool {
+	var count int64
+	s.db.Model(&models.Commit{}).Where("id = ? AND project_id = ?", commitID, projectID).Count(&count)
+	return count > 0
+}
+
+// isAncestor reports whether ancestor is reachable from head through the pushed commits
+// and the project's history on the server
+func (s *Server) isAncestor(projectID, ancestor, head string, pushed map[string]*PushedCommit) bool {
+	visited := make(map[string]bool)
+	queue := []string{head}
+
+	for len(queue) > 0 {
+		commitID := queue[0]
+		queue = queue[1:]
+		if commitID == ancestor {
+			return true
+		}
+		if visited[commitID] {
+			continue
+		}
+		visited[commitID] = true
+
+		if commit := pushed[commitID]; commit != nil {
+			queue = append(queue, commit.Parents...)
+			continue
+		}
+		var commit models.Commit
+		if err := s.db.Select("id", "parent_ids").Where("id = ? AND project_id = ?", commitID, projectID).First(&commit).Error; err == nil {
+			queue = append(queue, commit.ParentIDs...)
+		}
+	}
+
+	return false
+}
+
 // pullChanges handles pulling remote changes to the client
 func (s *Server) pullChanges(c *gin.Context) {
 	projectID := c.Param("project")
@@ -254,11 +637,11 @@ func (s *Server) pullChanges(c *gin.Context) {
 
 		// Get commit tree
 		var tree models.CommitTree
-		if err := s.db.Where("id = ?", commitWithTree.TreeHash).First(&tree).Error; err == nil {
+		if err := s.db.Where("id = ? AND project_id = ?", commitWithTree.TreeHash, projectID).First(&tree).Error; err == nil {
 			// Get file versions for this commit
 			for _, treeFile := range tree.Files {
 				fileVersion, err := commitService.GetFileAtCommit(commit.ID, treeFile.Path)
-				if err != nil {
+				if err != nil || fileVersion.IsDeletion() {
 					continue
 				}
 				files = append(files, *fileVersion)
@@ -327,6 +327,7 @@ func (s *Server) setupRoutes() {
 		{
 			files.POST("/upload", s.uploadFile)
 			files.POST("/batch-upload", s.batchUploadFiles) // NEW: Add this line
+			files.PUT("/objects/:hash", s.uploadObject)
 			files.GET("/:hash", s.downloadFile)
 			files.POST("/upload-chunk", s.uploadChunk)
 			files.POST("/finalize-upload", s.finalizeUpload)
@@ -355,11 +356,16 @@ func (s *Server) setupRoutes() {
 		commits := v1.Group("/commits")
 		commits.Use(s.AuthMiddleware())
 		{
-			commits.POST("/:project", s.createCommit)              // Create new commit
-			commits.GET("/:project", s.getCommitHistory)           // Get commit history
-			commits.GET("/:project/:commit", s.getCommit)          // Get specific commit
-			commits.GET("/:project/diff", s.diffCommits)           // Compare commits
-			commits.GET("/:project/files/*file", s.getFileHistory) // Get file history
+			commits.POST("/:project", s.createCommit)                         // Create new commit
+			commits.GET("/:project", s.getCommitHistory)                      // Get commit history
+			commits.GET("/:project/:commit", s.getCommit)                     // Get specific commit
+			commits.GET("/:project/:commit/tree", s.getCommitTree)            // Get all files at a commit
+			commits.POST("/:project/:commit/revert", s.revertCommit)          // Undo a commit on a branch
+			commits.POST("/:project/:commit/cherry-pick", s.cherryPickCommit) // Apply a commit to a branch
+			commits.GET("/:project/diff", s.diffCommits)                      // Compare commits
+			commits.POST("/:project/check-references", s.checkReferences)     // Dry-run reference validation
+			commits.GET("/:project/semantic-diff", s.semanticDiff)            // Compare Blueprint revisions
+			commits.GET("/:project/files/*file", s.getFileHistory)            // Get file history
 		}
 
 		// Branches (protected routes)
@@ -376,12 +382,23 @@ func (s *Server) setupRoutes() {
 		sync := v1.Group("/sync")
 		sync.Use(s.AuthMiddleware())
 		{
+			sync.POST("/:project/negotiate", s.negotiatePush)       // Find the commits and objects a push needs
 			sync.POST("/:project/push", s.pushChanges)              // Push changes to server
 			sync.POST("/:project/pull", s.pullChanges)              // Pull changes from server
 			sync.GET("/:project/status", s.syncStatus)              // Get sync status
 			sync.GET("/:project/branches/:branch", s.getBranchInfo) // Get branch info
 		}
 
+		// Shelves of uncommitted work (protected routes)
+		shelves := v1.Group("/shelves")
+		shelves.Use(s.AuthMiddleware())
+		{
+			shelves.POST("/:project", s.createShelf)          // Shelve files
+			shelves.GET("/:project", s.listShelves)           // List shelves
+			shelves.GET("/:project/:shelf", s.getShelf)       // Get a shelf and its files
+			shelves.DELETE("/:project/:shelf", s.deleteShelf) // Delete a shelf
+		}
+
 		// Analytics
 		analytics := v1.Group("/analytics")
 		analytics.Use(s.AuthMiddleware())
@@ -399,6 +416,10 @@ func (s *Server) setupRoutes() {
 		{
 			assets.GET("/:project/validate", s.validateAssetIntegrity)
 			assets.GET("/:project/dependencies", s.getDependencyGraph)
+			assets.POST("/:project/impact", s.analyzeChangeImpact)
+			assets.POST("/:project/corruption", s.reportCorruption)    // Report a corruption event
+			assets.GET("/:project/corruption", s.listCorruptionAlerts) // Corruption alert log
+			assets.PUT("/:project/corruption/webhooks/:name/secret", s.setWebhookSecret)
 		}
 
 		// System management
@@ -407,6 +428,12 @@ func (s *Server) setupRoutes() {
 		{
 			system.GET("/storage/stats", s.getStorageStats)
 			system.POST("/cleanup", s.performCleanup)
+			system.POST("/fsck/:project", s.runFsck)
+			system.GET("/fsck/:project/:run", s.getFsckRun)
+			system.GET("/quarantine/:project", s.listQuarantine)
+			system.GET("/quarantine/:project/:id", s.inspectQuarantine)
+			system.POST("/quarantine/:project/:id/release", s.releaseQuarantine)
+			system.DELETE("/quarantine/:project/:id", s.purgeQuarantine)
 		}
 
 		// Health check
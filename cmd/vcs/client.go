@@ -9,13 +9,17 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	fileops "github.com/Telerallc/gamedev-vcs/internal/fileOps"
+	"github.com/Telerallc/gamedev-vcs/internal/analyzer"
+	"github.com/Telerallc/gamedev-vcs/internal/integrity"
 	"github.com/Telerallc/gamedev-vcs/internal/storage"
 	"github.com/gorilla/websocket"
 )
@@ -30,6 +34,10 @@ type APIClient struct {
 	// Phase 1: Git-style components
 	objectStore *storage.GitStyleObjectStore
 	fileIndex   *storage.FileIndex
+	commitStore *storage.GitStyleCommitStore
+
+	downloadWorkers int
+	downloadContext downloadContext
 }
 
 // FileUploadResponse represents the server response for file uploads
@@ -53,6 +61,25 @@ type BatchUploadResult struct {
 	ObjectsStored  map[string]*storage.ObjectInfo `json:"objects_stored"`
 }
 
+// PushPack is the set of offline commits a push publishes; their objects are uploaded first
+type PushPack struct {
+	Branch       string           `json:"branch"`
+	Head         string           `json:"head"`
+	Force        bool             `json:"force,omitempty"`
+	LocalCommits []string         `json:"local_commits"`
+	Commits      []PushPackCommit `json:"commits"`
+}
+
+// PushPackCommit is a local commit with the files it changed
+type PushPackCommit struct {
+	ID        string              `json:"id"`
+	Tree      string              `json:"tree"`
+	Parents   []string            `json:"parents"`
+	Message   string              `json:"message"`
+	Timestamp time.Time           `json:"timestamp"`
+	Files     []storage.TreeEntry `json:"files"`
+}
+
 // LockResponse represents the server response for file locking
 type LockResponse struct {
 	Success  bool                   `json:"success"`
@@ -104,6 +131,7 @@ type FileUploadResult struct {
 	Size        int64
 	Duration    time.Duration
 	Skipped     bool // true if file was already uploaded
+	Deleted     bool // true if the file's deletion was staged
 }
 
 func NewFileCache() *FileCache {
@@ -136,6 +164,7 @@ func NewAPIClient(baseURL string) (*APIClient, error) {
 		return nil, fmt.Errorf("failed to initialize file index: %w", err)
 	}
 	client.fileIndex = fileIndex
+	client.commitStore = storage.NewGitStyleCommitStore(".vcs", objectStore, fileIndex)
 
 	return client, nil
 }
@@ -200,11 +229,14 @@ func (c *APIClient) UploadFile(projectID, filePath string, content io.Reader) (*
 		return nil, fmt.Errorf("failed to create form file: %w", err)
 	}
 
-	if _, err := io.Copy(part, content); err != nil {
+	// Hash while copying so the server can verify what it receives
+	hasher := sha256.New()
+	if _, err := io.Copy(part, io.TeeReader(content, hasher)); err != nil {
 		return nil, fmt.Errorf("failed to copy file content: %w", err)
 	}
 
 	// Add metadata
+	writer.WriteField("content_hash", hex.EncodeToString(hasher.Sum(nil)))
 	writer.WriteField("file_path", filePath)
 	writer.WriteField("user_name", "CLI User")
 	writer.WriteField("session_id", c.sessionID)
@@ -377,6 +409,42 @@ func (c *APIClient) GetDependencyGraph(projectID, assetPath string) ([]byte, err
 	return c.makeRequest("GET", url, nil)
 }
 
+// GetDependencyAnalysis runs a project-wide dependency analysis (cycles, closure or offenders)
+func (c *APIClient) GetDependencyAnalysis(projectID, mode, assetPath string, limit int) ([]byte, error) {
+	query := url.Values{}
+	query.Set("mode", mode)
+	if assetPath != "" {
+		query.Set("asset_path", assetPath)
+	}
+	if limit > 0 {
+		query.Set("limit", fmt.Sprintf("%d", limit))
+	}
+
+	return c.makeRequest("GET", fmt.Sprintf("/api/v1/assets/%s/dependencies?%s", projectID, query.Encode()), nil)
+}
+
+// AnalyzeImpact asks the server which assets transitively reference the given files
+func (c *APIClient) AnalyzeImpact(projectID string, filePaths []string) ([]byte, error) {
+	body := map[string]interface{}{
+		"file_paths": filePaths,
+	}
+	return c.makeRequest("POST", fmt.Sprintf("/api/v1/assets/%s/impact", projectID), body)
+}
+
+// GetIndexStagedFiles returns the paths staged in the local file index
+func (c *APIClient) GetIndexStagedFiles() []string {
+	if c.fileIndex == nil {
+		return nil
+	}
+
+	stagedEntries := c.fileIndex.GetStagedEntries()
+	paths := make([]string, 0, len(stagedEntries))
+	for filePath := range stagedEntries {
+		paths = append(paths, filePath)
+	}
+	return paths
+}
+
 // SubscribeToEvents creates a WebSocket connection for real-time events
 func (c *APIClient) SubscribeToEvents(projectID string, eventHandler func(map[string]interface{})) error {
 	wsURL := strings.Replace(c.baseURL, "http", "ws", 1) + "/api/v1/collaboration/ws?project_id=" + projectID
@@ -424,12 +492,13 @@ func (c *APIClient) SubscribeToEvents(projectID string, eventHandler func(map[st
 	return nil
 }
 
-// CreateCommit creates a new commit on the server
-func (c *APIClient) CreateCommit(projectID, message, branch string, filePaths []string, parentCommits []string) ([]byte, error) {
+// CreateCommit creates a new commit on the server; deletedPaths lists files the commit removes
+func (c *APIClient) CreateCommit(projectID, message, branch string, filePaths, deletedPaths []string, parentCommits []string) ([]byte, error) {
 	commitData := map[string]interface{}{
 		"message":        message,
 		"branch":         branch,
 		"file_paths":     filePaths,
+		"deleted_paths":  deletedPaths,
 		"parent_commits": parentCommits,
 	}
 
@@ -443,6 +512,13 @@ func (c *APIClient) GetCommitHistory(projectID, branch string, limit int) ([]byt
 	return c.makeRequest("GET", url, nil)
 }
 
+// QueryCommitLog retrieves one page of history matching query: branch, from, author,
+// since, until, grep, path, limit and the cursor of the previous page
+func (c *APIClient) QueryCommitLog(projectID string, query url.Values) ([]byte, error) {
+	url := fmt.Sprintf("/api/v1/commits/%s?%s", projectID, query.Encode())
+	return c.makeRequest("GET", url, nil)
+}
+
 // GetCommit retrieves a specific commit by ID
 func (c *APIClient) GetCommit(projectID, commitID string) ([]byte, error) {
 	url := fmt.Sprintf("/api/v1/commits/%s/%s", projectID, commitID)
@@ -461,6 +537,56 @@ func (c *APIClient) DiffCommits(projectID, fromCommit, toCommit string) ([]byte,
 	return c.makeRequest("GET", url, nil)
 }
 
+// SemanticDiff compares two revisions of a Blueprint, or of a table when kind is "table";
+// an empty toCommit means the current version
+func (c *APIClient) SemanticDiff(projectID, fromCommit, toCommit, filePath, kind string) ([]byte, error) {
+	query := url.Values{}
+	query.Set("from", fromCommit)
+	query.Set("path", filePath)
+	if toCommit != "" {
+		query.Set("to", toCommit)
+	}
+	if kind != "" {
+		query.Set("kind", kind)
+	}
+
+	return c.makeRequest("GET", fmt.Sprintf("/api/v1/commits/%s/semantic-diff?%s", projectID, query.Encode()), nil)
+}
+
+// ReportCorruption reports a corruption event so the server routes it to the project's alert sinks
+func (c *APIClient) ReportCorruption(projectID string, event integrity.CorruptionEvent) ([]byte, error) {
+	return c.makeRequest("POST", fmt.Sprintf("/api/v1/assets/%s/corruption", projectID), event)
+}
+
+// CorruptionSink returns an alert sink that reports events to the project on the server
+func (c *APIClient) CorruptionSink(projectID string) integrity.AlertSink {
+	return integrity.AlertSinkFunc(func(event integrity.CorruptionEvent) error {
+		_, err := c.ReportCorruption(projectID, event)
+		return err
+	})
+}
+
+// ListCorruptionAlerts lists the project's corruption alert log, newest first
+func (c *APIClient) ListCorruptionAlerts(projectID, severity string, limit int) ([]byte, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	if severity != "" {
+		query.Set("severity", severity)
+	}
+
+	return c.makeRequest("GET", fmt.Sprintf("/api/v1/assets/%s/corruption?%s", projectID, query.Encode()), nil)
+}
+
+// RunServerFsck starts a background check of the project's content and history on the server (admin only)
+func (c *APIClient) RunServerFsck(projectID string) ([]byte, error) {
+	return c.makeRequest("POST", fmt.Sprintf("/api/v1/system/fsck/%s", projectID), nil)
+}
+
+// GetServerFsckRun returns the status and reports of a server fsck run
+func (c *APIClient) GetServerFsckRun(projectID, runID string) ([]byte, error) {
+	return c.makeRequest("GET", fmt.Sprintf("/api/v1/system/fsck/%s/%s", projectID, runID), nil)
+}
+
 // PushChanges pushes local changes to the server
 func (c *APIClient) PushChanges(projectID, branch string, localCommits, remoteCommits []string, files []string) ([]byte, error) {
 	pushData := map[string]interface{}{
@@ -497,6 +623,764 @@ func (c *APIClient) GetSyncStatus(projectID, branch string, localCommits []strin
 	return c.makeRequest("GET", url, nil)
 }
 
+// CommitStaged records the staged files as a commit in the local commit store on top of
+// parent, without contacting the server
+func (c *APIClient) CommitStaged(projectID, branch, parent, message, author string, stagedPaths []string) (*storage.CommitResult, error) {
+	staged := make(map[string]bool, len(stagedPaths))
+	for _, path := range stagedPaths {
+		staged[path] = true
+	}
+
+	// The index can still flag files staged before an earlier commit; commit only what's staged now
+	var stale []string
+	for path := range c.fileIndex.GetStagedEntries() {
+		if !staged[path] {
+			stale = append(stale, path)
+		}
+	}
+	c.fileIndex.MarkUnstaged(stale)
+
+	entries := c.fileIndex.GetStagedEntries()
+	var missing []string
+	for _, path := range stagedPaths {
+		if _, ok := entries[path]; !ok {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%d staged files are not in the index, run 'vcs add' again: %s", len(missing), strings.Join(missing, ", "))
+	}
+
+	commit := &storage.CommitObject{
+		Author:    author,
+		AuthorID:  author,
+		Committer: author,
+		Message:   message,
+		ProjectID: projectID,
+		Branch:    branch,
+		Metadata:  map[string]string{},
+	}
+	if parent != "" {
+		commit.Parents = []string{parent}
+		// The parent came from the server (clone or pull), so local history starts here
+		if !c.commitStore.HasCommit(parent) {
+			if err := c.commitStore.MarkShallow(parent); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c.commitStore.CreateCommit(commit)
+}
+
+// CommitChanges returns the files a local commit changed, by content hash, and the paths it deleted
+func (c *APIClient) CommitChanges(commitID string) (map[string]string, []string, error) {
+	commit, err := c.commitStore.GetCommit(commitID)
+	if err != nil {
+		return nil, nil, err
+	}
+	tree, err := c.commitStore.GetTree(commit.Tree)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes := make(map[string]string)
+	var deletions []string
+	for _, entry := range tree.Entries {
+		if entry.IsDeletion() {
+			deletions = append(deletions, entry.Name)
+			continue
+		}
+		changes[entry.Name] = entry.Hash
+	}
+	return changes, deletions, nil
+}
+
+// CheckReferences asks the server which hard asset references a change set would break on a branch
+func (c *APIClient) CheckReferences(projectID, branch string, changes map[string]string, deletedPaths []string) ([]byte, error) {
+	checkData := map[string]interface{}{
+		"branch":        branch,
+		"changes":       changes,
+		"deleted_paths": deletedPaths,
+	}
+	return c.makeRequest("POST", fmt.Sprintf("/api/v1/commits/%s/check-references", projectID), checkData)
+}
+
+// LocalCommitsSince returns the local commits reachable from head that aren't in known,
+// parents before children, with the files each one changed
+func (c *APIClient) LocalCommitsSince(head string, known []string) ([]PushPackCommit, error) {
+	knownSet := make(map[string]bool, len(known))
+	for _, commitID := range known {
+		knownSet[commitID] = true
+	}
+
+	commitIDs, err := c.commitStore.CommitsSince(head, knownSet)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]PushPackCommit, 0, len(commitIDs))
+	for _, commitID := range commitIDs {
+		commit, err := c.commitStore.GetCommit(commitID)
+		if err != nil {
+			return nil, err
+		}
+		tree, err := c.commitStore.GetTree(commit.Tree)
+		if err != nil {
+			return nil, err
+		}
+
+		// Trees don't keep sizes, the blobs do
+		for i, entry := range tree.Entries {
+			if entry.IsDeletion() {
+				continue
+			}
+			reader, info, err := c.objectStore.Get(entry.Hash)
+			if err != nil {
+				return nil, fmt.Errorf("commit %s: %w", commitID[:8], err)
+			}
+			reader.Close()
+			tree.Entries[i].Size = info.Size
+		}
+
+		commits = append(commits, PushPackCommit{
+			ID:        commitID,
+			Tree:      commit.Tree,
+			Parents:   commit.Parents,
+			Message:   commit.Message,
+			Timestamp: commit.Timestamp,
+			Files:     tree.Entries,
+		})
+	}
+
+	return commits, nil
+}
+
+// ReplayCommits copies local commits, oldest first, on top of onto, points the branch at
+// the last copy and returns the copies' hashes in the same order
+func (c *APIClient) ReplayCommits(branch, onto string, commits []PushPackCommit) ([]string, error) {
+	if !c.commitStore.HasCommit(onto) {
+		if err := c.commitStore.MarkShallow(onto); err != nil {
+			return nil, err
+		}
+	}
+
+	replayed := make(map[string]string, len(commits))
+	copies := make([]string, 0, len(commits))
+	head := onto
+	for _, commit := range commits {
+		// The oldest commit moves onto the new base; later ones follow their replayed parents
+		parents := make([]string, 0, len(commit.Parents))
+		for _, parent := range commit.Parents {
+			if copied, ok := replayed[parent]; ok {
+				parents = append(parents, copied)
+			}
+		}
+		if len(parents) == 0 {
+			parents = []string{onto}
+		}
+
+		copied, err := c.commitStore.ReplayCommit(commit.ID, parents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay commit %s: %w", commit.ID[:8], err)
+		}
+		replayed[commit.ID] = copied
+		copies = append(copies, copied)
+		head = copied
+	}
+
+	if err := c.commitStore.SetBranchHead(branch, head); err != nil {
+		return nil, err
+	}
+	return copies, nil
+}
+
+// NegotiatePush asks the server which of the commits and objects a push carries it's missing
+func (c *APIClient) NegotiatePush(projectID, branch string, commits, objects []string) ([]byte, error) {
+	negotiateData := map[string]interface{}{
+		"branch":  branch,
+		"commits": commits,
+		"objects": objects,
+	}
+
+	url := fmt.Sprintf("/api/v1/sync/%s/negotiate", projectID)
+	return c.makeRequest("POST", url, negotiateData)
+}
+
+// PublishCommits streams the objects the server is missing, one request each, then sends
+// the push pack
+func (c *APIClient) PublishCommits(projectID string, pack *PushPack, missingObjects []string) ([]byte, error) {
+	if _, err := c.UploadObjects(projectID, missingObjects); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("/api/v1/sync/%s/push", projectID)
+	return c.makeRequest("POST", url, pack)
+}
+
+// GetCommitTree gets every file visible at a server commit
+func (c *APIClient) GetCommitTree(projectID, commitID string) ([]byte, error) {
+	url := fmt.Sprintf("/api/v1/commits/%s/%s/tree", projectID, commitID)
+	return c.makeRequest("GET", url, nil)
+}
+
+// SnapshotAt returns every file visible at a commit keyed by path. Local commits are read
+// from the commit store along first parents, which merges record their changes against;
+// the server commit they build on fills in the rest.
+func (c *APIClient) SnapshotAt(projectID, commitID string) (map[string]storage.TreeEntry, error) {
+	snapshot := make(map[string]storage.TreeEntry)
+	if commitID == "" {
+		return snapshot, nil
+	}
+
+	visited := make(map[string]bool)
+	for current := commitID; current != "" && !visited[current]; {
+		visited[current] = true
+
+		if !c.commitStore.HasCommit(current) {
+			// Server trees are already complete, so there's no need to walk further back
+			files, err := c.serverTree(projectID, current)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				if _, seen := snapshot[file.Name]; !seen {
+					snapshot[file.Name] = file
+				}
+			}
+			break
+		}
+
+		commit, err := c.commitStore.GetCommit(current)
+		if err != nil {
+			return nil, err
+		}
+		tree, err := c.commitStore.GetTree(commit.Tree)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range tree.Entries {
+			if _, seen := snapshot[entry.Name]; !seen {
+				snapshot[entry.Name] = entry
+			}
+		}
+
+		current = ""
+		if len(commit.Parents) > 0 {
+			current = commit.Parents[0]
+		}
+	}
+
+	// A deletion hides the older versions of its path
+	for path, entry := range snapshot {
+		if entry.IsDeletion() {
+			delete(snapshot, path)
+		}
+	}
+	return snapshot, nil
+}
+
+func (c *APIClient) serverTree(projectID, commitID string) ([]storage.TreeEntry, error) {
+	resp, err := c.GetCommitTree(projectID, commitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of commit %s: %w", commitID, err)
+	}
+
+	var treeResp struct {
+		Success bool       `json:"success"`
+		Files   []FileInfo `json:"files"`
+	}
+	if err := json.Unmarshal(resp, &treeResp); err != nil {
+		return nil, fmt.Errorf("failed to parse tree response: %w", err)
+	}
+
+	entries := make([]storage.TreeEntry, 0, len(treeResp.Files))
+	for _, file := range treeResp.Files {
+		entries = append(entries, storage.TreeEntry{Mode: "100644", Name: file.Path, Hash: file.ContentHash, Size: file.Size})
+	}
+	return entries, nil
+}
+
+// WorkingFileHash returns the content hash of a working copy file, trusting the index
+// when the file's stat data hasn't changed since it was last hashed
+func (c *APIClient) WorkingFileHash(filePath string) (string, error) {
+	if entry, ok := c.fileIndex.GetEntry(filePath); ok {
+		if needsUpdate, err := c.fileIndex.NeedsUpdate(filePath); err == nil && !needsUpdate {
+			return entry.Hash, nil
+		}
+	}
+
+	hash, _, err := CalculateFileHash(filePath)
+	return hash, err
+}
+
+// WorkingFileHashes returns the content hashes of the working copies of files that exist,
+// hashing only the files whose stat data no longer matches the index
+func (c *APIClient) WorkingFileHashes(filePaths []string) (map[string]string, error) {
+	statuses, err := c.fileIndex.CompareWithFileSystem(filePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]string, len(filePaths))
+	for filePath, status := range statuses {
+		switch status {
+		case "deleted":
+			continue
+		case "unchanged":
+			if entry, ok := c.fileIndex.GetEntry(filePath); ok {
+				hashes[filePath] = entry.Hash
+				continue
+			}
+		}
+
+		hash, _, err := CalculateFileHash(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", filePath, err)
+		}
+		hashes[filePath] = hash
+	}
+	return hashes, nil
+}
+
+// StagedHashes returns the content hashes the index holds for staged files. Staged
+// deletions map to storage.DeletedHash.
+func (c *APIClient) StagedHashes(stagedPaths []string) map[string]string {
+	hashes := make(map[string]string, len(stagedPaths))
+	for _, path := range stagedPaths {
+		if entry, ok := c.fileIndex.GetEntry(path); ok {
+			hashes[path] = entry.Hash
+		}
+	}
+	return hashes
+}
+
+// TrackedFiles returns the paths the index holds a version of, without staged deletions
+func (c *APIClient) TrackedFiles() []string {
+	var paths []string
+	for path, entry := range c.fileIndex.GetAllEntries() {
+		if !entry.IsDeletion() {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ReadObject returns the content of an object, downloading it into the local object store
+// first when it's missing
+func (c *APIClient) ReadObject(projectID, hash string) ([]byte, error) {
+	exists, err := c.objectStore.Exists(hash)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := c.fetchObject(projectID, hash); err != nil {
+			return nil, err
+		}
+	}
+	return c.objectStore.ReadObject(hash)
+}
+
+// CheckoutFiles writes file versions into the working directory and removes deleted files,
+// downloading only the objects missing from the local object store, and records the
+// result in the index. It returns how many objects were downloaded.
+func (c *APIClient) CheckoutFiles(projectID string, updates map[string]storage.TreeEntry, deletions []string) (int, error) {
+	var jobs []downloadJob
+	queued := make(map[string]bool)
+	for path, entry := range updates {
+		if queued[entry.Hash] {
+			continue
+		}
+		exists, err := c.objectStore.Exists(entry.Hash)
+		if err != nil {
+			return 0, err
+		}
+		if exists {
+			continue
+		}
+		queued[entry.Hash] = true
+		jobs = append(jobs, downloadJob{Hash: entry.Hash, Size: entry.Size, Path: path})
+	}
+
+	downloaded, err := c.newDownloadEngine(projectID).run(jobs)
+	if err != nil {
+		return downloaded, err
+	}
+
+	// Every object is local now, so a failure below can't leave a half-fetched checkout
+	paths := make([]string, 0, len(updates))
+	for path := range updates {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		entry := updates[path]
+		if err := c.writeWorkingFile(path, entry.Hash); err != nil {
+			return downloaded, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := c.fileIndex.UpdateEntry(path, entry.Hash); err != nil {
+			return downloaded, err
+		}
+	}
+	c.fileIndex.MarkUnstaged(paths)
+
+	for _, path := range deletions {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return downloaded, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		c.fileIndex.RemoveEntry(path)
+
+		// Drop folders the removal left empty; os.Remove fails on the first one that isn't
+		for dir := filepath.Dir(path); dir != "."; dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+
+	if err := c.fileIndex.Save(); err != nil {
+		return downloaded, fmt.Errorf("failed to save index: %w", err)
+	}
+	return downloaded, nil
+}
+
+// fetchObject downloads an object into the local object store, rejecting content that
+// doesn't match its hash
+func (c *APIClient) fetchObject(projectID, hash string) error {
+	return c.newDownloadEngine(projectID).fetch(downloadJob{Hash: hash, Path: hash})
+}
+
+func (c *APIClient) writeWorkingFile(path, hash string) error {
+	content, err := c.objectStore.ReadObject(hash)
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	tempPath := path + ".vcs-tmp"
+	if err := os.WriteFile(tempPath, content, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// CheckoutHead points the local commit store's HEAD at a branch, or detaches it at a commit
+func (c *APIClient) CheckoutHead(branch, commitID string) error {
+	if branch == "" && !c.commitStore.HasCommit(commitID) {
+		// A server commit has nothing local behind it, like the base of a clone
+		if err := c.commitStore.MarkShallow(commitID); err != nil {
+			return err
+		}
+	}
+	return c.commitStore.CheckoutHead(branch, commitID)
+}
+
+// UnstageFiles clears the staged flag of the index entries matching paths, saves the
+// index and returns the paths it unstaged
+func (c *APIClient) UnstageFiles(paths []string) ([]string, error) {
+	var unstaged []string
+	for path := range c.fileIndex.GetStagedEntries() {
+		if matchesPathspec(path, paths) {
+			unstaged = append(unstaged, path)
+		}
+	}
+	if len(unstaged) == 0 {
+		return nil, nil
+	}
+
+	c.fileIndex.MarkUnstaged(unstaged)
+	if err := c.fileIndex.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save index: %w", err)
+	}
+	sort.Strings(unstaged)
+	return unstaged, nil
+}
+
+// PickResult is the server's answer to a revert or cherry-pick
+type PickResult struct {
+	Success   bool   `json:"success"`
+	Error     string `json:"error"`
+	Branch    string `json:"branch"`
+	Conflicts []struct {
+		Path     string `json:"path"`
+		Expected string `json:"expected"`
+		Actual   string `json:"actual"`
+	} `json:"conflicts"`
+	FilesCommitted int        `json:"files_committed"`
+	Commit         CommitInfo `json:"commit"`
+}
+
+// PickCommit reverts a commit on a branch, or cherry-picks it onto the branch. Conflicts
+// come back in the result rather than as an error.
+func (c *APIClient) PickCommit(projectID, commitID, branch, message string, revert bool) (*PickResult, error) {
+	action := "cherry-pick"
+	if revert {
+		action = "revert"
+	}
+	url := fmt.Sprintf("/api/v1/commits/%s/%s/%s", projectID, commitID, action)
+	status, resp, err := c.makeRequestWithStatus("POST", url, map[string]string{
+		"branch":  branch,
+		"message": message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result PickResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("request failed with status %d: %s", status, string(resp))
+	}
+	if status >= 400 && len(result.Conflicts) == 0 {
+		if result.Error == "" {
+			result.Error = string(resp)
+		}
+		return nil, fmt.Errorf("%s failed: %s", action, result.Error)
+	}
+	return &result, nil
+}
+
+// ResetBranchHead points a branch at a commit in the local commit store. The commit may
+// only exist on the server, in which case local history starts there.
+func (c *APIClient) ResetBranchHead(branch, commitID string) error {
+	if !c.commitStore.HasCommit(commitID) {
+		if err := c.commitStore.MarkShallow(commitID); err != nil {
+			return err
+		}
+	}
+	return c.commitStore.SetBranchHead(branch, commitID)
+}
+
+// DroppedCommits returns the unpublished local commits reachable from oldHead that are no
+// longer reachable from newHead, parents before children
+func (c *APIClient) DroppedCommits(oldHead, newHead string, published []string) ([]string, error) {
+	known := make(map[string]bool, len(published))
+	for _, commitID := range published {
+		known[commitID] = true
+	}
+
+	kept, err := c.commitStore.CommitsSince(newHead, known)
+	if err != nil {
+		return nil, err
+	}
+	for _, commitID := range kept {
+		known[commitID] = true
+	}
+	known[newHead] = true
+
+	return c.commitStore.CommitsSince(oldHead, known)
+}
+
+// ListLocks gets the active file locks of a project
+func (c *APIClient) ListLocks(projectID string) ([]LockInfo, error) {
+	resp, err := c.makeRequest("GET", fmt.Sprintf("/api/v1/locks/%s", projectID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locks: %w", err)
+	}
+
+	var locks struct {
+		Success bool       `json:"success"`
+		Locks   []LockInfo `json:"locks"`
+	}
+	if err := json.Unmarshal(resp, &locks); err != nil {
+		return nil, fmt.Errorf("failed to parse locks response: %w", err)
+	}
+	return locks.Locks, nil
+}
+
+// ShelfFileInfo is one file of a shelf
+type ShelfFileInfo struct {
+	Path        string `json:"path"`
+	ContentHash string `json:"content_hash,omitempty"`
+	Size        int64  `json:"size"`
+	Staged      bool   `json:"staged"`
+	Deleted     bool   `json:"deleted,omitempty"`
+}
+
+// ShelfInfo is a shelf of uncommitted work stored on the server
+type ShelfInfo struct {
+	ID         string          `json:"id"`
+	UserID     string          `json:"user_id"`
+	Name       string          `json:"name"`
+	Branch     string          `json:"branch"`
+	BaseCommit string          `json:"base_commit"`
+	Files      []ShelfFileInfo `json:"files"`
+	CreatedAt  time.Time       `json:"created_at"`
+	User       struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+// StoreWorkingFile copies a working copy file into the local object store
+func (c *APIClient) StoreWorkingFile(filePath string) (*storage.ObjectInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return c.objectStore.Store(file, map[string]string{
+		"source_path": filePath,
+		"stored_at":   time.Now().Format(time.RFC3339),
+	})
+}
+
+// UploadObjects sends the objects the server doesn't have yet, one request per object
+// streamed from the local object store. It returns how many objects were sent.
+func (c *APIClient) UploadObjects(projectID string, hashes []string) (int, error) {
+	sent := make(map[string]bool)
+	var quarantined []string
+	for _, hash := range hashes {
+		if sent[hash] {
+			continue
+		}
+		if exists, err := c.checkProjectHasObject(projectID, hash); err == nil && exists {
+			continue
+		}
+
+		rejected, err := c.uploadObject(projectID, hash)
+		if err != nil {
+			return 0, err
+		}
+		if rejected {
+			quarantined = append(quarantined, hash)
+		}
+		sent[hash] = true
+	}
+
+	if len(quarantined) > 0 {
+		return 0, fmt.Errorf("the server quarantined %d objects: %s", len(quarantined), strings.Join(quarantined, ", "))
+	}
+	return len(sent), nil
+}
+
+// uploadObject streams one object to the server, reporting whether it was quarantined
+func (c *APIClient) uploadObject(projectID, hash string) (bool, error) {
+	content, _, err := c.objectStore.Get(hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	defer content.Close()
+
+	endpoint := fmt.Sprintf("%s/api/v1/files/objects/%s?project=%s", c.baseURL, hash, url.QueryEscape(projectID))
+	req, err := http.NewRequest("PUT", endpoint, content)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if c.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to upload object %s: %w", hash, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnprocessableEntity:
+		return true, nil
+	case resp.StatusCode >= 400:
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("failed to upload object %s: status %d: %s", hash, resp.StatusCode, string(body))
+	}
+	return false, nil
+}
+
+// CreateShelf stores a shelf on the server. Its objects must have been uploaded first.
+func (c *APIClient) CreateShelf(projectID string, shelf *ShelfInfo) (*ShelfInfo, error) {
+	resp, err := c.makeRequest("POST", fmt.Sprintf("/api/v1/shelves/%s", projectID), map[string]interface{}{
+		"name":        shelf.Name,
+		"branch":      shelf.Branch,
+		"base_commit": shelf.BaseCommit,
+		"files":       shelf.Files,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shelf: %w", err)
+	}
+	return parseShelfResponse(resp)
+}
+
+// ListShelves gets the current user's shelves; with all set, everyone's shelves (or one
+// user's), which needs admin rights on the project
+func (c *APIClient) ListShelves(projectID string, all bool, user string) ([]ShelfInfo, error) {
+	query := url.Values{}
+	if all {
+		query.Set("all", "true")
+		if user != "" {
+			query.Set("user", user)
+		}
+	}
+
+	endpoint := fmt.Sprintf("/api/v1/shelves/%s", projectID)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shelves: %w", err)
+	}
+
+	var shelvesResp struct {
+		Success bool        `json:"success"`
+		Shelves []ShelfInfo `json:"shelves"`
+	}
+	if err := json.Unmarshal(resp, &shelvesResp); err != nil {
+		return nil, fmt.Errorf("failed to parse shelves response: %w", err)
+	}
+	return shelvesResp.Shelves, nil
+}
+
+// GetShelf gets a shelf and its files
+func (c *APIClient) GetShelf(projectID, shelfID string) (*ShelfInfo, error) {
+	resp, err := c.makeRequest("GET", fmt.Sprintf("/api/v1/shelves/%s/%s", projectID, shelfID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shelf: %w", err)
+	}
+	return parseShelfResponse(resp)
+}
+
+// DeleteShelf deletes a shelf from the server
+func (c *APIClient) DeleteShelf(projectID, shelfID string) error {
+	_, err := c.makeRequest("DELETE", fmt.Sprintf("/api/v1/shelves/%s/%s", projectID, shelfID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete shelf: %w", err)
+	}
+	return nil
+}
+
+// StageFiles marks index entries as staged and saves the index
+func (c *APIClient) StageFiles(paths []string) error {
+	c.fileIndex.MarkStaged(paths)
+	if err := c.fileIndex.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	return nil
+}
+
+func parseShelfResponse(resp []byte) (*ShelfInfo, error) {
+	var shelfResp struct {
+		Success bool      `json:"success"`
+		Shelf   ShelfInfo `json:"shelf"`
+	}
+	if err := json.Unmarshal(resp, &shelfResp); err != nil {
+		return nil, fmt.Errorf("failed to parse shelf response: %w", err)
+	}
+	return &shelfResp.Shelf, nil
+}
+
 // GetBranchInfo gets information about a specific branch
 func (c *APIClient) GetBranchInfo(projectID, branch string) ([]byte, error) {
 	url := fmt.Sprintf("/api/v1/sync/%s/branches/%s", projectID, branch)
@@ -580,18 +1464,33 @@ func (c *APIClient) PerformCleanup(cleanupType string) error {
 
 // Helper method to make HTTP requests
 func (c *APIClient) makeRequest(method, endpoint string, data interface{}) ([]byte, error) {
+	status, responseBody, err := c.makeRequestWithStatus(method, endpoint, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if status >= 400 {
+		return nil, fmt.Errorf("request failed with status %d: %s", status, string(responseBody))
+	}
+
+	return responseBody, nil
+}
+
+// makeRequestWithStatus makes an HTTP request and returns the status and body even when
+// the server answers with an error, for endpoints whose error responses carry details
+func (c *APIClient) makeRequestWithStatus(method, endpoint string, data interface{}) (int, []byte, error) {
 	var body io.Reader
 	if data != nil {
 		jsonData, err := json.Marshal(data)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request data: %w", err)
+			return 0, nil, fmt.Errorf("failed to marshal request data: %w", err)
 		}
 		body = bytes.NewReader(jsonData)
 	}
 
 	req, err := http.NewRequest(method, c.baseURL+endpoint, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if data != nil {
@@ -604,58 +1503,28 @@ func (c *APIClient) makeRequest(method, endpoint string, data interface{}) ([]by
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return 0, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(responseBody))
-	}
-
-	return responseBody, nil
+	return resp.StatusCode, responseBody, nil
 }
 
 // Utility functions for file operations
 
 // IsUE5Asset checks if a file is a UE5 asset
 func IsUE5Asset(filePath string) bool {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	ue5Extensions := []string{".uasset", ".umap", ".uexp", ".ubulk"}
-
-	for _, ue5Ext := range ue5Extensions {
-		if ext == ue5Ext {
-			return true
-		}
-	}
-
-	return false
+	return analyzer.DefaultRegistry().IsEngineFile(filePath, analyzer.ProjectTypeUnreal)
 }
 
 // IsBinaryAsset checks if a file should be treated as binary
 func IsBinaryAsset(filePath string) bool {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	binaryExtensions := []string{
-		".uasset", ".umap", ".uexp", ".ubulk", // UE5
-		".fbx", ".obj", ".dae", // 3D models
-		".png", ".jpg", ".jpeg", ".tga", ".bmp", ".gif", // Images
-		".wav", ".mp3", ".ogg", ".flac", // Audio
-		".mp4", ".avi", ".mov", // Video
-		".exe", ".dll", ".so", ".dylib", // Executables
-		".zip", ".rar", ".7z", // Archives
-	}
-
-	for _, binExt := range binaryExtensions {
-		if ext == binExt {
-			return true
-		}
-	}
-
-	return false
+	return analyzer.DefaultRegistry().IsBinary(filePath)
 }
 
 // GetFileSize returns the size of a file
@@ -1006,15 +1875,9 @@ func (fc *FileCache) GetStats() map[string]int {
 	}
 }
 
-func (c *APIClient) ProcessFilesBatchGitStyle(projectID string, filePaths []string) (*BatchUploadResult, error) {
-	return c.processFilesBatchGitStyleInternal(projectID, filePaths, false)
-}
-
-func (c *APIClient) ProcessFilesBatchGitStyleForCommit(projectID string, filePaths []string) (*BatchUploadResult, error) {
-	return c.processFilesBatchGitStyleInternal(projectID, filePaths, true)
-}
-
-func (c *APIClient) processFilesBatchGitStyleInternal(projectID string, filePaths []string, forceProcess bool) (*BatchUploadResult, error) {
+// ProcessFilesBatchGitStyle stores changed files in the local object store and stages them
+// in the index. Nothing is sent to the server until push.
+func (c *APIClient) ProcessFilesBatchGitStyle(out io.Writer, projectID string, filePaths []string) (*BatchUploadResult, error) {
 	start := time.Now()
 
 	result := &BatchUploadResult{
@@ -1023,41 +1886,33 @@ func (c *APIClient) processFilesBatchGitStyleInternal(projectID string, filePath
 		ObjectsStored: make(map[string]*storage.ObjectInfo),
 	}
 
-	var changedFiles []string
-	var err error
+	fmt.Fprintf(out, "🔍 Phase 1: Checking %d files for changes using stat optimization...\n", len(filePaths))
 
-	if forceProcess {
-		fmt.Printf("🔄 Processing %d files for commit (forced)...\n", len(filePaths))
-		// For commit, process all files regardless of stat optimization
-		changedFiles = filePaths
-	} else {
-		fmt.Printf("🔍 Phase 1: Checking %d files for changes using stat optimization...\n", len(filePaths))
-
-		// STEP 1: Batch stat-based change detection
-		changedFiles, err = c.fileIndex.GetChangedFiles(filePaths)
-		if err != nil {
-			return nil, fmt.Errorf("failed to detect changes: %w", err)
-		}
-
-		skippedCount := len(filePaths) - len(changedFiles)
-		if skippedCount > 0 {
-			fmt.Printf("⏭️  Skipped %d unchanged files (stat optimization)\n", skippedCount)
-		}
+	// STEP 1: Batch stat-based change detection
+	changedFiles, err := c.fileIndex.GetChangedFiles(filePaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect changes: %w", err)
+	}
 
-		if len(changedFiles) == 0 {
-			fmt.Printf("✅ All files are up to date!\n")
-			result.SkippedFiles = len(filePaths)
-			result.Duration = time.Since(start)
-			return result, nil
-		}
+	skippedCount := len(filePaths) - len(changedFiles)
+	if skippedCount > 0 {
+		fmt.Fprintf(out, "⏭️  Skipped %d unchanged files (stat optimization)\n", skippedCount)
+	}
 
-		fmt.Printf("📝 Processing %d changed files...\n", len(changedFiles))
+	if len(changedFiles) == 0 {
+		fmt.Fprintf(out, "✅ All files are up to date!\n")
+		result.SkippedFiles = len(filePaths)
+		result.Duration = time.Since(start)
+		return result, nil
 	}
 
+	fmt.Fprintf(out, "📝 Processing %d changed files...\n", len(changedFiles))
+
 	// STEP 2: Calculate hashes and store objects locally
 	fileToHash := make(map[string]string)
 	hashToFile := make(map[string][]string) // Multiple files can have same content
 
+	var deletions []string
 	for _, filePath := range changedFiles {
 		fileResult := FileUploadResult{
 			FilePath: filePath,
@@ -1065,6 +1920,14 @@ func (c *APIClient) processFilesBatchGitStyleInternal(projectID string, filePath
 
 		// Calculate hash and store in object store
 		file, err := os.Open(filePath)
+		if os.IsNotExist(err) {
+			// Only files the index tracks count as changed when missing; stage their deletion
+			deletions = append(deletions, filePath)
+			fileResult.Success = true
+			fileResult.Deleted = true
+			result.Results = append(result.Results, fileResult)
+			continue
+		}
 		if err != nil {
 			fileResult.Error = fmt.Errorf("failed to open file: %w", err)
 			result.Results = append(result.Results, fileResult)
@@ -1095,50 +1958,22 @@ func (c *APIClient) processFilesBatchGitStyleInternal(projectID string, filePath
 		result.Results = append(result.Results, fileResult)
 	}
 
-	// STEP 3: Check which objects already exist on server (batch)
-	hashes := make([]string, 0, len(result.ObjectsStored))
-	for hash := range result.ObjectsStored {
-		hashes = append(hashes, hash)
-	}
-
-	existingObjects, err := c.batchCheckObjectsExist(hashes)
-	if err != nil {
-		fmt.Printf("⚠️  Failed to check existing objects: %v\n", err)
-		// Continue anyway - will upload all objects
-		existingObjects = make(map[string]bool)
-	}
-
-	// STEP 4: Upload only new objects (batch)
-	newObjects := make(map[string]*storage.ObjectInfo)
-	for hash, objectInfo := range result.ObjectsStored {
-		if !existingObjects[hash] {
-			newObjects[hash] = objectInfo
-		}
-	}
-
-	if len(newObjects) > 0 {
-		fmt.Printf("📤 Uploading %d new objects...\n", len(newObjects))
-		err := c.batchUploadObjects(projectID, newObjects)
-		if err != nil {
-			return nil, fmt.Errorf("failed to upload objects: %w", err)
-		}
-	} else {
-		fmt.Printf("✅ All objects already exist on server\n 🔄 Skipping upload\n")
-	}
-
-	// STEP 5: Update file index with new hashes
+	// STEP 3: Update file index with new hashes
 	indexUpdates := make(map[string]string)
 	for filePath, hash := range fileToHash {
 		indexUpdates[filePath] = hash
 	}
 
 	if err := c.fileIndex.BatchUpdateEntries(indexUpdates); err != nil {
-		fmt.Printf("⚠️  Failed to update file index: %v\n", err)
+		fmt.Fprintf(out, "⚠️  Failed to update file index: %v\n", err)
+	}
+	for _, filePath := range deletions {
+		c.fileIndex.StageDeletion(filePath)
 	}
 
-	// STEP 6: Save index to disk
+	// STEP 4: Save index to disk
 	if err := c.fileIndex.Save(); err != nil {
-		fmt.Printf("⚠️  Failed to save file index: %v\n", err)
+		fmt.Fprintf(out, "⚠️  Failed to save file index: %v\n", err)
 	}
 
 	// Update results
@@ -1146,90 +1981,24 @@ func (c *APIClient) processFilesBatchGitStyleInternal(projectID string, filePath
 	result.SkippedFiles = len(filePaths) - len(changedFiles)
 	result.Duration = time.Since(start)
 
-	fmt.Printf("✅ Batch processing completed: %d processed, %d skipped in %v\n",
+	fmt.Fprintf(out, "✅ Batch processing completed: %d processed, %d skipped in %v\n",
 		result.ProcessedFiles, result.SkippedFiles, result.Duration)
 
 	return result, nil
 }
 
-func (c *APIClient) batchCheckObjectsExist(hashes []string) (map[string]bool, error) {
-	if len(hashes) == 0 {
-		return make(map[string]bool), nil
-	}
-
-	// For now, check each hash individually (Phase 2 will optimize this)
-	results := make(map[string]bool, len(hashes))
-
-	for _, hash := range hashes {
-		exists, err := c.CheckServerHasFile(hash)
-		if err != nil {
-			// Assume doesn't exist on error
-			results[hash] = false
-		} else {
-			results[hash] = exists
-		}
-	}
-
-	return results, nil
+// CheckServerHasFile checks if server has an object by hash
+func (c *APIClient) CheckServerHasFile(contentHash string) (bool, error) {
+	return c.checkObject(fmt.Sprintf("%s/api/v1/files/exists/%s", c.baseURL, contentHash))
 }
 
-func (c *APIClient) batchUploadObjects(projectID string, objects map[string]*storage.ObjectInfo) error {
-	// Create batch upload request
-	req := fileops.BatchUploadRequest{
-		ProjectID: projectID,
-		Objects:   objects,
-		FileMap:   make(map[string]string), // We'll populate this from the file index
-		UserID:    "cli-user",              // TODO: Get from auth
-		UserName:  "CLI User",
-		SessionID: c.sessionID,
-	}
-
-	// Get file paths for each hash from the file index
-	stagedEntries := c.fileIndex.GetStagedEntries()
-	for hash := range objects {
-		// Find files that have this hash
-		for filePath, entry := range stagedEntries {
-			if entry.Hash == hash {
-				req.FileMap[filePath] = hash
-			}
-		}
-	}
-
-	// Convert to JSON
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return fmt.Errorf("failed to marshal batch request: %w", err)
-	}
-
-	// Make batch upload request
-	url := fmt.Sprintf("%s/api/v1/files/batch-upload?project=%s", c.baseURL, projectID)
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create batch upload request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	if c.authToken != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+c.authToken)
-	}
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("batch upload request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("batch upload failed with status %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	return nil
+// checkProjectHasObject checks if the server has an object the project may use, i.e. one
+// uploaded to it rather than only to another project
+func (c *APIClient) checkProjectHasObject(projectID, contentHash string) (bool, error) {
+	return c.checkObject(fmt.Sprintf("%s/api/v1/files/exists/%s?project=%s", c.baseURL, contentHash, url.QueryEscape(projectID)))
 }
 
-// CheckServerHasFile checks if server has an object by hash
-func (c *APIClient) CheckServerHasFile(contentHash string) (bool, error) {
-	url := fmt.Sprintf("%s/api/v1/files/exists/%s", c.baseURL, contentHash)
+func (c *APIClient) checkObject(url string) (bool, error) {
 
 	req, err := http.NewRequest("HEAD", url, nil)
 	if err != nil {
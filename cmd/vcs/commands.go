@@ -11,15 +11,19 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
 	"golang.org/x/term"
 
-	fileops "github.com/Telerallc/gamedev-vcs/internal/fileOps"
+	"github.com/Telerallc/gamedev-vcs/internal/analyzer"
+	"github.com/Telerallc/gamedev-vcs/internal/integrity"
+	"github.com/Telerallc/gamedev-vcs/internal/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -56,6 +60,23 @@ type CommitInfo struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// FileVersionInfo is one version of a file in its server-side history
+type FileVersionInfo struct {
+	CommitID    string                 `json:"commit_id"`
+	ContentHash string                 `json:"content_hash"`
+	Size        int64                  `json:"size"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Changes     []string               `json:"changes"`
+	CreatedAt   time.Time              `json:"created_at"`
+	Commit      struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name     string `json:"name"`
+			Username string `json:"username"`
+		} `json:"author"`
+	} `json:"commit"`
+}
+
 type FileInfo struct {
 	Path        string `json:"path"`
 	ContentHash string `json:"content_hash"`
@@ -648,6 +669,7 @@ Examples:
 func addCmd() *cobra.Command {
 	var addAll bool
 	var verbose bool
+	var allowSparse bool
 
 	cmd := &cobra.Command{
 		Use:   "add [files...]",
@@ -662,19 +684,64 @@ Phase 1 Optimizations:
 
 Examples:
   vcs add file1.cpp file2.h         # Add specific files
+  vcs add Content/Maps              # Add everything in a folder
   vcs add -a                        # Add all files (respecting .vcsignore)
-  vcs add --verbose -a              # Show detailed processing info`,
+  vcs add --verbose -a              # Show detailed processing info
+
+Paths are relative to the current directory, which may be anywhere inside the project.
+
+In a sparse checkout, -a and folders skip files outside the sparse patterns, and naming
+such a file is refused unless --sparse is given. Files a partial clone hasn't downloaded
+yet are never staged as deleted.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runAddGitStyle(args, addAll, verbose)
+			return runAddGitStyle(os.Stdout, args, addAll, verbose, allowSparse)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&addAll, "all", "a", false, "Add all files")
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed processing information")
+	cmd.Flags().BoolVar(&allowSparse, "sparse", false, "Allow adding files outside the sparse checkout")
 
 	return cmd
 }
 
+// findProjectRoot returns the closest folder at or above the working directory that holds
+// a .vcs directory
+func findProjectRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".vcs")); err == nil && info.IsDir() {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf(".vcs directory not found")
+		}
+		dir = parent
+	}
+}
+
+// projectRelativePaths turns paths relative to the working directory into slash-separated
+// paths relative to the project root; "." is the whole project
+func projectRelativePaths(root string, paths []string) ([]string, error) {
+	relative := make([]string, 0, len(paths))
+	for _, path := range paths {
+		absolute, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(root, absolute)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is outside the project", path)
+		}
+		relative = append(relative, filepath.ToSlash(rel))
+	}
+	return relative, nil
+}
+
 // Helper function to load project config
 func LoadProjectConfig() (map[string]interface{}, error) {
 	// Check if .vcs directory exists
@@ -775,6 +842,18 @@ func lockCmd() *cobra.Command {
 
 			fmt.Printf("✅ Locked %s\n", filePath)
 
+			// A file locked for editing has to be in the working copy, even in a partial clone
+			if config, err := LoadProjectConfig(); err == nil {
+				if projectID, ok := config["project_id"].(string); ok && projectID != "" {
+					hydrated, err := hydrateForEditing(projectID, normalizePathspec([]string{filePath}))
+					if err != nil {
+						fmt.Printf("⚠️  Warning: failed to download %s: %v\n", filePath, err)
+					} else if hydrated > 0 {
+						fmt.Printf("💧 Downloaded %s for editing\n", filePath)
+					}
+				}
+			}
+
 			if result.LockInfo != nil {
 				if expiresAt, ok := result.LockInfo["expires_at"].(string); ok {
 					if expTime, err := time.Parse(time.RFC3339, expiresAt); err == nil {
@@ -954,493 +1033,4260 @@ func analyticsCmd() *cobra.Command {
 	cmd.Flags().IntVar(&limit, "limit", 10, "Number of recent activities to show")
 	cmd.Flags().StringVar(&assetPath, "asset", "", "Asset path to analyze dependencies")
 
+	cmd.AddCommand(analyticsDepsCmd())
+
 	return cmd
 }
 
-func watchCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "watch",
-		Short: "Watch for real-time collaboration events",
+func analyticsDepsCmd() *cobra.Command {
+	var mode string
+	var assetPath string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "deps",
+		Short: "Analyze circular references and hard-reference load chains",
+		Long: `Analyze the project's asset dependency graph.
+
+Modes:
+  offenders  Assets with the largest hard-reference load chains (default)
+  cycles     Circular hard-reference chains
+  closure    Everything a single asset drags in (requires --asset)`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if mode == "closure" && assetPath == "" {
+				return fmt.Errorf("--asset is required for closure mode")
+			}
+
 			if err := initializeClient(); err != nil {
 				return err
 			}
 
-			if currentProject == "" {
-				currentProject = "default"
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
 			}
 
-			fmt.Printf("🔄 Watching for real-time events in project: %s\n", currentProject)
-			fmt.Printf("Press Ctrl+C to stop...\n\n")
-
-			// Update presence to online
-			apiClient.UpdatePresence(currentProject, "online", "")
-
-			return apiClient.SubscribeToEvents(currentProject, func(event map[string]interface{}) {
-				if eventType, ok := event["type"].(string); ok {
-					if userName, ok := event["user_name"].(string); ok {
-						timestamp := time.Now().Format("15:04:05")
-						icon := getEventIcon(eventType)
-
-						message := fmt.Sprintf("[%s] %s %s", timestamp, icon, userName)
-
-						if filePath, ok := event["file_path"].(string); ok && filePath != "" {
-							message += fmt.Sprintf(" %s %s", eventType, filePath)
-						} else {
-							message += fmt.Sprintf(" %s", eventType)
-						}
-
-						fmt.Println(message)
-					}
-				}
-			})
-		},
-	}
-}
-
-func storageCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "storage",
-		Short: "Show storage statistics",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := initializeClient(); err != nil {
-				return err
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
 			}
 
-			resp, err := apiClient.GetStorageStats()
+			resp, err := apiClient.GetDependencyAnalysis(projectID, mode, assetPath, limit)
 			if err != nil {
-				return fmt.Errorf("failed to get storage stats: %w", err)
+				return fmt.Errorf("failed to analyze dependencies: %w", err)
 			}
 
-			var stats struct {
-				Success bool                   `json:"success"`
-				Stats   map[string]interface{} `json:"stats"`
+			var result struct {
+				PackageCount int        `json:"package_count"`
+				Cycles       [][]string `json:"cycles"`
+				LoadChain    *struct {
+					PackageName       string  `json:"package_name"`
+					FilePath          string  `json:"file_path"`
+					OwnSize           int64   `json:"own_size"`
+					PackageCount      int     `json:"package_count"`
+					TotalBytes        int64   `json:"total_bytes"`
+					EstimatedLoadTime float64 `json:"estimated_load_time_ms"`
+					IsCircular        bool    `json:"is_circular"`
+				} `json:"load_chain"`
+				Offenders []struct {
+					FilePath          string  `json:"file_path"`
+					PackageCount      int     `json:"package_count"`
+					TotalBytes        int64   `json:"total_bytes"`
+					EstimatedLoadTime float64 `json:"estimated_load_time_ms"`
+					IsCircular        bool    `json:"is_circular"`
+				} `json:"offenders"`
 			}
 
-			if err := json.Unmarshal(resp, &stats); err != nil {
-				return fmt.Errorf("failed to parse storage stats: %w", err)
+			if err := json.Unmarshal(resp, &result); err != nil {
+				return fmt.Errorf("failed to parse dependency analysis: %w", err)
 			}
 
-			fmt.Println("💾 Storage Statistics:")
+			switch mode {
+			case "cycles":
+				if len(result.Cycles) == 0 {
+					fmt.Printf("✅ No circular references across %d packages\n", result.PackageCount)
+					return nil
+				}
 
-			if totalFiles, ok := stats.Stats["total_files"].(float64); ok {
-				fmt.Printf("   Total Files: %.0f\n", totalFiles)
-			}
+				fmt.Printf("🔄 %d circular reference chains:\n", len(result.Cycles))
+				for i, cycle := range result.Cycles {
+					fmt.Printf("\n   Cycle %d (%d packages):\n", i+1, len(cycle))
+					for _, pkg := range cycle {
+						fmt.Printf("     ↻ %s\n", pkg)
+					}
+				}
 
-			if totalSize, ok := stats.Stats["total_size"].(float64); ok {
-				fmt.Printf("   Total Size: %s\n", FormatFileSize(int64(totalSize)))
-			}
+			case "closure":
+				chain := result.LoadChain
+				if chain == nil {
+					return fmt.Errorf("no load chain returned for %s", assetPath)
+				}
 
-			if basePath, ok := stats.Stats["base_path"].(string); ok {
-				fmt.Printf("   Storage Path: %s\n", basePath)
+				fmt.Printf("🔗 %s drags in %s across %d packages\n",
+					filepath.Base(chain.FilePath), FormatFileSize(chain.TotalBytes), chain.PackageCount)
+				fmt.Printf("   Own size:       %s\n", FormatFileSize(chain.OwnSize))
+				fmt.Printf("   Est. load time: %.0fms\n", chain.EstimatedLoadTime)
+				if chain.IsCircular {
+					fmt.Println("   ⚠️  Part of a circular reference chain")
+				}
+
+			default:
+				if len(result.Offenders) == 0 {
+					fmt.Println("📭 No assets found")
+					return nil
+				}
+
+				fmt.Printf("🐘 Heaviest load chains (%d packages analyzed):\n", result.PackageCount)
+				for _, offender := range result.Offenders {
+					circular := ""
+					if offender.IsCircular {
+						circular = " 🔄"
+					}
+					fmt.Printf("   %10s  %4d pkgs  %6.0fms  %s%s\n",
+						FormatFileSize(offender.TotalBytes), offender.PackageCount,
+						offender.EstimatedLoadTime, offender.FilePath, circular)
+				}
 			}
 
 			return nil
 		},
 	}
-}
 
-func cloneCmd() *cobra.Command {
-	var branch string
-	var shallow bool
+	cmd.Flags().StringVar(&mode, "mode", "offenders", "Analysis mode: offenders, cycles or closure")
+	cmd.Flags().StringVar(&assetPath, "asset", "", "Asset to compute the load chain for (closure mode)")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Number of offenders to show")
 
+	return cmd
+}
+
+func impactCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "clone <project-id> [directory]",
-		Short: "Clone a project from the server",
-		Args:  cobra.RangeArgs(1, 2),
+		Use:   "impact [files...]",
+		Short: "Show which levels and assets reference the staged changes",
+		Long: `Compute every asset that transitively references the staged files (or the
+given files) through hard or soft references, grouped by level and by owner, and
+flag referencers that are currently locked by someone else.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := initializeClient(); err != nil {
-				return err
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
 			}
 
-			projectID := args[0]
-			directory := projectID // Default to project ID as directory name
-			if len(args) > 1 {
-				directory = args[1]
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
 			}
 
-			if verbose {
-				fmt.Printf("Cloning project: %s\n", projectID)
-				fmt.Printf("Into directory: %s\n", directory)
+			if err := initializeClient(); err != nil {
+				return fmt.Errorf("failed to initialize client: %w", err)
 			}
 
-			// Create directory
-			if err := os.MkdirAll(directory, 0755); err != nil {
-				return fmt.Errorf("failed to create directory: %w", err)
-			}
+			filePaths := args
+			if len(filePaths) == 0 {
+				localState, err := LoadLocalState()
+				if err != nil {
+					return fmt.Errorf("failed to load local state: %w", err)
+				}
 
-			// Change to the new directory
-			if err := os.Chdir(directory); err != nil {
-				return fmt.Errorf("failed to change directory: %w", err)
+				seen := make(map[string]bool)
+				for _, filePath := range append(localState.GetStagedFiles(), apiClient.GetIndexStagedFiles()...) {
+					if !seen[filePath] {
+						seen[filePath] = true
+						filePaths = append(filePaths, filePath)
+					}
+				}
 			}
 
-			// Initialize VCS in the directory
-			if err := initializeProject(projectID); err != nil {
-				return fmt.Errorf("failed to initialize project: %w", err)
+			if len(filePaths) == 0 {
+				fmt.Println("📝 No files staged for commit")
+				return nil
 			}
 
-			// Get project info and files
-			projectInfo, err := getProjectInfo(projectID)
+			resp, err := apiClient.AnalyzeImpact(projectID, filePaths)
 			if err != nil {
-				return fmt.Errorf("failed to get project info: %w", err)
+				return fmt.Errorf("failed to analyze impact: %w", err)
 			}
 
-			fmt.Printf("📥 Cloning project: %s\n", projectInfo.Name)
-
-			// Get the target branch
-			targetBranch := branch
-			if targetBranch == "" {
-				targetBranch = projectInfo.DefaultBranch
+			var result struct {
+				Impact struct {
+					ChangedAssets []string `json:"changed_assets"`
+					Referencers   []struct {
+						FilePath string `json:"file_path"`
+						IsLevel  bool   `json:"is_level"`
+						LockedBy string `json:"locked_by"`
+					} `json:"referencers"`
+					Levels []struct {
+						Level         string   `json:"level"`
+						Owner         string   `json:"owner"`
+						ChangedAssets []string `json:"changed_assets"`
+						Referencers   []string `json:"referencers"`
+					} `json:"levels"`
+					ByOwner        map[string][]string `json:"by_owner"`
+					LockedByOthers []struct {
+						FilePath string `json:"file_path"`
+						LockedBy string `json:"locked_by"`
+					} `json:"locked_by_others"`
+				} `json:"impact"`
 			}
 
-			// Get commit history
-			commits, err := getCommitHistory(projectID, targetBranch)
-			if err != nil {
-				return fmt.Errorf("failed to get commit history: %w", err)
+			if err := json.Unmarshal(resp, &result); err != nil {
+				return fmt.Errorf("failed to parse impact response: %w", err)
 			}
+			impact := result.Impact
 
-			if len(commits) == 0 {
-				fmt.Printf("✅ Empty repository cloned\n")
+			if len(impact.ChangedAssets) == 0 {
+				fmt.Println("📭 No staged assets can be referenced by other assets")
 				return nil
 			}
 
-			// Get latest commit
-			latestCommit := commits[0]
-
-			// Download all files from the latest commit
-			files, err := getCommitFiles(projectID, latestCommit.ID)
-			if err != nil {
-				return fmt.Errorf("failed to get commit files: %w", err)
+			fmt.Printf("🎯 Impact of %d changed assets:\n", len(impact.ChangedAssets))
+			for _, asset := range impact.ChangedAssets {
+				fmt.Printf("   ✏️  %s\n", asset)
 			}
 
-			fmt.Printf("📦 Downloading %d files...\n", len(files))
+			if len(impact.Referencers) == 0 {
+				fmt.Println("\n✅ Nothing else references these assets")
+				return nil
+			}
 
-			for i, file := range files {
-				if verbose {
-					fmt.Printf("  [%d/%d] %s\n", i+1, len(files), file.Path)
+			if len(impact.Levels) > 0 {
+				fmt.Printf("\n🗺️  Levels to re-test (%d):\n", len(impact.Levels))
+				for _, level := range impact.Levels {
+					owner := ""
+					if level.Owner != "" {
+						owner = fmt.Sprintf(" (%s)", level.Owner)
+					}
+					fmt.Printf("   🗺️  %s%s\n", level.Level, owner)
+					for _, referencer := range level.Referencers {
+						fmt.Printf("      ↳ %s\n", referencer)
+					}
 				}
+			}
 
-				if err := downloadFile(file); err != nil {
-					fmt.Printf("⚠️  Failed to download %s: %v\n", file.Path, err)
-					continue
+			var assets []string
+			for _, referencer := range impact.Referencers {
+				if !referencer.IsLevel {
+					assets = append(assets, referencer.FilePath)
 				}
 			}
-
-			// Initialize local state
-			localState := &LocalState{
-				ProjectID:     projectID,
-				CurrentBranch: targetBranch,
-				LocalCommits:  []string{latestCommit.ID},
-				RemoteCommits: []string{latestCommit.ID},
-				LastSync:      time.Now().Format(time.RFC3339),
-				StagedFiles:   make(map[string]FileState),
-				LocalRefs:     map[string]string{targetBranch: latestCommit.ID},
+			if len(assets) > 0 {
+				fmt.Printf("\n🔗 Referencing assets (%d):\n", len(assets))
+				for _, asset := range assets {
+					fmt.Printf("   ← %s\n", asset)
+				}
 			}
 
-			if err := localState.SaveLocalState(); err != nil {
-				return fmt.Errorf("failed to save local state: %w", err)
+			if len(impact.ByOwner) > 0 {
+				owners := make([]string, 0, len(impact.ByOwner))
+				for owner := range impact.ByOwner {
+					owners = append(owners, owner)
+				}
+				sort.Strings(owners)
+
+				fmt.Printf("\n👥 By owner:\n")
+				for _, owner := range owners {
+					fmt.Printf("   👤 %s: %d assets\n", owner, len(impact.ByOwner[owner]))
+				}
 			}
 
-			fmt.Printf("✅ Successfully cloned project '%s'\n", projectInfo.Name)
-			fmt.Printf("📝 Branch: %s\n", targetBranch)
-			fmt.Printf("💾 Latest commit: %s\n", latestCommit.ID[:8])
-			fmt.Printf("📁 %d files downloaded\n", len(files))
+			if len(impact.LockedByOthers) > 0 {
+				fmt.Printf("\n🔒 Referencers locked by others (%d):\n", len(impact.LockedByOthers))
+				for _, locked := range impact.LockedByOthers {
+					fmt.Printf("   🔒 %s (locked by %s)\n", locked.FilePath, locked.LockedBy)
+				}
+			}
 
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&branch, "branch", "b", "", "Clone specific branch")
-	cmd.Flags().BoolVar(&shallow, "shallow", false, "Shallow clone (latest commit only)")
-
 	return cmd
 }
 
-func commitCmd() *cobra.Command {
-	var message string
-	var addAll bool
+func diffCmd() *cobra.Command {
+	var semantic, table, staged, stat, nameStatus bool
 
 	cmd := &cobra.Command{
-		Use:   "commit",
-		Short: "Create a commit with Git-style optimizations",
-		Long: `Create a commit from staged files.
-
-Phase 1 Optimizations:
-- Uses Git-style object storage for content
-- Leverages local index for fast status checks
-- Batch operations for better performance`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCommitGitStyle(message, addAll)
-		},
-	}
+		Use:   "diff [--staged] [<commit> [<commit>]] [-- <paths>...]",
+		Short: "Show changes in the working copy, the staging area or between commits",
+		Long: `Show what changed, file by file.
 
-	cmd.Flags().StringVarP(&message, "message", "m", "", "Commit message")
-	cmd.Flags().BoolVarP(&addAll, "all", "a", false, "Add all changes before commit")
+With no commits the working copy is compared against the staging area; --staged
+compares the staged files against HEAD. One commit compares it against the
+working copy (or the staged files with --staged), two commits compare them
+with each other. Paths after -- limit the diff to those files and folders.
 
-	return cmd
-}
+Text files (config .ini, C++, .json) get unified diffs. Binary files show their
+size and hash, plus what changed in terms an analyzer understands: DataTable
+rows, dependencies, texture and mesh properties, Blueprint members.
 
-func pushCmd() *cobra.Command {
-	var branch string
-	var force bool
+--stat and --name-status summarize the changed files instead.
 
-	cmd := &cobra.Command{
-		Use:   "push [files...]",
-		Short: "Push changes to the server",
+--semantic <file> and --table <file> compare a single Blueprint or DataTable
+revision by meaning; with no commits the working copy is compared against the
+version at HEAD, with one commit that commit is compared against the
+current version on the server.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := initializeClient(); err != nil {
-				return err
-			}
-
-			if currentProject == "" {
-				return fmt.Errorf("not in a VCS project directory. Run 'vcs init' first")
+			if semantic && table {
+				return fmt.Errorf("pass at most one of --semantic or --table")
 			}
 
-			// Load local state
-			localState, err := LoadLocalState()
+			config, err := LoadProjectConfig()
 			if err != nil {
-				return fmt.Errorf("failed to load local state: %w", err)
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
 			}
 
-			// Use provided branch or current branch
-			targetBranch := branch
-			if targetBranch == "" {
-				targetBranch = localState.CurrentBranch
-			}
-			if targetBranch == "" {
-				targetBranch = "main"
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
 			}
 
-			if verbose {
-				fmt.Printf("Pushing to branch: %s\n", targetBranch)
+			if err := initializeClient(); err != nil {
+				return fmt.Errorf("failed to initialize client: %w", err)
 			}
 
-			// Get sync status first
-			statusResp, err := apiClient.GetSyncStatus(currentProject, targetBranch, localState.LocalCommits)
-			if err != nil {
-				return fmt.Errorf("failed to get sync status: %w", err)
+			if !semantic && !table {
+				mode := diffModePatch
+				if stat {
+					mode = diffModeStat
+				}
+				if nameStatus {
+					mode = diffModeNameStatus
+				}
+				return runDiff(projectID, cmd, args, staged, mode)
 			}
 
-			var syncStatus struct {
-				Success       bool     `json:"success"`
-				Status        string   `json:"status"`
-				Ahead         int      `json:"ahead"`
-				Behind        int      `json:"behind"`
-				BehindCommits []string `json:"behind_commits"`
+			if len(args) < 1 || len(args) > 3 {
+				return fmt.Errorf("usage: vcs diff (--semantic | --table) <file> [<from-commit> [<to-commit>]]")
 			}
+			filePath := filepath.ToSlash(args[0])
 
-			if err := json.Unmarshal(statusResp, &syncStatus); err != nil {
-				return fmt.Errorf("failed to parse sync status: %w", err)
-			}
+			if len(args) == 1 {
+				committedContent, workingContent, err := loadWorkingRevisions(projectID, filePath)
+				if err != nil {
+					return err
+				}
 
-			// Check if we need to pull first
-			if syncStatus.Behind > 0 && !force {
-				fmt.Printf("⚠️  Your branch is behind by %d commits. Please run 'vcs pull' first.\n", syncStatus.Behind)
-				fmt.Printf("   Or use --force to push anyway (may cause conflicts).\n")
-				return nil
-			}
+				if table {
+					diff, err := diffTableContent(committedContent, workingContent)
+					if err != nil {
+						return err
+					}
+					printTableDiff(filePath, diff)
+					return nil
+				}
 
-			if syncStatus.Ahead == 0 {
-				fmt.Println("✅ Everything up-to-date")
+				diff, err := integrity.NewBlueprintTracker().DiffBlueprints(committedContent, workingContent)
+				if err != nil {
+					return err
+				}
+				printBlueprintDiff(cmd.OutOrStdout(), filePath, diff)
 				return nil
 			}
 
-			// Perform the push
-			var filesToPush []string
-			if len(args) > 0 {
-				filesToPush = args
+			toCommit := ""
+			if len(args) == 3 {
+				toCommit = args[2]
 			}
 
-			pushResp, err := apiClient.PushChanges(currentProject, targetBranch, localState.LocalCommits, localState.RemoteCommits, filesToPush)
-			if err != nil {
-				return fmt.Errorf("failed to push changes: %w", err)
+			if table {
+				var diff analyzer.DataTableDiff
+				if err := fetchSemanticDiff(projectID, args[1], toCommit, filePath, "table", &diff); err != nil {
+					return err
+				}
+				printTableDiff(filePath, &diff)
+				return nil
 			}
 
-			var pushResult struct {
-				Success      bool     `json:"success"`
-				Updated      bool     `json:"updated"`
-				NewCommits   []string `json:"new_commits"`
-				RequiredPull bool     `json:"required_pull"`
-				Conflicts    []string `json:"conflicts"`
+			var diff integrity.BlueprintDiff
+			if err := fetchSemanticDiff(projectID, args[1], toCommit, filePath, "", &diff); err != nil {
+				return err
 			}
+			printBlueprintDiff(cmd.OutOrStdout(), filePath, &diff)
+			return nil
+		},
+	}
 
-			if err := json.Unmarshal(pushResp, &pushResult); err != nil {
-				return fmt.Errorf("failed to parse push response: %w", err)
-			}
+	cmd.Flags().BoolVar(&semantic, "semantic", false, "Compare Blueprint variables, functions and graphs")
+	cmd.Flags().BoolVar(&table, "table", false, "Compare DataTable or CurveTable rows")
+	cmd.Flags().BoolVar(&staged, "staged", false, "Compare the staged files instead of the working copy")
+	cmd.Flags().BoolVar(&stat, "stat", false, "Show a summary of changed lines per file")
+	cmd.Flags().BoolVar(&nameStatus, "name-status", false, "Show only the names and kinds of changed files")
+	return cmd
+}
 
-			if !pushResult.Success {
-				if pushResult.RequiredPull {
+// Output modes of 'vcs diff'
+const (
+	diffModePatch      = "patch"
+	diffModeStat       = "stat"
+	diffModeNameStatus = "name-status"
+)
+
+// diffSide is one side of a diff: the content hash of every file by path. Working copy
+// content is read from disk, everything else from the object store.
+type diffSide struct {
+	Files   map[string]string
+	Working bool
+}
+
+// fileChange is a file that differs between the two sides of a diff
+type fileChange struct {
+	Path   string
+	Status string // "A", "M" or "D"
+	From   string // Content hash on the old side, empty when added
+	To     string // Content hash on the new side, empty when deleted
+}
+
+func runDiff(projectID string, cmd *cobra.Command, args []string, staged bool, mode string) error {
+	out := cmd.OutOrStdout()
+	localState, err := LoadLocalState()
+	if err != nil {
+		return fmt.Errorf("failed to load local state: %w", err)
+	}
+
+	// Arguments after -- are paths; before it, anything that exists on disk is a path too
+	var commits, paths []string
+	dash := cmd.ArgsLenAtDash()
+	for i, arg := range args {
+		if dash >= 0 && i >= dash {
+			paths = append(paths, arg)
+		} else if _, err := os.Stat(arg); dash < 0 && err == nil {
+			paths = append(paths, arg)
+		} else {
+			commits = append(commits, arg)
+		}
+	}
+	paths = normalizePathspec(paths)
+	if len(commits) > 2 {
+		return fmt.Errorf("diff takes at most two commits, got %d", len(commits))
+	}
+	if len(commits) == 2 && staged {
+		return fmt.Errorf("--staged compares against the staging area, so it takes at most one commit")
+	}
+
+	var from, to *diffSide
+	switch len(commits) {
+	case 2:
+		if from, err = commitSide(projectID, resolveCommit(localState, commits[0])); err != nil {
+			return err
+		}
+		if to, err = commitSide(projectID, resolveCommit(localState, commits[1])); err != nil {
+			return err
+		}
+	case 1:
+		if from, err = commitSide(projectID, resolveCommit(localState, commits[0])); err != nil {
+			return err
+		}
+		if to, err = stagedSide(projectID, localState); err != nil {
+			return err
+		}
+	default:
+		if from, err = stagedSide(projectID, localState); err != nil {
+			return err
+		}
+		to = from
+		if staged {
+			if from, err = commitSide(projectID, localState.HeadCommit()); err != nil {
+				return err
+			}
+		}
+	}
+	if !staged && len(commits) < 2 {
+		if to, err = workingSide(to, from, paths); err != nil {
+			return err
+		}
+	}
+
+	changes := compareSides(from, to, paths)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	switch mode {
+	case diffModeNameStatus:
+		for _, change := range changes {
+			fmt.Fprintf(out, "%s\t%s\n", change.Status, change.Path)
+		}
+		return nil
+	case diffModeStat:
+		return printDiffStat(out, projectID, changes, from, to)
+	}
+
+	for i, change := range changes {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		if err := printFileDiff(out, projectID, change, from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitSide is the files at a commit
+func commitSide(projectID, commitID string) (*diffSide, error) {
+	snapshot, err := apiClient.SnapshotAt(projectID, commitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read files at %s: %w", shortID(commitID), err)
+	}
+
+	files := make(map[string]string, len(snapshot))
+	for path, entry := range snapshot {
+		files[path] = entry.Hash
+	}
+	return &diffSide{Files: files}, nil
+}
+
+// stagedSide is the files at HEAD with the staged versions in place of the committed ones
+func stagedSide(projectID string, localState *LocalState) (*diffSide, error) {
+	side, err := commitSide(projectID, localState.HeadCommit())
+	if err != nil {
+		return nil, err
+	}
+	for path, hash := range apiClient.StagedHashes(localState.GetStagedFiles()) {
+		if hash == storage.DeletedHash {
+			delete(side.Files, path)
+			continue
+		}
+		side.Files[path] = hash
+	}
+	return side, nil
+}
+
+// workingSide hashes the working copy of every file either side tracks; files that
+// were never tracked are left out, as are files outside paths. Files a sparse checkout
+// or partial clone leaves out count as unchanged.
+func workingSide(tracked, base *diffSide, paths []string) (*diffSide, error) {
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, side := range []*diffSide{tracked, base} {
+		for path := range side.Files {
+			if !seen[path] && (len(paths) == 0 || matchesPathspec(path, paths)) {
+				seen[path] = true
+				candidates = append(candidates, path)
+			}
+		}
+	}
+
+	files, err := apiClient.WorkingFileHashes(candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	sparse, err := loadSparseCheckout()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range candidates {
+		if hash, tracked := tracked.Files[path]; tracked && sparse.absent(path) {
+			if _, exists := files[path]; !exists {
+				files[path] = hash
+			}
+		}
+	}
+	return &diffSide{Files: files, Working: true}, nil
+}
+
+// compareSides lists the files that differ between two sides, sorted by path
+func compareSides(from, to *diffSide, paths []string) []fileChange {
+	var changes []fileChange
+	for path, toHash := range to.Files {
+		if len(paths) > 0 && !matchesPathspec(path, paths) {
+			continue
+		}
+		fromHash, existed := from.Files[path]
+		switch {
+		case !existed:
+			changes = append(changes, fileChange{Path: path, Status: "A", To: toHash})
+		case fromHash != toHash:
+			changes = append(changes, fileChange{Path: path, Status: "M", From: fromHash, To: toHash})
+		}
+	}
+	for path, fromHash := range from.Files {
+		if len(paths) > 0 && !matchesPathspec(path, paths) {
+			continue
+		}
+		if _, exists := to.Files[path]; !exists {
+			changes = append(changes, fileChange{Path: path, Status: "D", From: fromHash})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// loadDiffContent returns one side of a changed file, nil when the file doesn't exist there
+func loadDiffContent(projectID string, side *diffSide, path, hash string) ([]byte, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	if side.Working {
+		return os.ReadFile(path)
+	}
+	return apiClient.ReadObject(projectID, hash)
+}
+
+func loadChangeContents(projectID string, change fileChange, from, to *diffSide) ([]byte, []byte, error) {
+	fromContent, err := loadDiffContent(projectID, from, change.Path, change.From)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read old version of %s: %w", change.Path, err)
+	}
+	toContent, err := loadDiffContent(projectID, to, change.Path, change.To)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read new version of %s: %w", change.Path, err)
+	}
+	return fromContent, toContent, nil
+}
+
+func isBinaryChange(path string, fromContent, toContent []byte) bool {
+	return analyzer.DefaultRegistry().IsBinary(path) || isBinaryContent(fromContent) || isBinaryContent(toContent)
+}
+
+func printFileDiff(out io.Writer, projectID string, change fileChange, from, to *diffSide) error {
+	fromContent, toContent, err := loadChangeContents(projectID, change, from, to)
+	if err != nil {
+		return err
+	}
+
+	oldName, newName := "a/"+change.Path, "b/"+change.Path
+	if change.Status == "A" {
+		oldName = "/dev/null"
+	}
+	if change.Status == "D" {
+		newName = "/dev/null"
+	}
+	fmt.Fprintf(out, "diff --vcs a/%s b/%s\n", change.Path, change.Path)
+	if change.Status == "A" {
+		fmt.Fprintf(out, "new file\n")
+	} else if change.Status == "D" {
+		fmt.Fprintf(out, "deleted file\n")
+	}
+
+	// Asset-aware changes come first; they're the useful part of a binary diff
+	printAssetChanges(out, change.Path, fromContent, toContent)
+
+	if isBinaryChange(change.Path, fromContent, toContent) {
+		fromHash, toHash := shortID(change.From), shortID(change.To)
+		if fromHash == "" {
+			fromHash = "none"
+		}
+		if toHash == "" {
+			toHash = "none"
+		}
+		fmt.Fprintf(out, "Binary files %s and %s differ (%s → %s, %s → %s)\n",
+			oldName, newName,
+			FormatFileSize(int64(len(fromContent))), FormatFileSize(int64(len(toContent))),
+			fromHash, toHash)
+		return nil
+	}
+
+	edits, ok := diffLines(splitLines(fromContent), splitLines(toContent))
+	if !ok {
+		fmt.Fprintf(out, "Files %s and %s differ in too many lines to show\n", oldName, newName)
+		return nil
+	}
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", oldName, newName)
+	for _, line := range unifiedHunks(edits) {
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
+
+// printAssetChanges prints what an analyzer can tell about a change: Blueprint members
+// through the Blueprint tracker, and anything the format registry can diff
+func printAssetChanges(out io.Writer, path string, fromContent, toContent []byte) {
+	if isBlueprintChange(path, fromContent, toContent) {
+		if diff, err := integrity.NewBlueprintTracker().DiffBlueprints(fromContent, toContent); err == nil {
+			printBlueprintDiff(out, path, diff)
+			fmt.Fprintln(out)
+			return
+		}
+	}
+
+	changes, handled, err := analyzer.DefaultRegistry().Diff(path, fromContent, toContent)
+	if !handled {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(out, "⚠️  %v\n", err)
+		return
+	}
+	for _, change := range changes {
+		fmt.Fprintf(out, "  • %s\n", change)
+	}
+}
+
+func isBlueprintChange(path string, fromContent, toContent []byte) bool {
+	for _, content := range [][]byte{toContent, fromContent} {
+		if len(content) == 0 {
+			continue
+		}
+		info, err := analyzer.DefaultRegistry().Analyze(path, content)
+		return err == nil && info != nil && (info.IsBlueprint || info.AssetType == analyzer.AssetTypeBlueprint)
+	}
+	return false
+}
+
+func printDiffStat(out io.Writer, projectID string, changes []fileChange, from, to *diffSide) error {
+	type statLine struct {
+		path, summary string
+		insertions    int
+		deletions     int
+	}
+
+	var lines []statLine
+	var totalInsertions, totalDeletions, widest, largest int
+	for _, change := range changes {
+		fromContent, toContent, err := loadChangeContents(projectID, change, from, to)
+		if err != nil {
+			return err
+		}
+
+		line := statLine{path: change.Path}
+		if isBinaryChange(change.Path, fromContent, toContent) {
+			line.summary = fmt.Sprintf("Bin %s → %s", FormatFileSize(int64(len(fromContent))), FormatFileSize(int64(len(toContent))))
+		} else if edits, ok := diffLines(splitLines(fromContent), splitLines(toContent)); ok {
+			line.insertions, line.deletions = countLineChanges(edits)
+			largest = max(largest, line.insertions+line.deletions)
+		} else {
+			line.summary = "too many changes"
+		}
+		totalInsertions += line.insertions
+		totalDeletions += line.deletions
+		widest = max(widest, len(change.Path))
+		lines = append(lines, line)
+	}
+
+	// Scale the +/- bars so the biggest change fits in 40 columns
+	const barWidth = 40
+	for _, line := range lines {
+		if line.summary != "" {
+			fmt.Fprintf(out, " %-*s | %s\n", widest, line.path, line.summary)
+			continue
+		}
+		plus, minus := line.insertions, line.deletions
+		if largest > barWidth {
+			plus = (plus*barWidth + largest - 1) / largest
+			minus = (minus*barWidth + largest - 1) / largest
+		}
+		fmt.Fprintf(out, " %-*s | %d %s%s\n", widest, line.path, line.insertions+line.deletions, strings.Repeat("+", plus), strings.Repeat("-", minus))
+	}
+	fmt.Fprintf(out, " %d files changed, %d insertions(+), %d deletions(-)\n", len(lines), totalInsertions, totalDeletions)
+	return nil
+}
+
+// loadWorkingRevisions returns the content of a file at the local HEAD commit and its
+// working copy; either side is empty when the file doesn't exist there
+func loadWorkingRevisions(projectID, filePath string) ([]byte, []byte, error) {
+	workingContent, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	localState, err := LoadLocalState()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load local state: %w", err)
+	}
+	head := localState.HeadCommit()
+	snapshot, err := apiClient.SnapshotAt(projectID, head)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read files at %s: %w", shortID(head), err)
+	}
+
+	var committedContent []byte
+	if entry, ok := snapshot[filePath]; ok {
+		if committedContent, err = apiClient.ReadObject(projectID, entry.Hash); err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s at %s: %w", filePath, shortID(head), err)
+		}
+	}
+
+	if len(committedContent) == 0 && len(workingContent) == 0 {
+		return nil, nil, fmt.Errorf("%s is neither committed nor present in the working copy", filePath)
+	}
+
+	return committedContent, workingContent, nil
+}
+
+func diffTableContent(fromContent, toContent []byte) (*analyzer.DataTableDiff, error) {
+	tableAnalyzer := analyzer.NewUE5AssetAnalyzer()
+
+	var from, to *analyzer.DataTable
+	var err error
+	if len(fromContent) > 0 {
+		if from, err = tableAnalyzer.DecodeDataTable(fromContent); err != nil {
+			return nil, fmt.Errorf("failed to decode committed version: %w", err)
+		}
+	}
+	if len(toContent) > 0 {
+		if to, err = tableAnalyzer.DecodeDataTable(toContent); err != nil {
+			return nil, fmt.Errorf("failed to decode working copy: %w", err)
+		}
+	}
+
+	return analyzer.DiffDataTables(from, to), nil
+}
+
+// fetchSemanticDiff asks the server to diff a file between commits and decodes the result into diff
+func fetchSemanticDiff(projectID, fromCommit, toCommit, filePath, kind string, diff interface{}) error {
+	resp, err := apiClient.SemanticDiff(projectID, fromCommit, toCommit, filePath, kind)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s: %w", filePath, err)
+	}
+
+	var result struct {
+		Diff json.RawMessage `json:"diff"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("failed to parse diff response: %w", err)
+	}
+	if len(result.Diff) == 0 {
+		return fmt.Errorf("server returned no diff for %s", filePath)
+	}
+
+	if err := json.Unmarshal(result.Diff, diff); err != nil {
+		return fmt.Errorf("failed to parse diff response: %w", err)
+	}
+	return nil
+}
+
+func printTableDiff(filePath string, diff *analyzer.DataTableDiff) {
+	if !diff.HasChanges() {
+		fmt.Printf("✅ No row changes in %s\n", filePath)
+		return
+	}
+
+	fmt.Printf("📊 %s diff of %s\n", diff.Kind, filePath)
+	if diff.RowStruct != nil {
+		fmt.Printf("\n🧬 Row struct: %s → %s\n", diff.RowStruct.From, diff.RowStruct.To)
+	}
+
+	if len(diff.AddedRows) > 0 {
+		fmt.Printf("\n➕ Added rows (%d):\n", len(diff.AddedRows))
+		for _, row := range diff.AddedRows {
+			fmt.Printf("   + %s\n", row)
+		}
+	}
+	if len(diff.RemovedRows) > 0 {
+		fmt.Printf("\n➖ Removed rows (%d):\n", len(diff.RemovedRows))
+		for _, row := range diff.RemovedRows {
+			fmt.Printf("   - %s\n", row)
+		}
+	}
+	if len(diff.ChangedRows) > 0 {
+		fmt.Printf("\n✏️  Changed rows (%d):\n", len(diff.ChangedRows))
+		for _, row := range diff.ChangedRows {
+			fmt.Printf("   ~ %s\n", row.Row)
+			for _, cell := range row.Cells {
+				fmt.Printf("       %s: %s → %s\n", cell.Column, displayCell(cell.From), displayCell(cell.To))
+			}
+		}
+	}
+}
+
+func displayCell(value string) string {
+	if value == "" {
+		return "(unset)"
+	}
+	return value
+}
+
+func exportTableCmd() *cobra.Command {
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export-table <asset>",
+		Short: "Export a DataTable or CurveTable to CSV or JSON",
+		Long: `Decode the rows of a DataTable or CurveTable asset in the working copy and
+write them as CSV or JSON. Curve tables are exported with one column per key time.
+
+DataTable rows only store the fields that differ from the row struct's defaults.
+CSV export writes the other fields as <default>; JSON export leaves them out.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", args[0], err)
+			}
+
+			table, err := analyzer.NewUE5AssetAnalyzer().DecodeDataTable(content)
+			if err != nil {
+				return fmt.Errorf("failed to decode %s: %w", args[0], err)
+			}
+
+			writer := io.Writer(os.Stdout)
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", output, err)
+				}
+				defer file.Close()
+				writer = file
+			}
+
+			switch format {
+			case "csv":
+				err = table.WriteCSV(writer)
+			case "json":
+				err = table.WriteJSON(writer)
+			default:
+				return fmt.Errorf("unsupported format %q (use csv or json)", format)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to export %s: %w", args[0], err)
+			}
+
+			if output != "" {
+				fmt.Printf("✅ Exported %d rows from %s to %s\n", len(table.Rows), table.Name, output)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "csv", "Output format (csv, json)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to a file instead of stdout")
+	return cmd
+}
+
+func historyCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history <file>",
+		Short: "Show the versions of a file and how its source metadata changed",
+		Long: `List the committed versions of a file, newest first. Textures and FBX files
+show their dimensions, channels and color profile or node, mesh and material counts,
+and each version notes what changed, such as "texture grew from 2K to 8K".`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			filePath := filepath.ToSlash(filepath.Clean(args[0]))
+			versions, err := getFileHistory(projectID, filePath, limit)
+			if err != nil {
+				return fmt.Errorf("failed to get history of %s: %w", filePath, err)
+			}
+			if len(versions) == 0 {
+				fmt.Printf("No committed versions of %s\n", filePath)
+				return nil
+			}
+
+			fmt.Printf("📜 History of %s (%d versions)\n", filePath, len(versions))
+			for _, version := range versions {
+				commitID := version.CommitID
+				if len(commitID) > 8 {
+					commitID = commitID[:8]
+				}
+				author := version.Commit.Author.Username
+				if author == "" {
+					author = version.Commit.Author.Name
+				}
+
+				fmt.Printf("\n%s  %s  %s\n", commitID, version.CreatedAt.Local().Format("2006-01-02 15:04"), author)
+				if version.Commit.Message != "" {
+					fmt.Printf("    %s\n", strings.SplitN(version.Commit.Message, "\n", 2)[0])
+				}
+				details := FormatFileSize(version.Size)
+				if summary := analyzer.SummarizeVersionMetadata(version.Metadata); summary != "" {
+					details += " · " + summary
+				}
+				fmt.Printf("    %s\n", details)
+				for _, change := range version.Changes {
+					fmt.Printf("    ↳ %s\n", change)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Maximum number of versions to show")
+	return cmd
+}
+
+func getFileHistory(projectID, filePath string, limit int) ([]FileVersionInfo, error) {
+	segments := strings.Split(filePath, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	endpoint := fmt.Sprintf("/api/v1/commits/%s/files/%s?limit=%d", projectID, strings.Join(segments, "/"), limit)
+	resp, err := apiClient.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success  bool              `json:"success"`
+		Versions []FileVersionInfo `json:"versions"`
+	}
+	if err := json.Unmarshal(resp, &response); err != nil {
+		return nil, err
+	}
+	if !response.Success {
+		return nil, fmt.Errorf("failed to get file history")
+	}
+
+	return response.Versions, nil
+}
+
+// logEntry is one commit as `vcs log` shows it, whether it came from the local store or
+// the server
+type logEntry struct {
+	ID        string    `json:"id"`
+	Parents   []string  `json:"parents"`
+	Author    string    `json:"author"`
+	AuthorID  string    `json:"author_id"`
+	Date      time.Time `json:"date"`
+	Message   string    `json:"message"`
+	Published bool      `json:"published"`
+}
+
+// logFilter holds the filters of `vcs log`; the server applies the same ones to the
+// history it returns
+type logFilter struct {
+	Author string
+	Grep   string
+	Since  time.Time
+	Until  time.Time
+	Paths  []string
+}
+
+func (f logFilter) active() bool {
+	return f.Author != "" || f.Grep != "" || !f.Since.IsZero() || !f.Until.IsZero() || len(f.Paths) > 0
+}
+
+func (f logFilter) matches(entry logEntry, changed []string) bool {
+	if !f.Since.IsZero() && entry.Date.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Date.After(f.Until) {
+		return false
+	}
+	if f.Grep != "" && !strings.Contains(strings.ToLower(entry.Message), strings.ToLower(f.Grep)) {
+		return false
+	}
+	if f.Author != "" && !strings.Contains(strings.ToLower(entry.Author), strings.ToLower(f.Author)) && entry.AuthorID != f.Author {
+		return false
+	}
+	if len(f.Paths) == 0 {
+		return true
+	}
+	for _, path := range changed {
+		if matchesPathspec(path, f.Paths) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultLogCount is how many commits log shows unless -n asks for another amount
+const defaultLogCount = 100
+
+func logCmd() *cobra.Command {
+	var graph bool
+	var author, since, until, grep, format string
+	var maxCount int
+
+	cmd := &cobra.Command{
+		Use:   "log [<revision>] [-- <paths>...]",
+		Short: "Show the commit history",
+		Long: `Show the commits reachable from HEAD, or from a branch or commit, newest first.
+Commits that haven't been pushed yet come from the local store, the rest from the
+server, one page at a time. Only the newest 100 commits are shown unless -n asks for
+more; -n 0 shows the whole history.
+
+Paths after -- only show commits that changed those files or folders, for example
+'vcs log -- Content/Maps/'. --since and --until take a date (2024-05-01), a timestamp
+(2024-05-01T10:00:00Z), 'yesterday' or an age such as '2 weeks ago' or '3d'.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+			if err := initializeClient(); err != nil {
+				return err
+			}
+			localState, err := LoadLocalState()
+			if err != nil {
+				return fmt.Errorf("failed to load local state: %w", err)
+			}
+
+			var revisions, paths []string
+			dash := cmd.ArgsLenAtDash()
+			for i, arg := range args {
+				if dash >= 0 && i >= dash {
+					paths = append(paths, arg)
+				} else {
+					revisions = append(revisions, arg)
+				}
+			}
+			if len(revisions) > 1 {
+				return fmt.Errorf("log takes at most one revision, got %d; put paths after --", len(revisions))
+			}
+			revision := ""
+			if len(revisions) == 1 {
+				revision = revisions[0]
+			}
+
+			switch format {
+			case "medium", "oneline", "json":
+			default:
+				return fmt.Errorf("unknown format %q: use medium, oneline or json", format)
+			}
+
+			filter := logFilter{Author: author, Grep: grep, Paths: normalizePathspec(paths)}
+			now := time.Now()
+			if since != "" {
+				if filter.Since, err = parseLogTime(since, now, false); err != nil {
+					return err
+				}
+			}
+			if until != "" {
+				if filter.Until, err = parseLogTime(until, now, true); err != nil {
+					return err
+				}
+			}
+
+			// One extra commit tells whether there is more history than was asked for
+			limit := maxCount
+			if limit > 0 {
+				limit++
+			}
+			entries, err := collectLog(projectID, localState, revision, filter, limit)
+			if err != nil {
+				return err
+			}
+			more := maxCount > 0 && len(entries) > maxCount
+			if more {
+				entries = entries[:maxCount]
+			}
+
+			if format == "json" {
+				data, err := json.MarshalIndent(entries, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+			if len(entries) == 0 {
+				fmt.Printf("No commits to show\n")
+				return nil
+			}
+			printLog(entries, localState, format == "oneline", graph, filter.active())
+			if more {
+				fmt.Printf("\nShowing the first %d commits; use -n to show more, or -n 0 for all of them\n", maxCount)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&graph, "graph", false, "Draw the branch and merge structure next to the commits")
+	cmd.Flags().StringVar(&author, "author", "", "Only commits by authors whose name contains this text")
+	cmd.Flags().StringVar(&since, "since", "", "Only commits made at or after this time")
+	cmd.Flags().StringVar(&until, "until", "", "Only commits made at or before this time")
+	cmd.Flags().StringVar(&grep, "grep", "", "Only commits whose message contains this text")
+	cmd.Flags().IntVarP(&maxCount, "max-count", "n", defaultLogCount, "Maximum number of commits to show, 0 for the whole history")
+	cmd.Flags().StringVar(&format, "format", "medium", "Output format: medium, oneline or json")
+	return cmd
+}
+
+// collectLog gathers up to maxCount matching commits (all of them when maxCount is 0):
+// first the ones in the local store, then the server history they were built on
+func collectLog(projectID string, localState *LocalState, revision string, filter logFilter, maxCount int) ([]logEntry, error) {
+	query := url.Values{}
+	var start []string
+	switch {
+	case revision != "":
+		commitID := resolveCommit(localState, revision)
+		if commitID == revision && !apiClient.commitStore.HasCommit(commitID) && !isHexString(revision) {
+			query.Set("branch", revision) // A branch that only exists on the server
+		} else {
+			start = []string{commitID}
+		}
+	case localState.HeadCommit() != "":
+		start = []string{localState.HeadCommit()}
+	default:
+		query.Set("branch", localState.CurrentBranch)
+	}
+
+	published := make(map[string]bool, len(localState.RemoteCommits))
+	for _, commitID := range localState.RemoteCommits {
+		published[commitID] = true
+	}
+
+	entries, boundary, err := localLog(start, filter, published, maxCount)
+	if err != nil {
+		return nil, err
+	}
+	if (maxCount > 0 && len(entries) >= maxCount) || (len(start) > 0 && len(boundary) == 0) {
+		return entries, nil
+	}
+
+	// The rest of the history lives on the server
+	for _, commitID := range boundary {
+		query.Add("from", commitID)
+	}
+	if filter.Author != "" {
+		query.Set("author", filter.Author)
+	}
+	if filter.Grep != "" {
+		query.Set("grep", filter.Grep)
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339Nano))
+	}
+	if !filter.Until.IsZero() {
+		query.Set("until", filter.Until.Format(time.RFC3339Nano))
+	}
+	for _, path := range filter.Paths {
+		query.Add("path", path)
+	}
+
+	for {
+		pageSize := 100
+		if maxCount > 0 {
+			pageSize = min(maxCount-len(entries), 500)
+		}
+		query.Set("limit", fmt.Sprintf("%d", pageSize))
+
+		resp, err := apiClient.QueryCommitLog(projectID, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit history: %w", err)
+		}
+		var page struct {
+			Success bool `json:"success"`
+			Commits []struct {
+				ID        string    `json:"id"`
+				Message   string    `json:"message"`
+				AuthorID  string    `json:"author_id"`
+				ParentIDs []string  `json:"parent_ids"`
+				CreatedAt time.Time `json:"created_at"`
+				Author    struct {
+					Name     string `json:"name"`
+					Username string `json:"username"`
+				} `json:"author"`
+			} `json:"commits"`
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse commit history: %w", err)
+		}
+		if !page.Success {
+			return nil, fmt.Errorf("failed to get commit history")
+		}
+
+		for _, commit := range page.Commits {
+			author := commit.Author.Name
+			if author == "" {
+				author = commit.Author.Username
+			}
+			entries = append(entries, logEntry{
+				ID:        commit.ID,
+				Parents:   commit.ParentIDs,
+				Author:    author,
+				AuthorID:  commit.AuthorID,
+				Date:      commit.CreatedAt,
+				Message:   commit.Message,
+				Published: true,
+			})
+		}
+
+		if page.NextCursor == "" || (maxCount > 0 && len(entries) >= maxCount) {
+			return entries, nil
+		}
+		query.Del("from")
+		query.Set("cursor", page.NextCursor)
+	}
+}
+
+// localLog walks the local commit store from start, newest first, and returns the
+// matching commits plus the server commits the walk reached but can't read locally
+func localLog(start []string, filter logFilter, published map[string]bool, maxCount int) ([]logEntry, []string, error) {
+	var entries []logEntry
+	var boundary []string
+	var pending []*storage.CommitObject
+	var pendingIDs []string
+	seen := make(map[string]bool)
+
+	push := func(commitID string) error {
+		if commitID == "" || seen[commitID] {
+			return nil
+		}
+		seen[commitID] = true
+		if !apiClient.commitStore.HasCommit(commitID) {
+			boundary = append(boundary, commitID)
+			return nil
+		}
+		commit, err := apiClient.commitStore.GetCommit(commitID)
+		if err != nil {
+			return fmt.Errorf("failed to read commit %s: %w", shortID(commitID), err)
+		}
+		pending = append(pending, commit)
+		pendingIDs = append(pendingIDs, commitID)
+		return nil
+	}
+	for _, commitID := range start {
+		if err := push(commitID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for len(pending) > 0 && (maxCount == 0 || len(entries) < maxCount) {
+		newest := 0
+		for i := range pending {
+			if pending[i].Timestamp.After(pending[newest].Timestamp) {
+				newest = i
+			}
+		}
+		commit, commitID := pending[newest], pendingIDs[newest]
+		pending = append(pending[:newest], pending[newest+1:]...)
+		pendingIDs = append(pendingIDs[:newest], pendingIDs[newest+1:]...)
+
+		entry := logEntry{
+			ID:        commitID,
+			Parents:   commit.Parents,
+			Author:    commit.Author,
+			AuthorID:  commit.AuthorID,
+			Date:      commit.Timestamp,
+			Message:   commit.Message,
+			Published: published[commitID],
+		}
+		var changed []string
+		if len(filter.Paths) > 0 {
+			tree, err := apiClient.commitStore.GetTree(commit.Tree)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read tree of commit %s: %w", shortID(commitID), err)
+			}
+			for _, file := range tree.Entries {
+				changed = append(changed, file.Name)
+			}
+		}
+		if filter.matches(entry, changed) {
+			entries = append(entries, entry)
+		}
+
+		for _, parent := range commit.Parents {
+			if err := push(parent); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return entries, boundary, nil
+}
+
+// parseLogTime parses the --since and --until values. A plain date as an upper bound
+// includes the whole day.
+func parseLogTime(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			if endOfDay && layout == "2006-01-02" {
+				t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+			return t, nil
+		}
+	}
+	switch value {
+	case "today":
+		year, month, day := now.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, now.Location()), nil
+	case "yesterday":
+		year, month, day := now.AddDate(0, 0, -1).Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, now.Location()), nil
+	}
+
+	// Ages: "2 weeks ago", "3 days", "12h"
+	age := strings.TrimSpace(strings.TrimSuffix(value, "ago"))
+	digits := 0
+	for digits < len(age) && age[digits] >= '0' && age[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 {
+		count := 0
+		fmt.Sscanf(age[:digits], "%d", &count)
+		switch strings.TrimSuffix(strings.TrimSpace(age[digits:]), "s") {
+		case "m", "minute", "min":
+			return now.Add(-time.Duration(count) * time.Minute), nil
+		case "h", "hour":
+			return now.Add(-time.Duration(count) * time.Hour), nil
+		case "d", "day":
+			return now.AddDate(0, 0, -count), nil
+		case "w", "week":
+			return now.AddDate(0, 0, -7*count), nil
+		case "month":
+			return now.AddDate(0, -count, 0), nil
+		case "y", "year":
+			return now.AddDate(-count, 0, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't understand the time %q: use a date like 2024-05-01 or an age like '2 weeks ago'", value)
+}
+
+func isHexString(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func printLog(entries []logEntry, localState *LocalState, oneline, graph, filtered bool) {
+	// Label commits with the branches pointing at them
+	labels := make(map[string][]string)
+	if localState.Detached {
+		labels[localState.HeadCommit()] = append(labels[localState.HeadCommit()], "HEAD")
+	}
+	var branches []string
+	for ref := range localState.LocalRefs {
+		if strings.HasPrefix(ref, "refs/heads/") {
+			branches = append(branches, strings.TrimPrefix(ref, "refs/heads/"))
+		}
+	}
+	sort.Strings(branches)
+	for _, branch := range branches {
+		commitID := localState.GetBranchHead(branch)
+		label := branch
+		if branch == localState.CurrentBranch && !localState.Detached {
+			label = "HEAD -> " + branch
+			labels[commitID] = append([]string{label}, labels[commitID]...)
+			continue
+		}
+		labels[commitID] = append(labels[commitID], label)
+	}
+
+	// With filters, a parent that isn't shown would leave its lane open to the bottom
+	shown := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		shown[entry.ID] = true
+	}
+
+	lanes := &logGraph{}
+	for i, entry := range entries {
+		decoration := ""
+		names := labels[entry.ID]
+		if !entry.Published {
+			names = append(names, "not pushed")
+		}
+		if len(names) > 0 {
+			decoration = " (" + strings.Join(names, ", ") + ")"
+		}
+
+		prefix := ""
+		var joins, connectors []string
+		if graph {
+			parents := entry.Parents
+			if filtered {
+				parents = nil
+				for _, parent := range entry.Parents {
+					if shown[parent] {
+						parents = append(parents, parent)
+					}
+				}
+			}
+			joins, prefix, connectors = lanes.next(entry.ID, parents)
+			prefix += " "
+		}
+		for _, join := range joins {
+			fmt.Println(join)
+		}
+
+		subject := strings.SplitN(strings.TrimSpace(entry.Message), "\n", 2)[0]
+		if oneline {
+			fmt.Printf("%s%s%s %s\n", prefix, shortID(entry.ID), decoration, subject)
+			for _, connector := range connectors {
+				fmt.Println(connector)
+			}
+			continue
+		}
+
+		fmt.Printf("%scommit %s%s\n", prefix, entry.ID, decoration)
+		for _, connector := range connectors {
+			fmt.Println(connector)
+		}
+		padding := ""
+		if graph {
+			// Keep the text lined up with the commit line even when no column continues
+			padding = lanes.padding()
+			if padding == "" {
+				padding = " "
+			}
+			padding += " "
+		}
+		if len(entry.Parents) > 1 {
+			short := make([]string, len(entry.Parents))
+			for j, parent := range entry.Parents {
+				short[j] = shortID(parent)
+			}
+			fmt.Printf("%sMerge:  %s\n", padding, strings.Join(short, " "))
+		}
+		fmt.Printf("%sAuthor: %s\n", padding, entry.Author)
+		fmt.Printf("%sDate:   %s\n", padding, entry.Date.Local().Format("Mon Jan 2 15:04:05 2006 -0700"))
+		fmt.Printf("%s\n", strings.TrimRight(padding, " "))
+		for _, line := range strings.Split(strings.TrimSpace(entry.Message), "\n") {
+			fmt.Println(strings.TrimRight(padding+"    "+line, " "))
+		}
+		if i < len(entries)-1 {
+			fmt.Printf("%s\n", strings.TrimRight(padding, " "))
+		}
+	}
+}
+
+func printBlueprintDiff(out io.Writer, filePath string, diff *integrity.BlueprintDiff) {
+	if !diff.HasChanges() {
+		fmt.Fprintf(out, "✅ No semantic changes in %s\n", filePath)
+		return
+	}
+
+	fmt.Fprintf(out, "🔍 Semantic diff of %s\n", filePath)
+	if diff.ParentClass != nil {
+		fmt.Fprintf(out, "\n🧬 Parent class: %v → %v\n", diff.ParentClass.From, diff.ParentClass.To)
+	}
+
+	printMemberChanges(out, "📦 Variables", diff.Variables)
+	printMemberChanges(out, "🔧 Functions", diff.Functions)
+	printMemberChanges(out, "⚡ Events", diff.Events)
+
+	for _, graph := range diff.Graphs {
+		fmt.Fprintf(out, "\n🕸️  Graph %s:\n", graph.Graph)
+		for _, node := range graph.AddedNodes {
+			fmt.Fprintf(out, "   + %s\n", describeNode(node))
+		}
+		for _, node := range graph.RemovedNodes {
+			fmt.Fprintf(out, "   - %s\n", describeNode(node))
+		}
+		for _, pin := range graph.Rewired {
+			marker := "+"
+			if pin.Change == integrity.ChangeRemoved {
+				marker = "-"
+			}
+			fmt.Fprintf(out, "   %s %s.%s → %s\n", marker, pin.NodeID, pin.PinName, pin.TargetNode)
+		}
+	}
+}
+
+func printMemberChanges(out io.Writer, title string, changes []integrity.MemberChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	fmt.Fprintf(out, "\n%s:\n", title)
+	for _, change := range changes {
+		switch change.Change {
+		case integrity.ChangeAdded:
+			fmt.Fprintf(out, "   + %s\n", change.Name)
+		case integrity.ChangeRemoved:
+			fmt.Fprintf(out, "   - %s\n", change.Name)
+		case integrity.ChangeRenamed:
+			fmt.Fprintf(out, "   ~ %s → %s\n", change.OldName, change.Name)
+		default:
+			fmt.Fprintf(out, "   ~ %s\n", change.Name)
+		}
+		for _, detail := range change.Details {
+			fmt.Fprintf(out, "       %s: %v → %v\n", detail.Field, detail.From, detail.To)
+		}
+	}
+}
+
+func describeNode(node integrity.NodeChange) string {
+	if node.FunctionName != "" {
+		return fmt.Sprintf("%s %s (%s)", node.NodeClass, node.FunctionName, node.NodeID)
+	}
+	return fmt.Sprintf("%s (%s)", node.NodeClass, node.NodeID)
+}
+
+func watchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch",
+		Short: "Watch for real-time collaboration events",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			if currentProject == "" {
+				currentProject = "default"
+			}
+
+			fmt.Printf("🔄 Watching for real-time events in project: %s\n", currentProject)
+			fmt.Printf("Press Ctrl+C to stop...\n\n")
+
+			// Update presence to online
+			apiClient.UpdatePresence(currentProject, "online", "")
+
+			return apiClient.SubscribeToEvents(currentProject, func(event map[string]interface{}) {
+				if eventType, ok := event["type"].(string); ok {
+					if userName, ok := event["user_name"].(string); ok {
+						timestamp := time.Now().Format("15:04:05")
+						icon := getEventIcon(eventType)
+
+						message := fmt.Sprintf("[%s] %s %s", timestamp, icon, userName)
+
+						if filePath, ok := event["file_path"].(string); ok && filePath != "" {
+							message += fmt.Sprintf(" %s %s", eventType, filePath)
+						} else {
+							message += fmt.Sprintf(" %s", eventType)
+						}
+
+						fmt.Println(message)
+					}
+				}
+			})
+		},
+	}
+}
+
+func storageCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "storage",
+		Short: "Show storage statistics",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			resp, err := apiClient.GetStorageStats()
+			if err != nil {
+				return fmt.Errorf("failed to get storage stats: %w", err)
+			}
+
+			var stats struct {
+				Success bool                   `json:"success"`
+				Stats   map[string]interface{} `json:"stats"`
+			}
+
+			if err := json.Unmarshal(resp, &stats); err != nil {
+				return fmt.Errorf("failed to parse storage stats: %w", err)
+			}
+
+			fmt.Println("💾 Storage Statistics:")
+
+			if totalFiles, ok := stats.Stats["total_files"].(float64); ok {
+				fmt.Printf("   Total Files: %.0f\n", totalFiles)
+			}
+
+			if totalSize, ok := stats.Stats["total_size"].(float64); ok {
+				fmt.Printf("   Total Size: %s\n", FormatFileSize(int64(totalSize)))
+			}
+
+			if basePath, ok := stats.Stats["base_path"].(string); ok {
+				fmt.Printf("   Storage Path: %s\n", basePath)
+			}
+
+			return nil
+		},
+	}
+}
+
+func integrityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "integrity",
+		Short: "Inspect asset integrity records",
+	}
+
+	cmd.AddCommand(integrityStatusCmd())
+	cmd.AddCommand(integrityAlertsCmd())
+	cmd.AddCommand(integrityRestoreCmd())
+	return cmd
+}
+
+func integrityRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <assets>...",
+		Short: "Restore assets to their last healthy committed revision",
+		Long: `Replace each asset in the working copy with its most recent committed revision that
+passes integrity checks and was never recorded as corrupt. Revisions older than a
+shallow or partial clone come from the server.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			projectPath, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			manager, err := storage.NewWorkingDirectoryManager(projectPath, &serverAssetRemote{projectID: projectID})
+			if err != nil {
+				return fmt.Errorf("failed to open working directory: %w", err)
+			}
+
+			failed := 0
+			for _, arg := range args {
+				assetPath := filepath.ToSlash(filepath.Clean(arg))
+				recovery, err := manager.RestoreLastKnownGood(assetPath)
+				if err != nil {
+					fmt.Printf("❌ %s: %v\n", assetPath, err)
+					failed++
+					continue
+				}
+				fmt.Printf("📦 Restored %s from commit %s\n", assetPath, shortID(recovery.CommitID))
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d assets could not be restored", failed, len(args))
+			}
+			return nil
+		},
+	}
+}
+
+// serverAssetHistoryLimit bounds how many server revisions of an asset recovery tries
+const serverAssetHistoryLimit = 100
+
+// serverAssetRemote gives asset recovery the server's history of a file and the objects
+// a shallow or partial clone never downloaded
+type serverAssetRemote struct {
+	projectID string
+}
+
+func (r *serverAssetRemote) AssetRevisions(assetPath string) ([]integrity.AssetRevision, error) {
+	versions, err := getFileHistory(r.projectID, assetPath, serverAssetHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]integrity.AssetRevision, 0, len(versions))
+	for _, version := range versions {
+		if version.ContentHash == "" {
+			continue // a deletion has no content to recover
+		}
+		revisions = append(revisions, integrity.AssetRevision{
+			CommitID:    version.CommitID,
+			ContentHash: version.ContentHash,
+			Size:        version.Size,
+			Timestamp:   version.CreatedAt,
+		})
+	}
+	return revisions, nil
+}
+
+func (r *serverAssetRemote) ReadObject(hash string) ([]byte, error) {
+	if err := apiClient.fetchObject(r.projectID, hash); err != nil {
+		return nil, err
+	}
+	return apiClient.objectStore.ReadObject(hash)
+}
+
+func integrityStatusCmd() *cobra.Command {
+	var corruptedOnly bool
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "status [asset]",
+		Short: "Show integrity status of tracked assets or the health trend of one asset",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := LoadProjectConfig(); err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+
+			db, err := integrity.NewAssetIntegrityDB(filepath.Join(".vcs", "integrity"))
+			if err != nil {
+				return fmt.Errorf("failed to open integrity database: %w", err)
+			}
+
+			if len(args) == 1 {
+				return printHealthTrend(db, filepath.ToSlash(args[0]), limit)
+			}
+
+			assets := db.ListAssets()
+			if corruptedOnly {
+				assets = db.AssetsWithStatus(integrity.IntegrityCorrupted)
+			}
+
+			if len(assets) == 0 {
+				if corruptedOnly {
+					fmt.Println("✅ No corrupted assets")
+				} else {
+					fmt.Println("📭 No assets tracked yet")
+				}
+				return nil
+			}
+
+			counts := make(map[integrity.IntegrityStatus]int)
+			for _, asset := range db.ListAssets() {
+				counts[asset.Status]++
+			}
+			fmt.Printf("🛡️  Integrity: %d valid, %d corrupted, %d missing, %d unchecked\n",
+				counts[integrity.IntegrityValid], counts[integrity.IntegrityCorrupted],
+				counts[integrity.IntegrityMissing], counts[integrity.IntegrityUnknown])
+			fmt.Println()
+
+			for _, asset := range assets {
+				checked := "never checked"
+				if !asset.LastChecked.IsZero() {
+					checked = "checked " + asset.LastChecked.Format("2006-01-02 15:04")
+				}
+				fmt.Printf("%s %-50s %5.1f  %s\n", integrityStatusIcon(asset.Status), asset.AssetPath, asset.HealthScore, checked)
+				if asset.LastRecovery != "" {
+					fmt.Printf("     🔧 last recovery: %s\n", asset.LastRecovery)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&corruptedOnly, "corrupted", false, "Only list assets that are currently corrupted")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Number of checks to show in an asset's health trend")
+	return cmd
+}
+
+func printHealthTrend(db *integrity.AssetIntegrityDB, assetPath string, limit int) error {
+	samples, err := db.HealthTrend(assetPath, limit)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		fmt.Printf("📭 No integrity checks recorded for %s\n", assetPath)
+		return nil
+	}
+
+	fmt.Printf("📈 Health trend for %s (last %d checks):\n", assetPath, len(samples))
+	for _, sample := range samples {
+		bar := strings.Repeat("█", int(sample.HealthScore/5))
+		hash := sample.ContentHash
+		if len(hash) > 8 {
+			hash = hash[:8]
+		}
+		fmt.Printf("   %s %s %s %5.1f %s\n", sample.Timestamp.Format("2006-01-02 15:04"), hash,
+			integrityStatusIcon(sample.Status), sample.HealthScore, bar)
+		for _, check := range sample.Checks {
+			if check.Status != integrity.IntegrityValid {
+				fmt.Printf("        ⚠️  %s: %s\n", check.CheckType, check.ErrorDetails)
+			}
+		}
+		for _, recovery := range sample.Recovery {
+			fmt.Printf("        🔧 recovered: %s\n", recovery)
+		}
+	}
+	return nil
+}
+
+func integrityAlertsCmd() *cobra.Command {
+	var severity string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "alerts",
+		Short: "List corruption alerts reported for the project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			resp, err := apiClient.ListCorruptionAlerts(projectID, severity, limit)
+			if err != nil {
+				return fmt.Errorf("failed to list corruption alerts: %w", err)
+			}
+
+			var result struct {
+				Alerts []struct {
+					Severity       string                 `json:"severity"`
+					CorruptionType string                 `json:"corruption_type"`
+					AffectedAssets map[string]interface{} `json:"affected_assets"`
+					Details        map[string]interface{} `json:"details"`
+					DeliveredTo    map[string]interface{} `json:"delivered_to"`
+					CreatedAt      time.Time              `json:"created_at"`
+				} `json:"alerts"`
+			}
+			if err := json.Unmarshal(resp, &result); err != nil {
+				return fmt.Errorf("failed to parse alerts response: %w", err)
+			}
+
+			if len(result.Alerts) == 0 {
+				fmt.Println("✅ No corruption alerts")
+				return nil
+			}
+
+			fmt.Printf("🚨 %d corruption alerts:\n", len(result.Alerts))
+			for _, alert := range result.Alerts {
+				fmt.Printf("\n%s %-8s %s  %s\n", corruptionSeverityIcon(alert.Severity), alert.Severity,
+					alert.CreatedAt.Local().Format("2006-01-02 15:04"), alert.CorruptionType)
+				if paths, ok := alert.AffectedAssets["paths"].([]interface{}); ok {
+					for _, path := range paths {
+						fmt.Printf("     📄 %v\n", path)
+					}
+				}
+				if cause, ok := alert.Details["root_cause"].(string); ok && cause != "" {
+					fmt.Printf("     🔍 %s\n", cause)
+				}
+				if sinks, ok := alert.DeliveredTo["sinks"].([]interface{}); ok && len(sinks) > 0 {
+					names := make([]string, len(sinks))
+					for i, sink := range sinks {
+						names[i] = fmt.Sprint(sink)
+					}
+					fmt.Printf("     📣 %s\n", strings.Join(names, ", "))
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&severity, "severity", "", "Only list alerts of this severity (low, medium, high, critical)")
+	cmd.Flags().IntVar(&limit, "limit", 50, "Maximum number of alerts to list")
+	return cmd
+}
+
+func corruptionSeverityIcon(severity string) string {
+	switch integrity.CorruptionSeverity(severity) {
+	case integrity.CorruptionSeverityCritical:
+		return "🔴"
+	case integrity.CorruptionSeverityHigh:
+		return "🟠"
+	case integrity.CorruptionSeverityMedium:
+		return "🟡"
+	}
+	return "⚪"
+}
+
+func integrityStatusIcon(status integrity.IntegrityStatus) string {
+	switch status {
+	case integrity.IntegrityValid:
+		return "✅"
+	case integrity.IntegrityCorrupted:
+		return "❌"
+	case integrity.IntegrityMissing:
+		return "❓"
+	}
+	return "⏳"
+}
+
+func fsckCmd() *cobra.Command {
+	var repair bool
+	var server bool
+	var showDangling bool
+
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Verify the objects, index and history of the repository",
+		Long: `Re-hash every stored object, verify the index checksum and walk every commit
+and tree reachable from the refs, reporting corrupt, missing and dangling objects.
+With --repair, corrupt or missing file contents are re-fetched from the server.
+With --server, the check runs against the server's stores instead (admin only).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+
+			if server {
+				return runServerFsck(projectID, showDangling)
+			}
+
+			fmt.Println("🔍 Checking local repository...")
+			checker := storage.NewRepositoryChecker(".vcs")
+			report, err := checker.Check()
+			if err != nil {
+				return err
+			}
+
+			if repair && report.HasErrors() {
+				if err := initializeClient(); err != nil {
+					return err
+				}
+				fmt.Println("🔧 Re-fetching damaged objects from the server...")
+				err := checker.Repair(func(hash string) ([]byte, error) {
+					reader, err := apiClient.DownloadFile(hash, projectID)
+					if err != nil {
+						return nil, err
+					}
+					defer reader.Close()
+					return io.ReadAll(reader)
+				})
+				if err != nil {
+					return fmt.Errorf("repair failed: %w", err)
+				}
+			}
+
+			printFsckReport("Local repository", report, showDangling)
+			reportLocalCorruption(projectID, report)
+			if report.HasErrors() {
+				if !repair {
+					fmt.Println("\n💡 Run 'vcs fsck --repair' to re-fetch damaged objects from the server")
+				}
+				return fmt.Errorf("repository has integrity errors")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&repair, "repair", false, "Re-fetch corrupt or missing objects from the server")
+	cmd.Flags().BoolVar(&server, "server", false, "Check the server's stores instead of the local repository (admin only)")
+	cmd.Flags().BoolVar(&showDangling, "dangling", false, "List dangling objects instead of only counting them")
+	return cmd
+}
+
+// reportLocalCorruption reports file contents fsck found damaged to the server, so the
+// project's alert routes hear about corruption on working copies too
+func reportLocalCorruption(projectID string, report *storage.FsckReport) {
+	seen := make(map[string]bool)
+	var paths []string
+	recovered := true
+	for _, issue := range report.Issues {
+		if issue.Type != "blob" || (issue.Kind != storage.FsckCorruptObject && issue.Kind != storage.FsckMissingObject) {
+			continue
+		}
+		recovered = recovered && issue.Repaired
+
+		// Corrupt objects found while scanning the store are named by hash, not by file
+		asset := issue.Path
+		if asset == "" || strings.HasPrefix(filepath.ToSlash(asset), ".vcs/") {
+			asset = issue.Object
+		}
+		if !seen[asset] {
+			seen[asset] = true
+			paths = append(paths, asset)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	if err := initializeClient(); err != nil {
+		fmt.Printf("⚠️  Could not report corruption to the server: %v\n", err)
+		return
+	}
+
+	alerts := integrity.NewCorruptionAlertSystem()
+	alerts.AddSink("server", apiClient.CorruptionSink(projectID))
+	alerts.AddRoute(integrity.AlertRoute{Sink: "server", MinSeverity: integrity.CorruptionSeverityLow})
+
+	event := &integrity.CorruptionEvent{
+		EventID:         fmt.Sprintf("fsck_%d", time.Now().UnixNano()),
+		Timestamp:       time.Now(),
+		CorruptionType:  integrity.CorruptionContent,
+		Severity:        integrity.CorruptionSeverityHigh,
+		AffectedAssets:  paths,
+		RootCause:       fmt.Sprintf("local fsck found %d corrupt and %d missing objects", report.Count(storage.FsckCorruptObject), report.Count(storage.FsckMissingObject)),
+		DetectionMethod: "fsck",
+		AutoRecovered:   recovered,
+	}
+	if recovered {
+		event.RecoveryMethod = "refetch_from_server"
+	}
+	if err := alerts.SendAlert(event); err != nil {
+		fmt.Printf("⚠️  Could not report corruption to the server: %v\n", err)
+		return
+	}
+	fmt.Printf("📣 Reported %d damaged files to the server\n", len(paths))
+}
+
+func runServerFsck(projectID string, showDangling bool) error {
+	if err := initializeClient(); err != nil {
+		return err
+	}
+
+	fmt.Println("🔍 Checking the project on the server (this may take a while)...")
+	resp, err := apiClient.RunServerFsck(projectID)
+	if err != nil {
+		return fmt.Errorf("server fsck failed: %w", err)
+	}
+
+	type fsckRun struct {
+		ID      string `json:"id"`
+		Status  string `json:"status"`
+		Healthy bool   `json:"healthy"`
+		Error   string `json:"error"`
+		Reports struct {
+			Content storage.FsckReport `json:"content"`
+			History storage.FsckReport `json:"history"`
+		} `json:"reports"`
+	}
+	var result struct {
+		Run fsckRun `json:"run"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("failed to parse fsck response: %w", err)
+	}
+
+	run := result.Run
+	for run.Status == "running" {
+		time.Sleep(2 * time.Second)
+		resp, err := apiClient.GetServerFsckRun(projectID, run.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get fsck status: %w", err)
+		}
+		if err := json.Unmarshal(resp, &result); err != nil {
+			return fmt.Errorf("failed to parse fsck status: %w", err)
+		}
+		run = result.Run
+	}
+
+	if run.Status != "completed" {
+		return fmt.Errorf("server fsck failed: %s", run.Error)
+	}
+
+	printFsckReport("Content store", &run.Reports.Content, showDangling)
+	fmt.Println()
+	printFsckReport("Project history", &run.Reports.History, showDangling)
+
+	if !run.Healthy {
+		return fmt.Errorf("server stores have integrity errors")
+	}
+	return nil
+}
+
+func printFsckReport(title string, report *storage.FsckReport, showDangling bool) {
+	fmt.Printf("📦 %s: %d objects, %d commits, %d trees", title, report.ObjectsChecked, report.CommitsWalked, report.TreesWalked)
+	if report.IndexEntries > 0 {
+		fmt.Printf(", %d index entries", report.IndexEntries)
+	}
+	fmt.Printf(" (%s)\n", report.Duration.Round(time.Millisecond))
+
+	dangling := 0
+	for _, issue := range report.Issues {
+		if issue.Kind == storage.FsckDanglingObject {
+			dangling++
+			if !showDangling {
+				continue
+			}
+		}
+
+		icon := "❌"
+		switch {
+		case issue.Repaired:
+			icon = "🔧"
+		case issue.Kind == storage.FsckDanglingObject:
+			icon = "💤"
+		}
+
+		subject := issue.Path
+		if issue.Object != "" {
+			subject = fmt.Sprintf("%s %s", issue.Type, issue.Object)
+			if issue.Path != "" {
+				subject += fmt.Sprintf(" (%s)", issue.Path)
+			}
+		}
+		fmt.Printf("   %s %-16s %s: %s\n", icon, issue.Kind, strings.TrimSpace(subject), issue.Detail)
+	}
+
+	if dangling > 0 && !showDangling {
+		fmt.Printf("   💤 %d dangling objects (use --dangling to list them)\n", dangling)
+	}
+	if !report.HasErrors() {
+		fmt.Println("   ✅ No errors found")
+	}
+}
+
+func cloneCmd() *cobra.Command {
+	var branch string
+	var shallow, partial bool
+	var sparsePatterns []string
+	var jobs int
+
+	cmd := &cobra.Command{
+		Use:   "clone <project-id> [directory]",
+		Short: "Clone a project from the server",
+		Long: `Clone a project and check out the head of its default branch (or --branch).
+
+For huge projects, --sparse limits the working copy to the files matching the given
+patterns, anchored at the project root: a folder matches everything below it, "*"
+matches within a folder name, "**" across folders, and a leading "!" excludes. The
+last matching pattern wins. Change them later with 'vcs sparse'.
+
+With --partial no file content is downloaded up front. Fetch files with
+'vcs hydrate <paths>'; 'vcs lock' fetches the file it locks. Until then a file counts
+as unchanged, and diff reads the versions it compares from the server.
+
+Files are downloaded in parallel (--jobs). If the connection drops, run the same
+clone again: it continues with the same commit and resumes partly downloaded files.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectID := args[0]
+			directory := projectID // Default to project ID as directory name
+			if len(args) > 1 {
+				directory = args[1]
+			}
+
+			if verbose {
+				fmt.Printf("Cloning project: %s\n", projectID)
+				fmt.Printf("Into directory: %s\n", directory)
+			}
+
+			// Create directory
+			if err := os.MkdirAll(directory, 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+
+			// Change to the new directory
+			if err := os.Chdir(directory); err != nil {
+				return fmt.Errorf("failed to change directory: %w", err)
+			}
+
+			// The client keeps its object store and index in the new directory's .vcs
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			// Initialize VCS in the directory
+			if err := initializeProject(projectID); err != nil {
+				return fmt.Errorf("failed to initialize project: %w", err)
+			}
+
+			// Get project info and files
+			projectInfo, err := getProjectInfo(projectID)
+			if err != nil {
+				return fmt.Errorf("failed to get project info: %w", err)
+			}
+
+			fmt.Printf("📥 Cloning project: %s\n", projectInfo.Name)
+
+			// Get the target branch
+			targetBranch := branch
+			if targetBranch == "" {
+				targetBranch = projectInfo.DefaultBranch
+			}
+
+			// Get commit history
+			commits, err := getCommitHistory(projectID, targetBranch)
+			if err != nil {
+				return fmt.Errorf("failed to get commit history: %w", err)
+			}
+
+			if len(commits) == 0 {
+				fmt.Printf("✅ Empty repository cloned\n")
+				return nil
+			}
+
+			// Get latest commit
+			latestCommit := commits[0]
+
+			// A clone interrupted by a network drop continues with the commit it started on
+			journal, err := loadDownloadJournal()
+			if err != nil {
+				return err
+			}
+			if journal.resumes("clone", projectID, branch) && journal.Commit != "" {
+				latestCommit = CommitInfo{ID: journal.Commit}
+				targetBranch = journal.Branch
+				fmt.Printf("♻️  Resuming interrupted clone of %s (%d objects left)\n", shortID(journal.Commit), len(journal.Pending))
+			}
+
+			sparse := &sparseCheckout{Patterns: normalizeSparsePatterns(sparsePatterns), Partial: partial}
+			files, err := apiClient.SnapshotAt(projectID, latestCommit.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get commit files: %w", err)
+			}
+
+			// Only the files in the sparse set are checked out, and a partial clone leaves
+			// even those on the server until they are hydrated
+			updates := make(map[string]storage.TreeEntry)
+			var dehydrated []string
+			var totalSize int64
+			for path, entry := range files {
+				if !sparse.includes(path) {
+					continue
+				}
+				if partial {
+					dehydrated = append(dehydrated, path)
+					continue
+				}
+				updates[path] = entry
+				totalSize += entry.Size
+			}
+			sparse.markDehydrated(dehydrated)
+			if err := sparse.save(); err != nil {
+				return fmt.Errorf("failed to save sparse checkout: %w", err)
+			}
+
+			fmt.Printf("📦 Downloading %d of %d files (%s)...\n", len(updates), len(files), FormatFileSize(totalSize))
+			apiClient.SetDownloadWorkers(jobs)
+			apiClient.SetDownloadContext("clone", targetBranch, latestCommit.ID)
+			downloaded, err := apiClient.CheckoutFiles(projectID, updates, nil)
+			if err != nil {
+				return fmt.Errorf("failed to check out files: %w", err)
+			}
+
+			// Initialize local state
+			localState := &LocalState{
+				ProjectID:     projectID,
+				CurrentBranch: targetBranch,
+				LocalCommits:  []string{latestCommit.ID},
+				RemoteCommits: []string{latestCommit.ID},
+				LastSync:      time.Now().Format(time.RFC3339),
+				StagedFiles:   make(map[string]FileState),
+			}
+			localState.SetBranchHead(targetBranch, latestCommit.ID)
+
+			if err := apiClient.ResetBranchHead(targetBranch, latestCommit.ID); err != nil {
+				return err
+			}
+			if err := apiClient.CheckoutHead(targetBranch, latestCommit.ID); err != nil {
+				return err
+			}
+			if err := localState.SaveLocalState(); err != nil {
+				return fmt.Errorf("failed to save local state: %w", err)
+			}
+
+			fmt.Printf("✅ Successfully cloned project '%s'\n", projectInfo.Name)
+			fmt.Printf("📝 Branch: %s\n", targetBranch)
+			fmt.Printf("💾 Latest commit: %s\n", shortID(latestCommit.ID))
+			fmt.Printf("📁 %d files checked out (%d objects downloaded)\n", len(updates), downloaded)
+			if len(sparse.Patterns) > 0 {
+				fmt.Printf("🧩 Sparse checkout: %s\n", strings.Join(sparse.Patterns, " "))
+			}
+			if len(dehydrated) > 0 {
+				fmt.Printf("💧 %d files were left on the server; run 'vcs hydrate <paths>' or lock a file to fetch it\n", len(dehydrated))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&branch, "branch", "b", "", "Clone specific branch")
+	cmd.Flags().BoolVar(&shallow, "shallow", false, "Shallow clone (latest commit only)")
+	cmd.Flags().StringSliceVar(&sparsePatterns, "sparse", nil, "Only check out files matching these patterns")
+	cmd.Flags().BoolVar(&partial, "partial", false, "Leave file content on the server until it is hydrated or locked")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", defaultDownloadWorkers, "Number of files to download at the same time")
+
+	return cmd
+}
+
+func sparseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sparse",
+		Short: "Show or change which files the working copy holds",
+		Long: `Show the sparse checkout patterns of the working copy, or change them with the
+subcommands. Patterns are anchored at the project root: a folder matches everything
+below it, "*" matches within a folder name, "**" across folders, and a leading "!"
+excludes. The last matching pattern wins.
+
+Files leaving the sparse set are removed from the working copy unless they have
+local changes; files entering it are downloaded, or in a partial clone left on the
+server until 'vcs hydrate' or 'vcs lock' fetches them.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := LoadProjectConfig(); err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			sparse, err := loadSparseCheckout()
+			if err != nil {
+				return err
+			}
+
+			if len(sparse.Patterns) == 0 {
+				fmt.Printf("📁 Full checkout: every file of the project is in the working copy\n")
+			} else {
+				fmt.Printf("🧩 Sparse checkout patterns:\n")
+				for _, pattern := range sparse.Patterns {
+					fmt.Printf("   %s\n", pattern)
+				}
+			}
+			if sparse.Partial {
+				fmt.Printf("💧 Partial clone: %d files not downloaded yet (vcs hydrate)\n", len(sparse.Dehydrated))
+				if verbose {
+					for _, path := range sparse.dehydratedPaths() {
+						fmt.Printf("   %s\n", path)
+					}
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set <patterns>...",
+		Short: "Replace the sparse checkout patterns",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSparseChange(func(sparse *sparseCheckout) {
+				sparse.Patterns = normalizeSparsePatterns(args)
+			})
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add <patterns>...",
+		Short: "Add patterns to the sparse checkout",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSparseChange(func(sparse *sparseCheckout) {
+				sparse.Patterns = append(sparse.Patterns, normalizeSparsePatterns(args)...)
+			})
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "disable",
+		Short: "Check out every file of the project again",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSparseChange(func(sparse *sparseCheckout) {
+				sparse.Patterns = nil
+			})
+		},
+	})
+
+	return cmd
+}
+
+// runSparseChange changes the sparse checkout patterns and brings the working copy in
+// line: files leaving the sparse set are removed, files entering it are checked out
+func runSparseChange(change func(sparse *sparseCheckout)) error {
+	config, err := LoadProjectConfig()
+	if err != nil {
+		return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+	}
+	projectID, ok := config["project_id"].(string)
+	if !ok || projectID == "" {
+		return fmt.Errorf("invalid project configuration")
+	}
+	if err := initializeClient(); err != nil {
+		return err
+	}
+	localState, err := LoadLocalState()
+	if err != nil {
+		return fmt.Errorf("failed to load local state: %w", err)
+	}
+
+	sparse, err := loadSparseCheckout()
+	if err != nil {
+		return err
+	}
+	previous := *sparse
+	change(sparse)
+
+	headFiles, err := apiClient.SnapshotAt(projectID, localState.HeadCommit())
+	if err != nil {
+		return fmt.Errorf("failed to read files at HEAD: %w", err)
+	}
+
+	updates := make(map[string]storage.TreeEntry)
+	var removals, kept, dehydrated []string
+	for path, entry := range headFiles {
+		was, now := previous.includes(path), sparse.includes(path)
+		switch {
+		case was && !now:
+			delete(sparse.Dehydrated, path)
+			workingHash, err := apiClient.WorkingFileHash(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			if workingHash != entry.Hash || localState.IsFileStaged(path) {
+				kept = append(kept, path)
+				continue
+			}
+			removals = append(removals, path)
+		case !was && now:
+			if _, err := os.Stat(path); err == nil {
+				continue // A copy left behind earlier stays as it is
+			}
+			if sparse.Partial {
+				dehydrated = append(dehydrated, path)
+				continue
+			}
+			updates[path] = entry
+		}
+	}
+	sort.Strings(removals)
+	sort.Strings(kept)
+
+	downloaded, err := apiClient.CheckoutFiles(projectID, updates, removals)
+	if err != nil {
+		return err
+	}
+	sparse.markDehydrated(dehydrated)
+	if err := sparse.save(); err != nil {
+		return fmt.Errorf("failed to save sparse checkout: %w", err)
+	}
+
+	fmt.Printf("🧩 %d files checked out (%d objects downloaded), %d removed\n", len(updates), downloaded, len(removals))
+	if len(dehydrated) > 0 {
+		fmt.Printf("💧 %d files left on the server until hydrated\n", len(dehydrated))
+	}
+	if len(kept) > 0 {
+		fmt.Printf("⚠️  %d files outside the sparse checkout have local changes and were kept:\n", len(kept))
+		for _, path := range kept {
+			fmt.Printf("   %s\n", path)
+		}
+	}
+	return nil
+}
+
+func hydrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "hydrate <paths>...",
+		Short: "Download files a partial clone or sparse checkout left out",
+		Long: `Download the files at HEAD matching the given files or folders into the working
+copy. In a partial clone this fetches files the clone left on the server; paths outside
+the sparse checkout are added to it, so later checkouts keep them up to date.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+			if err := initializeClient(); err != nil {
+				return err
+			}
+			localState, err := LoadLocalState()
+			if err != nil {
+				return fmt.Errorf("failed to load local state: %w", err)
+			}
+			sparse, err := loadSparseCheckout()
+			if err != nil {
+				return err
+			}
+
+			head := localState.HeadCommit()
+			headFiles, err := apiClient.SnapshotAt(projectID, head)
+			if err != nil {
+				return fmt.Errorf("failed to read files at %s: %w", shortID(head), err)
+			}
+
+			paths := normalizePathspec(args)
+			updates := make(map[string]storage.TreeEntry)
+			hydrated := make([]string, 0)
+			widen := make(map[string]bool)
+			matched := 0
+			var totalSize int64
+			for path, entry := range headFiles {
+				if !matchesPathspec(path, paths) {
+					continue
+				}
+				matched++
+				if !sparse.absent(path) {
+					continue
+				}
+				if !sparse.includes(path) {
+					for _, spec := range paths {
+						if matchesPathspec(path, []string{spec}) {
+							widen[spec] = true
+						}
+					}
+				}
+				hydrated = append(hydrated, path)
+				if _, err := os.Stat(path); err == nil {
+					continue // A copy left behind earlier stays as it is
+				}
+				updates[path] = entry
+				totalSize += entry.Size
+			}
+			if matched == 0 {
+				return fmt.Errorf("no files at %s match %s", shortID(head), strings.Join(paths, ", "))
+			}
+			if len(hydrated) == 0 {
+				fmt.Printf("✅ All %d matching files are already in the working copy\n", matched)
+				return nil
+			}
+
+			fmt.Printf("💧 Hydrating %d files (%s)...\n", len(updates), FormatFileSize(totalSize))
+			downloaded, err := apiClient.CheckoutFiles(projectID, updates, nil)
+			if err != nil {
+				return err
+			}
+
+			for _, spec := range paths {
+				if widen[spec] {
+					sparse.Patterns = append(sparse.Patterns, spec)
+				}
+			}
+			sparse.markHydrated(hydrated)
+			if err := sparse.save(); err != nil {
+				return fmt.Errorf("failed to save sparse checkout: %w", err)
+			}
+
+			fmt.Printf("✅ Hydrated %d files (%d objects downloaded)\n", len(hydrated), downloaded)
+			if len(widen) > 0 {
+				fmt.Printf("🧩 Added to the sparse checkout: %s\n", strings.Join(sparse.Patterns[len(sparse.Patterns)-len(widen):], " "))
+			}
+			return nil
+		},
+	}
+}
+
+func commitCmd() *cobra.Command {
+	var message string
+	var addAll bool
+
+	cmd := &cobra.Command{
+		Use:   "commit",
+		Short: "Create a commit with Git-style optimizations",
+		Long: `Create a commit from staged files.
+
+Phase 1 Optimizations:
+- Uses Git-style object storage for content
+- Leverages local index for fast status checks
+- Batch operations for better performance`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCommitGitStyle(os.Stdout, message, addAll)
+		},
+	}
+
+	cmd.Flags().StringVarP(&message, "message", "m", "", "Commit message")
+	cmd.Flags().BoolVarP(&addAll, "all", "a", false, "Add all changes before commit")
+
+	return cmd
+}
+
+func pushCmd() *cobra.Command {
+	var branch string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "push",
+		Short: "Publish local commits to the server",
+		Long: `Publish the commits made on the current branch since the last push.
+
+The server is asked which of the commits and their objects it is missing. The
+missing file contents are uploaded one object at a time, then the missing commits
+and trees are sent and the server branch is fast-forwarded to the local head.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+
+			// Load local state
+			localState, err := LoadLocalState()
+			if err != nil {
+				return fmt.Errorf("failed to load local state: %w", err)
+			}
+
+			// Use provided branch or current branch
+			targetBranch := branch
+			if targetBranch == "" {
+				targetBranch = localState.CurrentBranch
+			}
+			if targetBranch == "" {
+				targetBranch = "main"
+			}
+
+			head := localState.GetBranchHead(targetBranch)
+			if head == "" {
+				fmt.Printf("✅ Nothing to push on %s\n", targetBranch)
+				return nil
+			}
+
+			if verbose {
+				fmt.Printf("Pushing %s (%s)\n", targetBranch, head[:8])
+			}
+
+			// Commits the server has acknowledged are already published
+			commits, err := apiClient.LocalCommitsSince(head, localState.RemoteCommits)
+			if err != nil {
+				return fmt.Errorf("failed to read local commits: %w", err)
+			}
+
+			commitIDs := make([]string, 0, len(commits))
+			var objects []string
+			seenObjects := make(map[string]bool)
+			for _, commit := range commits {
+				commitIDs = append(commitIDs, commit.ID)
+				for _, entry := range commit.Files {
+					if !entry.IsDeletion() && !seenObjects[entry.Hash] {
+						seenObjects[entry.Hash] = true
+						objects = append(objects, entry.Hash)
+					}
+				}
+			}
+
+			negotiateResp, err := apiClient.NegotiatePush(projectID, targetBranch, commitIDs, objects)
+			if err != nil {
+				return fmt.Errorf("failed to negotiate push: %w", err)
+			}
+
+			var negotiation struct {
+				Success        bool     `json:"success"`
+				HeadCommit     string   `json:"head_commit"`
+				MissingCommits []string `json:"missing_commits"`
+				MissingObjects []string `json:"missing_objects"`
+			}
+			if err := json.Unmarshal(negotiateResp, &negotiation); err != nil {
+				return fmt.Errorf("failed to parse push negotiation: %w", err)
+			}
+
+			if negotiation.HeadCommit == head {
+				fmt.Println("✅ Everything up-to-date")
+				return nil
+			}
+
+			// The server head must be what the local commits were built on
+			if negotiation.HeadCommit != "" && !force && !commitsBuildOn(commits, negotiation.HeadCommit) {
+				fmt.Printf("⚠️  %s has commits you don't have. Please run 'vcs pull' first.\n", targetBranch)
+				fmt.Printf("   Or use --force to replace the server branch.\n")
+				return nil
+			}
+
+			missingCommits := make(map[string]bool, len(negotiation.MissingCommits))
+			for _, commitID := range negotiation.MissingCommits {
+				missingCommits[commitID] = true
+			}
+			missingObjects := make(map[string]bool, len(negotiation.MissingObjects))
+			for _, hash := range negotiation.MissingObjects {
+				missingObjects[hash] = true
+			}
+
+			pack := &PushPack{
+				Branch: targetBranch,
+				Head:   head,
+				Force:  force,
+			}
+			var transferSize int64
+			for i := len(commits) - 1; i >= 0; i-- {
+				pack.LocalCommits = append(pack.LocalCommits, commits[i].ID)
+			}
+			for _, commit := range commits {
+				if !missingCommits[commit.ID] {
+					continue
+				}
+				pack.Commits = append(pack.Commits, commit)
+				for _, entry := range commit.Files {
+					if missingObjects[entry.Hash] {
+						transferSize += entry.Size
+					}
+				}
+			}
+
+			fmt.Printf("📦 Pushing %d commits and %d objects (%s) to %s...\n",
+				len(pack.Commits), len(negotiation.MissingObjects), FormatFileSize(transferSize), targetBranch)
+
+			pushResp, err := apiClient.PublishCommits(projectID, pack, negotiation.MissingObjects)
+			if err != nil {
+				if strings.Contains(err.Error(), `"required_pull":true`) {
 					fmt.Printf("❌ Push rejected: remote has newer commits\n")
 					fmt.Printf("   Run 'vcs pull' to sync with remote changes first\n")
 					return nil
 				}
-				return fmt.Errorf("push failed: %s", string(pushResp))
+				return fmt.Errorf("failed to push changes: %w", err)
+			}
+
+			var pushResult struct {
+				Success           bool     `json:"success"`
+				Updated           bool     `json:"updated"`
+				NewCommits        []string `json:"new_commits"`
+				ReferenceWarnings []struct {
+					SourceAsset string `json:"source_asset"`
+					TargetAsset string `json:"target_asset"`
+					Reason      string `json:"reason"`
+				} `json:"reference_warnings"`
+			}
+
+			if err := json.Unmarshal(pushResp, &pushResult); err != nil {
+				return fmt.Errorf("failed to parse push response: %w", err)
+			}
+
+			if !pushResult.Success {
+				return fmt.Errorf("push failed: %s", string(pushResp))
+			}
+
+			if !pushResult.Updated {
+				fmt.Println("✅ Everything up-to-date")
+				return nil
+			}
+
+			fmt.Printf("✅ Pushed %d commits to %s\n", len(commits), targetBranch)
+			for i := len(commits) - 1; i >= 0; i-- {
+				fmt.Printf("   %s %s\n", commits[i].ID[:8], strings.SplitN(commits[i].Message, "\n", 2)[0])
+			}
+
+			if len(pushResult.ReferenceWarnings) > 0 {
+				fmt.Printf("⚠️  %d broken asset references:\n", len(pushResult.ReferenceWarnings))
+				for _, warning := range pushResult.ReferenceWarnings {
+					fmt.Printf("   %s → %s (%s)\n", warning.SourceAsset, warning.TargetAsset, warning.Reason)
+				}
+			}
+
+			// Everything up to the pushed head is now on the server
+			localState.RemoteCommits = append(pack.LocalCommits, localState.RemoteCommits...)
+			localState.LastSync = time.Now().Format(time.RFC3339)
+			if err := localState.SaveLocalState(); err != nil {
+				fmt.Printf("Warning: failed to save local state: %v\n", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&branch, "branch", "b", "", "Target branch")
+	cmd.Flags().BoolVar(&force, "force", false, "Force push even if remote has newer commits")
+
+	return cmd
+}
+
+// commitsBuildOn reports whether a run of local commits starts from, or includes, commitID
+func commitsBuildOn(commits []PushPackCommit, commitID string) bool {
+	for _, commit := range commits {
+		if commit.ID == commitID {
+			return true
+		}
+		for _, parent := range commit.Parents {
+			if parent == commitID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func pullCmd() *cobra.Command {
+	var branch string
+	var jobs int
+
+	cmd := &cobra.Command{
+		Use:   "pull",
+		Short: "Pull changes from the server",
+		Long: `Pull the new commits of the current branch (or --branch) from the server and
+update the working copy to match.
+
+Files are downloaded in parallel (--jobs). If the connection drops, run pull again:
+partly downloaded files resume where they stopped. Files with local changes are
+never overwritten; commit or shelve them first.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not in a VCS project directory. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+
+			// Load local state
+			localState, err := LoadLocalState()
+			if err != nil {
+				return fmt.Errorf("failed to load local state: %w", err)
+			}
+
+			// Use provided branch or current branch
+			targetBranch := branch
+			if targetBranch == "" {
+				targetBranch = localState.CurrentBranch
+			}
+			if targetBranch == "" {
+				targetBranch = "main"
+			}
+
+			if verbose {
+				fmt.Printf("Pulling from branch: %s\n", targetBranch)
+			}
+
+			journal, err := loadDownloadJournal()
+			if err != nil {
+				return err
+			}
+			if journal.resumes("pull", projectID, targetBranch) {
+				fmt.Printf("♻️  Resuming interrupted pull of %s (%d objects left)\n", targetBranch, len(journal.Pending))
+			}
+
+			// Perform the pull
+			pullResp, err := apiClient.PullChanges(projectID, targetBranch, localState.LocalCommits, localState.RemoteCommits)
+			if err != nil {
+				return fmt.Errorf("failed to pull changes: %w", err)
+			}
+
+			var pullResult struct {
+				Success    bool `json:"success"`
+				Updated    bool `json:"updated"`
+				NewCommits []struct {
+					ID        string `json:"id"`
+					Message   string `json:"message"`
+					AuthorID  string `json:"author_id"`
+					CreatedAt string `json:"created_at"`
+				} `json:"new_commits"`
+				Files []struct {
+					Path        string `json:"path"`
+					ContentHash string `json:"content_hash"`
+					CommitID    string `json:"commit_id"`
+				} `json:"files"`
+				HeadCommit string   `json:"head_commit"`
+				Conflicts  []string `json:"conflicts"`
+			}
+
+			if err := json.Unmarshal(pullResp, &pullResult); err != nil {
+				return fmt.Errorf("failed to parse pull response: %w", err)
+			}
+
+			if !pullResult.Success {
+				return fmt.Errorf("pull failed: %s", string(pullResp))
+			}
+
+			if !pullResult.Updated {
+				fmt.Println("✅ Already up-to-date")
+				return nil
+			}
+
+			// Unpublished local commits are replayed on top of the pulled head, unless both
+			// sides changed the same files
+			unpublished, err := apiClient.LocalCommitsSince(localState.GetBranchHead(targetBranch), localState.RemoteCommits)
+			if err != nil {
+				return fmt.Errorf("failed to read local commits: %w", err)
+			}
+			if len(unpublished) > 0 {
+				pulledPaths := make(map[string]bool, len(pullResult.Files))
+				for _, file := range pullResult.Files {
+					pulledPaths[file.Path] = true
+				}
+				var conflicts []string
+				for _, commit := range unpublished {
+					for _, entry := range commit.Files {
+						if pulledPaths[entry.Name] {
+							conflicts = append(conflicts, entry.Name)
+							delete(pulledPaths, entry.Name)
+						}
+					}
+				}
+				if len(conflicts) > 0 {
+					sort.Strings(conflicts)
+					fmt.Printf("❌ Your %d unpublished commits and the pulled commits both change:\n", len(unpublished))
+					for _, path := range conflicts {
+						fmt.Printf("   %s\n", path)
+					}
+					return fmt.Errorf("pull would conflict with local commits")
+				}
+			}
+
+			// Work out the new head first, so the working copy is complete before any ref moves
+			oldHead := localState.GetBranchHead(targetBranch)
+			head := pullResult.HeadCommit
+			var copies []string
+			if head != "" && len(unpublished) > 0 {
+				copies, err = apiClient.ReplayCommits(targetBranch, pullResult.HeadCommit, unpublished)
+				if err != nil {
+					return fmt.Errorf("failed to replay local commits: %w", err)
+				}
+				head = copies[len(copies)-1]
+			}
+
+			// Only the checked out branch has files in the working copy
+			if head != "" && targetBranch == localState.CurrentBranch && !localState.Detached {
+				plan, err := planCheckout(projectID, oldHead, head, nil, localState)
+				if err == nil && len(plan.Blocked) > 0 {
+					err = fmt.Errorf("pull would overwrite local changes")
+					fmt.Printf("❌ The pulled commits change %d files you have local changes to or locks on:\n", len(plan.Blocked))
+					for _, path := range plan.Blocked {
+						fmt.Printf("   %s\n", path)
+					}
+					fmt.Printf("💡 Commit or shelve them, then pull again\n")
+				}
+				if err == nil {
+					apiClient.SetDownloadWorkers(jobs)
+					apiClient.SetDownloadContext("pull", targetBranch, head)
+					err = applyCheckout(projectID, plan, localState, false)
+				}
+				if err != nil {
+					if len(copies) > 0 {
+						apiClient.ResetBranchHead(targetBranch, oldHead) // Drop the replayed copies again
+					}
+					return err
+				}
+			}
+
+			fmt.Printf("✅ Pulled %d new commits from %s\n", len(pullResult.NewCommits), targetBranch)
+
+			// Show new commits
+			for _, commit := range pullResult.NewCommits {
+				fmt.Printf("   %s %s (%s)\n",
+					commit.ID[:8],
+					commit.Message,
+					commit.AuthorID)
+			}
+
+			// Update local state with new commits
+			for _, commit := range pullResult.NewCommits {
+				// Add to local commits list
+				localState.AddLocalCommit(commit.ID)
+				// Also add to remote commits list
+				found := false
+				for _, existing := range localState.RemoteCommits {
+					if existing == commit.ID {
+						found = true
+						break
+					}
+				}
+				if !found {
+					localState.RemoteCommits = append([]string{commit.ID}, localState.RemoteCommits...)
+				}
+			}
+
+			// Update branch HEAD
+			if head != "" {
+				if len(copies) > 0 {
+					for i, commit := range unpublished {
+						localState.ReplaceLocalCommit(commit.ID, copies[i])
+					}
+					fmt.Printf("🔁 Replayed %d unpublished commits on top of %s\n", len(unpublished), pullResult.HeadCommit[:8])
+				}
+				localState.SetBranchHead(targetBranch, head)
+			}
+
+			// Save updated state
+			if err := localState.SaveLocalState(); err != nil {
+				fmt.Printf("Warning: failed to save local state: %v\n", err)
+			}
+
+			// Show file changes
+			if len(pullResult.Files) > 0 {
+				fmt.Printf("\nUpdated files:\n")
+				for _, file := range pullResult.Files {
+					fmt.Printf("   %s\n", file.Path)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&branch, "branch", "b", "", "Target branch")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", defaultDownloadWorkers, "Number of files to download at the same time")
+
+	return cmd
+}
+
+// checkoutPlan is the working copy change that moves a checkout to another commit
+type checkoutPlan struct {
+	Updates   map[string]storage.TreeEntry
+	Deletions []string
+	Blocked   []string // Files with local changes or held locks the checkout would overwrite
+	Dehydrate []string // Files a partial clone leaves on the server until hydrated
+}
+
+// planCheckout compares the files at head with the files at target. Without paths every
+// file that differs between the two commits changes, and files only head has are removed;
+// with paths the matching files are set to their version at target. Files whose working
+// copy differs from head, staged files and files the current user has locked are blocked.
+// Files outside a sparse checkout are left out; a partial clone only downloads files it
+// already has, or files asked for by path.
+func planCheckout(projectID, head, target string, paths []string, localState *LocalState) (*checkoutPlan, error) {
+	headFiles, err := apiClient.SnapshotAt(projectID, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read files at %s: %w", shortID(head), err)
+	}
+	targetFiles, err := apiClient.SnapshotAt(projectID, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read files at %s: %w", shortID(target), err)
+	}
+
+	candidates := make(map[string]bool)
+	if len(paths) > 0 {
+		for path := range targetFiles {
+			if matchesPathspec(path, paths) {
+				candidates[path] = true
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no files at %s match %s", shortID(target), strings.Join(paths, ", "))
+		}
+	} else {
+		for path, entry := range targetFiles {
+			if headFiles[path].Hash != entry.Hash {
+				candidates[path] = true
+			}
+		}
+		for path := range headFiles {
+			if _, ok := targetFiles[path]; !ok {
+				candidates[path] = true
+			}
+		}
+	}
+
+	lockedByMe := make(map[string]bool)
+	if len(candidates) > 0 {
+		if userID, err := currentUserID(); err == nil {
+			if locks, err := apiClient.ListLocks(projectID); err == nil {
+				for _, lock := range locks {
+					if lock.UserID == userID {
+						lockedByMe[lock.FilePath] = true
+					}
+				}
+			}
+		}
+	}
+
+	sparse, err := loadSparseCheckout()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &checkoutPlan{Updates: make(map[string]storage.TreeEntry)}
+	for path := range candidates {
+		if !sparse.includes(path) {
+			continue
+		}
+
+		workingHash, err := apiClient.WorkingFileHash(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		entry, inTarget := targetFiles[path]
+		dehydrated := sparse.Dehydrated[path]
+		if dehydrated && !inTarget {
+			plan.Deletions = append(plan.Deletions, path) // Nothing to remove but the record
+			continue
+		}
+		if sparse.Partial && len(paths) == 0 && inTarget && (dehydrated || (headFiles[path].Hash == "" && workingHash == "")) {
+			plan.Dehydrate = append(plan.Dehydrate, path)
+			continue
+		}
+		if workingHash == entry.Hash {
+			continue // Already matches the target (or already absent)
+		}
+
+		// A file you've locked may be open in the editor even when it's unchanged
+		if !dehydrated && (workingHash != headFiles[path].Hash || localState.IsFileStaged(path) || (lockedByMe[path] && workingHash != "")) {
+			plan.Blocked = append(plan.Blocked, path)
+		}
+		if inTarget {
+			plan.Updates[path] = entry
+		} else {
+			plan.Deletions = append(plan.Deletions, path)
+		}
+	}
+	sort.Strings(plan.Blocked)
+	sort.Strings(plan.Deletions)
+	sort.Strings(plan.Dehydrate)
+
+	return plan, nil
+}
+
+// applyCheckout writes a checkout plan to the working directory, refusing to overwrite
+// blocked files unless forced
+func applyCheckout(projectID string, plan *checkoutPlan, localState *LocalState, force bool) error {
+	if len(plan.Blocked) > 0 && !force {
+		fmt.Printf("❌ The checkout would overwrite %d files with local changes or locks:\n", len(plan.Blocked))
+		for _, path := range plan.Blocked {
+			fmt.Printf("   %s\n", path)
+		}
+		fmt.Printf("💡 Commit them, or use --force to discard the changes\n")
+		return fmt.Errorf("checkout would overwrite local changes")
+	}
+
+	downloaded, err := apiClient.CheckoutFiles(projectID, plan.Updates, plan.Deletions)
+	if err != nil {
+		return err
+	}
+
+	// Discarded changes no longer need staging
+	updated := make([]string, 0, len(plan.Updates))
+	for path := range plan.Updates {
+		localState.RemoveStagedFile(path)
+		updated = append(updated, path)
+	}
+	for _, path := range plan.Deletions {
+		localState.RemoveStagedFile(path)
+	}
+
+	sparse, err := loadSparseCheckout()
+	if err != nil {
+		return err
+	}
+	if sparse.active() {
+		sparse.markHydrated(updated)
+		sparse.markHydrated(plan.Deletions)
+		sparse.markDehydrated(plan.Dehydrate)
+		if err := sparse.save(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("📁 %d files updated, %d removed (%d objects downloaded)\n", len(plan.Updates), len(plan.Deletions), downloaded)
+	if len(plan.Dehydrate) > 0 {
+		fmt.Printf("💧 %d files left on the server until hydrated (vcs hydrate)\n", len(plan.Dehydrate))
+	}
+	if verbose {
+		for path := range plan.Updates {
+			fmt.Printf("   M %s\n", path)
+		}
+		for _, path := range plan.Deletions {
+			fmt.Printf("   D %s\n", path)
+		}
+	}
+	return nil
+}
+
+func switchCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "switch <branch>",
+		Short: "Switch the working directory to another branch",
+		Long: `Switch the working directory to the head of another branch.
+
+Only the files that differ between the current commit and the branch head are
+rewritten, and only objects missing locally are downloaded. Files with local
+changes, staged files and files you have locked are never overwritten unless
+--force is given; other local changes are carried over to the branch.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+
+			localState, err := LoadLocalState()
+			if err != nil {
+				return fmt.Errorf("failed to load local state: %w", err)
+			}
+
+			branch := args[0]
+			if branch == localState.CurrentBranch && !localState.Detached {
+				fmt.Printf("✅ Already on %s\n", branch)
+				return nil
+			}
+
+			// Prefer the local head, which may carry unpublished commits
+			target := localState.GetBranchHead(branch)
+			if target == "" {
+				resp, err := apiClient.GetBranchInfo(projectID, branch)
+				if err != nil {
+					return fmt.Errorf("branch %s not found: %w", branch, err)
+				}
+				var branchInfo struct {
+					HeadCommit string `json:"head_commit"`
+				}
+				if err := json.Unmarshal(resp, &branchInfo); err != nil {
+					return fmt.Errorf("failed to parse branch info: %w", err)
+				}
+				if branchInfo.HeadCommit == "" {
+					return fmt.Errorf("branch %s has no commits yet", branch)
+				}
+				target = branchInfo.HeadCommit
+
+				// The server head is published history that later pushes build on
+				known := false
+				for _, commitID := range localState.RemoteCommits {
+					if commitID == target {
+						known = true
+						break
+					}
+				}
+				if !known {
+					localState.RemoteCommits = append([]string{target}, localState.RemoteCommits...)
+				}
+			}
+
+			plan, err := planCheckout(projectID, localState.HeadCommit(), target, nil, localState)
+			if err != nil {
+				return err
+			}
+			if err := applyCheckout(projectID, plan, localState, force); err != nil {
+				return err
+			}
+
+			localState.SwitchBranch(branch, target)
+			if err := apiClient.CheckoutHead(branch, target); err != nil {
+				return err
+			}
+			if err := localState.SaveLocalState(); err != nil {
+				return fmt.Errorf("failed to save local state: %w", err)
+			}
+
+			fmt.Printf("✅ Switched to %s (%s)\n", branch, shortID(target))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite files with local changes")
+
+	return cmd
+}
+
+func checkoutCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "checkout <commit> [-- <paths>...]",
+		Short: "Check out a commit, or restore paths from a commit",
+		Long: `Check out a historical commit, or restore files from it.
+
+Without paths the working directory is moved to the commit and HEAD is
+detached from the current branch; run 'vcs switch <branch>' to return. With
+paths, the matching files and folders are set to their version at the commit
+and the current branch is left where it is.
+
+Files with local changes, staged files and files you have locked are never
+overwritten unless --force is given.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+
+			var paths []string
+			if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+				if dash != 1 {
+					return fmt.Errorf("expected one commit before --")
+				}
+				paths = normalizePathspec(args[dash:])
+			} else if len(args) > 1 {
+				return fmt.Errorf("separate paths from the commit with --, e.g. vcs checkout <commit> -- <paths>")
+			}
+
+			localState, err := LoadLocalState()
+			if err != nil {
+				return fmt.Errorf("failed to load local state: %w", err)
+			}
+
+			target := resolveCommit(localState, args[0])
+			plan, err := planCheckout(projectID, localState.HeadCommit(), target, paths, localState)
+			if err != nil {
+				return err
+			}
+			if err := applyCheckout(projectID, plan, localState, force); err != nil {
+				return err
+			}
+
+			if len(paths) == 0 {
+				localState.DetachHead(target)
+				if err := apiClient.CheckoutHead("", target); err != nil {
+					return err
+				}
+			}
+			if err := localState.SaveLocalState(); err != nil {
+				return fmt.Errorf("failed to save local state: %w", err)
+			}
+
+			if len(paths) > 0 {
+				fmt.Printf("✅ Restored %d files from %s\n", len(plan.Updates), shortID(target))
+				fmt.Printf("💡 Use 'vcs add' to stage them\n")
+			} else {
+				fmt.Printf("✅ HEAD is now detached at %s\n", shortID(target))
+				fmt.Printf("💡 Run 'vcs switch <branch>' to get back to a branch\n")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite files with local changes")
+
+	return cmd
+}
+
+func restoreCmd() *cobra.Command {
+	var staged, worktree bool
+	var source string
+
+	cmd := &cobra.Command{
+		Use:   "restore [--staged] [--source <commit>] <paths>...",
+		Short: "Discard local changes to files, or unstage them",
+		Long: `Throw away local changes by restoring files from HEAD, or from the commit given
+with --source. Files deleted locally come back, only files whose content differs
+are rewritten, and objects missing locally are downloaded. Restoring a staged file
+also unstages it.
+
+With --staged the files are only unstaged and the working copy is left alone; add
+--worktree to unstage them and discard their changes in one go.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+
+			localState, err := LoadLocalState()
+			if err != nil {
+				return fmt.Errorf("failed to load local state: %w", err)
+			}
+			paths := normalizePathspec(args)
+
+			if staged {
+				unstaged, err := apiClient.UnstageFiles(paths)
+				if err != nil {
+					return err
+				}
+				seen := make(map[string]bool, len(unstaged))
+				for _, path := range unstaged {
+					seen[path] = true
+				}
+				for _, path := range localState.GetStagedFiles() {
+					if matchesPathspec(path, paths) && !seen[path] {
+						unstaged = append(unstaged, path)
+					}
+				}
+				for _, path := range unstaged {
+					localState.RemoveStagedFile(path)
+				}
+				if err := localState.SaveLocalState(); err != nil {
+					return fmt.Errorf("failed to save local state: %w", err)
+				}
+
+				if len(unstaged) == 0 {
+					fmt.Printf("📝 No staged files match %s\n", strings.Join(paths, ", "))
+				} else {
+					fmt.Printf("✅ Unstaged %d files\n", len(unstaged))
+					if verbose {
+						for _, path := range unstaged {
+							fmt.Printf("   %s\n", path)
+						}
+					}
+				}
+				if !worktree {
+					return nil
+				}
+			}
+
+			commit := localState.HeadCommit()
+			if source != "" {
+				commit = resolveCommit(localState, source)
+			}
+			if commit == "" {
+				return fmt.Errorf("nothing to restore from: there are no commits yet")
+			}
+
+			// Restoring from a single commit: every matching file whose working copy differs
+			plan, err := planCheckout(projectID, commit, commit, paths, localState)
+			if err != nil {
+				return err
+			}
+			if len(plan.Updates) == 0 {
+				fmt.Printf("✅ Nothing to restore, the files already match %s\n", shortID(commit))
+				return nil
+			}
+			if err := applyCheckout(projectID, plan, localState, true); err != nil {
+				return err
+			}
+			if err := localState.SaveLocalState(); err != nil {
+				return fmt.Errorf("failed to save local state: %w", err)
+			}
+
+			fmt.Printf("✅ Restored %d files from %s\n", len(plan.Updates), shortID(commit))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&staged, "staged", false, "Unstage the files instead of restoring them")
+	cmd.Flags().BoolVar(&worktree, "worktree", false, "With --staged, also discard the changes in the working copy")
+	cmd.Flags().StringVarP(&source, "source", "s", "", "Restore from this commit instead of HEAD")
+
+	return cmd
+}
+
+func resetCmd() *cobra.Command {
+	var soft, mixed, hard bool
+
+	cmd := &cobra.Command{
+		Use:   "reset [--soft|--mixed|--hard] [<commit>]",
+		Short: "Move the current branch to another commit",
+		Long: `Point the current branch, or a detached HEAD, at another commit (HEAD by default).
+
+  --soft   only move the branch; staged files and the working copy stay as they are
+  --mixed  also unstage everything (the default)
+  --hard   also make the working copy match the commit, discarding local changes
+           to committed files; files that were never committed are left alone
+
+Commits that were already pushed stay on the server. Unpublished commits the branch
+no longer reaches are listed, so you can get back to them with 'vcs reset <commit>'.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+
+			mode := "mixed"
+			modes := 0
+			for flag, set := range map[string]bool{"soft": soft, "mixed": mixed, "hard": hard} {
+				if set {
+					mode = flag
+					modes++
+				}
+			}
+			if modes > 1 {
+				return fmt.Errorf("choose only one of --soft, --mixed and --hard")
+			}
+
+			localState, err := LoadLocalState()
+			if err != nil {
+				return fmt.Errorf("failed to load local state: %w", err)
+			}
+
+			ref := "HEAD"
+			if len(args) == 1 {
+				ref = args[0]
+			}
+			head := localState.HeadCommit()
+			target := resolveCommit(localState, ref)
+			if target == "" {
+				return fmt.Errorf("nothing to reset to: there are no commits yet")
+			}
+			if err := ensureKnownCommit(projectID, localState, target); err != nil {
+				return err
 			}
 
-			if pushResult.Updated {
-				fmt.Printf("✅ Pushed %d commits to %s\n", len(pushResult.NewCommits), targetBranch)
-				for _, commitID := range pushResult.NewCommits {
-					fmt.Printf("   %s\n", commitID[:8])
+			if mode == "hard" {
+				plan, err := planCheckout(projectID, head, target, nil, localState)
+				if err != nil {
+					return err
 				}
 
-				// Update local state - mark pushed commits as remote
-				for _, commitID := range pushResult.NewCommits {
-					// Add to remote commits list
-					found := false
-					for _, existing := range localState.RemoteCommits {
-						if existing == commitID {
-							found = true
-							break
-						}
+				// Files the two commits share may still have local changes to discard
+				targetFiles, err := apiClient.SnapshotAt(projectID, target)
+				if err != nil {
+					return fmt.Errorf("failed to read files at %s: %w", shortID(target), err)
+				}
+				sparse, err := loadSparseCheckout()
+				if err != nil {
+					return err
+				}
+				for path, entry := range targetFiles {
+					if _, planned := plan.Updates[path]; planned || sparse.absent(path) {
+						continue
+					}
+					workingHash, err := apiClient.WorkingFileHash(path)
+					if err != nil && !os.IsNotExist(err) {
+						return fmt.Errorf("failed to read %s: %w", path, err)
 					}
-					if !found {
-						localState.RemoteCommits = append([]string{commitID}, localState.RemoteCommits...)
+					if workingHash != entry.Hash {
+						plan.Updates[path] = entry
 					}
 				}
+				if err := applyCheckout(projectID, plan, localState, true); err != nil {
+					return err
+				}
+			}
+
+			var dropped []string
+			if head != "" && head != target {
+				if dropped, err = apiClient.DroppedCommits(head, target, localState.RemoteCommits); err != nil {
+					return fmt.Errorf("failed to compare history: %w", err)
+				}
+			}
 
-				// Save updated state
-				if err := localState.SaveLocalState(); err != nil {
-					fmt.Printf("Warning: failed to save local state: %v\n", err)
+			if localState.Detached {
+				localState.DetachHead(target)
+				if err := apiClient.CheckoutHead("", target); err != nil {
+					return err
 				}
 			} else {
-				fmt.Println("✅ Everything up-to-date")
+				localState.SetBranchHead(localState.CurrentBranch, target)
+				if err := apiClient.ResetBranchHead(localState.CurrentBranch, target); err != nil {
+					return err
+				}
+			}
+
+			if mode != "soft" {
+				if _, err := apiClient.UnstageFiles([]string{"."}); err != nil {
+					return err
+				}
+				localState.ClearStagedFiles()
+			}
+			if err := localState.SaveLocalState(); err != nil {
+				return fmt.Errorf("failed to save local state: %w", err)
 			}
 
+			if localState.Detached {
+				fmt.Printf("✅ HEAD is now at %s\n", shortID(target))
+			} else {
+				fmt.Printf("✅ %s is now at %s\n", localState.CurrentBranch, shortID(target))
+			}
+			if len(dropped) > 0 {
+				fmt.Printf("⚠️  %d unpublished commits are no longer reachable:\n", len(dropped))
+				for i := len(dropped) - 1; i >= 0; i-- {
+					subject := ""
+					if commit, err := apiClient.commitStore.GetCommit(dropped[i]); err == nil {
+						subject = strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]
+					}
+					fmt.Printf("   %s %s\n", shortID(dropped[i]), subject)
+				}
+				fmt.Printf("💡 Run 'vcs reset %s' to get them back\n", dropped[len(dropped)-1])
+			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&branch, "branch", "b", "", "Target branch")
-	cmd.Flags().BoolVar(&force, "force", false, "Force push even if remote has newer commits")
+	cmd.Flags().BoolVar(&soft, "soft", false, "Only move the branch")
+	cmd.Flags().BoolVar(&mixed, "mixed", false, "Move the branch and unstage everything (default)")
+	cmd.Flags().BoolVar(&hard, "hard", false, "Move the branch and discard local changes to committed files")
 
 	return cmd
 }
 
-func pullCmd() *cobra.Command {
-	var branch string
+func revertCmd() *cobra.Command {
+	var branch, message string
 
 	cmd := &cobra.Command{
-		Use:   "pull",
-		Short: "Pull changes from the server",
+		Use:   "revert <commit>",
+		Short: "Undo a published commit with a new commit",
+		Long: `Create a commit on the server that puts every file the given commit changed back
+to its previous version: files it added are deleted and files it deleted come
+back. History isn't rewritten: the commit stays, and the revert lands on top of
+the branch (the current branch by default).
+
+Files changed again on the branch since the commit are conflicts: nothing is
+committed and the files are listed so they can be fixed by hand.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := initializeClient(); err != nil {
-				return err
+			return runPick(args[0], branch, message, true)
+		},
+	}
+
+	cmd.Flags().StringVarP(&branch, "branch", "b", "", "Branch to revert the commit on (default: current branch)")
+	cmd.Flags().StringVarP(&message, "message", "m", "", "Commit message (default: Revert \"<subject>\")")
+
+	return cmd
+}
+
+func cherryPickCmd() *cobra.Command {
+	var branch, message string
+
+	cmd := &cobra.Command{
+		Use:   "cherry-pick <commit>",
+		Short: "Apply a published commit's changes to another branch",
+		Long: `Create a commit on the server that applies the file changes of the given commit
+to a branch (the current branch by default), for example to bring a fix from
+main into a release branch.
+
+A file whose version on the branch differs from its version before the commit is
+a conflict: nothing is committed and the files are listed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPick(args[0], branch, message, false)
+		},
+	}
+
+	cmd.Flags().StringVarP(&branch, "branch", "b", "", "Branch to apply the commit to (default: current branch)")
+	cmd.Flags().StringVarP(&message, "message", "m", "", "Commit message (default: the original message)")
+
+	return cmd
+}
+
+func runPick(commitRef, branch, message string, revert bool) error {
+	config, err := LoadProjectConfig()
+	if err != nil {
+		return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+	}
+	projectID, ok := config["project_id"].(string)
+	if !ok || projectID == "" {
+		return fmt.Errorf("invalid project configuration")
+	}
+	if err := initializeClient(); err != nil {
+		return err
+	}
+	localState, err := LoadLocalState()
+	if err != nil {
+		return fmt.Errorf("failed to load local state: %w", err)
+	}
+
+	if branch == "" {
+		if localState.Detached {
+			return fmt.Errorf("HEAD is detached; choose a branch with --branch")
+		}
+		branch = localState.CurrentBranch
+	}
+
+	// Reverts and cherry-picks run on the server, so the commit has to be published
+	commitID := resolveCommit(localState, commitRef)
+	if apiClient.commitStore.HasCommit(commitID) {
+		published := false
+		for _, remote := range localState.RemoteCommits {
+			if remote == commitID {
+				published = true
+				break
 			}
+		}
+		if !published {
+			return fmt.Errorf("commit %s hasn't been pushed yet; run 'vcs push' first", shortID(commitID))
+		}
+	}
 
-			if currentProject == "" {
-				return fmt.Errorf("not in a VCS project directory. Run 'vcs init' first")
+	action, done := "Cherry-picking", "Cherry-picked"
+	if revert {
+		action, done = "Reverting", "Reverted"
+	}
+	fmt.Printf("🔁 %s %s on %s...\n", action, shortID(commitID), branch)
+
+	result, err := apiClient.PickCommit(projectID, commitID, branch, message, revert)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Conflicts) > 0 {
+		fmt.Printf("❌ %d files were changed on %s since %s:\n", len(result.Conflicts), branch, shortID(commitID))
+		for _, conflict := range result.Conflicts {
+			actual := "none"
+			if conflict.Actual != "" {
+				actual = shortID(conflict.Actual)
 			}
+			expected := "none"
+			if conflict.Expected != "" {
+				expected = shortID(conflict.Expected)
+			}
+			fmt.Printf("   %s (expected %s, branch has %s)\n", conflict.Path, expected, actual)
+		}
+		fmt.Printf("💡 Nothing was committed. Update these files on %s by hand, then try again\n", branch)
+		return fmt.Errorf("%d files conflict", len(result.Conflicts))
+	}
 
-			// Load local state
+	fmt.Printf("✅ %s %s on %s as %s (%d files)\n", done, shortID(commitID), branch, shortID(result.Commit.ID), result.FilesCommitted)
+	if branch == localState.CurrentBranch && !localState.Detached {
+		fmt.Printf("💡 Run 'vcs pull' to bring the new commit into your working copy\n")
+	}
+	return nil
+}
+
+func shelveCmd() *cobra.Command {
+	var name string
+	var keep bool
+
+	cmd := &cobra.Command{
+		Use:   "shelve [paths]...",
+		Short: "Park uncommitted changes on the server",
+		Long: `Store the staged files and the local changes to committed files on the server as
+a shelf, then put those files back to how they are at HEAD so you can move on to
+another task. The shelf remembers the commit the work is based on and which files
+were staged; bring it back with 'vcs unshelve <id>'.
+
+Files that were never committed are only shelved when they're staged. Use --keep
+to leave the working copy alone, for example to share work in progress for review.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+			if err := initializeClient(); err != nil {
+				return err
+			}
 			localState, err := LoadLocalState()
 			if err != nil {
 				return fmt.Errorf("failed to load local state: %w", err)
 			}
 
-			// Use provided branch or current branch
-			targetBranch := branch
-			if targetBranch == "" {
-				targetBranch = localState.CurrentBranch
+			paths := []string{"."}
+			if len(args) > 0 {
+				paths = normalizePathspec(args)
 			}
-			if targetBranch == "" {
-				targetBranch = "main"
+
+			head := localState.HeadCommit()
+			headFiles, err := apiClient.SnapshotAt(projectID, head)
+			if err != nil {
+				return fmt.Errorf("failed to read files at %s: %w", shortID(head), err)
+			}
+			files, err := collectShelfFiles(paths, headFiles, localState)
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				fmt.Printf("✅ No local changes to shelve\n")
+				return nil
+			}
+
+			hashes := make([]string, 0, len(files))
+			for _, file := range files {
+				if !file.Deleted {
+					hashes = append(hashes, file.ContentHash)
+				}
+			}
+			uploaded, err := apiClient.UploadObjects(projectID, hashes)
+			if err != nil {
+				return err
+			}
+
+			branch := localState.CurrentBranch
+			if localState.Detached {
+				branch = ""
+			}
+			shelf, err := apiClient.CreateShelf(projectID, &ShelfInfo{
+				Name:       name,
+				Branch:     branch,
+				BaseCommit: head,
+				Files:      files,
+			})
+			if err != nil {
+				return err
 			}
 
+			fmt.Printf("✅ Shelved %d files as %s (%s)\n", len(files), shelf.ID, shelf.Name)
 			if verbose {
-				fmt.Printf("Pulling from branch: %s\n", targetBranch)
+				for _, file := range files {
+					fmt.Printf("   %s %s\n", shelfFileStatus(file, headFiles), file.Path)
+				}
+				fmt.Printf("📦 %d objects uploaded\n", uploaded)
+			}
+			if keep {
+				return nil
 			}
 
-			// Perform the pull
-			pullResp, err := apiClient.PullChanges(currentProject, targetBranch, localState.LocalCommits, localState.RemoteCommits)
+			// Put the shelved files back to HEAD; files HEAD doesn't have go away
+			plan := &checkoutPlan{Updates: make(map[string]storage.TreeEntry)}
+			var staged []string
+			for _, file := range files {
+				if entry, tracked := headFiles[file.Path]; tracked {
+					if file.Deleted || file.ContentHash != entry.Hash {
+						plan.Updates[file.Path] = entry
+					}
+				} else if !file.Deleted {
+					plan.Deletions = append(plan.Deletions, file.Path)
+				}
+				if file.Staged {
+					staged = append(staged, file.Path)
+				}
+			}
+			if err := applyCheckout(projectID, plan, localState, true); err != nil {
+				return fmt.Errorf("the shelf was saved, but the working copy couldn't be reset: %w", err)
+			}
+			if _, err := apiClient.UnstageFiles(staged); err != nil {
+				return err
+			}
+			for _, path := range staged {
+				localState.RemoveStagedFile(path)
+			}
+			if err := localState.SaveLocalState(); err != nil {
+				return fmt.Errorf("failed to save local state: %w", err)
+			}
+
+			fmt.Printf("💡 Run 'vcs unshelve %s' to bring the changes back\n", shelf.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&name, "message", "m", "", "Name of the shelf (default: the date)")
+	cmd.Flags().BoolVar(&keep, "keep", false, "Keep the changes in the working copy")
+
+	cmd.AddCommand(shelveListCmd())
+	cmd.AddCommand(shelveDeleteCmd())
+	return cmd
+}
+
+func shelveListCmd() *cobra.Command {
+	var all bool
+	var user string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List your shelves, or everyone's with --all",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
 			if err != nil {
-				return fmt.Errorf("failed to pull changes: %w", err)
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+			if err := initializeClient(); err != nil {
+				return err
 			}
 
-			var pullResult struct {
-				Success    bool `json:"success"`
-				Updated    bool `json:"updated"`
-				NewCommits []struct {
-					ID        string `json:"id"`
-					Message   string `json:"message"`
-					AuthorID  string `json:"author_id"`
-					CreatedAt string `json:"created_at"`
-				} `json:"new_commits"`
-				Files []struct {
-					Path        string `json:"path"`
-					ContentHash string `json:"content_hash"`
-					CommitID    string `json:"commit_id"`
-				} `json:"files"`
-				HeadCommit string   `json:"head_commit"`
-				Conflicts  []string `json:"conflicts"`
+			shelves, err := apiClient.ListShelves(projectID, all || user != "", user)
+			if err != nil {
+				return err
+			}
+			if len(shelves) == 0 {
+				fmt.Printf("📭 No shelves\n")
+				return nil
 			}
 
-			if err := json.Unmarshal(pullResp, &pullResult); err != nil {
-				return fmt.Errorf("failed to parse pull response: %w", err)
+			fmt.Printf("📦 %d shelves:\n", len(shelves))
+			for _, shelf := range shelves {
+				owner := shelf.User.Username
+				if owner == "" {
+					owner = shelf.UserID
+				}
+				base := shortID(shelf.BaseCommit)
+				if shelf.Branch != "" {
+					base = shelf.Branch + "@" + base
+				}
+				fmt.Printf("   %s  %s  %-12s %3d files  %-20s %s\n", shelf.ID, shelf.CreatedAt.Local().Format("2006-01-02 15:04"),
+					owner, len(shelf.Files), base, shelf.Name)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "List the shelves of every user (project admins)")
+	cmd.Flags().StringVar(&user, "user", "", "List the shelves of this user ID (project admins)")
+	return cmd
+}
+
+func shelveDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete a shelf without applying it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			if err := apiClient.DeleteShelf(projectID, args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Deleted shelf %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func unshelveCmd() *cobra.Command {
+	var force, deleteShelf bool
+
+	cmd := &cobra.Command{
+		Use:   "unshelve <id>",
+		Short: "Bring shelved changes into the working copy",
+		Long: `Write the files of a shelf into the working copy and stage the ones that were
+staged when it was shelved. Files with local changes are left alone unless --force
+is given. The shelf stays on the server unless --delete is given.
+
+Project admins can unshelve someone else's shelf, for example to review work in
+progress; find it with 'vcs shelve list --all'.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+			if err := initializeClient(); err != nil {
+				return err
+			}
+			localState, err := LoadLocalState()
+			if err != nil {
+				return fmt.Errorf("failed to load local state: %w", err)
 			}
 
-			if !pullResult.Success {
-				return fmt.Errorf("pull failed: %s", string(pullResp))
+			shelf, err := apiClient.GetShelf(projectID, args[0])
+			if err != nil {
+				return err
 			}
 
-			if !pullResult.Updated {
-				fmt.Println("✅ Already up-to-date")
-				return nil
+			head := localState.HeadCommit()
+			headFiles, err := apiClient.SnapshotAt(projectID, head)
+			if err != nil {
+				return fmt.Errorf("failed to read files at %s: %w", shortID(head), err)
 			}
-
-			fmt.Printf("✅ Pulled %d new commits from %s\n", len(pullResult.NewCommits), targetBranch)
-
-			// Show new commits
-			for _, commit := range pullResult.NewCommits {
-				fmt.Printf("   %s %s (%s)\n",
-					commit.ID[:8],
-					commit.Message,
-					commit.AuthorID)
+			if shelf.BaseCommit != head {
+				fmt.Printf("⚠️  %s was shelved on %s, HEAD is at %s; files changed since then get the shelved version\n",
+					shelf.ID, shortID(shelf.BaseCommit), shortID(head))
 			}
 
-			// Update local state with new commits
-			for _, commit := range pullResult.NewCommits {
-				// Add to local commits list
-				localState.AddLocalCommit(commit.ID)
-				// Also add to remote commits list
-				found := false
-				for _, existing := range localState.RemoteCommits {
-					if existing == commit.ID {
-						found = true
-						break
+			plan := &checkoutPlan{Updates: make(map[string]storage.TreeEntry)}
+			var staged []string
+			for _, file := range shelf.Files {
+				workingHash, err := apiClient.WorkingFileHash(file.Path)
+				if err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to read %s: %w", file.Path, err)
+				}
+				if file.Staged && !file.Deleted {
+					staged = append(staged, file.Path)
+				}
+
+				if workingHash == file.ContentHash && !file.Staged {
+					continue // Already has the shelved version
+				}
+				if workingHash != headFiles[file.Path].Hash || localState.IsFileStaged(file.Path) {
+					if workingHash != file.ContentHash {
+						plan.Blocked = append(plan.Blocked, file.Path)
 					}
 				}
-				if !found {
-					localState.RemoteCommits = append([]string{commit.ID}, localState.RemoteCommits...)
+				if file.Deleted {
+					if workingHash != "" {
+						plan.Deletions = append(plan.Deletions, file.Path)
+					}
+				} else {
+					plan.Updates[file.Path] = storage.TreeEntry{Mode: "100644", Name: file.Path, Hash: file.ContentHash, Size: file.Size}
 				}
 			}
+			sort.Strings(plan.Blocked)
+			sort.Strings(plan.Deletions)
 
-			// Update branch HEAD
-			if pullResult.HeadCommit != "" {
-				localState.SetBranchHead(targetBranch, pullResult.HeadCommit)
+			if err := applyCheckout(projectID, plan, localState, force); err != nil {
+				return err
+			}
+			if err := apiClient.StageFiles(staged); err != nil {
+				return err
+			}
+			for _, path := range staged {
+				localState.AddStagedFile(path)
 			}
-
-			// Save updated state
 			if err := localState.SaveLocalState(); err != nil {
-				fmt.Printf("Warning: failed to save local state: %v\n", err)
+				return fmt.Errorf("failed to save local state: %w", err)
 			}
 
-			// Show file changes
-			if len(pullResult.Files) > 0 {
-				fmt.Printf("\nUpdated files:\n")
-				for _, file := range pullResult.Files {
-					fmt.Printf("   %s\n", file.Path)
+			fmt.Printf("✅ Unshelved %s (%d files, %d staged)\n", shelf.ID, len(shelf.Files), len(staged))
+			if userID, err := currentUserID(); err == nil && userID != shelf.UserID {
+				owner := shelf.User.Name
+				if owner == "" {
+					owner = shelf.UserID
 				}
+				fmt.Printf("👀 This is %s's work in progress\n", owner)
 			}
 
+			if deleteShelf {
+				if err := apiClient.DeleteShelf(projectID, shelf.ID); err != nil {
+					return err
+				}
+				fmt.Printf("🗑️  Deleted shelf %s\n", shelf.ID)
+			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&branch, "branch", "b", "", "Target branch")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite files with local changes")
+	cmd.Flags().BoolVarP(&deleteShelf, "delete", "d", false, "Delete the shelf once it's applied")
 
 	return cmd
 }
 
+// collectShelfFiles finds the files matching paths that a shelf should hold: staged files
+// and committed files whose working copy differs from HEAD. Their current content is
+// copied into the local object store.
+func collectShelfFiles(paths []string, headFiles map[string]storage.TreeEntry, localState *LocalState) ([]ShelfFileInfo, error) {
+	sparse, err := loadSparseCheckout()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]bool)
+	for path := range headFiles {
+		if matchesPathspec(path, paths) {
+			candidates[path] = true
+		}
+	}
+	for _, path := range localState.GetStagedFiles() {
+		if matchesPathspec(path, paths) {
+			candidates[path] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(candidates))
+	for path := range candidates {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var files []ShelfFileInfo
+	for _, path := range sorted {
+		staged := localState.IsFileStaged(path)
+		entry, tracked := headFiles[path]
+
+		workingHash, err := apiClient.WorkingFileHash(path)
+		if os.IsNotExist(err) {
+			if tracked && !sparse.absent(path) {
+				files = append(files, ShelfFileInfo{Path: path, Staged: staged, Deleted: true})
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if tracked && workingHash == entry.Hash && !staged {
+			continue
+		}
+
+		info, err := apiClient.StoreWorkingFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store %s: %w", path, err)
+		}
+		files = append(files, ShelfFileInfo{Path: path, ContentHash: info.Hash, Size: info.Size, Staged: staged})
+	}
+	return files, nil
+}
+
+// shelfFileStatus is the one-letter status of a shelved file compared with HEAD
+func shelfFileStatus(file ShelfFileInfo, headFiles map[string]storage.TreeEntry) string {
+	switch _, tracked := headFiles[file.Path]; {
+	case file.Deleted:
+		return "D"
+	case !tracked:
+		return "A"
+	default:
+		return "M"
+	}
+}
+
+// ensureKnownCommit checks that a commit exists locally or on the server, remembering
+// server commits as published history
+func ensureKnownCommit(projectID string, localState *LocalState, commitID string) error {
+	if apiClient.commitStore.HasCommit(commitID) {
+		return nil
+	}
+	for _, known := range [][]string{localState.LocalCommits, localState.RemoteCommits} {
+		for _, id := range known {
+			if id == commitID {
+				return nil
+			}
+		}
+	}
+
+	if _, err := apiClient.GetCommit(projectID, commitID); err != nil {
+		return fmt.Errorf("unknown commit %s: %w", commitID, err)
+	}
+	localState.RemoteCommits = append([]string{commitID}, localState.RemoteCommits...)
+	return nil
+}
+
+// resolveCommit expands a branch name or an abbreviated commit ID that matches exactly one
+// known commit; anything else is returned unchanged for the server to resolve
+func resolveCommit(localState *LocalState, ref string) string {
+	if head := localState.GetBranchHead(ref); head != "" {
+		return head
+	}
+	if ref == "HEAD" {
+		return localState.HeadCommit()
+	}
+
+	matches := make(map[string]bool)
+	for _, known := range [][]string{localState.LocalCommits, localState.RemoteCommits} {
+		for _, commitID := range known {
+			if strings.HasPrefix(commitID, ref) {
+				matches[commitID] = true
+			}
+		}
+	}
+	if len(matches) == 1 {
+		for commitID := range matches {
+			return commitID
+		}
+	}
+	return ref
+}
+
+// normalizePathspec turns command line paths into the slash-separated, project-relative
+// form commit trees use
+func normalizePathspec(paths []string) []string {
+	normalized := make([]string, 0, len(paths))
+	for _, path := range paths {
+		normalized = append(normalized, filepath.ToSlash(filepath.Clean(path)))
+	}
+	return normalized
+}
+
+// matchesPathspec reports whether a file is one of the paths or inside one of them; "."
+// is the whole project
+func matchesPathspec(filePath string, paths []string) bool {
+	for _, path := range paths {
+		if path == "." || filePath == path || strings.HasPrefix(filePath, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// currentUserID asks the server who the logged in user is
+func currentUserID() (string, error) {
+	resp, err := apiClient.makeRequest("GET", "/api/v1/auth/me", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var userResp struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(resp, &userResp); err != nil {
+		return "", err
+	}
+	return userResp.User.ID, nil
+}
+
+func shortID(commitID string) string {
+	if len(commitID) > 8 {
+		return commitID[:8]
+	}
+	return commitID
+}
+
 func branchCmd() *cobra.Command {
 	var createBranch string
 	var deleteBranch string
@@ -1658,7 +5504,7 @@ func initializeProject(projectID string) error {
 		"version":    version,
 	}
 
-	configFile := filepath.Join(vcsDir, "config.json")
+	configFile := filepath.Join(vcsDir, "project.json")
 	configData, _ := json.MarshalIndent(config, "", "  ")
 	if err := os.WriteFile(configFile, configData, 0644); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
@@ -1712,65 +5558,6 @@ func getCommitHistory(projectID, branch string) ([]CommitInfo, error) {
 	return response.Commits, nil
 }
 
-func getCommitFiles(projectID, commitID string) ([]FileInfo, error) {
-	url := fmt.Sprintf("/api/v1/commits/%s/%s", projectID, commitID)
-	resp, err := apiClient.makeRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var response struct {
-		Success bool `json:"success"`
-		Commit  struct {
-			ID    string     `json:"id"`
-			Files []FileInfo `json:"files"`
-		} `json:"commit"`
-	}
-
-	if err := json.Unmarshal(resp, &response); err != nil {
-		return nil, err
-	}
-
-	if !response.Success {
-		return nil, fmt.Errorf("failed to get commit files")
-	}
-
-	return response.Commit.Files, nil
-}
-
-func downloadFile(file FileInfo) error {
-	// Create directory structure
-	dir := filepath.Dir(file.Path)
-	if dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
-		}
-	}
-
-	// Download file content
-	downloadReq := &fileops.DownloadRequest{
-		ContentHash: file.ContentHash,
-		ProjectID:   currentProject,
-		// UserID:      apiClient.authToken, // This needs to be fixed
-	}
-
-	result, err := apiClient.DownloadFile(downloadReq.ContentHash, downloadReq.ProjectID)
-	if err != nil {
-		return err
-	}
-	defer result.Close()
-
-	// Write to local file
-	localFile, err := os.Create(file.Path)
-	if err != nil {
-		return err
-	}
-	defer localFile.Close()
-
-	_, err = io.Copy(localFile, result)
-	return err
-}
-
 func getEventIcon(eventType string) string {
 	switch eventType {
 	case "file_locked":
@@ -1971,13 +5758,19 @@ func accountUpdateCmd() *cobra.Command {
 }
 
 func getAllFilesRespectingIgnore() ([]string, error) {
+	return getFilesRespectingIgnore(".")
+}
+
+// getFilesRespectingIgnore lists the files below a folder of the project that .vcsignore
+// doesn't exclude
+func getFilesRespectingIgnore(folder string) ([]string, error) {
 	ignorePatterns, err := loadVCSIgnorePatterns()
 	if err != nil {
 		return nil, err
 	}
 
 	var files []string
-	err = filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -1988,7 +5781,7 @@ func getAllFilesRespectingIgnore() ([]string, error) {
 		}
 
 		// Normalize path (remove leading ./)
-		cleanPath := strings.TrimPrefix(path, "./")
+		cleanPath := strings.TrimPrefix(filepath.ToSlash(path), "./")
 		if cleanPath == "." {
 			cleanPath = path
 		}
@@ -2592,30 +6385,20 @@ func runClean(restoreGit bool) error {
 	return nil
 }
 
-// verifyProjectExists checks if project exists on server
-func verifyProjectExists(projectID string) error {
-	resp, err := apiClient.makeRequest("GET", fmt.Sprintf("/api/v1/projects/%s/exists", projectID), nil)
+func runAddGitStyle(out io.Writer, args []string, addAll, verbose, allowSparse bool) error {
+	// Paths are given relative to the working directory, which may be below the project root
+	root, err := findProjectRoot()
 	if err != nil {
-		return err
-	}
-
-	var response struct {
-		Success bool `json:"success"`
-		Exists  bool `json:"exists"`
+		return fmt.Errorf("failed to load project config. Run 'vcs init' first: %w", err)
 	}
-
-	if err := json.Unmarshal(resp, &response); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+	paths, err := projectRelativePaths(root, args)
+	if err != nil {
+		return err
 	}
-
-	if !response.Success || !response.Exists {
-		return fmt.Errorf("project does not exist on server")
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("failed to enter project root: %w", err)
 	}
 
-	return nil
-}
-
-func runAddGitStyle(args []string, addAll, verbose bool) error {
 	// Load project configuration
 	config, err := LoadProjectConfig()
 	if err != nil {
@@ -2627,57 +6410,121 @@ func runAddGitStyle(args []string, addAll, verbose bool) error {
 		return fmt.Errorf("invalid project configuration. Run 'vcs init' to fix")
 	}
 
-	// Initialize API client with Git-style storage
+	// Staging only touches the local object store and index, so it works offline
 	if err := initializeClient(); err != nil {
-		return fmt.Errorf("failed to connect to VCS server: %w", err)
+		return fmt.Errorf("failed to initialize client: %w", err)
 	}
 
-	// Verify project exists on server
-	if err := verifyProjectExists(projectID); err != nil {
-		return fmt.Errorf("project not found on server: %w", err)
+	// Files outside a sparse checkout aren't part of this working copy's work: naming one
+	// is refused, while -a and folders skip them
+	sparse, err := loadSparseCheckout()
+	if err != nil {
+		return err
+	}
+	var filesToAdd, outside, folders []string
+	skipped := 0
+	seen := make(map[string]bool)
+	keep := func(filePath string, named bool) {
+		switch {
+		case seen[filePath]:
+		case allowSparse || sparse.includes(filePath):
+			seen[filePath] = true
+			filesToAdd = append(filesToAdd, filePath)
+		case named:
+			outside = append(outside, filePath)
+		default:
+			skipped++
+		}
 	}
 
 	// Determine files to process
-	var filesToAdd []string
-
 	if addAll {
-		fmt.Printf("🔍 Discovering files (respecting .vcsignore)...\n")
+		fmt.Fprintf(out, "🔍 Discovering files (respecting .vcsignore)...\n")
 		files, err := getAllFilesRespectingIgnore()
 		if err != nil {
 			return fmt.Errorf("failed to get files: %w", err)
 		}
-		filesToAdd = files
-		fmt.Printf("📁 Found %d files to consider\n", len(filesToAdd))
+		fmt.Fprintf(out, "📁 Found %d files to consider\n", len(files))
+		for _, filePath := range files {
+			keep(filePath, false)
+		}
+		folders = []string{"."}
 	} else {
-		if len(args) == 0 {
+		if len(paths) == 0 {
 			return fmt.Errorf("specify files to add or use -a to add all files")
 		}
-		filesToAdd = args
-		fmt.Printf("📁 Processing %d specified files\n", len(filesToAdd))
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				files, err := getFilesRespectingIgnore(path)
+				if err != nil {
+					return fmt.Errorf("failed to get files in %s: %w", path, err)
+				}
+				for _, filePath := range files {
+					keep(filePath, false)
+				}
+				folders = append(folders, path)
+				continue
+			}
+			keep(path, true)
+		}
+		fmt.Fprintf(out, "📁 Processing %d specified files\n", len(filesToAdd))
+	}
+
+	if len(outside) > 0 {
+		fmt.Fprintf(out, "❌ %d files are outside the sparse checkout:\n", len(outside))
+		for _, filePath := range outside {
+			fmt.Fprintf(out, "   %s\n", filePath)
+		}
+		fmt.Fprintf(out, "💡 Use --sparse to add them anyway, or widen the checkout with 'vcs sparse add'\n")
+		return fmt.Errorf("files outside the sparse checkout")
+	}
+	if skipped > 0 {
+		fmt.Fprintf(out, "🧩 Skipping %d files outside the sparse checkout\n", skipped)
+	}
+
+	// Adding a folder also stages the deletion of tracked files missing from it; files a
+	// sparse checkout or partial clone left out are missing on purpose and stay as they are
+	for _, filePath := range apiClient.TrackedFiles() {
+		if matchesPathspec(filePath, folders) && !seen[filePath] {
+			if _, err := os.Stat(filePath); os.IsNotExist(err) && !sparse.absent(filePath) {
+				seen[filePath] = true
+				filesToAdd = append(filesToAdd, filePath)
+			}
+		}
+	}
+	if len(sparse.Dehydrated) > 0 {
+		present := filesToAdd[:0]
+		for _, filePath := range filesToAdd {
+			if _, err := os.Stat(filePath); os.IsNotExist(err) && sparse.Dehydrated[filePath] {
+				continue
+			}
+			present = append(present, filePath)
+		}
+		filesToAdd = present
 	}
 
 	if len(filesToAdd) == 0 {
-		fmt.Printf("✅ No files to add\n")
+		fmt.Fprintf(out, "✅ No files to add\n")
 		return nil
 	}
 
 	// PHASE 1: Git-style batch processing
-	fmt.Printf("\n🚀 Starting Git-style batch processing...\n")
+	fmt.Fprintf(out, "\n🚀 Starting Git-style batch processing...\n")
 	// start := time.Now()
 
-	batchResult, err := apiClient.ProcessFilesBatchGitStyle(projectID, filesToAdd)
+	batchResult, err := apiClient.ProcessFilesBatchGitStyle(out, projectID, filesToAdd)
 	if err != nil {
 		return fmt.Errorf("batch processing failed: %w", err)
 	}
 
 	// Display results
-	fmt.Printf("\n📊 Processing Summary:\n")
-	fmt.Printf("   Total files: %d\n", batchResult.TotalFiles)
-	fmt.Printf("   Processed: %d\n", batchResult.ProcessedFiles)
-	fmt.Printf("   Skipped (unchanged): %d\n", batchResult.SkippedFiles)
-	fmt.Printf("   Failed: %d\n", batchResult.FailedFiles)
-	fmt.Printf("   Objects stored: %d\n", len(batchResult.ObjectsStored))
-	fmt.Printf("   Total time: %v\n", batchResult.Duration)
+	fmt.Fprintf(out, "\n📊 Processing Summary:\n")
+	fmt.Fprintf(out, "   Total files: %d\n", batchResult.TotalFiles)
+	fmt.Fprintf(out, "   Processed: %d\n", batchResult.ProcessedFiles)
+	fmt.Fprintf(out, "   Skipped (unchanged): %d\n", batchResult.SkippedFiles)
+	fmt.Fprintf(out, "   Failed: %d\n", batchResult.FailedFiles)
+	fmt.Fprintf(out, "   Objects stored: %d\n", len(batchResult.ObjectsStored))
+	fmt.Fprintf(out, "   Total time: %v\n", batchResult.Duration)
 
 	if len(batchResult.ObjectsStored) > 0 {
 		var totalSize int64
@@ -2687,23 +6534,25 @@ func runAddGitStyle(args []string, addAll, verbose bool) error {
 			totalCompressed += obj.CompressedSize
 		}
 		compressionRatio := float64(totalCompressed) / float64(totalSize) * 100
-		fmt.Printf("   Compression: %.1f%% (saved %s)\n",
+		fmt.Fprintf(out, "   Compression: %.1f%% (saved %s)\n",
 			compressionRatio, FormatFileSize(totalSize-totalCompressed))
 	}
 
 	// Show detailed results if verbose
 	if verbose && len(batchResult.Results) > 0 {
-		fmt.Printf("\n📋 Detailed Results:\n")
+		fmt.Fprintf(out, "\n📋 Detailed Results:\n")
 		for _, result := range batchResult.Results {
 			if result.Success {
 				if result.Skipped {
-					fmt.Printf("   ⏭️  %s (unchanged)\n", result.FilePath)
+					fmt.Fprintf(out, "   ⏭️  %s (unchanged)\n", result.FilePath)
+				} else if result.Deleted {
+					fmt.Fprintf(out, "   🗑️  %s (deleted)\n", result.FilePath)
 				} else {
-					fmt.Printf("   ✅ %s (%s)\n",
+					fmt.Fprintf(out, "   ✅ %s (%s)\n",
 						result.FilePath, FormatFileSize(result.Size))
 				}
 			} else {
-				fmt.Printf("   ❌ %s - %v\n", result.FilePath, result.Error)
+				fmt.Fprintf(out, "   ❌ %s - %v\n", result.FilePath, result.Error)
 			}
 		}
 	}
@@ -2725,25 +6574,25 @@ func runAddGitStyle(args []string, addAll, verbose bool) error {
 	}
 
 	if err := SaveLocalState(localState); err != nil {
-		fmt.Printf("⚠️  Warning: Failed to save local state: %v\n", err)
+		fmt.Fprintf(out, "⚠️  Warning: Failed to save local state: %v\n", err)
 	}
 
 	// Show performance comparison
 	estimatedOldTime := time.Duration(len(filesToAdd)) * 500 * time.Millisecond // Estimate old per-file time
 	speedup := float64(estimatedOldTime) / float64(batchResult.Duration)
 
-	fmt.Printf("\n🎯 Performance Impact:\n")
-	fmt.Printf("   Estimated old time: %v\n", estimatedOldTime)
-	fmt.Printf("   Actual time: %v\n", batchResult.Duration)
-	fmt.Printf("   Speedup: %.1fx faster\n", speedup)
+	fmt.Fprintf(out, "\n🎯 Performance Impact:\n")
+	fmt.Fprintf(out, "   Estimated old time: %v\n", estimatedOldTime)
+	fmt.Fprintf(out, "   Actual time: %v\n", batchResult.Duration)
+	fmt.Fprintf(out, "   Speedup: %.1fx faster\n", speedup)
 
 	if batchResult.FailedFiles > 0 {
-		fmt.Printf("\n⚠️  %d files failed to process. Use --verbose to see details.\n", batchResult.FailedFiles)
+		fmt.Fprintf(out, "\n⚠️  %d files failed to process. Use --verbose to see details.\n", batchResult.FailedFiles)
 		return fmt.Errorf("some files failed to process")
 	}
 
-	fmt.Printf("\n✅ All files processed successfully!\n")
-	fmt.Printf("💡 Next step: vcs commit -m \"Your commit message\"\n")
+	fmt.Fprintf(out, "\n✅ All files processed successfully!\n")
+	fmt.Fprintf(out, "💡 Next step: vcs commit -m \"Your commit message\"\n")
 
 	return nil
 }
@@ -2756,7 +6605,7 @@ func runStatusGitStyle(verbose bool) error {
 	}
 
 	projectID, ok := config["project_id"].(string)
-	if !ok {
+	if !ok || projectID == "" {
 		return fmt.Errorf("invalid project configuration")
 	}
 
@@ -2806,12 +6655,20 @@ func runStatusGitStyle(verbose bool) error {
 	if len(stagedFiles) > 0 {
 		fmt.Printf("\n📝 Staged Files (%d):\n", len(stagedFiles))
 		for _, filePath := range stagedFiles {
+			if localState.StagedFiles[filePath].Deleted {
+				fmt.Printf("   🗑️  %s (deleted)\n", filePath)
+				continue
+			}
 			fmt.Printf("   ✅ %s\n", filePath)
 		}
 	} else {
 		fmt.Printf("\n📝 No files staged for commit\n")
 	}
 
+	if err := printWorkingChanges(projectID, localState); err != nil {
+		fmt.Printf("\n⚠️  Couldn't check for local changes: %v\n", err)
+	}
+
 	// Quick change detection on common files
 	if verbose {
 		fmt.Printf("\n🔍 Quick Change Detection:\n")
@@ -2837,6 +6694,66 @@ func runStatusGitStyle(verbose bool) error {
 	return nil
 }
 
+// printWorkingChanges lists committed files that were changed or deleted locally without
+// being staged. Files a sparse checkout or partial clone leaves out are absent on purpose
+// and only counted.
+func printWorkingChanges(projectID string, localState *LocalState) error {
+	headFiles, err := apiClient.SnapshotAt(projectID, localState.HeadCommit())
+	if err != nil {
+		return err
+	}
+	sparse, err := loadSparseCheckout()
+	if err != nil {
+		return err
+	}
+
+	var present []string
+	absent := 0
+	for path := range headFiles {
+		if sparse.absent(path) {
+			absent++
+			continue
+		}
+		if !localState.IsFileStaged(path) {
+			present = append(present, path)
+		}
+	}
+	hashes, err := apiClient.WorkingFileHashes(present)
+	if err != nil {
+		return err
+	}
+
+	var changes []string
+	for _, path := range present {
+		hash, exists := hashes[path]
+		switch {
+		case !exists:
+			changes = append(changes, "D "+path)
+		case hash != headFiles[path].Hash:
+			changes = append(changes, "M "+path)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i][2:] < changes[j][2:] })
+
+	if len(changes) > 0 {
+		fmt.Printf("\n✏️  Changes not staged (%d):\n", len(changes))
+		for _, change := range changes {
+			fmt.Printf("   %s\n", change)
+		}
+	} else {
+		fmt.Printf("\n✏️  No unstaged changes to committed files\n")
+	}
+
+	if sparse.active() {
+		fmt.Printf("\n🧩 Sparse checkout: %d of %d files in the working copy", len(headFiles)-absent, len(headFiles))
+		if len(sparse.Dehydrated) > 0 {
+			fmt.Printf(", %d more not downloaded yet", len(sparse.Dehydrated))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
 func cleanupCmd() *cobra.Command {
 	var aggressive bool
 
@@ -2905,15 +6822,15 @@ func runCleanupGitStyle(bool) error {
 	return nil
 }
 
-func runCommitGitStyle(message string, addAll bool) error {
+func runCommitGitStyle(out io.Writer, message string, addAll bool) error {
 	if message == "" {
 		return fmt.Errorf("commit message is required. Use -m flag")
 	}
 
 	// Auto-add if requested
 	if addAll {
-		fmt.Printf("🔄 Auto-adding all changes...\n")
-		if err := runAddGitStyle([]string{}, true, false); err != nil {
+		fmt.Fprintf(out, "🔄 Auto-adding all changes...\n")
+		if err := runAddGitStyle(out, []string{}, true, false, false); err != nil {
 			return fmt.Errorf("failed to add files: %w", err)
 		}
 	}
@@ -2924,7 +6841,10 @@ func runCommitGitStyle(message string, addAll bool) error {
 		return fmt.Errorf("not a VCS repository")
 	}
 
-	projectID := config["project_id"].(string)
+	projectID, ok := config["project_id"].(string)
+	if !ok || projectID == "" {
+		return fmt.Errorf("invalid project configuration")
+	}
 
 	// Initialize client
 	if err := initializeClient(); err != nil {
@@ -2937,79 +6857,95 @@ func runCommitGitStyle(message string, addAll bool) error {
 		return fmt.Errorf("failed to load local state: %w", err)
 	}
 
+	if localState.Detached {
+		return fmt.Errorf("HEAD is detached at %s. Run 'vcs switch <branch>' before committing", shortID(localState.HeadCommit()))
+	}
+
 	stagedFiles := localState.GetStagedFiles()
 	if len(stagedFiles) == 0 {
-		fmt.Printf("📝 No files staged for commit\n")
-		fmt.Printf("💡 Use 'vcs add' to stage files first\n")
+		fmt.Fprintf(out, "📝 No files staged for commit\n")
+		fmt.Fprintf(out, "💡 Use 'vcs add' to stage files first\n")
 		return nil
 	}
 
-	fmt.Printf("🚀 Creating commit with %d staged files...\n", len(stagedFiles))
-	fmt.Printf("📝 Message: %s\n", message)
-
-	// STEP 1: Upload staged files to server first
-	fmt.Printf("📤 Uploading %d staged files to server...\n", len(stagedFiles))
-
-	// Convert staged files to file paths
-	filePaths := append([]string{}, stagedFiles...)
+	branch := localState.CurrentBranch
+	if branch == "" {
+		branch = "main"
+	}
 
-	// Force upload all staged files (bypass stat optimization for commit)
-	fmt.Printf("🔄 Processing all staged files for commit...\n")
+	fmt.Fprintf(out, "🚀 Creating commit with %d staged files on %s...\n", len(stagedFiles), branch)
+	fmt.Fprintf(out, "📝 Message: %s\n", message)
 
-	// Upload files using batch processing with forced processing for commit
-	uploadResult, err := apiClient.ProcessFilesBatchGitStyleForCommit(projectID, filePaths)
+	// Commits are recorded locally and published by 'vcs push', so this works offline
+	result, err := apiClient.CommitStaged(projectID, branch, localState.GetBranchHead(branch), message, localAuthor(), stagedFiles)
 	if err != nil {
-		return fmt.Errorf("failed to upload staged files: %w", err)
+		return fmt.Errorf("failed to create commit: %w", err)
 	}
 
-	fmt.Printf("✅ Uploaded %d files (%d processed, %d skipped)\n",
-		uploadResult.ProcessedFiles, uploadResult.ProcessedFiles, uploadResult.SkippedFiles)
-
-	// STEP 2: Create commit with uploaded files
-	commitData := map[string]interface{}{
-		"message":    message,
-		"file_paths": filePaths, // Add the file paths to the commit request
-		"branch":     "main",
+	localState.SetBranchHead(branch, result.CommitHash)
+	localState.AddLocalCommit(result.CommitHash)
+	localState.ClearStagedFiles()
+	if err := localState.SaveLocalState(); err != nil {
+		return fmt.Errorf("commit %s created but failed to save local state: %w", result.CommitHash[:8], err)
 	}
 
-	// Use project ID in URL path, not body
-	url := fmt.Sprintf("/api/v1/commits/%s", projectID)
-	resp, err := apiClient.makeRequest("POST", url, commitData)
+	fmt.Fprintf(out, "✅ Commit created: %s\n", result.CommitHash[:8])
+	fmt.Fprintf(out, "📁 %d files (%s)\n", result.FilesCount, FormatFileSize(result.TreeSize))
+
+	printCommitReferenceWarnings(out, projectID, branch, result.CommitHash)
+
+	ahead, _, _ := localState.SyncStatus()
+	fmt.Fprintf(out, "💡 %d unpublished commits on %s. Run 'vcs push' to publish them\n", ahead, branch)
+
+	return nil
+}
+
+// printCommitReferenceWarnings asks the server, when it can be reached, which hard asset
+// references a new local commit breaks on its branch. The push runs the same check.
+func printCommitReferenceWarnings(out io.Writer, projectID, branch, commitID string) {
+	changes, deletions, err := apiClient.CommitChanges(commitID)
 	if err != nil {
-		return fmt.Errorf("failed to create commit: %w", err)
+		return
 	}
 
-	var commitResp struct {
-		Success bool `json:"success"`
-		Commit  struct {
-			ID        string `json:"id"`
-			Message   string `json:"message"`
-			CreatedAt string `json:"created_at"`
-		} `json:"commit"`
-		Error string `json:"error"`
+	resp, err := apiClient.CheckReferences(projectID, branch, changes, deletions)
+	if err != nil {
+		fmt.Fprintf(out, "💡 Asset references weren't checked (server unreachable); 'vcs push' checks them\n")
+		return
 	}
 
-	if err := json.Unmarshal(resp, &commitResp); err != nil {
-		return fmt.Errorf("failed to parse commit response: %w", err)
+	var check struct {
+		ReferenceWarnings []struct {
+			SourceAsset string `json:"source_asset"`
+			TargetAsset string `json:"target_asset"`
+			Reason      string `json:"reason"`
+		} `json:"reference_warnings"`
+		Unverified []string `json:"unverified"`
 	}
-
-	if !commitResp.Success {
-		return fmt.Errorf("commit failed: %s", commitResp.Error)
+	if err := json.Unmarshal(resp, &check); err != nil {
+		return
 	}
 
-	fmt.Printf("✅ Commit created: %s\n", commitResp.Commit.ID)
-	fmt.Printf("📅 Created at: %s\n", commitResp.Commit.CreatedAt)
-
-	// Clear staged files from local state
-	if localState != nil {
-		localState.ClearStagedFiles()
-		SaveLocalState(localState)
+	if len(check.ReferenceWarnings) > 0 {
+		fmt.Fprintf(out, "⚠️  %d broken asset references:\n", len(check.ReferenceWarnings))
+		for _, warning := range check.ReferenceWarnings {
+			fmt.Fprintf(out, "   %s → %s (%s)\n", warning.SourceAsset, warning.TargetAsset, warning.Reason)
+		}
 	}
+	if len(check.Unverified) > 0 {
+		fmt.Fprintf(out, "💡 %d changed assets aren't on the server yet; their references are checked on push\n", len(check.Unverified))
+	}
+}
 
-	// Mark files as unstaged in index
-	// This would be implemented in Phase 2 with proper index management
-
-	return nil
+// localAuthor names the author of local commits; the server records the pushing user
+func localAuthor() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
 }
 
 func FormatFileSize(bytes int64) string {
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Telerallc/gamedev-vcs/internal/storage"
+)
+
+const (
+	// downloadDir holds partly downloaded objects and the download journal
+	downloadDir         = ".vcs/downloads"
+	downloadJournalFile = ".vcs/downloads/journal.json"
+
+	defaultDownloadWorkers = 4
+	downloadAttempts       = 4
+)
+
+// errObjectCorrupt is returned when a downloaded object doesn't match its content hash
+var errObjectCorrupt = errors.New("downloaded content doesn't match its hash")
+
+// downloadJob is one object to fetch into the local object store
+type downloadJob struct {
+	Hash string
+	Size int64  // Expected size; 0 when unknown
+	Path string // A file with this content, for messages
+}
+
+// downloadJournal records an unfinished download, so a clone or pull interrupted by a
+// network drop can be run again and continue where it stopped
+type downloadJournal struct {
+	Operation string           `json:"operation"` // clone, pull or checkout
+	ProjectID string           `json:"project_id"`
+	Branch    string           `json:"branch,omitempty"`
+	Commit    string           `json:"commit,omitempty"`
+	StartedAt time.Time        `json:"started_at"`
+	Pending   map[string]int64 `json:"pending"` // Object hash → size
+}
+
+// loadDownloadJournal reads the journal of an interrupted download; it returns nil when
+// the last download finished
+func loadDownloadJournal() (*downloadJournal, error) {
+	data, err := os.ReadFile(downloadJournalFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download journal: %w", err)
+	}
+
+	var journal downloadJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", downloadJournalFile, err)
+	}
+	return &journal, nil
+}
+
+func (j *downloadJournal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		return err
+	}
+	tempPath := downloadJournalFile + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write download journal: %w", err)
+	}
+	return os.Rename(tempPath, downloadJournalFile)
+}
+
+// resumes reports whether the journal belongs to the same operation on the same branch
+func (j *downloadJournal) resumes(operation, projectID, branch string) bool {
+	return j != nil && j.Operation == operation && j.ProjectID == projectID && (branch == "" || j.Branch == branch)
+}
+
+// downloadContext describes the operation downloads belong to, as recorded in the journal
+type downloadContext struct {
+	operation string
+	branch    string
+	commit    string
+}
+
+// SetDownloadWorkers sets how many objects are downloaded at the same time
+func (c *APIClient) SetDownloadWorkers(workers int) {
+	c.downloadWorkers = workers
+}
+
+// SetDownloadContext names the operation the next downloads belong to, so the journal
+// of an interrupted download says what to run again
+func (c *APIClient) SetDownloadContext(operation, branch, commit string) {
+	c.downloadContext = downloadContext{operation: operation, branch: branch, commit: commit}
+}
+
+// downloadEngine fetches objects with bounded parallelism. Each object is streamed into a
+// part file that an interrupted download resumes with a Range request, and only moves
+// into the object store once its content hash checks out.
+type downloadEngine struct {
+	client    *APIClient
+	projectID string
+	http      *http.Client
+	progress  *downloadProgress
+}
+
+func (c *APIClient) newDownloadEngine(projectID string) *downloadEngine {
+	return &downloadEngine{
+		client:    c,
+		projectID: projectID,
+		// Large objects take longer than the API timeout; only waiting for a response is bounded
+		http: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: 30 * time.Second,
+				IdleConnTimeout:       90 * time.Second,
+				MaxIdleConnsPerHost:   16,
+			},
+		},
+	}
+}
+
+// run downloads the objects, journaling what's left until every one is stored. It
+// returns how many objects were downloaded.
+func (e *downloadEngine) run(jobs []downloadJob) (int, error) {
+	if len(jobs) == 0 {
+		return 0, nil
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Size > jobs[j].Size }) // Big objects first keep the workers busy
+
+	current := e.client.downloadContext
+	if current.operation == "" {
+		current.operation = "checkout"
+	}
+	journal := &downloadJournal{
+		Operation: current.operation,
+		ProjectID: e.projectID,
+		Branch:    current.branch,
+		Commit:    current.commit,
+		StartedAt: time.Now(),
+		Pending:   make(map[string]int64, len(jobs)),
+	}
+	if previous, err := loadDownloadJournal(); err == nil && previous.resumes(journal.Operation, journal.ProjectID, journal.Branch) {
+		journal.StartedAt = previous.StartedAt
+	}
+	var totalSize int64
+	for _, job := range jobs {
+		journal.Pending[job.Hash] = job.Size
+		totalSize += job.Size
+	}
+	if err := journal.save(); err != nil {
+		return 0, err
+	}
+
+	workers := e.client.downloadWorkers
+	if workers <= 0 {
+		workers = defaultDownloadWorkers
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	e.progress = newDownloadProgress(len(jobs), totalSize)
+	e.progress.start()
+
+	var (
+		mu        sync.Mutex
+		firstErr  error
+		completed int
+		lastSave  = time.Now()
+		wg        sync.WaitGroup
+	)
+	queue := make(chan downloadJob)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				err := e.fetch(job)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to download %s: %w", job.Path, err)
+					}
+				} else {
+					completed++
+					e.progress.completed.Add(1)
+					delete(journal.Pending, job.Hash)
+					// Rewriting the journal for every object would cost more than it saves
+					if time.Since(lastSave) > time.Second {
+						journal.save()
+						lastSave = time.Now()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break // Objects already in flight finish; the rest wait for the next run
+		}
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+	e.progress.finish(firstErr == nil)
+
+	if firstErr != nil {
+		journal.save()
+		fmt.Printf("💾 %d of %d objects downloaded; run the command again to resume\n", completed, len(jobs))
+		return completed, firstErr
+	}
+	if err := os.Remove(downloadJournalFile); err != nil && !os.IsNotExist(err) {
+		return completed, err
+	}
+	return completed, nil
+}
+
+// fetch downloads one object into the object store, resuming from its part file and
+// retrying with backoff when the connection drops
+func (e *downloadEngine) fetch(job downloadJob) error {
+	if len(job.Hash) != sha256.Size*2 || strings.Trim(job.Hash, "0123456789abcdef") != "" {
+		return fmt.Errorf("invalid object hash %q", job.Hash)
+	}
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		return err
+	}
+	partPath := filepath.Join(downloadDir, job.Hash+".part")
+
+	progress := &objectProgress{total: e.progress}
+	var err error
+	for attempt := 0; attempt < downloadAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt*attempt) * 500 * time.Millisecond)
+		}
+		if err = e.fetchPart(job, partPath, progress); err != nil {
+			var status *downloadStatusError
+			if errors.As(err, &status) && !status.retryable() {
+				return err
+			}
+			continue
+		}
+		if err = e.store(job, partPath); err == nil || !errors.Is(err, errObjectCorrupt) {
+			return err
+		}
+	}
+	return err
+}
+
+// fetchPart brings the part file of an object up to its full size
+func (e *downloadEngine) fetchPart(job downloadJob, partPath string, progress *objectProgress) error {
+	part, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer part.Close()
+
+	offset, err := part.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if job.Size > 0 && offset >= job.Size {
+		return nil // Finished before the last run was interrupted
+	}
+
+	url := fmt.Sprintf("%s/api/v1/files/%s?project_id=%s", e.client.baseURL, job.Hash, e.projectID)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if e.client.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+e.client.authToken)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		progress.from(offset)
+	case http.StatusOK:
+		// The server sent the whole object, so the part file starts over
+		if err := part.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := part.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		progress.from(0)
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The part file is longer than the object; it can't be trusted
+		part.Truncate(0)
+		return &downloadStatusError{code: resp.StatusCode}
+	default:
+		return &downloadStatusError{code: resp.StatusCode}
+	}
+
+	_, err = io.Copy(part, io.TeeReader(resp.Body, progress))
+	return err
+}
+
+// store moves a complete part file into the object store, which verifies it against its
+// content hash while compressing it
+func (e *downloadEngine) store(job downloadJob, partPath string) error {
+	if _, err := e.client.objectStore.StoreFile(partPath, job.Hash); err != nil {
+		if errors.Is(err, storage.ErrHashMismatch) {
+			os.Remove(partPath)
+			return fmt.Errorf("%w: %s", errObjectCorrupt, job.Hash)
+		}
+		return err
+	}
+	return os.Remove(partPath)
+}
+
+// downloadStatusError is an unexpected HTTP status from the object download
+type downloadStatusError struct {
+	code int
+}
+
+func (e *downloadStatusError) Error() string {
+	return fmt.Sprintf("download failed with status: %d", e.code)
+}
+
+// retryable reports whether the request might succeed if made again
+func (e *downloadStatusError) retryable() bool {
+	return e.code == http.StatusRequestedRangeNotSatisfiable || e.code == http.StatusTooManyRequests || e.code >= 500
+}
+
+// downloadProgress tracks the bytes of a download and, on a terminal, keeps one line
+// with the overall progress, throughput and ETA up to date
+type downloadProgress struct {
+	objects    int
+	totalBytes int64
+	completed  atomic.Int64
+	received   atomic.Int64 // Bytes received in this run
+	resumed    atomic.Int64 // Bytes part files held before being resumed, less bytes thrown away
+	started    time.Time
+	live       bool
+	done       chan struct{}
+	stopped    sync.WaitGroup
+}
+
+func newDownloadProgress(objects int, totalBytes int64) *downloadProgress {
+	live := false
+	if info, err := os.Stdout.Stat(); err == nil {
+		live = info.Mode()&os.ModeCharDevice != 0
+	}
+	return &downloadProgress{objects: objects, totalBytes: totalBytes, live: live, done: make(chan struct{})}
+}
+
+// Write counts received bytes, so the progress can sit behind an io.TeeReader
+func (p *downloadProgress) Write(data []byte) (int, error) {
+	if p != nil {
+		p.received.Add(int64(len(data)))
+	}
+	return len(data), nil
+}
+
+func (p *downloadProgress) resume(bytes int64) {
+	if p != nil {
+		p.resumed.Add(bytes)
+	}
+}
+
+// objectProgress counts one object's bytes toward the download's progress. A retry that
+// resumes the part file only adds what the object hadn't counted yet, and one that starts
+// over takes back what it had.
+type objectProgress struct {
+	total   *downloadProgress
+	counted int64 // Bytes of the part file the total includes
+}
+
+// from notes that the part file continues at offset
+func (o *objectProgress) from(offset int64) {
+	o.total.resume(offset - o.counted)
+	o.counted = offset
+}
+
+func (o *objectProgress) Write(data []byte) (int, error) {
+	o.counted += int64(len(data))
+	return o.total.Write(data)
+}
+
+func (p *downloadProgress) start() {
+	p.started = time.Now()
+	if !p.live {
+		return
+	}
+	p.stopped.Add(1)
+	go func() {
+		defer p.stopped.Done()
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				fmt.Printf("\r%s\033[K", p.line())
+			}
+		}
+	}()
+}
+
+// finish stops the live line and prints the totals of a download that got data
+func (p *downloadProgress) finish(complete bool) {
+	close(p.done)
+	p.stopped.Wait()
+	if p.live {
+		fmt.Printf("\r\033[K")
+	}
+
+	received := p.received.Load()
+	if received == 0 {
+		return
+	}
+	elapsed := time.Since(p.started)
+	verb := "Downloaded"
+	if !complete {
+		verb = "Interrupted after"
+	}
+	fmt.Printf("📦 %s %s in %s", verb, FormatFileSize(received), formatDownloadDuration(elapsed))
+	if rate := p.rate(received, elapsed); rate > 0 {
+		fmt.Printf(" (%s/s)", FormatFileSize(rate))
+	}
+	fmt.Println()
+}
+
+func (p *downloadProgress) line() string {
+	received := p.received.Load()
+	have := received + p.resumed.Load()
+	elapsed := time.Since(p.started)
+	rate := p.rate(received, elapsed)
+
+	var parts []string
+	if p.totalBytes > 0 {
+		percent := have * 100 / p.totalBytes
+		if percent > 100 {
+			percent = 100
+		}
+		parts = append(parts, fmt.Sprintf("📦 %s / %s (%d%%)", FormatFileSize(have), FormatFileSize(p.totalBytes), percent))
+	} else {
+		parts = append(parts, fmt.Sprintf("📦 %s", FormatFileSize(have)))
+	}
+	parts = append(parts, fmt.Sprintf("%d/%d objects", p.completed.Load(), p.objects), fmt.Sprintf("%s/s", FormatFileSize(rate)))
+	if rate > 0 && p.totalBytes > have {
+		eta := time.Duration(float64(p.totalBytes-have) / float64(rate) * float64(time.Second))
+		parts = append(parts, "ETA "+formatDownloadDuration(eta))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (p *downloadProgress) rate(bytes int64, elapsed time.Duration) int64 {
+	if elapsed < time.Second/10 {
+		return 0
+	}
+	return int64(float64(bytes) / elapsed.Seconds())
+}
+
+func formatDownloadDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -28,6 +28,7 @@ type LocalState struct {
 	StagedFiles   map[string]FileState `json:"staged_files"`
 	LastSync      string               `json:"last_sync"`
 	LocalRefs     map[string]string    `json:"local_refs"`
+	Detached      bool                 `json:"detached,omitempty"`
 	Version       int                  `json:"version"`
 	UpdatedAt     time.Time            `json:"updated_at"`
 }
@@ -136,8 +137,10 @@ func (ls *LocalState) AddStagedFile(filePath string) {
 	fileState.Added = true
 	fileState.LastUpdated = time.Now()
 
-	// Try to get file stats if file exists
-	if info, err := os.Stat(filePath); err == nil {
+	// Try to get file stats if file exists; staging a missing file stages its deletion
+	info, err := os.Stat(filePath)
+	fileState.Deleted = os.IsNotExist(err)
+	if err == nil {
 		fileState.Size = info.Size()
 		fileState.ModTime = info.ModTime()
 	}
@@ -155,6 +158,17 @@ func (ls *LocalState) AddLocalCommit(commitID string) {
 	}
 }
 
+// ReplaceLocalCommit swaps a local commit for the copy it was replayed as
+func (ls *LocalState) ReplaceLocalCommit(oldID, newID string) {
+	for i, commit := range ls.LocalCommits {
+		if commit == oldID {
+			ls.LocalCommits[i] = newID
+			return
+		}
+	}
+	ls.AddLocalCommit(newID)
+}
+
 // UpdateRemoteCommits updates the remote commit list
 func (ls *LocalState) UpdateRemoteCommits(remoteCommits []string) {
 	ls.RemoteCommits = remoteCommits
@@ -166,8 +180,8 @@ func (ls *LocalState) SetBranchHead(branch, commitID string) {
 	}
 	ls.LocalRefs["refs/heads/"+branch] = commitID
 
-	// Update current branch if it matches
-	if ls.CurrentBranch == branch {
+	// Update current branch if it matches and HEAD isn't detached
+	if ls.CurrentBranch == branch && !ls.Detached {
 		ls.LocalRefs["HEAD"] = commitID
 	}
 }
@@ -180,6 +194,30 @@ func (ls *LocalState) GetBranchHead(branch string) string {
 	return ls.LocalRefs["refs/heads/"+branch]
 }
 
+// HeadCommit returns the commit the working directory is checked out at
+func (ls *LocalState) HeadCommit() string {
+	if ls.Detached {
+		return ls.LocalRefs["HEAD"]
+	}
+	return ls.GetBranchHead(ls.CurrentBranch)
+}
+
+// SwitchBranch makes branch current and points it at commitID
+func (ls *LocalState) SwitchBranch(branch, commitID string) {
+	ls.CurrentBranch = branch
+	ls.Detached = false
+	ls.SetBranchHead(branch, commitID)
+}
+
+// DetachHead checks out a commit without moving any branch
+func (ls *LocalState) DetachHead(commitID string) {
+	if ls.LocalRefs == nil {
+		ls.LocalRefs = make(map[string]string)
+	}
+	ls.Detached = true
+	ls.LocalRefs["HEAD"] = commitID
+}
+
 // RemoveStagedFile removes a file from the staging area
 func (ls *LocalState) RemoveStagedFile(filePath string) {
 	delete(ls.StagedFiles, filePath)
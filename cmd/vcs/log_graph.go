@@ -0,0 +1,120 @@
+package main
+
+import "strings"
+
+// logGraph draws the ASCII history graph of `vcs log --graph`. Each column is a line of
+// history waiting for its next commit; merges open columns and shared ancestors close them.
+type logGraph struct {
+	columns []string
+}
+
+// next places a commit in the graph. It returns the rows that bring the columns waiting
+// for the commit together, the prefix of the commit's own line, and the rows that lead
+// on to its parents.
+func (g *logGraph) next(commitID string, parents []string) ([]string, string, []string) {
+	idx := -1
+	for i, column := range g.columns {
+		if column == commitID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		g.columns = append(g.columns, commitID)
+		idx = len(g.columns) - 1
+	}
+
+	// Other columns waiting for this commit join it first
+	var joins []string
+	for j := len(g.columns) - 1; j > idx; j-- {
+		if g.columns[j] == commitID {
+			joins = append(joins, g.joinRow(idx, j))
+			g.columns = append(g.columns[:j], g.columns[j+1:]...)
+		}
+	}
+	prefix := g.row(idx, '*')
+
+	var connectors []string
+	if len(parents) == 0 {
+		if idx < len(g.columns)-1 {
+			connectors = append(connectors, g.joinRow(idx, idx))
+		}
+		g.columns = append(g.columns[:idx], g.columns[idx+1:]...)
+		return joins, prefix, connectors
+	}
+
+	g.columns[idx] = parents[0]
+	for _, parent := range parents[1:] {
+		if g.waitingFor(parent) {
+			continue
+		}
+		connectors = append(connectors, g.forkRow(idx))
+		g.columns = append(g.columns[:idx+1], append([]string{parent}, g.columns[idx+1:]...)...)
+	}
+	return joins, prefix, connectors
+}
+
+// padding is the prefix for lines that belong to the last commit, such as its message
+func (g *logGraph) padding() string {
+	if len(g.columns) == 0 {
+		return ""
+	}
+	return g.row(-1, '|')
+}
+
+func (g *logGraph) waitingFor(commitID string) bool {
+	for _, column := range g.columns {
+		if column == commitID {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *logGraph) row(idx int, mark byte) string {
+	cells := make([]string, len(g.columns))
+	for i := range g.columns {
+		cells[i] = "|"
+		if i == idx {
+			cells[i] = string(mark)
+		}
+	}
+	return strings.Join(cells, " ")
+}
+
+// joinRow draws column j closing into column idx, and the columns right of j moving
+// one place left. With j == idx the column itself ends.
+func (g *logGraph) joinRow(idx, j int) string {
+	line := []byte(strings.Repeat(" ", 2*len(g.columns)))
+	for i := 0; i < len(g.columns); i++ {
+		switch {
+		case i < j:
+			line[2*i] = '|'
+		case i == j && j > idx:
+			line[2*i-1] = '/'
+		case i > j:
+			line[2*i-1] = '/'
+		}
+	}
+	for pos := 2*idx + 1; pos < 2*j-1; pos++ {
+		if line[pos] == ' ' {
+			line[pos] = '_'
+		}
+	}
+	return strings.TrimRight(string(line), " ")
+}
+
+// forkRow draws a new column opening right of idx, and the columns after it moving one
+// place right
+func (g *logGraph) forkRow(idx int) string {
+	line := []byte(strings.Repeat(" ", 2*len(g.columns)+2))
+	for i := 0; i < len(g.columns); i++ {
+		if i <= idx {
+			line[2*i] = '|'
+		} else {
+			line[2*i+1] = '\\'
+		}
+	}
+	line[2*idx+1] = '\\'
+	return strings.TrimRight(string(line), " ")
+}
@@ -27,33 +27,51 @@ func main() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
 
 	// ───── Core VCS Commands ────────────────────────────────────────
-	rootCmd.AddCommand(initCmd())   // Initialize a new repo
-	rootCmd.AddCommand(cloneCmd())  // Clone an existing repo
-	rootCmd.AddCommand(addCmd())    // Add files
-	rootCmd.AddCommand(commitCmd()) // Commit changes
-	rootCmd.AddCommand(pushCmd())   // Push to remote
-	rootCmd.AddCommand(pullCmd())   // Pull from remote
-	rootCmd.AddCommand(statusCmd()) // View current status
+	rootCmd.AddCommand(initCmd())    // Initialize a new repo
+	rootCmd.AddCommand(cloneCmd())   // Clone an existing repo
+	rootCmd.AddCommand(sparseCmd())  // Choose which files the working copy holds
+	rootCmd.AddCommand(hydrateCmd()) // Download files left out of the working copy
+	rootCmd.AddCommand(addCmd())     // Add files
+	rootCmd.AddCommand(commitCmd())  // Commit changes
+	rootCmd.AddCommand(pushCmd())    // Push to remote
+	rootCmd.AddCommand(pullCmd())    // Pull from remote
+	rootCmd.AddCommand(statusCmd())  // View current status
+	rootCmd.AddCommand(uiCmd())      // Full-screen status, staging and locks
+	rootCmd.AddCommand(diffCmd())    // Compare revisions of an asset
+	rootCmd.AddCommand(historyCmd()) // Show the versions of a file
+	rootCmd.AddCommand(logCmd())     // Show the commit history
 
 	// ───── Locking / Asset Collaboration ────────────────────────────
 	rootCmd.AddCommand(lockCmd())   // Lock a file or asset
 	rootCmd.AddCommand(unlockCmd()) // Unlock a file or asset
 
 	// ───── Branching and Versioning ────────────────────────────────
-	rootCmd.AddCommand(branchCmd())  // Manage branches
-	rootCmd.AddCommand(migrateCmd()) // Migrate project schema
-	rootCmd.AddCommand(cleanCmd())   // Clean unused branches or data
+	rootCmd.AddCommand(branchCmd())     // Manage branches
+	rootCmd.AddCommand(switchCmd())     // Switch to another branch
+	rootCmd.AddCommand(checkoutCmd())   // Check out a commit or restore paths
+	rootCmd.AddCommand(restoreCmd())    // Discard or unstage file changes
+	rootCmd.AddCommand(resetCmd())      // Move the current branch to another commit
+	rootCmd.AddCommand(revertCmd())     // Undo a published commit
+	rootCmd.AddCommand(cherryPickCmd()) // Apply a commit to another branch
+	rootCmd.AddCommand(shelveCmd())     // Park uncommitted changes on the server
+	rootCmd.AddCommand(unshelveCmd())   // Bring shelved changes back
+	rootCmd.AddCommand(migrateCmd())    // Migrate project schema
+	rootCmd.AddCommand(cleanCmd())      // Clean unused branches or data
 
 	// ───── Project Lifecycle / Initialization ──────────────────────
 	rootCmd.AddCommand(initVCSCmd()) // `vcs init` command for new projects
 
 	// ───── Real-time & Watcher Tools ───────────────────────────────
-	rootCmd.AddCommand(watchCmd())   // Watch for file changes
-	rootCmd.AddCommand(storageCmd()) // View/manage storage usage
-	rootCmd.AddCommand(cleanupCmd()) // Cleanup temp or orphaned files
+	rootCmd.AddCommand(watchCmd())     // Watch for file changes
+	rootCmd.AddCommand(storageCmd())   // View/manage storage usage
+	rootCmd.AddCommand(integrityCmd()) // Inspect asset integrity records
+	rootCmd.AddCommand(fsckCmd())      // Verify repository objects and history
+	rootCmd.AddCommand(cleanupCmd())   // Cleanup temp or orphaned files
 
 	// ───── Analytics and Insights ──────────────────────────────────
-	rootCmd.AddCommand(analyticsCmd()) // View commit and usage analytics
+	rootCmd.AddCommand(analyticsCmd())   // View commit and usage analytics
+	rootCmd.AddCommand(impactCmd())      // Show what references the staged changes
+	rootCmd.AddCommand(exportTableCmd()) // Export DataTable/CurveTable rows
 
 	// ───── Authentication & User Management ────────────────────────
 	rootCmd.AddCommand(loginCmd())   // Log in (supports --google)
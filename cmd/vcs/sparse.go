@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Telerallc/gamedev-vcs/internal/storage"
+)
+
+// sparseCheckoutFile holds the sparse checkout definition of a working copy
+const sparseCheckoutFile = ".vcs/sparse.json"
+
+// sparseCheckout describes which project files a working copy holds. Files the patterns
+// leave out are intentionally absent; in a partial clone, files the patterns include may
+// also be absent until they're hydrated. Neither counts as a local deletion.
+type sparseCheckout struct {
+	Patterns   []string        `json:"patterns,omitempty"`   // Later patterns win; a leading "!" excludes
+	Partial    bool            `json:"partial,omitempty"`    // Blobs are downloaded when hydrated or locked, not at clone
+	Dehydrated map[string]bool `json:"dehydrated,omitempty"` // Included files that haven't been downloaded yet
+}
+
+// loadSparseCheckout reads the sparse checkout definition; without one every file is
+// checked out
+func loadSparseCheckout() (*sparseCheckout, error) {
+	sparse := &sparseCheckout{}
+	data, err := os.ReadFile(sparseCheckoutFile)
+	if os.IsNotExist(err) {
+		return sparse, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sparse checkout: %w", err)
+	}
+	if err := json.Unmarshal(data, sparse); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", sparseCheckoutFile, err)
+	}
+	return sparse, nil
+}
+
+func (s *sparseCheckout) save() error {
+	if !s.active() {
+		if err := os.Remove(sparseCheckoutFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tempPath := sparseCheckoutFile + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sparse checkout: %w", err)
+	}
+	return os.Rename(tempPath, sparseCheckoutFile)
+}
+
+// active reports whether the working copy may be missing files on purpose
+func (s *sparseCheckout) active() bool {
+	return len(s.Patterns) > 0 || s.Partial
+}
+
+// includes reports whether a file belongs in the working copy. The last pattern matching
+// the file decides; when none does, the file is included only if every pattern excludes.
+func (s *sparseCheckout) includes(filePath string) bool {
+	if len(s.Patterns) == 0 {
+		return true
+	}
+
+	included := true
+	for _, pattern := range s.Patterns {
+		if !strings.HasPrefix(pattern, "!") {
+			included = false
+			break
+		}
+	}
+	for _, pattern := range s.Patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		if matchesSparsePattern(filePath, strings.TrimPrefix(pattern, "!")) {
+			included = !exclude
+		}
+	}
+	return included
+}
+
+// absent reports whether a file is missing from the working copy on purpose
+func (s *sparseCheckout) absent(filePath string) bool {
+	return s.Dehydrated[filePath] || !s.includes(filePath)
+}
+
+func (s *sparseCheckout) markHydrated(paths []string) {
+	for _, filePath := range paths {
+		delete(s.Dehydrated, filePath)
+	}
+}
+
+func (s *sparseCheckout) markDehydrated(paths []string) {
+	if s.Dehydrated == nil {
+		s.Dehydrated = make(map[string]bool, len(paths))
+	}
+	for _, filePath := range paths {
+		s.Dehydrated[filePath] = true
+	}
+}
+
+// dehydratedPaths returns the files still waiting to be downloaded, sorted
+func (s *sparseCheckout) dehydratedPaths() []string {
+	paths := make([]string, 0, len(s.Dehydrated))
+	for filePath := range s.Dehydrated {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// hydrateForEditing downloads the files among paths that a partial clone hasn't fetched
+// yet, so a command about to hand them to an editor finds them in the working copy. It
+// returns how many files it downloaded.
+func hydrateForEditing(projectID string, paths []string) (int, error) {
+	sparse, err := loadSparseCheckout()
+	if err != nil {
+		return 0, err
+	}
+	var pending []string
+	for _, filePath := range paths {
+		if sparse.Dehydrated[filePath] {
+			pending = append(pending, filePath)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	localState, err := LoadLocalState()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load local state: %w", err)
+	}
+	head := localState.HeadCommit()
+	headFiles, err := apiClient.SnapshotAt(projectID, head)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read files at %s: %w", shortID(head), err)
+	}
+
+	updates := make(map[string]storage.TreeEntry, len(pending))
+	for _, filePath := range pending {
+		if entry, ok := headFiles[filePath]; ok {
+			if _, err := os.Stat(filePath); os.IsNotExist(err) {
+				updates[filePath] = entry
+			}
+		}
+	}
+	if _, err := apiClient.CheckoutFiles(projectID, updates, nil); err != nil {
+		return 0, err
+	}
+
+	sparse.markHydrated(pending)
+	if err := sparse.save(); err != nil {
+		return 0, fmt.Errorf("failed to save sparse checkout: %w", err)
+	}
+	return len(updates), nil
+}
+
+// normalizeSparsePatterns cleans up patterns given on the command line, dropping blank
+// lines and comments
+func normalizeSparsePatterns(patterns []string) []string {
+	var normalized []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.Trim(path.Clean(strings.ReplaceAll(strings.TrimPrefix(pattern, "!"), "\\", "/")), "/")
+		if exclude {
+			pattern = "!" + pattern
+		}
+		normalized = append(normalized, pattern)
+	}
+	return normalized
+}
+
+// matchesSparsePattern matches a project-relative file against a pattern anchored at the
+// project root. A pattern naming a folder matches everything below it; "*" and "?" match
+// within one path segment and "**" matches any number of segments.
+func matchesSparsePattern(filePath, pattern string) bool {
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" || pattern == "." {
+		return true
+	}
+	return matchSparseSegments(strings.Split(filePath, "/"), strings.Split(pattern, "/"))
+}
+
+func matchSparseSegments(segments, pattern []string) bool {
+	if len(pattern) == 0 {
+		return true // The pattern matched a folder the file is in
+	}
+	if pattern[0] == "**" {
+		for skip := 0; skip <= len(segments); skip++ {
+			if matchSparseSegments(segments[skip:], pattern[1:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, err := path.Match(pattern[0], segments[0]); err != nil || !matched {
+		return false
+	}
+	return matchSparseSegments(segments[1:], pattern[1:])
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// maxDiffEdits bounds the edit distance unifiedDiff searches; larger rewrites of a text
+// file are summarized instead of diffed line by line
+const maxDiffEdits = 2000
+
+// diffContextLines is how many unchanged lines surround each hunk
+const diffContextLines = 3
+
+// lineEdit is one line of an edit script: kept, deleted from the old side or inserted
+// from the new side
+type lineEdit struct {
+	Kind byte // ' ', '-' or '+'
+	Line string
+	Old  int // 0-based line in the old side, for kept and deleted lines
+	New  int // 0-based line in the new side, for kept and inserted lines
+}
+
+// isBinaryContent reports whether content looks binary: it has a NUL byte near the start
+func isBinaryContent(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the shortest edit script turning a into b using Myers' algorithm.
+// The second result is false when the files differ by more than maxDiffEdits lines.
+func diffLines(a, b []string) ([]lineEdit, bool) {
+	// Common leading and trailing lines never need searching
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	middle, ok := myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if !ok {
+		return nil, false
+	}
+
+	edits := make([]lineEdit, 0, prefix+len(middle)+suffix)
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, lineEdit{Kind: ' ', Line: a[i], Old: i, New: i})
+	}
+	for _, edit := range middle {
+		edit.Old += prefix
+		edit.New += prefix
+		edits = append(edits, edit)
+	}
+	for i := 0; i < suffix; i++ {
+		oldIndex, newIndex := len(a)-suffix+i, len(b)-suffix+i
+		edits = append(edits, lineEdit{Kind: ' ', Line: a[oldIndex], Old: oldIndex, New: newIndex})
+	}
+	return edits, true
+}
+
+func myersDiff(a, b []string) ([]lineEdit, bool) {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxDiffEdits {
+		limit = maxDiffEdits
+	}
+
+	// v[offset+k] is the furthest x reached on diagonal k. Only diagonals -d..d are live
+	// after round d, so trace[d] keeps just those, indexed by k+d, to walk the path back.
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	if !found {
+		return nil, false
+	}
+
+	var reversed []lineEdit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		previous := trace[d-1] // diagonal k of round d-1 is at k+d-1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && previous[k+d-2] < previous[k+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := previous[prevK+d-1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, lineEdit{Kind: ' ', Line: a[x], Old: x, New: y})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, lineEdit{Kind: '+', Line: b[y], Old: x, New: y})
+		} else {
+			x--
+			reversed = append(reversed, lineEdit{Kind: '-', Line: a[x], Old: x, New: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, lineEdit{Kind: ' ', Line: a[x], Old: x, New: y})
+	}
+
+	edits := make([]lineEdit, len(reversed))
+	for i, edit := range reversed {
+		edits[len(reversed)-1-i] = edit
+	}
+	return edits, true
+}
+
+// unifiedHunks formats an edit script as unified diff hunks with diffContextLines of context
+func unifiedHunks(edits []lineEdit) []string {
+	var lines []string
+	for start := 0; start < len(edits); {
+		// Find the next change
+		for start < len(edits) && edits[start].Kind == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Extend the hunk while changes are close enough for their context to touch
+		end := start
+		for i := start; i < len(edits); i++ {
+			if edits[i].Kind != ' ' {
+				end = i
+			} else if i-end > 2*diffContextLines {
+				break
+			}
+		}
+
+		from := max(start-diffContextLines, 0)
+		to := min(end+diffContextLines+1, len(edits))
+		hunk := edits[from:to]
+
+		oldStart, oldCount, newStart, newCount := hunk[0].Old, 0, hunk[0].New, 0
+		var body []string
+		for _, edit := range hunk {
+			if edit.Kind != '+' {
+				oldCount++
+			}
+			if edit.Kind != '-' {
+				newCount++
+			}
+			body = append(body, string(edit.Kind)+edit.Line)
+		}
+		lines = append(lines, fmt.Sprintf("@@ -%s +%s @@", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount)))
+		lines = append(lines, body...)
+
+		start = to
+	}
+	return lines
+}
+
+// hunkRange formats a 0-based start and a line count the way unified diffs number lines
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// countLineChanges returns how many lines an edit script inserts and deletes
+func countLineChanges(edits []lineEdit) (int, int) {
+	var insertions, deletions int
+	for _, edit := range edits {
+		switch edit.Kind {
+		case '+':
+			insertions++
+		case '-':
+			deletions++
+		}
+	}
+	return insertions, deletions
+}
@@ -0,0 +1,1092 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// uiFile is a changed or staged file in `vcs ui`
+type uiFile struct {
+	path   string
+	name   string
+	status byte // M modified, D deleted, ? new; A a staged new file
+	staged bool
+}
+
+// uiFolder groups the changed files of one folder
+type uiFolder struct {
+	path  string
+	files []*uiFile
+}
+
+// uiRow is one line of the file list: a folder, or a file when file is set
+type uiRow struct {
+	folder *uiFolder
+	file   *uiFile
+}
+
+type uiPresence struct {
+	userName string
+	status   string
+	file     string
+}
+
+type uiMode int
+
+const (
+	uiBrowse uiMode = iota
+	uiFilter
+	uiCommitMessage
+	uiViewer
+)
+
+// uiScan is what a scan of the working copy found, handed to the UI goroutine once done
+type uiScan struct {
+	localState *LocalState
+	files      []*uiFile
+	message    string // Outcome of the action the scan followed
+	err        error
+}
+
+// statusUI is the state of the `vcs ui` screen. Everything runs on one goroutine; the
+// event stream, lock refreshes and slow actions (scanning, staging, committing) hand
+// their results over through channels.
+type statusUI struct {
+	projectID string
+	userID    string
+	out       *os.File
+	width     int
+	height    int
+
+	localState *LocalState
+	folders    []*uiFolder
+	collapsed  map[string]bool
+	rows       []uiRow
+	cursor     int
+	offset     int
+	filter     string
+
+	locks      map[string]LockInfo
+	presence   map[string]*uiPresence
+	streamLive bool
+
+	mode       uiMode
+	working    bool // A background action is running; another has to wait
+	input      string
+	viewer     []string
+	viewerTop  int
+	viewerName string
+	message    string
+
+	events      chan map[string]interface{}
+	streamDown  chan error
+	lockUpdates chan []LockInfo
+	teamUpdates chan *TeamStatus
+	scans       chan *uiScan
+}
+
+func uiCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ui",
+		Short: "Browse, stage, lock and commit changes in a full-screen terminal UI",
+		Long: `Open a full-screen view of the working copy: changed and staged files grouped by
+folder, who holds a lock on each file, and who else is working on the project right
+now. It only needs a terminal, so it works over SSH.
+
+Keys:
+  ↑/↓ j/k        move              enter/→/←  open or close a folder
+  enter on a file, or d            diff the file
+  space          stage or unstage the file or folder
+  s / u          stage / unstage   l / L      lock / unlock
+  c              commit the staged files
+  /              filter by path    r          refresh
+  ?              help              q          quit`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadProjectConfig()
+			if err != nil {
+				return fmt.Errorf("not a VCS repository. Run 'vcs init' first")
+			}
+			projectID, ok := config["project_id"].(string)
+			if !ok || projectID == "" {
+				return fmt.Errorf("invalid project configuration")
+			}
+			if err := initializeClient(); err != nil {
+				return err
+			}
+
+			stdin, stdout := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+			if !term.IsTerminal(stdin) || !term.IsTerminal(stdout) {
+				return fmt.Errorf("vcs ui needs an interactive terminal")
+			}
+
+			ui := &statusUI{
+				projectID:   projectID,
+				out:         os.Stdout,
+				collapsed:   make(map[string]bool),
+				locks:       make(map[string]LockInfo),
+				presence:    make(map[string]*uiPresence),
+				events:      make(chan map[string]interface{}, 64),
+				streamDown:  make(chan error, 1),
+				lockUpdates: make(chan []LockInfo, 1),
+				teamUpdates: make(chan *TeamStatus, 1),
+				scans:       make(chan *uiScan, 1),
+			}
+			fmt.Printf("🔍 Scanning the working copy...\n")
+			scan := scanWorkingCopy(projectID)
+			if scan.err != nil {
+				return scan.err
+			}
+			ui.applyScan(scan)
+			if len(ui.folders) > 0 && ui.fileCount() > 200 {
+				for _, folder := range ui.folders {
+					ui.collapsed[folder.path] = true // Thousands of assets read better folder by folder
+				}
+				ui.buildRows()
+			}
+
+			oldState, err := term.MakeRaw(stdin)
+			if err != nil {
+				return fmt.Errorf("failed to set up the terminal: %w", err)
+			}
+			defer term.Restore(stdin, oldState)
+			fmt.Fprint(ui.out, "\x1b[?1049h\x1b[?25l")
+			defer fmt.Fprint(ui.out, "\x1b[?25h\x1b[?1049l")
+
+			ui.startBackground()
+			keys := make(chan string, 16)
+			go readUIKeys(os.Stdin, keys)
+			return ui.run(keys)
+		},
+	}
+}
+
+// startBackground loads who is online and the locks, and follows the event stream
+func (ui *statusUI) startBackground() {
+	client, projectID := apiClient, ui.projectID
+	go func() {
+		if userID, err := currentUserID(); err == nil {
+			ui.events <- map[string]interface{}{"type": "ui_user", "user_id": userID}
+		}
+		if team, err := client.GetTeamStatus(projectID); err == nil {
+			ui.teamUpdates <- team
+		}
+		client.UpdatePresence(projectID, "online", "")
+	}()
+	go func() {
+		err := client.SubscribeToEvents(projectID, func(event map[string]interface{}) {
+			ui.events <- event
+		})
+		ui.streamDown <- err
+	}()
+	ui.streamLive = true
+}
+
+func (ui *statusUI) run(keys <-chan string) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	lastLockRefresh := time.Now()
+
+	for {
+		ui.render()
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			if ui.handleKey(key) {
+				return nil
+			}
+		case event := <-ui.events:
+			ui.applyEvent(event)
+		case err := <-ui.streamDown:
+			ui.streamLive = false
+			if err != nil {
+				ui.message = fmt.Sprintf("⚠️  Lost the event stream: %v", err)
+			}
+		case scan := <-ui.scans:
+			ui.working = false
+			ui.applyScan(scan)
+		case locks := <-ui.lockUpdates:
+			ui.setLocks(locks)
+		case team := <-ui.teamUpdates:
+			ui.setLocks(team.Locks)
+			for _, presence := range team.Presence {
+				ui.presence[presence.UserID] = &uiPresence{userName: presence.UserName, status: presence.Status, file: presence.CurrentFile}
+			}
+		case <-ticker.C:
+			// Locks expire on their own, so refresh them now and then
+			if time.Since(lastLockRefresh) > 30*time.Second {
+				ui.reloadLocks()
+				lastLockRefresh = time.Now()
+			}
+		}
+	}
+}
+
+// scanWorkingCopy finds the changed and staged files. It doesn't touch the UI, so it can
+// run off the UI goroutine.
+func scanWorkingCopy(projectID string) *uiScan {
+	scan := &uiScan{}
+	scan.err = func() error {
+		localState, err := LoadLocalState()
+		if err != nil {
+			return fmt.Errorf("failed to load local state: %w", err)
+		}
+		scan.localState = localState
+
+		headFiles, err := apiClient.SnapshotAt(projectID, localState.HeadCommit())
+		if err != nil {
+			return fmt.Errorf("failed to read files at HEAD: %w", err)
+		}
+		sparse, err := loadSparseCheckout()
+		if err != nil {
+			return err
+		}
+
+		for _, filePath := range localState.GetStagedFiles() {
+			status := byte('M')
+			if localState.StagedFiles[filePath].Deleted {
+				status = 'D'
+			} else if _, committed := headFiles[filePath]; !committed {
+				status = 'A'
+			}
+			scan.files = append(scan.files, &uiFile{path: filePath, status: status, staged: true})
+		}
+
+		var committed []string
+		for filePath := range headFiles {
+			if !sparse.absent(filePath) && !localState.IsFileStaged(filePath) {
+				committed = append(committed, filePath)
+			}
+		}
+		hashes, err := apiClient.WorkingFileHashes(committed)
+		if err != nil {
+			return err
+		}
+		for _, filePath := range committed {
+			hash, exists := hashes[filePath]
+			switch {
+			case !exists:
+				scan.files = append(scan.files, &uiFile{path: filePath, status: 'D'})
+			case hash != headFiles[filePath].Hash:
+				scan.files = append(scan.files, &uiFile{path: filePath, status: 'M'})
+			}
+		}
+
+		working, err := getAllFilesRespectingIgnore()
+		if err != nil {
+			return fmt.Errorf("failed to scan the working copy: %w", err)
+		}
+		for _, filePath := range working {
+			filePath = filepath.ToSlash(filePath)
+			if strings.HasPrefix(filePath, ".vcs/") || !sparse.includes(filePath) {
+				continue
+			}
+			if _, committed := headFiles[filePath]; committed || localState.IsFileStaged(filePath) {
+				continue
+			}
+			scan.files = append(scan.files, &uiFile{path: filePath, status: '?'})
+		}
+		return nil
+	}()
+	return scan
+}
+
+// applyScan shows the result of a scan: its message and the files grouped by folder
+func (ui *statusUI) applyScan(scan *uiScan) {
+	ui.message = scan.message
+	if scan.err != nil {
+		ui.message = fmt.Sprintf("❌ Refresh failed: %v", scan.err)
+		return
+	}
+	ui.localState = scan.localState
+
+	byFolder := make(map[string]*uiFolder)
+	ui.folders = nil
+	for _, file := range scan.files {
+		dir, name := path.Split(file.path)
+		dir = strings.TrimSuffix(dir, "/")
+		file.name = name
+		folder, ok := byFolder[dir]
+		if !ok {
+			folder = &uiFolder{path: dir}
+			byFolder[dir] = folder
+			ui.folders = append(ui.folders, folder)
+		}
+		folder.files = append(folder.files, file)
+	}
+	sort.Slice(ui.folders, func(i, j int) bool { return ui.folders[i].path < ui.folders[j].path })
+	for _, folder := range ui.folders {
+		sort.Slice(folder.files, func(i, j int) bool { return folder.files[i].name < folder.files[j].name })
+	}
+	ui.buildRows()
+}
+
+// buildRows lays out the folders and files that pass the filter, keeping the cursor on
+// the same line where it still exists
+func (ui *statusUI) buildRows() {
+	var current string
+	if ui.cursor < len(ui.rows) {
+		current = ui.rows[ui.cursor].key()
+	}
+
+	ui.rows = ui.rows[:0]
+	for _, folder := range ui.folders {
+		visible := ui.visibleFiles(folder)
+		if len(visible) == 0 {
+			continue
+		}
+		ui.rows = append(ui.rows, uiRow{folder: folder})
+		if ui.collapsed[folder.path] {
+			continue
+		}
+		for _, file := range visible {
+			ui.rows = append(ui.rows, uiRow{folder: folder, file: file})
+		}
+	}
+
+	ui.cursor = 0
+	for i, row := range ui.rows {
+		if row.key() == current {
+			ui.cursor = i
+			break
+		}
+	}
+}
+
+func (r uiRow) key() string {
+	if r.file != nil {
+		return r.file.path
+	}
+	return r.folder.path + "/"
+}
+
+func (ui *statusUI) visibleFiles(folder *uiFolder) []*uiFile {
+	if ui.filter == "" {
+		return folder.files
+	}
+	filter := strings.ToLower(ui.filter)
+	var visible []*uiFile
+	for _, file := range folder.files {
+		if strings.Contains(strings.ToLower(file.path), filter) {
+			visible = append(visible, file)
+		}
+	}
+	return visible
+}
+
+func (ui *statusUI) fileCount() int {
+	count := 0
+	for _, folder := range ui.folders {
+		count += len(folder.files)
+	}
+	return count
+}
+
+// targets returns the files an action on the selected line applies to: the file, or
+// every visible file of the folder
+func (ui *statusUI) targets() []*uiFile {
+	if ui.cursor >= len(ui.rows) {
+		return nil
+	}
+	row := ui.rows[ui.cursor]
+	if row.file != nil {
+		return []*uiFile{row.file}
+	}
+	return ui.visibleFiles(row.folder)
+}
+
+func (ui *statusUI) handleKey(key string) bool {
+	switch ui.mode {
+	case uiFilter, uiCommitMessage:
+		ui.handleInput(key)
+		return false
+	case uiViewer:
+		ui.handleViewerKey(key)
+		return false
+	}
+
+	ui.message = ""
+	switch key {
+	case "q", "ctrl-c":
+		if ui.working {
+			ui.message = "⏳ Wait for the current action to finish"
+			break
+		}
+		return true
+	case "up", "k":
+		ui.moveCursor(-1)
+	case "down", "j":
+		ui.moveCursor(1)
+	case "pgup":
+		ui.moveCursor(-ui.listHeight())
+	case "pgdn":
+		ui.moveCursor(ui.listHeight())
+	case "home", "g":
+		ui.cursor = 0
+	case "end", "G":
+		ui.moveCursor(len(ui.rows))
+	case "enter", "right", "left", "tab":
+		if key == "enter" && ui.cursor < len(ui.rows) && ui.rows[ui.cursor].file != nil {
+			ui.diff()
+			break
+		}
+		if ui.cursor < len(ui.rows) {
+			folder := ui.rows[ui.cursor].folder
+			switch key {
+			case "right":
+				ui.collapsed[folder.path] = false
+			case "left":
+				ui.collapsed[folder.path] = true
+			default:
+				ui.collapsed[folder.path] = !ui.collapsed[folder.path]
+			}
+			ui.cursor = ui.folderRow(folder)
+			ui.buildRows()
+		}
+	case " ":
+		files := ui.targets()
+		allStaged := len(files) > 0
+		for _, file := range files {
+			allStaged = allStaged && file.staged
+		}
+		if allStaged {
+			ui.unstage(files)
+		} else {
+			ui.stage(files)
+		}
+	case "s":
+		ui.stage(ui.targets())
+	case "u":
+		ui.unstage(ui.targets())
+	case "l":
+		ui.lock(ui.targets())
+	case "L":
+		ui.unlock(ui.targets())
+	case "d":
+		ui.diff()
+	case "c":
+		if len(ui.localState.GetStagedFiles()) == 0 {
+			ui.message = "📝 No files staged for commit"
+			break
+		}
+		ui.mode, ui.input = uiCommitMessage, ""
+	case "/":
+		ui.mode, ui.input = uiFilter, ui.filter
+	case "r":
+		ui.background("🔍 Scanning the working copy...", func() string { return "🔄 Refreshed" })
+		ui.reloadLocks()
+	case "?":
+		ui.showViewer("Help", strings.Split(uiCmd().Long, "\n"))
+	}
+	return false
+}
+
+func (ui *statusUI) handleInput(key string) {
+	switch key {
+	case "esc", "ctrl-c":
+		if ui.mode == uiFilter {
+			ui.filter = ""
+			ui.buildRows()
+		}
+		ui.mode = uiBrowse
+	case "enter":
+		mode := ui.mode
+		ui.mode = uiBrowse
+		if mode == uiCommitMessage {
+			ui.commit(strings.TrimSpace(ui.input))
+		}
+	case "backspace":
+		if len(ui.input) > 0 {
+			_, size := utf8.DecodeLastRuneInString(ui.input)
+			ui.input = ui.input[:len(ui.input)-size]
+		}
+	default:
+		if utf8.RuneCountInString(key) == 1 && key >= " " {
+			ui.input += key
+		}
+	}
+	if ui.mode == uiFilter {
+		ui.filter = ui.input
+		ui.buildRows()
+	}
+}
+
+func (ui *statusUI) handleViewerKey(key string) {
+	page := ui.listHeight()
+	switch key {
+	case "q", "esc", "ctrl-c", "enter":
+		ui.mode = uiBrowse
+	case "up", "k":
+		ui.viewerTop--
+	case "down", "j":
+		ui.viewerTop++
+	case "pgup":
+		ui.viewerTop -= page
+	case "pgdn", " ":
+		ui.viewerTop += page
+	case "home", "g":
+		ui.viewerTop = 0
+	case "end", "G":
+		ui.viewerTop = len(ui.viewer)
+	}
+	if ui.viewerTop > len(ui.viewer)-page {
+		ui.viewerTop = len(ui.viewer) - page
+	}
+	if ui.viewerTop < 0 {
+		ui.viewerTop = 0
+	}
+}
+
+func (ui *statusUI) moveCursor(delta int) {
+	ui.cursor += delta
+	if ui.cursor >= len(ui.rows) {
+		ui.cursor = len(ui.rows) - 1
+	}
+	if ui.cursor < 0 {
+		ui.cursor = 0
+	}
+}
+
+func (ui *statusUI) folderRow(folder *uiFolder) int {
+	for i, row := range ui.rows {
+		if row.folder == folder && row.file == nil {
+			return i
+		}
+	}
+	return ui.cursor
+}
+
+// stage adds files to the staging area the same way `vcs add` does
+func (ui *statusUI) stage(files []*uiFile) {
+	var paths []string
+	for _, file := range files {
+		if !file.staged {
+			paths = append(paths, file.path)
+		}
+	}
+	if len(paths) == 0 {
+		ui.message = "✅ Already staged"
+		return
+	}
+
+	ui.background(fmt.Sprintf("📦 Staging %d files...", len(paths)), func() string {
+		if err := runAddGitStyle(io.Discard, paths, false, false, false); err != nil {
+			return fmt.Sprintf("❌ Staging failed: %v", err)
+		}
+		return fmt.Sprintf("✅ Staged %d files", len(paths))
+	})
+}
+
+func (ui *statusUI) unstage(files []*uiFile) {
+	var paths []string
+	for _, file := range files {
+		if file.staged {
+			paths = append(paths, file.path)
+		}
+	}
+	if len(paths) == 0 {
+		ui.message = "📝 Nothing staged here"
+		return
+	}
+	if ui.working {
+		ui.message = "⏳ Wait for the current action to finish"
+		return
+	}
+
+	if _, err := apiClient.UnstageFiles(paths); err != nil {
+		ui.message = fmt.Sprintf("❌ Unstaging failed: %v", err)
+		return
+	}
+	for _, filePath := range paths {
+		ui.localState.RemoveStagedFile(filePath)
+	}
+	if err := ui.localState.SaveLocalState(); err != nil {
+		ui.message = fmt.Sprintf("❌ Failed to save local state: %v", err)
+		return
+	}
+	message := fmt.Sprintf("✅ Unstaged %d files", len(paths))
+	ui.background(message, func() string { return message })
+}
+
+func (ui *statusUI) lock(files []*uiFile) {
+	locked, failed := 0, ""
+	ui.busy(fmt.Sprintf("🔒 Locking %d files...", len(files)))
+	for _, file := range files {
+		if lock, ok := ui.locks[file.path]; ok {
+			if lock.UserID != ui.userID && failed == "" {
+				failed = fmt.Sprintf("%s is locked by %s", file.path, lock.UserName)
+			}
+			continue
+		}
+		result, err := apiClient.LockFile(ui.projectID, file.path)
+		switch {
+		case err != nil:
+			failed = err.Error()
+		case !result.Locked:
+			failed = fmt.Sprintf("%s: %s", file.path, result.Error)
+		default:
+			locked++
+		}
+		if err != nil {
+			break // The server is unreachable; the rest would fail the same way
+		}
+	}
+
+	ui.message = fmt.Sprintf("✅ Locked %d files", locked)
+	if failed != "" {
+		ui.message = fmt.Sprintf("❌ Locked %d files; %s", locked, failed)
+	}
+	ui.reloadLocks()
+}
+
+func (ui *statusUI) unlock(files []*uiFile) {
+	unlocked, failed := 0, ""
+	for _, file := range files {
+		lock, ok := ui.locks[file.path]
+		if !ok {
+			continue
+		}
+		if ui.userID != "" && lock.UserID != ui.userID {
+			failed = fmt.Sprintf("%s is locked by %s", file.path, lock.UserName)
+			continue
+		}
+		if err := apiClient.UnlockFile(ui.projectID, file.path); err != nil {
+			failed = err.Error()
+			break
+		}
+		unlocked++
+	}
+
+	switch {
+	case failed != "":
+		ui.message = fmt.Sprintf("❌ Unlocked %d files; %s", unlocked, failed)
+	case unlocked == 0:
+		ui.message = "🔓 No locks here"
+	default:
+		ui.message = fmt.Sprintf("✅ Unlocked %d files", unlocked)
+	}
+	ui.reloadLocks()
+}
+
+// diff shows `vcs diff` of the selected file: the staged version against HEAD for
+// staged files, the working copy otherwise
+func (ui *statusUI) diff() {
+	if ui.cursor >= len(ui.rows) || ui.rows[ui.cursor].file == nil {
+		ui.message = "💡 Select a file to diff"
+		return
+	}
+	if ui.working {
+		ui.message = "⏳ Wait for the current action to finish"
+		return
+	}
+	file := ui.rows[ui.cursor].file
+
+	args := []string{"--", file.path}
+	if file.staged {
+		args = append([]string{"--staged"}, args...)
+	}
+	ui.busy("🔍 Comparing " + file.path + "...")
+	var output strings.Builder
+	cmd := diffCmd()
+	cmd.SetArgs(args)
+	cmd.SetOut(&output)
+	cmd.SetErr(io.Discard)
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	err := cmd.Execute()
+	lines := strings.Split(strings.TrimRight(output.String(), "\n"), "\n")
+	if err != nil {
+		lines = append(lines, "", fmt.Sprintf("❌ %v", err))
+	}
+	ui.message = ""
+	ui.showViewer("Diff of "+file.path, lines)
+}
+
+func (ui *statusUI) commit(message string) {
+	if message == "" {
+		ui.message = "❌ Commit cancelled: the message is empty"
+		return
+	}
+
+	ui.background("💾 Committing...", func() string {
+		var output strings.Builder
+		if err := runCommitGitStyle(&output, message, false); err != nil {
+			return fmt.Sprintf("❌ Commit failed: %v", err)
+		}
+		result := "✅ Committed"
+		for _, line := range strings.Split(output.String(), "\n") {
+			if strings.HasPrefix(line, "✅ Commit created") {
+				result = line
+			}
+		}
+		return result + "; run 'vcs push' to publish it"
+	})
+}
+
+// background runs a slow action off the UI goroutine and rescans the working copy after
+// it; the scan comes back with the action's message. One action runs at a time.
+func (ui *statusUI) background(message string, action func() string) {
+	if ui.working {
+		ui.message = "⏳ Wait for the current action to finish"
+		return
+	}
+	ui.working = true
+	ui.message = message
+
+	projectID := ui.projectID
+	go func() {
+		result := action()
+		scan := scanWorkingCopy(projectID)
+		scan.message = result
+		ui.scans <- scan
+	}()
+}
+
+func (ui *statusUI) reloadLocks() {
+	client, projectID := apiClient, ui.projectID
+	go func() {
+		if locks, err := client.ListLocks(projectID); err == nil {
+			ui.lockUpdates <- locks
+		}
+	}()
+}
+
+func (ui *statusUI) setLocks(locks []LockInfo) {
+	ui.locks = make(map[string]LockInfo, len(locks))
+	for _, lock := range locks {
+		ui.locks[lock.FilePath] = lock
+	}
+}
+
+// applyEvent updates presence and locks from an event of the project's event stream
+func (ui *statusUI) applyEvent(event map[string]interface{}) {
+	eventType, _ := event["type"].(string)
+	userID, _ := event["user_id"].(string)
+	userName, _ := event["user_name"].(string)
+	filePath, _ := event["file_path"].(string)
+
+	if eventType == "ui_user" {
+		ui.userID = userID
+		return
+	}
+	if userID == "" {
+		return
+	}
+	presence, ok := ui.presence[userID]
+	if !ok {
+		presence = &uiPresence{userName: userName}
+		ui.presence[userID] = presence
+	}
+
+	switch eventType {
+	case "user_left":
+		delete(ui.presence, userID)
+	case "user_idle":
+		presence.status = "idle"
+	case "user_joined":
+		presence.status = "online"
+	case "file_locked", "file_unlocked", "file_modified":
+		presence.status, presence.file = "editing", filePath
+		if eventType != "file_modified" {
+			ui.reloadLocks()
+		}
+	case "commit_created":
+		presence.status, presence.file = "online", ""
+	}
+}
+
+func (ui *statusUI) showViewer(name string, lines []string) {
+	ui.mode = uiViewer
+	ui.viewerName = name
+	ui.viewer = lines
+	ui.viewerTop = 0
+}
+
+// busy shows a message while a slow action runs
+func (ui *statusUI) busy(message string) {
+	ui.message = message
+	ui.render()
+}
+
+func (ui *statusUI) listHeight() int {
+	if height := ui.height - 6; height > 1 {
+		return height
+	}
+	return 1
+}
+
+// render redraws the whole screen: header, presence, the file list (or the viewer),
+// the last message and the key help
+func (ui *statusUI) render() {
+	if width, height, err := term.GetSize(int(ui.out.Fd())); err == nil {
+		ui.width, ui.height = width, height
+	}
+	if ui.width <= 0 || ui.height <= 0 {
+		ui.width, ui.height = 80, 24
+	}
+
+	var screen strings.Builder
+	screen.WriteString("\x1b[H")
+	line := func(text, style string) {
+		text = fitUIText(text, ui.width)
+		if style != "" {
+			text = style + text + strings.Repeat(" ", max(0, ui.width-uiTextWidth(text))) + "\x1b[0m"
+		}
+		screen.WriteString(text + "\x1b[K\r\n")
+	}
+
+	staged := len(ui.localState.GetStagedFiles())
+	head := "vcs ui · " + ui.projectID + " · " + ui.localState.CurrentBranch
+	if ui.localState.Detached {
+		head = "vcs ui · " + ui.projectID + " · detached"
+	}
+	head += " @ " + shortID(ui.localState.HeadCommit())
+	counts := fmt.Sprintf("%d staged · %d changed ", staged, ui.fileCount()-staged)
+	line(" "+head+strings.Repeat(" ", max(1, ui.width-uiTextWidth(head)-uiTextWidth(counts)-1))+counts, "\x1b[7m")
+	line(ui.presenceLine(), "")
+	line(strings.Repeat("─", ui.width), "\x1b[2m")
+
+	height := ui.listHeight()
+	if ui.mode == uiViewer {
+		line(" "+ui.viewerName, "\x1b[1m")
+		for i := 0; i < height-1; i++ {
+			if index := ui.viewerTop + i; index < len(ui.viewer) {
+				line(" "+strings.TrimRight(ui.viewer[index], "\r"), "")
+			} else {
+				line("", "")
+			}
+		}
+	} else {
+		if ui.cursor < ui.offset {
+			ui.offset = ui.cursor
+		}
+		if ui.cursor >= ui.offset+height {
+			ui.offset = ui.cursor - height + 1
+		}
+		for i := 0; i < height; i++ {
+			index := ui.offset + i
+			switch {
+			case index < len(ui.rows):
+				text, style := ui.rowText(ui.rows[index])
+				if index == ui.cursor {
+					style = "\x1b[7m"
+				}
+				line(text, style)
+			case index == 0:
+				line("  ✅ No changes", "")
+			default:
+				line("", "")
+			}
+		}
+	}
+
+	line(strings.Repeat("─", ui.width), "\x1b[2m")
+	line(" "+ui.message, "")
+	switch ui.mode {
+	case uiFilter:
+		line(" Filter: "+ui.input+"▏  (enter keep · esc clear)", "")
+	case uiCommitMessage:
+		line(fmt.Sprintf(" Commit %d files, message: %s▏  (enter commit · esc cancel)", staged, ui.input), "")
+	case uiViewer:
+		line(" ↑/↓ pgup/pgdn scroll · q back", "\x1b[2m")
+	default:
+		line(" space stage · l/L lock · d diff · c commit · / filter · ? help · q quit", "\x1b[2m")
+	}
+	fmt.Fprint(ui.out, strings.TrimSuffix(screen.String(), "\r\n"))
+}
+
+func (ui *statusUI) rowText(row uiRow) (string, string) {
+	if row.file == nil {
+		files := ui.visibleFiles(row.folder)
+		stagedCount := 0
+		for _, file := range files {
+			if file.staged {
+				stagedCount++
+			}
+		}
+		marker := "▾"
+		if ui.collapsed[row.folder.path] {
+			marker = "▸"
+		}
+		name := row.folder.path
+		if name == "" {
+			name = "(project root)"
+		}
+		return fmt.Sprintf(" %s %s/  %d files, %d staged", marker, name, len(files), stagedCount), "\x1b[1m"
+	}
+
+	file := row.file
+	check, style := "[ ]", ""
+	switch {
+	case file.staged:
+		check, style = "[x]", "\x1b[32m"
+	case file.status == 'D':
+		style = "\x1b[31m"
+	case file.status == '?':
+		style = "\x1b[36m"
+	}
+	text := fmt.Sprintf("     %s %c %s", check, file.status, file.name)
+
+	if lock, ok := ui.locks[file.path]; ok {
+		owner := lock.UserName
+		if lock.UserID == ui.userID {
+			owner = "you"
+		} else if !file.staged {
+			style = "\x1b[33m"
+		}
+		tag := "🔒 " + owner
+		text = fitUIText(text, ui.width-uiTextWidth(tag)-3)
+		text += strings.Repeat(" ", max(1, ui.width-uiTextWidth(text)-uiTextWidth(tag)-2)) + tag
+	}
+	return text, style
+}
+
+func (ui *statusUI) presenceLine() string {
+	var people []string
+	for userID, presence := range ui.presence {
+		if userID == ui.userID || presence.status == "offline" {
+			continue
+		}
+		person := presence.userName
+		switch {
+		case presence.file != "":
+			person += " (" + presence.file + ")"
+		case presence.status == "idle":
+			person += " (idle)"
+		}
+		people = append(people, person)
+	}
+	sort.Strings(people)
+
+	text := " 👥 Nobody else is online"
+	if len(people) > 0 {
+		text = " 👥 " + strings.Join(people, " · ")
+	}
+	if !ui.streamLive {
+		text += "  (live updates unavailable)"
+	}
+	return text
+}
+
+// readUIKeys turns raw terminal input into key names
+func readUIKeys(in io.Reader, keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 256)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range splitUIKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
+
+func splitUIKeys(data []byte) []string {
+	var keys []string
+	for i := 0; i < len(data); {
+		switch data[i] {
+		case 0x1b:
+			if i+1 < len(data) && (data[i+1] == '[' || data[i+1] == 'O') {
+				end := i + 2
+				for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+					end++
+				}
+				if end >= len(data) {
+					end = len(data) - 1
+				}
+				keys = append(keys, uiEscapeKey(string(data[i+2:end+1])))
+				i = end + 1
+				continue
+			}
+			keys = append(keys, "esc")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case '\t':
+			keys = append(keys, "tab")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		default:
+			r, size := utf8.DecodeRune(data[i:])
+			if r >= ' ' {
+				keys = append(keys, string(r))
+			}
+			i += size
+			continue
+		}
+		i++
+	}
+	return keys
+}
+
+func uiEscapeKey(sequence string) string {
+	switch sequence {
+	case "A":
+		return "up"
+	case "B":
+		return "down"
+	case "C":
+		return "right"
+	case "D":
+		return "left"
+	case "H", "1~", "7~":
+		return "home"
+	case "F", "4~", "8~":
+		return "end"
+	case "5~":
+		return "pgup"
+	case "6~":
+		return "pgdn"
+	}
+	return ""
+}
+
+// uiTextWidth estimates how many terminal columns text takes: emoji take two, and
+// escape sequences and variation selectors none
+func uiTextWidth(text string) int {
+	width := 0
+	inEscape := false
+	for _, r := range text {
+		switch {
+		case inEscape:
+			inEscape = !(r >= '@' && r <= '~' && r != '[')
+		case r == 0x1b:
+			inEscape = true
+		case r == 0xfe0f || r == 0x200d:
+		case r >= 0x1f000 || (r >= 0x2600 && r <= 0x27bf):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// fitUIText cuts text down to width columns
+func fitUIText(text string, width int) string {
+	if uiTextWidth(text) <= width {
+		return text
+	}
+	var fitted strings.Builder
+	for _, r := range text {
+		if uiTextWidth(fitted.String()+string(r)) > width-1 {
+			break
+		}
+		fitted.WriteRune(r)
+	}
+	return fitted.String() + "…"
+}
@@ -133,15 +133,92 @@ func (db *DB) Migrate() error {
 		&models.Tag{},
 		&models.FileVersion{},
 		&models.FileEvent{},
+		&models.CorruptionAlert{},
+		&models.FsckRun{},
+		&models.Shelf{},
+		&models.ProjectSecret{},
+		&models.ProjectObject{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
+	if err := db.migrateCommitTreeKey(); err != nil {
+		return err
+	}
+	if err := db.migrateWebhookSecrets(); err != nil {
+		return err
+	}
 
 	log.Println("Database migration completed successfully")
 	return nil
 }
 
+// migrateCommitTreeKey widens the primary key of commit_trees from the tree hash to the
+// hash and project, which AutoMigrate doesn't do for existing tables. Identical trees of
+// different projects then get rows of their own.
+func (db *DB) migrateCommitTreeKey() error {
+	var keyColumns int64
+	err := db.Raw(`
+		SELECT COUNT(*) FROM information_schema.key_column_usage
+		WHERE table_name = 'commit_trees' AND constraint_name = 'commit_trees_pkey'
+	`).Scan(&keyColumns).Error
+	if err != nil {
+		return fmt.Errorf("failed to inspect commit_trees: %w", err)
+	}
+	if keyColumns != 1 {
+		return nil
+	}
+
+	err = db.Exec(`ALTER TABLE commit_trees DROP CONSTRAINT commit_trees_pkey, ADD PRIMARY KEY (id, project_id)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to migrate commit_trees key: %w", err)
+	}
+	return nil
+}
+
+// migrateWebhookSecrets moves webhook signing secrets out of the "corruption_alerts"
+// project setting, which every project member can read, into project secrets
+func (db *DB) migrateWebhookSecrets() error {
+	var projects []models.Project
+	if err := db.Where("settings -> 'corruption_alerts' -> 'webhooks' IS NOT NULL").Find(&projects).Error; err != nil {
+		return fmt.Errorf("failed to load project settings: %w", err)
+	}
+
+	for _, project := range projects {
+		alerts, _ := project.Settings["corruption_alerts"].(map[string]interface{})
+		webhooks, _ := alerts["webhooks"].([]interface{})
+
+		moved := false
+		for i, entry := range webhooks {
+			webhook, _ := entry.(map[string]interface{})
+			raw, ok := webhook["secret"]
+			if !ok {
+				continue
+			}
+			if secret, _ := raw.(string); secret != "" {
+				name, _ := webhook["name"].(string)
+				err := db.Save(&models.ProjectSecret{
+					ProjectID: project.ID,
+					Name:      models.WebhookSecretName(name, i+1),
+					Value:     secret,
+				}).Error
+				if err != nil {
+					return fmt.Errorf("failed to store webhook secret of project %s: %w", project.ID, err)
+				}
+			}
+			delete(webhook, "secret")
+			moved = true
+		}
+
+		if moved {
+			if err := db.Model(&project).Update("settings", project.Settings).Error; err != nil {
+				return fmt.Errorf("failed to update settings of project %s: %w", project.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
 // MigrateDrizzle runs Drizzle-compatible migrations
 func (db *DB) MigrateDrizzle() error {
 	// Create enums first
@@ -192,10 +269,21 @@ func (db *DB) MigrateDrizzle() error {
 		&models.Ref{},
 		&models.Tag{},
 		&models.FileVersion{},
+		&models.CorruptionAlert{},
+		&models.FsckRun{},
+		&models.Shelf{},
+		&models.ProjectSecret{},
+		&models.ProjectObject{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
+	if err := db.migrateCommitTreeKey(); err != nil {
+		return err
+	}
+	if err := db.migrateWebhookSecrets(); err != nil {
+		return err
+	}
 
 	log.Println("✅ Drizzle migrations completed successfully")
 	return nil
@@ -519,6 +607,7 @@ func (r *FileRepository) CreateOrUpdateFile(file *models.File) error {
 			"content_hash":     file.ContentHash,
 			"size":             file.Size,
 			"mime_type":        file.MimeType,
+			"metadata":         file.Metadata,
 			"last_modified_by": file.LastModifiedBy,
 			"last_modified_at": time.Now(),
 		}).Error
@@ -45,6 +45,9 @@ type FileChange struct {
 	BlueprintType    string    `json:"blueprint_type"`
 	SyncDurationMS   uint32    `json:"sync_duration_ms"`
 	CompressionRatio float32   `json:"compression_ratio"`
+
+	// Source art metadata of the new content (texture dimensions, FBX mesh counts)
+	SourceMetadata map[string]string `json:"source_metadata,omitempty"`
 }
 
 // AssetDependency represents dependency relationships between assets
@@ -209,13 +212,18 @@ func (ac *AnalyticsClient) RecordFileChanges(changes []FileChange) error {
 		INSERT INTO file_changes (
 			commit_hash, file_path, change_type, content_hash, file_size_bytes,
 			author, commit_time, asset_type, asset_class, ue5_package_path,
-			is_blueprint, blueprint_type, sync_duration_ms, compression_ratio
+			is_blueprint, blueprint_type, sync_duration_ms, compression_ratio,
+			source_metadata
 		)`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare batch: %w", err)
 	}
 
 	for _, change := range changes {
+		sourceMetadata := change.SourceMetadata
+		if sourceMetadata == nil {
+			sourceMetadata = map[string]string{}
+		}
 		if err := batch.Append(
 			change.CommitHash,
 			change.FilePath,
@@ -231,6 +239,7 @@ func (ac *AnalyticsClient) RecordFileChanges(changes []FileChange) error {
 			change.BlueprintType,
 			change.SyncDurationMS,
 			change.CompressionRatio,
+			sourceMetadata,
 		); err != nil {
 			return fmt.Errorf("failed to append change: %w", err)
 		}
@@ -567,6 +576,7 @@ func (ac *AnalyticsClient) initializeSchema() error {
 	tables := []string{
 		createCommitsTable,
 		createFileChangesTable,
+		addFileChangesSourceMetadata,
 		createAssetDependenciesTable,
 		createCollaborationEventsTable,
 		createProductivityMetricsTable,
@@ -618,11 +628,16 @@ const (
 			is_blueprint Bool,
 			blueprint_type String,
 			sync_duration_ms UInt32,
-			compression_ratio Float32
+			compression_ratio Float32,
+			source_metadata Map(String, String)
 		) ENGINE = MergeTree()
 		PARTITION BY toYYYYMM(commit_time)
 		ORDER BY (file_path, commit_time)`
 
+	// Columns added to file_changes after it was first created
+	addFileChangesSourceMetadata = `
+		ALTER TABLE file_changes ADD COLUMN IF NOT EXISTS source_metadata Map(String, String)`
+
 	createAssetDependenciesTable = `
 		CREATE TABLE IF NOT EXISTS asset_dependencies (
 			asset_path String,
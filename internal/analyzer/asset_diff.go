@@ -0,0 +1,157 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Diff describes how a file changed between two versions in the terms its format
+// understands: table rows, dependencies, texture and mesh properties. The second result is
+// false when no registered format can compare the file. Either side may be empty for an
+// added or deleted file.
+func (r *Registry) Diff(filePath string, from, to []byte) ([]string, bool, error) {
+	header := leadingBytes(to)
+	if len(header) == 0 {
+		header = leadingBytes(from)
+	}
+	format := r.Lookup(filePath, header)
+	if format == nil {
+		return nil, false, nil
+	}
+
+	var changes []string
+	var err error
+	switch {
+	case format.Diff != nil:
+		changes, err = format.Diff(filePath, from, to)
+	case format.VersionMetadata && format.Analyze != nil:
+		changes, err = diffVersionMetadata(format.Analyze, filePath, from, to)
+	case format.Analyze != nil:
+		changes, err = diffDependencies(format.Analyze, filePath, from, to)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, fmt.Errorf("%s diff failed: %w", format.Name, err)
+	}
+	return changes, true, nil
+}
+
+// DiffAsset compares two revisions of an Unreal package: the rows of DataTables and
+// CurveTables, and the dependencies of everything else
+func (ua *UE5AssetAnalyzer) DiffAsset(filePath string, from, to []byte) ([]string, error) {
+	fromTable, fromErr := decodeTableSide(ua, from)
+	toTable, toErr := decodeTableSide(ua, to)
+	if fromErr == nil && toErr == nil && (fromTable != nil || toTable != nil) {
+		return DiffDataTables(fromTable, toTable).Describe(), nil
+	}
+	return diffDependencies(ua.AnalyzeAsset, filePath, from, to)
+}
+
+// Describe lists the table changes one per line
+func (d *DataTableDiff) Describe() []string {
+	var changes []string
+	if d.RowStruct != nil {
+		changes = append(changes, fmt.Sprintf("row struct %s → %s", d.RowStruct.From, d.RowStruct.To))
+	}
+	for _, row := range d.AddedRows {
+		changes = append(changes, "row added: "+row)
+	}
+	for _, row := range d.RemovedRows {
+		changes = append(changes, "row removed: "+row)
+	}
+	for _, row := range d.ChangedRows {
+		for _, cell := range row.Cells {
+			changes = append(changes, fmt.Sprintf("row %s: %s %s → %s", row.Row, cell.Column, describeCell(cell.From), describeCell(cell.To)))
+		}
+	}
+	return changes
+}
+
+func describeCell(value string) string {
+	if value == "" {
+		return "(unset)"
+	}
+	return value
+}
+
+// decodeTableSide decodes one side of a diff as a table; an empty side is a nil table
+func decodeTableSide(ua *UE5AssetAnalyzer, content []byte) (*DataTable, error) {
+	if len(content) == 0 {
+		return nil, nil
+	}
+	return ua.DecodeDataTable(content)
+}
+
+func diffVersionMetadata(analyze func(string, []byte) (*AssetInfo, error), filePath string, from, to []byte) ([]string, error) {
+	fromInfo, err := analyzeSide(analyze, filePath, from)
+	if err != nil {
+		return nil, err
+	}
+	toInfo, err := analyzeSide(analyze, filePath, to)
+	if err != nil {
+		return nil, err
+	}
+	if fromInfo == nil || toInfo == nil {
+		return nil, nil
+	}
+	return DescribeVersionChanges(fromInfo.Properties, toInfo.Properties), nil
+}
+
+// diffDependencies lists the asset type change and the dependencies added and removed
+// between two revisions
+func diffDependencies(analyze func(string, []byte) (*AssetInfo, error), filePath string, from, to []byte) ([]string, error) {
+	fromInfo, err := analyzeSide(analyze, filePath, from)
+	if err != nil {
+		return nil, err
+	}
+	toInfo, err := analyzeSide(analyze, filePath, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	if fromInfo != nil && toInfo != nil && fromInfo.AssetType != toInfo.AssetType {
+		changes = append(changes, fmt.Sprintf("asset type %s → %s", fromInfo.AssetType, toInfo.AssetType))
+	}
+
+	fromTargets, toTargets := dependencyTargets(fromInfo), dependencyTargets(toInfo)
+	for _, target := range sortedKeys(toTargets) {
+		if !fromTargets[target] {
+			changes = append(changes, "dependency added: "+target)
+		}
+	}
+	for _, target := range sortedKeys(fromTargets) {
+		if !toTargets[target] {
+			changes = append(changes, "dependency removed: "+target)
+		}
+	}
+	return changes, nil
+}
+
+func analyzeSide(analyze func(string, []byte) (*AssetInfo, error), filePath string, content []byte) (*AssetInfo, error) {
+	if len(content) == 0 {
+		return nil, nil
+	}
+	return analyze(filePath, content)
+}
+
+func dependencyTargets(info *AssetInfo) map[string]bool {
+	targets := make(map[string]bool)
+	if info == nil {
+		return targets
+	}
+	for _, dep := range info.Dependencies {
+		targets[dep.TargetAsset] = true
+	}
+	return targets
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,399 @@
+package analyzer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Table asset kinds
+const (
+	TableKindData  = "DataTable"
+	TableKindCurve = "CurveTable"
+)
+
+// DefaultCell stands for a DataTable cell the row doesn't serialize. Rows only store the
+// properties that differ from the row struct's defaults, and the defaults live with the
+// struct rather than in the table's package, so the actual value isn't known here.
+const DefaultCell = "<default>"
+
+// Curve table storage modes (ECurveTableMode)
+const (
+	curveTableEmpty  = 0
+	curveTableSimple = 1
+	curveTableRich   = 2
+)
+
+// DataTable is the decoded row data of a DataTable or CurveTable asset.
+// Curve table columns are the key times and cells the key values.
+type DataTable struct {
+	Kind      string         `json:"kind"`
+	Name      string         `json:"name"`
+	RowStruct string         `json:"row_struct,omitempty"`
+	Columns   []string       `json:"columns"`
+	Rows      []DataTableRow `json:"rows"`
+}
+
+// DataTableRow is a single named table row
+type DataTableRow struct {
+	Name   string                 `json:"name"`
+	Values map[string]interface{} `json:"values"`
+}
+
+// DataTableDiff is a row-level diff between two revisions of a table
+type DataTableDiff struct {
+	Kind        string    `json:"kind"`
+	RowStruct   *CellDiff `json:"row_struct,omitempty"`
+	AddedRows   []string  `json:"added_rows"`
+	RemovedRows []string  `json:"removed_rows"`
+	ChangedRows []RowDiff `json:"changed_rows"`
+}
+
+// RowDiff lists the cells that changed within a row present in both revisions
+type RowDiff struct {
+	Row   string     `json:"row"`
+	Cells []CellDiff `json:"cells"`
+}
+
+// CellDiff is a single cell that differs between revisions; an empty side means the cell is unset
+// and DefaultCell that it holds the row struct's default
+type CellDiff struct {
+	Column string `json:"column"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// HasChanges reports whether the two revisions differ
+func (d *DataTableDiff) HasChanges() bool {
+	return d.RowStruct != nil || len(d.AddedRows) > 0 || len(d.RemovedRows) > 0 || len(d.ChangedRows) > 0
+}
+
+// DecodeDataTable decodes the rows of a DataTable or CurveTable package
+func (ua *UE5AssetAnalyzer) DecodeDataTable(content []byte) (*DataTable, error) {
+	pkg, err := ua.ParsePackage(content)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pkg.Exports {
+		export := &pkg.Exports[i]
+		switch pkg.ExportClass(export) {
+		case "DataTable", "CompositeDataTable":
+			return decodeDataTableExport(pkg, export)
+		case "CurveTable", "CompositeCurveTable":
+			return decodeCurveTableExport(pkg, export)
+		}
+	}
+
+	return nil, fmt.Errorf("package contains no DataTable or CurveTable export")
+}
+
+func decodeDataTableExport(pkg *Package, export *PackageExport) (*DataTable, error) {
+	reader, properties, err := readTableObject(pkg, export)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &DataTable{
+		Kind:    TableKindData,
+		Name:    export.ObjectName,
+		Columns: make([]string, 0),
+		Rows:    make([]DataTableRow, 0),
+	}
+	if rowStruct, ok := PropertyMap(properties)["RowStruct"].(string); ok {
+		table.RowStruct = rowStruct
+	}
+
+	rowCount, err := reader.ReadInt32()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read row count: %w", err)
+	}
+	if rowCount < 0 || int(rowCount) > reader.Remaining() {
+		return nil, fmt.Errorf("row count %d out of range", rowCount)
+	}
+
+	seenColumns := make(map[string]bool)
+	for i := 0; i < int(rowCount); i++ {
+		rowName, err := reader.ReadFName()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row %d name: %w", i, err)
+		}
+
+		rowProperties, err := reader.ReadTaggedProperties()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row %s: %w", rowName, err)
+		}
+
+		row := DataTableRow{Name: rowName, Values: PropertyMap(rowProperties)}
+		// Columns keep the row struct's serialization order
+		for _, prop := range rowProperties {
+			column := prop.Name
+			if prop.ArrayIndex > 0 {
+				column = fmt.Sprintf("%s[%d]", prop.Name, prop.ArrayIndex)
+			}
+			if !seenColumns[column] {
+				seenColumns[column] = true
+				table.Columns = append(table.Columns, column)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table, nil
+}
+
+func decodeCurveTableExport(pkg *Package, export *PackageExport) (*DataTable, error) {
+	reader, _, err := readTableObject(pkg, export)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &DataTable{
+		Kind:    TableKindCurve,
+		Name:    export.ObjectName,
+		Columns: make([]string, 0),
+		Rows:    make([]DataTableRow, 0),
+	}
+
+	mode, err := reader.ReadUint8()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read curve table mode: %w", err)
+	}
+	switch mode {
+	case curveTableEmpty:
+		return table, nil
+	case curveTableSimple:
+		table.RowStruct = "SimpleCurve"
+	case curveTableRich:
+		table.RowStruct = "RichCurve"
+	default:
+		return nil, fmt.Errorf("unknown curve table mode %d", mode)
+	}
+
+	rowCount, err := reader.ReadInt32()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read row count: %w", err)
+	}
+	if rowCount < 0 || int(rowCount) > reader.Remaining() {
+		return nil, fmt.Errorf("row count %d out of range", rowCount)
+	}
+
+	times := make(map[float32]bool)
+	for i := 0; i < int(rowCount); i++ {
+		rowName, err := reader.ReadFName()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row %d name: %w", i, err)
+		}
+
+		curveProperties, err := reader.ReadTaggedProperties()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read curve %s: %w", rowName, err)
+		}
+
+		row := DataTableRow{Name: rowName, Values: make(map[string]interface{})}
+		keys, _ := PropertyMap(curveProperties)["Keys"].([]interface{})
+		for _, entry := range keys {
+			key, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			keyTime, _ := key["Time"].(float32)
+			times[keyTime] = true
+			row.Values[formatKeyTime(keyTime)] = key["Value"]
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	sortedTimes := make([]float32, 0, len(times))
+	for keyTime := range times {
+		sortedTimes = append(sortedTimes, keyTime)
+	}
+	sort.Slice(sortedTimes, func(i, j int) bool { return sortedTimes[i] < sortedTimes[j] })
+	for _, keyTime := range sortedTimes {
+		table.Columns = append(table.Columns, formatKeyTime(keyTime))
+	}
+
+	return table, nil
+}
+
+// readTableObject reads the UObject part of a table export and returns a reader positioned at the row data
+func readTableObject(pkg *Package, export *PackageExport) (*PackageReader, []TaggedProperty, error) {
+	reader, err := pkg.ExportReader(export)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	properties, err := reader.ReadTaggedProperties()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s properties: %w", export.ObjectName, err)
+	}
+
+	hasGuid, err := reader.ReadBool()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s object guid: %w", export.ObjectName, err)
+	}
+	if hasGuid {
+		if err := reader.Skip(16); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return reader, properties, nil
+}
+
+func formatKeyTime(keyTime float32) string {
+	return strconv.FormatFloat(float64(keyTime), 'g', -1, 32)
+}
+
+// Cell renders a row value as text; structs and arrays are rendered as JSON
+func (row *DataTableRow) Cell(column string) string {
+	return formatCell(row.Values[column])
+}
+
+// Cell renders a cell of one of the table's rows. A DataTable column the row omits
+// reads as DefaultCell; an omitted curve table key is empty.
+func (t *DataTable) Cell(row *DataTableRow, column string) string {
+	if _, ok := row.Values[column]; !ok && t.Kind == TableKindData {
+		return DefaultCell
+	}
+	return row.Cell(column)
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case map[string]interface{}, []interface{}, []float64, []float32:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
+
+// WriteCSV writes the table in the editor's import layout: a Name column followed by one column per field.
+// DataTable cells the row omits are written as DefaultCell.
+func (t *DataTable) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(append([]string{"Name"}, t.Columns...)); err != nil {
+		return err
+	}
+	for i := range t.Rows {
+		record := make([]string, 0, len(t.Columns)+1)
+		record = append(record, t.Rows[i].Name)
+		for _, column := range t.Columns {
+			record = append(record, t.Cell(&t.Rows[i], column))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the table as an array of row objects keyed by column, with the row name under "Name".
+// Cells the row omits are left out, which the editor's JSON import reads as the row struct's default.
+func (t *DataTable) WriteJSON(w io.Writer) error {
+	records := make([]map[string]interface{}, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		record := make(map[string]interface{}, len(row.Values)+1)
+		for column, value := range row.Values {
+			record[column] = value
+		}
+		record["Name"] = row.Name
+		records = append(records, record)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// DiffDataTables compares two table revisions row by row. Either side may be nil
+// to represent an added or deleted table.
+func DiffDataTables(from, to *DataTable) *DataTableDiff {
+	if from == nil {
+		from = &DataTable{}
+	}
+	if to == nil {
+		to = &DataTable{}
+	}
+
+	diff := &DataTableDiff{
+		Kind:        to.Kind,
+		AddedRows:   make([]string, 0),
+		RemovedRows: make([]string, 0),
+		ChangedRows: make([]RowDiff, 0),
+	}
+	if diff.Kind == "" {
+		diff.Kind = from.Kind
+	}
+	if from.Kind != "" && to.Kind != "" && from.RowStruct != to.RowStruct {
+		diff.RowStruct = &CellDiff{Column: "row_struct", From: from.RowStruct, To: to.RowStruct}
+	}
+
+	oldRows := make(map[string]*DataTableRow, len(from.Rows))
+	for i := range from.Rows {
+		oldRows[from.Rows[i].Name] = &from.Rows[i]
+	}
+
+	columns := mergeColumns(from.Columns, to.Columns)
+	seen := make(map[string]bool)
+	for i := range to.Rows {
+		newRow := &to.Rows[i]
+		seen[newRow.Name] = true
+
+		oldRow, existed := oldRows[newRow.Name]
+		if !existed {
+			diff.AddedRows = append(diff.AddedRows, newRow.Name)
+			continue
+		}
+
+		rowDiff := RowDiff{Row: newRow.Name}
+		for _, column := range columns {
+			if before, after := from.Cell(oldRow, column), to.Cell(newRow, column); before != after {
+				rowDiff.Cells = append(rowDiff.Cells, CellDiff{Column: column, From: before, To: after})
+			}
+		}
+		if len(rowDiff.Cells) > 0 {
+			diff.ChangedRows = append(diff.ChangedRows, rowDiff)
+		}
+	}
+
+	for _, row := range from.Rows {
+		if !seen[row.Name] {
+			diff.RemovedRows = append(diff.RemovedRows, row.Name)
+		}
+	}
+
+	return diff
+}
+
+// mergeColumns returns the new revision's columns followed by any columns only the old one had
+func mergeColumns(from, to []string) []string {
+	columns := append([]string{}, to...)
+	present := make(map[string]bool, len(to))
+	for _, column := range to {
+		present[column] = true
+	}
+	for _, column := range from {
+		if !present[column] {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
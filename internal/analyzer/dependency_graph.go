@@ -0,0 +1,393 @@
+package analyzer
+
+import (
+	"sort"
+)
+
+// Rough streaming throughput used to turn closure size into a load time estimate
+const estimatedLoadBytesPerMS = 200 * 1024 // ~200 MB/s
+
+// DependencyGraph is the project-wide package dependency graph
+type DependencyGraph struct {
+	nodes map[string]*GraphNode
+
+	// Lazily computed analysis results
+	components  [][]string
+	componentOf map[string]int
+	closures    map[int]*closureSet
+	referencers map[string][]string
+}
+
+// GraphNode is a package in the dependency graph
+type GraphNode struct {
+	PackageName  string            `json:"package_name"`
+	FilePath     string            `json:"file_path"`
+	Size         int64             `json:"size"`
+	Dependencies []AssetDependency `json:"dependencies"`
+}
+
+// LoadChain describes everything an asset drags in through hard references
+type LoadChain struct {
+	PackageName       string  `json:"package_name"`
+	FilePath          string  `json:"file_path"`
+	OwnSize           int64   `json:"own_size"`
+	PackageCount      int     `json:"package_count"`
+	TotalBytes        int64   `json:"total_bytes"`
+	EstimatedLoadTime float64 `json:"estimated_load_time_ms"`
+	IsCircular        bool    `json:"is_circular"`
+}
+
+type closureSet struct {
+	packages map[string]bool
+	bytes    int64
+}
+
+// NewDependencyGraph creates an empty dependency graph
+func NewDependencyGraph() *DependencyGraph {
+	return &DependencyGraph{
+		nodes: make(map[string]*GraphNode),
+	}
+}
+
+// AddAsset adds a package and its outgoing dependencies to the graph
+func (g *DependencyGraph) AddAsset(packageName, filePath string, size int64, deps []AssetDependency) {
+	// Copy so IsCircular/Weight annotations don't leak into the caller's slice
+	g.nodes[packageName] = &GraphNode{
+		PackageName:  packageName,
+		FilePath:     filePath,
+		Size:         size,
+		Dependencies: append([]AssetDependency(nil), deps...),
+	}
+	g.components = nil
+	g.referencers = nil
+}
+
+// Node returns the graph node for a package
+func (g *DependencyGraph) Node(packageName string) (*GraphNode, bool) {
+	node, ok := g.nodes[packageName]
+	return node, ok
+}
+
+// Lookup finds a package by package name, object path or file path
+func (g *DependencyGraph) Lookup(asset string) (*GraphNode, bool) {
+	if name := g.resolve(asset); name != "" {
+		return g.nodes[name], true
+	}
+
+	for _, node := range g.nodes {
+		if node.FilePath == asset {
+			return node, true
+		}
+	}
+
+	return nil, false
+}
+
+// NodeCount returns the number of packages in the graph
+func (g *DependencyGraph) NodeCount() int {
+	return len(g.nodes)
+}
+
+// Cycles returns every strongly connected component that forms a reference cycle
+func (g *DependencyGraph) Cycles() [][]string {
+	g.analyze()
+
+	var cycles [][]string
+	for _, component := range g.components {
+		if len(component) > 1 || g.hasSelfReference(component[0]) {
+			cycles = append(cycles, component)
+		}
+	}
+
+	return cycles
+}
+
+// CircularDependencies returns the dependencies that participate in a cycle
+func (g *DependencyGraph) CircularDependencies() []AssetDependency {
+	g.analyze()
+
+	var circular []AssetDependency
+	for _, name := range g.sortedPackages() {
+		for _, dep := range g.nodes[name].Dependencies {
+			if dep.IsCircular {
+				circular = append(circular, dep)
+			}
+		}
+	}
+
+	return circular
+}
+
+// LoadChain computes the transitive hard-reference closure of a package
+func (g *DependencyGraph) LoadChain(packageName string) (*LoadChain, bool) {
+	node, ok := g.nodes[packageName]
+	if !ok {
+		return nil, false
+	}
+
+	g.analyze()
+	closure := g.closureOf(packageName)
+	component := g.components[g.componentOf[packageName]]
+
+	return &LoadChain{
+		PackageName:       packageName,
+		FilePath:          node.FilePath,
+		OwnSize:           node.Size,
+		PackageCount:      len(closure.packages),
+		TotalBytes:        closure.bytes,
+		EstimatedLoadTime: float64(closure.bytes) / estimatedLoadBytesPerMS,
+		IsCircular:        len(component) > 1 || g.hasSelfReference(packageName),
+	}, true
+}
+
+// WorstOffenders returns the packages with the largest hard-reference closures
+func (g *DependencyGraph) WorstOffenders(limit int) []LoadChain {
+	chains := make([]LoadChain, 0, len(g.nodes))
+	for _, name := range g.sortedPackages() {
+		if chain, ok := g.LoadChain(name); ok {
+			chains = append(chains, *chain)
+		}
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		return chains[i].TotalBytes > chains[j].TotalBytes
+	})
+
+	if limit > 0 && len(chains) > limit {
+		chains = chains[:limit]
+	}
+
+	return chains
+}
+
+// Closure returns every package a package loads through hard references, including itself
+func (g *DependencyGraph) Closure(packageName string) []string {
+	if _, ok := g.nodes[packageName]; !ok {
+		return nil
+	}
+
+	g.analyze()
+	closure := g.closureOf(packageName)
+
+	packages := make([]string, 0, len(closure.packages))
+	for name := range closure.packages {
+		packages = append(packages, name)
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// Reachable returns every package a package references transitively through hard or soft
+// references, including itself. It follows the references Referencers follows backwards.
+func (g *DependencyGraph) Reachable(packageName string) []string {
+	if _, ok := g.nodes[packageName]; !ok {
+		return nil
+	}
+
+	visited := map[string]bool{packageName: true}
+	queue := []string{packageName}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		for _, dep := range g.nodes[name].Dependencies {
+			if target := g.resolve(dep.TargetAsset); target != "" && !visited[target] {
+				visited[target] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+
+	packages := make([]string, 0, len(visited))
+	for name := range visited {
+		packages = append(packages, name)
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// Referencers returns every package that transitively references the given package
+// through hard or soft references
+func (g *DependencyGraph) Referencers(packageName string) []string {
+	if g.referencers == nil {
+		g.referencers = make(map[string][]string)
+		for _, name := range g.sortedPackages() {
+			for _, dep := range g.nodes[name].Dependencies {
+				if target := g.resolve(dep.TargetAsset); target != "" && target != name {
+					g.referencers[target] = append(g.referencers[target], name)
+				}
+			}
+		}
+	}
+
+	visited := map[string]bool{packageName: true}
+	queue := []string{packageName}
+	var result []string
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		for _, referencer := range g.referencers[name] {
+			if visited[referencer] {
+				continue
+			}
+			visited[referencer] = true
+			result = append(result, referencer)
+			queue = append(queue, referencer)
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// analyze computes strongly connected components and annotates dependencies
+// with IsCircular and Weight
+func (g *DependencyGraph) analyze() {
+	if g.components != nil {
+		return
+	}
+
+	g.computeComponents()
+	g.closures = make(map[int]*closureSet)
+
+	for _, name := range g.sortedPackages() {
+		node := g.nodes[name]
+		sourceClosure := g.closureOf(name)
+
+		for i := range node.Dependencies {
+			dep := &node.Dependencies[i]
+			target := g.resolve(dep.TargetAsset)
+
+			// Components only follow hard edges, so a soft edge inside one isn't part of a cycle
+			dep.IsCircular = target != "" && dep.DependencyType == DependencyHard && g.componentOf[target] == g.componentOf[name]
+
+			// Weight is the share of the source's load chain reached through this edge
+			if target != "" && dep.DependencyType == DependencyHard && sourceClosure.bytes > 0 {
+				dep.Weight = float64(g.closureOf(target).bytes) / float64(sourceClosure.bytes)
+			}
+		}
+	}
+}
+
+// computeComponents runs Tarjan's algorithm over hard references, so every
+// package in a component shares the same load chain
+func (g *DependencyGraph) computeComponents() {
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+
+	g.components = make([][]string, 0)
+	g.componentOf = make(map[string]int)
+
+	var strongConnect func(name string)
+	strongConnect = func(name string) {
+		indices[name] = index
+		lowlink[name] = index
+		index++
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, dep := range g.nodes[name].Dependencies {
+			target := g.resolve(dep.TargetAsset)
+			if target == "" || dep.DependencyType != DependencyHard {
+				continue
+			}
+			if _, visited := indices[target]; !visited {
+				strongConnect(target)
+				lowlink[name] = min(lowlink[name], lowlink[target])
+			} else if onStack[target] {
+				lowlink[name] = min(lowlink[name], indices[target])
+			}
+		}
+
+		if lowlink[name] == indices[name] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				g.componentOf[top] = len(g.components)
+				component = append(component, top)
+				if top == name {
+					break
+				}
+			}
+			sort.Strings(component)
+			g.components = append(g.components, component)
+		}
+	}
+
+	for _, name := range g.sortedPackages() {
+		if _, visited := indices[name]; !visited {
+			strongConnect(name)
+		}
+	}
+}
+
+// closureOf returns the hard-reference closure of a package, shared by its component
+func (g *DependencyGraph) closureOf(packageName string) *closureSet {
+	componentID := g.componentOf[packageName]
+	if closure, ok := g.closures[componentID]; ok {
+		return closure
+	}
+
+	closure := &closureSet{packages: make(map[string]bool)}
+	queue := []string{packageName}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if closure.packages[name] {
+			continue
+		}
+		closure.packages[name] = true
+		closure.bytes += g.nodes[name].Size
+
+		for _, dep := range g.nodes[name].Dependencies {
+			if dep.DependencyType != DependencyHard {
+				continue
+			}
+			if target := g.resolve(dep.TargetAsset); target != "" && !closure.packages[target] {
+				queue = append(queue, target)
+			}
+		}
+	}
+
+	g.closures[componentID] = closure
+	return closure
+}
+
+// resolve maps a dependency target to a package in the graph
+func (g *DependencyGraph) resolve(target string) string {
+	if _, ok := g.nodes[target]; ok {
+		return target
+	}
+
+	packageName := packageNameFromReference(target)
+	if _, ok := g.nodes[packageName]; ok {
+		return packageName
+	}
+
+	return ""
+}
+
+func (g *DependencyGraph) hasSelfReference(packageName string) bool {
+	for _, dep := range g.nodes[packageName].Dependencies {
+		if dep.DependencyType == DependencyHard && g.resolve(dep.TargetAsset) == packageName {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *DependencyGraph) sortedPackages() []string {
+	names := make([]string, 0, len(g.nodes))
+	for name := range g.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,358 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	fbxASCIIVersionPattern    = regexp.MustCompile(`;\s*FBX\s+(\d+)\.(\d+)`)
+	fbxASCIIObjectPattern     = regexp.MustCompile(`(?m)^\s*(Model|Geometry|Material):\s*(?:(-?\d+)\s*,\s*)?"([^"]*)"\s*,\s*"([^"]*)"`)
+	fbxASCIIConnectionPattern = regexp.MustCompile(`(?m)^\s*(?:C|Connect):\s*"OO"\s*,\s*("[^"]*"|-?\d+)\s*,\s*("[^"]*"|-?\d+)`)
+)
+
+// fbxObject is a Model, Geometry or Material in the Objects section of an FBX file
+type fbxObject struct {
+	kind  string // Model, Geometry, Material
+	id    string
+	name  string
+	class string // Mesh, LimbNode, Root, Null...
+}
+
+// fbxScene is the object graph of an FBX file, enough to count and name its contents
+type fbxScene struct {
+	version  int
+	encoding string
+	objects  []fbxObject
+	parents  map[string]string // child object ID -> parent object ID
+}
+
+// analyzeFBX reads the node, mesh and material counts and the skeletons of an FBX file
+func analyzeFBX(filePath string, content []byte) (*AssetInfo, error) {
+	scene, err := parseFBX(content)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make(map[string]fbxObject)
+	nodes, meshes, materials, bones := 0, 0, 0, 0
+	for _, obj := range scene.objects {
+		switch obj.kind {
+		case "Model":
+			nodes++
+			models[obj.id] = obj
+			if isFBXBone(obj) {
+				bones++
+			}
+		case "Geometry":
+			if obj.class == "Mesh" {
+				meshes++
+			}
+		case "Material":
+			materials++
+		}
+	}
+
+	// A skeleton is named after its root: a bone whose parent isn't a bone
+	skeletons := []string{}
+	for id, obj := range models {
+		if !isFBXBone(obj) {
+			continue
+		}
+		if parent, ok := models[scene.parents[id]]; ok && isFBXBone(parent) {
+			continue
+		}
+		skeletons = append(skeletons, obj.name)
+	}
+	sort.Strings(skeletons)
+
+	info := newFormatAssetInfo(filePath, AssetTypeStaticMesh)
+	if len(skeletons) > 0 {
+		info.AssetType = AssetTypeSkeletalMesh
+	}
+	info.Properties["version"] = scene.version
+	info.Properties["encoding"] = scene.encoding
+	info.Properties["node_count"] = nodes
+	info.Properties["mesh_count"] = meshes
+	info.Properties["material_count"] = materials
+	info.Properties["bone_count"] = bones
+	info.Properties["skeletons"] = skeletons
+	info.Complexity = nodes + meshes*2 + materials
+	return info, nil
+}
+
+func checkFBX(filePath string, content []byte) []string {
+	if _, err := parseFBX(content); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func isFBXBone(obj fbxObject) bool {
+	return obj.kind == "Model" && (obj.class == "LimbNode" || obj.class == "Root")
+}
+
+// parseFBX reads the object graph of a binary or ASCII FBX file
+func parseFBX(content []byte) (*fbxScene, error) {
+	if bytes.HasPrefix(content, fbxBinaryMagic) {
+		return parseBinaryFBX(content)
+	}
+	return parseASCIIFBX(content)
+}
+
+func parseASCIIFBX(content []byte) (*fbxScene, error) {
+	head := content
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	if !bytes.Contains(head, []byte("FBXHeaderExtension")) && !fbxASCIIVersionPattern.Match(head) {
+		return nil, fmt.Errorf("missing FBX header")
+	}
+
+	scene := &fbxScene{encoding: "ascii", parents: make(map[string]string)}
+	if m := fbxASCIIVersionPattern.FindSubmatch(head); m != nil {
+		major, _ := strconv.Atoi(string(m[1]))
+		minor, _ := strconv.Atoi(string(m[2]))
+		scene.version = major*1000 + minor*100
+	}
+
+	for _, m := range fbxASCIIObjectPattern.FindAllSubmatch(content, -1) {
+		kind, name := string(m[1]), string(m[3])
+		id := string(m[2])
+		if id == "" {
+			id = name // FBX 6 connects objects by name
+		}
+		scene.objects = append(scene.objects, fbxObject{
+			kind:  kind,
+			id:    id,
+			name:  strings.TrimPrefix(name, kind+"::"),
+			class: string(m[4]),
+		})
+	}
+	for _, m := range fbxASCIIConnectionPattern.FindAllSubmatch(content, -1) {
+		scene.parents[strings.Trim(string(m[1]), `"`)] = strings.Trim(string(m[2]), `"`)
+	}
+
+	return scene, nil
+}
+
+// fbxReader walks the node records of a binary FBX file
+type fbxReader struct {
+	data    []byte
+	wide    bool // 7.5+ files use 64-bit offsets
+	nullLen int
+}
+
+// fbxNode is a node record header; properties start at propsOffset
+type fbxNode struct {
+	name        string
+	endOffset   int
+	numProps    int
+	propsOffset int
+	childOffset int
+}
+
+func parseBinaryFBX(content []byte) (*fbxScene, error) {
+	headerLen := len(fbxBinaryMagic) + 6
+	if len(content) < headerLen {
+		return nil, fmt.Errorf("truncated FBX header")
+	}
+
+	scene := &fbxScene{
+		version:  int(binary.LittleEndian.Uint32(content[len(fbxBinaryMagic)+2:])),
+		encoding: "binary",
+		parents:  make(map[string]string),
+	}
+	r := &fbxReader{data: content, wide: scene.version >= 7500, nullLen: 13}
+	if r.wide {
+		r.nullLen = 25
+	}
+
+	for offset := headerLen; ; {
+		node, ok, err := r.readNode(offset)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		switch node.name {
+		case "Objects":
+			err = r.eachChild(node, func(child *fbxNode) error {
+				if child.name != "Model" && child.name != "Geometry" && child.name != "Material" {
+					return nil
+				}
+				props, err := r.readProperties(child, 3)
+				if err != nil || len(props) < 2 {
+					return err
+				}
+				obj := fbxObject{kind: child.name, id: props[0], name: props[1]}
+				if idx := strings.Index(obj.name, "\x00\x01"); idx >= 0 {
+					obj.name = obj.name[:idx] // "Name\x00\x01Class"
+				}
+				if len(props) > 2 {
+					obj.class = props[2]
+				}
+				scene.objects = append(scene.objects, obj)
+				return nil
+			})
+		case "Connections":
+			err = r.eachChild(node, func(child *fbxNode) error {
+				if child.name != "C" {
+					return nil
+				}
+				props, err := r.readProperties(child, 3)
+				if err != nil || len(props) < 3 || props[0] != "OO" {
+					return err
+				}
+				scene.parents[props[1]] = props[2]
+				return nil
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+		offset = node.endOffset
+	}
+
+	return scene, nil
+}
+
+// readNode reads the node record at offset; ok is false at a null record or end of data
+func (r *fbxReader) readNode(offset int) (*fbxNode, bool, error) {
+	fieldLen := 4
+	if r.wide {
+		fieldLen = 8
+	}
+	headerLen := fieldLen*3 + 1
+	if offset+headerLen > len(r.data) {
+		return nil, false, nil // files may end without the final null record
+	}
+
+	field := func(i int) int {
+		at := offset + i*fieldLen
+		if r.wide {
+			return int(binary.LittleEndian.Uint64(r.data[at:]))
+		}
+		return int(binary.LittleEndian.Uint32(r.data[at:]))
+	}
+	endOffset := field(0)
+	if endOffset == 0 {
+		return nil, false, nil
+	}
+
+	nameLen := int(r.data[offset+headerLen-1])
+	nameStart := offset + headerLen
+	if endOffset <= offset || endOffset > len(r.data) || nameStart+nameLen > endOffset {
+		return nil, false, fmt.Errorf("FBX node at offset %d overruns the file", offset)
+	}
+
+	node := &fbxNode{
+		name:        string(r.data[nameStart : nameStart+nameLen]),
+		endOffset:   endOffset,
+		numProps:    field(1),
+		propsOffset: nameStart + nameLen,
+	}
+	node.childOffset = node.propsOffset + field(2)
+	if node.childOffset < node.propsOffset || node.childOffset > endOffset {
+		return nil, false, fmt.Errorf("FBX node %s properties overrun the node", node.name)
+	}
+	return node, true, nil
+}
+
+// eachChild calls fn for every nested node of a node
+func (r *fbxReader) eachChild(node *fbxNode, fn func(*fbxNode) error) error {
+	for offset := node.childOffset; offset+r.nullLen <= node.endOffset; {
+		child, ok, err := r.readNode(offset)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := fn(child); err != nil {
+			return err
+		}
+		offset = child.endOffset
+	}
+	return nil
+}
+
+// readProperties returns up to max leading properties of a node as strings; array
+// properties are skipped and read as ""
+func (r *fbxReader) readProperties(node *fbxNode, max int) ([]string, error) {
+	var props []string
+	offset := node.propsOffset
+	for i := 0; i < node.numProps && i < max; i++ {
+		if offset >= node.childOffset {
+			return nil, fmt.Errorf("FBX node %s has truncated properties", node.name)
+		}
+		typeCode := r.data[offset]
+		offset++
+
+		need := func(n int) bool { return offset+n <= node.childOffset }
+		switch typeCode {
+		case 'Y':
+			if !need(2) {
+				return nil, fmt.Errorf("FBX node %s has truncated properties", node.name)
+			}
+			props = append(props, strconv.Itoa(int(int16(binary.LittleEndian.Uint16(r.data[offset:])))))
+			offset += 2
+		case 'C':
+			if !need(1) {
+				return nil, fmt.Errorf("FBX node %s has truncated properties", node.name)
+			}
+			props = append(props, strconv.FormatBool(r.data[offset] != 0))
+			offset++
+		case 'I', 'F':
+			if !need(4) {
+				return nil, fmt.Errorf("FBX node %s has truncated properties", node.name)
+			}
+			bits := binary.LittleEndian.Uint32(r.data[offset:])
+			if typeCode == 'F' {
+				props = append(props, strconv.FormatFloat(float64(math.Float32frombits(bits)), 'g', -1, 32))
+			} else {
+				props = append(props, strconv.Itoa(int(int32(bits))))
+			}
+			offset += 4
+		case 'L', 'D':
+			if !need(8) {
+				return nil, fmt.Errorf("FBX node %s has truncated properties", node.name)
+			}
+			bits := binary.LittleEndian.Uint64(r.data[offset:])
+			if typeCode == 'D' {
+				props = append(props, strconv.FormatFloat(math.Float64frombits(bits), 'g', -1, 64))
+			} else {
+				props = append(props, strconv.FormatInt(int64(bits), 10))
+			}
+			offset += 8
+		case 'S', 'R':
+			if !need(4) {
+				return nil, fmt.Errorf("FBX node %s has truncated properties", node.name)
+			}
+			length := int(binary.LittleEndian.Uint32(r.data[offset:]))
+			offset += 4
+			if !need(length) {
+				return nil, fmt.Errorf("FBX node %s has truncated properties", node.name)
+			}
+			props = append(props, string(r.data[offset:offset+length]))
+			offset += length
+		case 'f', 'd', 'l', 'i', 'b':
+			if !need(12) {
+				return nil, fmt.Errorf("FBX node %s has truncated properties", node.name)
+			}
+			offset += 12 + int(binary.LittleEndian.Uint32(r.data[offset+8:]))
+			props = append(props, "")
+		default:
+			return nil, fmt.Errorf("FBX node %s has unknown property type %q", node.name, typeCode)
+		}
+	}
+	return props, nil
+}
@@ -0,0 +1,300 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path"
+	"strings"
+)
+
+var (
+	ue5PackageMagic = []byte{0xC1, 0x83, 0x2A, 0x9E} // 0x9E2A83C1 little-endian
+	fbxBinaryMagic  = []byte("Kaydara FBX Binary  \x00")
+	riffMagic       = []byte("RIFF")
+	psdMagic        = []byte("8BPS")
+	pngMagic        = []byte("\x89PNG\r\n\x1a\n")
+	jpegMagic       = []byte{0xFF, 0xD8, 0xFF}
+	exrMagic        = []byte{0x76, 0x2F, 0x31, 0x01}
+)
+
+// registerBuiltinFormats registers the engine and interchange formats the server understands
+func registerBuiltinFormats(r *Registry) {
+	ue := NewUE5AssetAnalyzer()
+	unity := NewUnityAssetAnalyzer()
+	godot := NewGodotAssetAnalyzer(".")
+
+	checkPackage := func(filePath string, header []byte, size int64) []string {
+		if err := ue.CheckPackage(header, size); err != nil {
+			return []string{fmt.Sprintf("package failed sanity checks: %v", err)}
+		}
+		return nil
+	}
+
+	// Unreal
+	r.Register(&FileFormat{
+		Name:        "unreal-asset",
+		Engine:      ProjectTypeUnreal,
+		Extensions:  []string{".uasset"},
+		Magic:       [][]byte{ue5PackageMagic},
+		Binary:      true,
+		Analyze:     ue.AnalyzeAsset,
+		CheckHeader: checkPackage,
+		HeaderBytes: PackageSummaryBytes,
+		Diff:        ue.DiffAsset,
+	})
+	r.Register(&FileFormat{
+		Name:        "unreal-map",
+		Engine:      ProjectTypeUnreal,
+		Extensions:  []string{".umap"},
+		AssetType:   AssetTypeLevel,
+		Binary:      true,
+		Analyze:     ue.AnalyzeAsset,
+		CheckHeader: checkPackage,
+		HeaderBytes: PackageSummaryBytes,
+	})
+	// Export and bulk data of a package carry no summary to analyze or check; the
+	// package's .uasset or .umap speaks for them
+	r.Register(&FileFormat{
+		Name:       "unreal-bulk",
+		Engine:     ProjectTypeUnreal,
+		Extensions: []string{".uexp", ".ubulk", ".uptnl"},
+		AssetType:  AssetTypeUnknown,
+		Binary:     true,
+	})
+
+	// Unity
+	r.Register(&FileFormat{
+		Name:       "unity-meta",
+		Engine:     ProjectTypeUnity,
+		Extensions: []string{".meta"},
+		Analyze:    unity.AnalyzeAsset,
+		Check: func(filePath string, content []byte) []string {
+			if _, err := unity.ParseMeta(content); err != nil {
+				return []string{fmt.Sprintf("invalid .meta file: %v", err)}
+			}
+			return nil
+		},
+	})
+	r.Register(&FileFormat{
+		Name:       "unity-yaml",
+		Engine:     ProjectTypeUnity,
+		Extensions: []string{".unity", ".prefab", ".mat", ".asset", ".anim", ".controller", ".overridecontroller"},
+		Magic:      [][]byte{[]byte("%YAML")},
+		Analyze:    unity.AnalyzeAsset,
+	})
+
+	// Godot
+	r.Register(&FileFormat{
+		Name:       "godot-resource",
+		Engine:     ProjectTypeGodot,
+		Extensions: []string{".tscn", ".tres"},
+		Magic:      [][]byte{[]byte("[gd_scene"), []byte("[gd_resource")},
+		Analyze:    godot.AnalyzeAsset,
+		Check: func(filePath string, content []byte) []string {
+			if _, err := godot.AnalyzeAsset(filePath, content); err != nil {
+				return []string{fmt.Sprintf("resource failed to parse: %v", err)}
+			}
+			return nil
+		},
+	})
+	r.Register(&FileFormat{
+		Name:       "godot-script",
+		Engine:     ProjectTypeGodot,
+		Extensions: []string{".gd", ".gdshader", ".gdshaderinc", ".godot"},
+		Analyze:    godot.AnalyzeAsset,
+	})
+
+	// Interchange formats
+	r.Register(&FileFormat{
+		Name:            "fbx",
+		Extensions:      []string{".fbx"},
+		Magic:           [][]byte{fbxBinaryMagic},
+		AssetType:       AssetTypeStaticMesh,
+		Binary:          true,
+		VersionMetadata: true,
+		Analyze:         analyzeFBX,
+		Check:           checkFBX,
+	})
+	r.Register(&FileFormat{
+		Name:       "wav",
+		Extensions: []string{".wav"},
+		Magic:      [][]byte{riffMagic},
+		AssetType:  AssetTypeSound,
+		Binary:     true,
+		Analyze:    analyzeWAV,
+		Check:      checkWAV,
+	})
+	r.Register(&FileFormat{
+		Name:            "psd",
+		Extensions:      []string{".psd", ".psb"},
+		Magic:           [][]byte{psdMagic},
+		AssetType:       AssetTypeTexture2D,
+		Binary:          true,
+		VersionMetadata: true,
+		Analyze:         analyzePSD,
+		Check:           checkPSD,
+	})
+	r.Register(&FileFormat{
+		Name:            "png",
+		Extensions:      []string{".png"},
+		Magic:           [][]byte{pngMagic},
+		AssetType:       AssetTypeTexture2D,
+		Binary:          true,
+		VersionMetadata: true,
+		Analyze:         textureAnalyzer(parsePNG),
+		Check:           textureCheck(parsePNG),
+	})
+	r.Register(&FileFormat{
+		Name:            "jpeg",
+		Extensions:      []string{".jpg", ".jpeg"},
+		Magic:           [][]byte{jpegMagic},
+		AssetType:       AssetTypeTexture2D,
+		Binary:          true,
+		VersionMetadata: true,
+		Analyze:         textureAnalyzer(parseJPEG),
+		Check:           textureCheck(parseJPEG),
+	})
+	r.Register(&FileFormat{
+		Name:            "tga",
+		Extensions:      []string{".tga"},
+		AssetType:       AssetTypeTexture2D,
+		Binary:          true,
+		VersionMetadata: true,
+		Analyze:         textureAnalyzer(parseTGA),
+		Check:           textureCheck(parseTGA),
+	})
+	r.Register(&FileFormat{
+		Name:            "exr",
+		Extensions:      []string{".exr"},
+		Magic:           [][]byte{exrMagic},
+		AssetType:       AssetTypeTexture2D,
+		Binary:          true,
+		VersionMetadata: true,
+		Analyze:         textureAnalyzer(parseEXR),
+		Check:           textureCheck(parseEXR),
+	})
+	r.Register(&FileFormat{
+		Name:       "image",
+		Extensions: []string{".bmp", ".gif"},
+		AssetType:  AssetTypeTexture2D,
+		Binary:     true,
+	})
+	r.Register(&FileFormat{
+		Name:       "model",
+		Extensions: []string{".obj", ".dae"},
+		AssetType:  AssetTypeStaticMesh,
+		Binary:     true,
+	})
+	r.Register(&FileFormat{
+		Name:       "audio",
+		Extensions: []string{".mp3", ".ogg", ".flac"},
+		AssetType:  AssetTypeSound,
+		Binary:     true,
+	})
+	r.Register(&FileFormat{
+		Name:       "video",
+		Extensions: []string{".mp4", ".avi", ".mov"},
+		Binary:     true,
+	})
+	r.Register(&FileFormat{
+		Name:       "executable",
+		Extensions: []string{".exe", ".dll", ".so", ".dylib"},
+		Binary:     true,
+	})
+	r.Register(&FileFormat{
+		Name:       "archive",
+		Extensions: []string{".zip", ".rar", ".7z"},
+		Binary:     true,
+	})
+}
+
+func newFormatAssetInfo(filePath string, assetType AssetType) *AssetInfo {
+	return &AssetInfo{
+		FilePath:     filePath,
+		AssetName:    strings.TrimSuffix(path.Base(filePath), path.Ext(filePath)),
+		AssetType:    assetType,
+		Properties:   make(map[string]interface{}),
+		Dependencies: make([]AssetDependency, 0),
+	}
+}
+
+// wavChunk is a RIFF chunk of a WAV file
+type wavChunk struct {
+	id     string
+	offset int // start of the chunk data
+	size   int
+}
+
+// wavChunks walks the RIFF chunks of a WAV file, reporting chunks that overrun it
+func wavChunks(content []byte) ([]wavChunk, error) {
+	if len(content) < 12 || !bytes.HasPrefix(content, riffMagic) || string(content[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a RIFF/WAVE file")
+	}
+
+	var chunks []wavChunk
+	for offset := 12; offset+8 <= len(content); {
+		chunk := wavChunk{
+			id:     string(content[offset : offset+4]),
+			offset: offset + 8,
+			size:   int(binary.LittleEndian.Uint32(content[offset+4:])),
+		}
+		if chunk.offset+chunk.size > len(content) {
+			return chunks, fmt.Errorf("chunk %q overruns the file", strings.TrimSpace(chunk.id))
+		}
+		chunks = append(chunks, chunk)
+		offset = chunk.offset + chunk.size + chunk.size%2
+	}
+	return chunks, nil
+}
+
+// analyzeWAV reads the sample format and duration of a WAV file
+func analyzeWAV(filePath string, content []byte) (*AssetInfo, error) {
+	chunks, err := wavChunks(content)
+	if err != nil && len(chunks) == 0 {
+		return nil, err
+	}
+
+	info := newFormatAssetInfo(filePath, AssetTypeSound)
+	var byteRate, dataSize int
+	for _, chunk := range chunks {
+		switch chunk.id {
+		case "fmt ":
+			if chunk.size < 16 {
+				continue
+			}
+			data := content[chunk.offset:]
+			info.Properties["audio_format"] = int(binary.LittleEndian.Uint16(data[0:]))
+			info.Properties["channels"] = int(binary.LittleEndian.Uint16(data[2:]))
+			info.Properties["sample_rate"] = int(binary.LittleEndian.Uint32(data[4:]))
+			info.Properties["bits_per_sample"] = int(binary.LittleEndian.Uint16(data[14:]))
+			byteRate = int(binary.LittleEndian.Uint32(data[8:]))
+		case "data":
+			dataSize = chunk.size
+		}
+	}
+	if byteRate > 0 {
+		info.Properties["duration_seconds"] = float64(dataSize) / float64(byteRate)
+	}
+	return info, nil
+}
+
+func checkWAV(filePath string, content []byte) []string {
+	chunks, err := wavChunks(content)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	seen := make(map[string]bool)
+	for _, chunk := range chunks {
+		seen[chunk.id] = true
+	}
+	if !seen["fmt "] {
+		problems = append(problems, "missing fmt chunk")
+	}
+	if !seen["data"] {
+		problems = append(problems, "missing data chunk")
+	}
+	return problems
+}
@@ -0,0 +1,410 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Godot asset types without a UE counterpart
+const (
+	AssetTypeScene AssetType = "Scene"
+)
+
+// GodotAssetAnalyzer analyzes Godot text scenes and resources, scripts and project.godot.
+// Assets are named by res:// path relative to the project root.
+type GodotAssetAnalyzer struct {
+	root           string
+	extensionTypes map[string]AssetType
+	resourceTypes  map[string]AssetType
+}
+
+var (
+	godotSectionPattern     = regexp.MustCompile(`^\[(\w+)(.*)\]\s*$`)
+	godotAttributePattern   = regexp.MustCompile(`(\w+)=("(?:[^"\\]|\\.)*"|[^\s\]]+)`)
+	godotExtResourcePattern = regexp.MustCompile(`ExtResource\(\s*"?([^")\s]+)"?\s*\)`)
+	godotPropertyPattern    = regexp.MustCompile(`^([\w/:]+)\s*=`)
+	godotResPathPattern     = regexp.MustCompile(`"\*?((?:res|uid)://[^"]+)"`)
+	godotPreloadPattern     = regexp.MustCompile(`(?:preload\(|^extends\s+)\s*"((?:res|uid)://[^"]+)"`)
+	godotLoadPattern        = regexp.MustCompile(`\bload\(\s*"((?:res|uid)://[^"]+)"`)
+	godotIncludePattern     = regexp.MustCompile(`^#include\s+"(res://[^"]+)"`)
+)
+
+// godotExtResource is an [ext_resource] entry of a scene or resource
+type godotExtResource struct {
+	id           string
+	path         string
+	uid          string
+	resourceType string
+}
+
+// NewGodotAssetAnalyzer creates an analyzer for a Godot project whose project.godot is in root
+func NewGodotAssetAnalyzer(root string) *GodotAssetAnalyzer {
+	analyzer := &GodotAssetAnalyzer{
+		root:           strings.TrimSuffix(root, "/"),
+		extensionTypes: make(map[string]AssetType),
+		resourceTypes:  make(map[string]AssetType),
+	}
+
+	analyzer.initializeTypeMappings()
+
+	return analyzer
+}
+
+// AnalyzeAsset analyzes a Godot file and extracts its resource references
+func (ga *GodotAssetAnalyzer) AnalyzeAsset(filePath string, content []byte) (*AssetInfo, error) {
+	resPath, _ := ga.AssetFor(filePath)
+	assetInfo := &AssetInfo{
+		FilePath:     filePath,
+		AssetName:    strings.TrimSuffix(path.Base(filePath), path.Ext(filePath)),
+		AssetType:    ga.determineAssetType(filePath),
+		PackageName:  resPath,
+		Properties:   make(map[string]interface{}),
+		Dependencies: make([]AssetDependency, 0),
+	}
+
+	switch ext := strings.ToLower(path.Ext(filePath)); {
+	case ext == ".tscn" || ext == ".tres":
+		if err := ga.analyzeTextResource(assetInfo, content); err != nil {
+			return assetInfo, err
+		}
+	case path.Base(filePath) == "project.godot":
+		ga.analyzeProjectFile(assetInfo, content)
+	case ext == ".gd" || ext == ".gdshader" || ext == ".gdshaderinc":
+		ga.analyzeScript(assetInfo, content)
+	}
+
+	assetInfo.Complexity = len(assetInfo.Dependencies) * 2
+	return assetInfo, nil
+}
+
+// ProjectType implements ProjectAnalyzer
+func (ga *GodotAssetAnalyzer) ProjectType() ProjectType {
+	return ProjectTypeGodot
+}
+
+// IsAsset reports whether a file is a project resource
+func (ga *GodotAssetAnalyzer) IsAsset(filePath string) bool {
+	if strings.HasSuffix(filePath, ".import") || strings.HasSuffix(filePath, ".uid") {
+		return false
+	}
+	_, ok := ga.AssetFor(filePath)
+	return ok
+}
+
+// AssetFor returns the res:// path of a resource, or of the resource a .import or .uid describes
+func (ga *GodotAssetAnalyzer) AssetFor(filePath string) (string, bool) {
+	rel := filePath
+	if ga.root != "." && ga.root != "" {
+		var ok bool
+		if rel, ok = strings.CutPrefix(filePath, ga.root+"/"); !ok {
+			return "", false
+		}
+	}
+
+	// .godot/ (Godot 4) and .import/ (Godot 3) hold the editor's import cache
+	for _, part := range strings.Split(rel, "/") {
+		if strings.HasPrefix(part, ".") {
+			return "", false
+		}
+	}
+
+	rel = strings.TrimSuffix(rel, ".import")
+	rel = strings.TrimSuffix(rel, ".uid")
+	return "res://" + rel, true
+}
+
+// ReferenceName strips autoload markers and sub-resource suffixes from a res:// path
+func (ga *GodotAssetAnalyzer) ReferenceName(target string) string {
+	target = strings.TrimPrefix(target, "*")
+	if idx := strings.Index(target, "::"); idx >= 0 {
+		target = target[:idx]
+	}
+	return target
+}
+
+// IsLevel is always false: Godot doesn't separate levels from other scenes
+func (ga *GodotAssetAnalyzer) IsLevel(filePath string) bool {
+	return false
+}
+
+// References returns the hard resource references of a file
+func (ga *GodotAssetAnalyzer) References(filePath string, content []byte) ([]AssetDependency, error) {
+	assetInfo, err := ga.AnalyzeAsset(filePath, content)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := make([]AssetDependency, 0, len(assetInfo.Dependencies))
+	for _, dep := range assetInfo.Dependencies {
+		if dep.DependencyType == DependencyHard {
+			deps = append(deps, dep)
+		}
+	}
+	return deps, nil
+}
+
+// IsIdentityFile reports whether a file can assign a UID: .uid and .import sidecars and
+// the headers of text scenes and resources
+func (ga *GodotAssetAnalyzer) IsIdentityFile(filePath string) bool {
+	if _, ok := ga.AssetFor(filePath); !ok {
+		return false
+	}
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".uid", ".import", ".tscn", ".tres":
+		return true
+	}
+	return false
+}
+
+// Identify returns the uid:// a file assigns to its resource, if any
+func (ga *GodotAssetAnalyzer) Identify(filePath string, content []byte) ([]string, error) {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".uid":
+		uid := strings.TrimSpace(string(content))
+		if !strings.HasPrefix(uid, "uid://") {
+			return nil, fmt.Errorf("%s doesn't contain a uid", filePath)
+		}
+		return []string{uid}, nil
+
+	case ".import":
+		// uid= sits in the [remap] section; Godot 3 imports have none
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "uid="); ok {
+				return []string{strings.Trim(value, `"`)}, nil
+			}
+		}
+		return nil, nil
+
+	default:
+		header, _, _ := bytes.Cut(content, []byte("\n"))
+		if match := godotSectionPattern.FindStringSubmatch(string(header)); match != nil {
+			if uid := godotAttributes(match[2])["uid"]; uid != "" {
+				return []string{uid}, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// analyzeTextResource parses the [ext_resource] entries of a .tscn/.tres and finds which
+// properties and node instances use them
+func (ga *GodotAssetAnalyzer) analyzeTextResource(assetInfo *AssetInfo, content []byte) error {
+	extResources := make(map[string]*godotExtResource)
+	var order []string
+	usedBy := make(map[string]string)
+	nodeCount := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if match := godotSectionPattern.FindStringSubmatch(line); match != nil {
+			attrs := godotAttributes(match[2])
+			switch match[1] {
+			case "gd_scene", "gd_resource":
+				if !first {
+					break
+				}
+				assetInfo.Properties["format"] = attrs["format"]
+				if uid := attrs["uid"]; uid != "" {
+					assetInfo.Properties["uid"] = uid
+				}
+				if resourceType := attrs["type"]; resourceType != "" {
+					assetInfo.Properties["resource_type"] = resourceType
+					if assetType, ok := ga.resourceTypes[resourceType]; ok {
+						assetInfo.AssetType = assetType
+					}
+				}
+			case "ext_resource":
+				res := &godotExtResource{id: attrs["id"], path: attrs["path"], uid: attrs["uid"], resourceType: attrs["type"]}
+				if _, exists := extResources[res.id]; !exists {
+					order = append(order, res.id)
+				}
+				extResources[res.id] = res
+			case "node":
+				nodeCount++
+				if ref := godotExtResourcePattern.FindStringSubmatch(attrs["instance"]); ref != nil && usedBy[ref[1]] == "" {
+					usedBy[ref[1]] = "instance"
+				}
+			}
+			first = false
+			continue
+		}
+		if first && line != "" {
+			return fmt.Errorf("not a Godot text resource")
+		}
+
+		// Attribute each ExtResource to the first property that uses it
+		for _, ref := range godotExtResourcePattern.FindAllStringSubmatch(line, -1) {
+			if usedBy[ref[1]] == "" {
+				if match := godotPropertyPattern.FindStringSubmatch(line); match != nil {
+					usedBy[ref[1]] = match[1]
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read resource: %w", err)
+	}
+
+	if nodeCount > 0 {
+		assetInfo.Properties["node_count"] = nodeCount
+	}
+	assetInfo.Properties["ext_resource_count"] = len(order)
+
+	for _, id := range order {
+		res := extResources[id]
+		if res.path == "" && res.uid == "" {
+			continue
+		}
+		assetInfo.Dependencies = append(assetInfo.Dependencies, AssetDependency{
+			SourceAsset:    assetInfo.PackageName,
+			TargetAsset:    res.path,
+			TargetID:       res.uid,
+			DependencyType: DependencyHard,
+			PropertyName:   usedBy[id],
+			Weight:         1.0,
+		})
+	}
+	return nil
+}
+
+// analyzeProjectFile collects the main scene, autoloads and other resources project.godot names
+func (ga *GodotAssetAnalyzer) analyzeProjectFile(assetInfo *AssetInfo, content []byte) {
+	seen := make(map[string]bool)
+	section := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := godotSectionPattern.FindStringSubmatch(line); match != nil {
+			section = match[1]
+			continue
+		}
+
+		property := ""
+		if match := godotPropertyPattern.FindStringSubmatch(line); match != nil {
+			property = match[1]
+		}
+		if section == "autoload" {
+			property = "autoload/" + property
+		}
+
+		for _, match := range godotResPathPattern.FindAllStringSubmatch(line, -1) {
+			if seen[match[1]] {
+				continue
+			}
+			seen[match[1]] = true
+			assetInfo.Dependencies = append(assetInfo.Dependencies, godotReference(assetInfo.PackageName, match[1], DependencyHard, property))
+			if property == "run/main_scene" {
+				assetInfo.Properties["main_scene"] = match[1]
+			}
+		}
+	}
+}
+
+// analyzeScript collects preload/extends (hard) and load (soft) references of a script or
+// the includes of a shader
+func (ga *GodotAssetAnalyzer) analyzeScript(assetInfo *AssetInfo, content []byte) {
+	seen := make(map[string]bool)
+	add := func(target string, depType DependencyType, property string) {
+		if seen[target] {
+			return
+		}
+		seen[target] = true
+		assetInfo.Dependencies = append(assetInfo.Dependencies, godotReference(assetInfo.PackageName, target, depType, property))
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#include") {
+			continue
+		}
+
+		for _, match := range godotPreloadPattern.FindAllStringSubmatch(line, -1) {
+			add(match[1], DependencyHard, "preload")
+		}
+		for _, match := range godotIncludePattern.FindAllStringSubmatch(line, -1) {
+			add(match[1], DependencyHard, "include")
+		}
+		for _, match := range godotLoadPattern.FindAllStringSubmatch(line, -1) {
+			add(match[1], DependencySoft, "load")
+		}
+	}
+}
+
+// godotReference builds a dependency on a res:// path or uid://
+func godotReference(source, target string, depType DependencyType, property string) AssetDependency {
+	dep := AssetDependency{
+		SourceAsset:    source,
+		TargetAsset:    target,
+		DependencyType: depType,
+		PropertyName:   property,
+		Weight:         1.0,
+	}
+	if strings.HasPrefix(target, "uid://") {
+		dep.TargetID = target
+	}
+	if depType == DependencySoft {
+		dep.Weight = 0.5
+	}
+	return dep
+}
+
+// godotAttributes parses key=value pairs of a section header, unquoting string values
+func godotAttributes(text string) map[string]string {
+	attrs := make(map[string]string)
+	for _, match := range godotAttributePattern.FindAllStringSubmatch(text, -1) {
+		value := match[2]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		attrs[match[1]] = value
+	}
+	return attrs
+}
+
+func (ga *GodotAssetAnalyzer) determineAssetType(filePath string) AssetType {
+	if assetType, ok := ga.extensionTypes[strings.ToLower(path.Ext(filePath))]; ok {
+		return assetType
+	}
+	return AssetTypeUnknown
+}
+
+func (ga *GodotAssetAnalyzer) initializeTypeMappings() {
+	extensions := map[AssetType][]string{
+		AssetTypeScene:      {".tscn", ".scn"},
+		AssetTypeDataAsset:  {".tres", ".res"},
+		AssetTypeScript:     {".gd", ".cs"},
+		AssetTypeShader:     {".gdshader", ".gdshaderinc"},
+		AssetTypeTexture2D:  {".png", ".svg", ".jpg", ".jpeg", ".webp", ".exr", ".hdr", ".tga"},
+		AssetTypeStaticMesh: {".glb", ".gltf", ".fbx", ".obj", ".blend"},
+		AssetTypeSound:      {".wav", ".ogg", ".mp3"},
+	}
+	for assetType, exts := range extensions {
+		for _, ext := range exts {
+			ga.extensionTypes[ext] = assetType
+		}
+	}
+
+	resources := map[AssetType][]string{
+		AssetTypeMaterial:  {"StandardMaterial3D", "ORMMaterial3D", "ShaderMaterial", "CanvasItemMaterial", "ParticleProcessMaterial"},
+		AssetTypeTexture2D: {"Texture2D", "AtlasTexture", "GradientTexture2D", "NoiseTexture2D", "ImageTexture"},
+		AssetTypeAnimation: {"Animation", "AnimationLibrary"},
+		AssetTypeSound:     {"AudioStreamWAV", "AudioStreamOggVorbis", "AudioStreamMP3"},
+	}
+	for assetType, names := range resources {
+		for _, name := range names {
+			ga.resourceTypes[name] = assetType
+		}
+	}
+}
@@ -0,0 +1,928 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+// Upper bound on strings read from package data, guards against corrupt lengths
+const maxSerializedStringLen = 64 * 1024
+
+// Package is a parsed UE package summary with its name, import and export tables
+type Package struct {
+	Header  *UAssetHeader
+	Names   []string
+	Imports []PackageImport
+	Exports []PackageExport
+
+	content []byte
+}
+
+// PackageImport is an entry of the package import table
+type PackageImport struct {
+	ClassPackage string `json:"class_package"`
+	ClassName    string `json:"class_name"`
+	OuterIndex   int32  `json:"outer_index"`
+	ObjectName   string `json:"object_name"`
+	PackageName  string `json:"package_name,omitempty"` // set for imports not outered to their package
+	Optional     bool   `json:"optional,omitempty"`
+}
+
+// PackageExport is an entry of the package export table
+type PackageExport struct {
+	Index         int    `json:"index"`
+	ClassIndex    int32  `json:"class_index"`
+	SuperIndex    int32  `json:"super_index"`
+	TemplateIndex int32  `json:"template_index"`
+	OuterIndex    int32  `json:"outer_index"`
+	ObjectName    string `json:"object_name"`
+	ObjectFlags   uint32 `json:"object_flags"`
+	SerialSize    int64  `json:"serial_size"`
+	SerialOffset  int64  `json:"serial_offset"`
+}
+
+// TaggedProperty is a property decoded from tagged property serialization
+type TaggedProperty struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	StructName string      `json:"struct_name,omitempty"`
+	InnerType  string      `json:"inner_type,omitempty"`
+	ArrayIndex int32       `json:"array_index"`
+	Size       int32       `json:"size"`
+	Value      interface{} `json:"value"`
+}
+
+// ParsePackage deserializes the package summary and the name, import and export tables
+// of a .uasset/.umap, using the table layouts of the package's object versions
+func (ua *UE5AssetAnalyzer) ParsePackage(content []byte) (*Package, error) {
+	header, err := ua.parseUAssetHeader(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse package summary: %w", err)
+	}
+
+	names, err := ua.extractNamesTable(content, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract names table: %w", err)
+	}
+
+	pkg := &Package{
+		Header:  header,
+		Names:   names,
+		content: content,
+	}
+
+	if err := pkg.readImports(); err != nil {
+		return nil, err
+	}
+	if err := pkg.readExports(); err != nil {
+		return nil, err
+	}
+
+	return pkg, nil
+}
+
+// ObjectName resolves a package index: positive values are exports, negative values imports
+func (p *Package) ObjectName(index int32) string {
+	switch {
+	case index > 0 && int(index) <= len(p.Exports):
+		return p.Exports[index-1].ObjectName
+	case index < 0 && int(-index) <= len(p.Imports):
+		return p.Imports[-index-1].ObjectName
+	}
+	return ""
+}
+
+// ExportByIndex returns the export a positive package index refers to
+func (p *Package) ExportByIndex(index int32) (*PackageExport, bool) {
+	if index <= 0 || int(index) > len(p.Exports) {
+		return nil, false
+	}
+	return &p.Exports[index-1], true
+}
+
+// ExportClass returns the class name of an export
+func (p *Package) ExportClass(export *PackageExport) string {
+	return p.ObjectName(export.ClassIndex)
+}
+
+// ExportData returns the serialized bytes of an export
+func (p *Package) ExportData(export *PackageExport) ([]byte, error) {
+	start, end := export.SerialOffset, export.SerialOffset+export.SerialSize
+	if export.SerialSize < 0 || start < 0 || end > int64(len(p.content)) {
+		return nil, fmt.Errorf("export %s has invalid serial range %d+%d", export.ObjectName, export.SerialOffset, export.SerialSize)
+	}
+	return p.content[start:end], nil
+}
+
+// ExportReader returns a reader positioned at the start of an export's tagged properties
+func (p *Package) ExportReader(export *PackageExport) (*PackageReader, error) {
+	data, err := p.ExportData(export)
+	if err != nil {
+		return nil, err
+	}
+	reader := &PackageReader{pkg: p, data: data}
+
+	// UE5 objects lead with a serialization control byte and, when flagged, an override operation
+	if p.Header.FileVersionUE5 >= ue5VersionPropertyTagExtension {
+		control, err := reader.ReadUint8()
+		if err != nil {
+			return nil, err
+		}
+		if control&propertyExtensionOverridable != 0 {
+			if err := reader.Skip(1); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return reader, nil
+}
+
+func (p *Package) readImports() error {
+	imports, err := p.tableEntries(p.Header.ImportOffset, p.Header.ImportCount, p.Header.importEntrySize(), "import")
+	if err != nil {
+		return err
+	}
+
+	p.Imports = make([]PackageImport, 0, len(imports))
+	for _, entry := range imports {
+		r := &PackageReader{pkg: p, data: entry}
+		imp := PackageImport{}
+		if imp.ClassPackage, err = r.ReadFName(); err != nil {
+			return fmt.Errorf("invalid import table: %w", err)
+		}
+		if imp.ClassName, err = r.ReadFName(); err != nil {
+			return fmt.Errorf("invalid import table: %w", err)
+		}
+		if imp.OuterIndex, err = r.ReadInt32(); err != nil {
+			return err
+		}
+		if imp.ObjectName, err = r.ReadFName(); err != nil {
+			return fmt.Errorf("invalid import table: %w", err)
+		}
+		if !p.Header.FilterEditorOnly() && p.Header.FileVersion >= ue4VersionNonOuterPackageImport {
+			if imp.PackageName, err = r.ReadFName(); err != nil {
+				return fmt.Errorf("invalid import table: %w", err)
+			}
+			if imp.PackageName == "None" {
+				imp.PackageName = ""
+			}
+		}
+		if p.Header.FileVersionUE5 >= ue5VersionOptionalResources {
+			if imp.Optional, err = r.ReadBool(); err != nil {
+				return err
+			}
+		}
+		p.Imports = append(p.Imports, imp)
+	}
+
+	return nil
+}
+
+func (p *Package) readExports() error {
+	exports, err := p.tableEntries(p.Header.ExportOffset, p.Header.ExportCount, p.Header.exportEntrySize(), "export")
+	if err != nil {
+		return err
+	}
+
+	p.Exports = make([]PackageExport, 0, len(exports))
+	for i, entry := range exports {
+		export, err := p.readExport(entry)
+		if err != nil {
+			return fmt.Errorf("invalid export table entry %d: %w", i+1, err)
+		}
+		export.Index = i + 1
+		p.Exports = append(p.Exports, export)
+	}
+
+	return nil
+}
+
+// readExport decodes the leading FObjectExport fields up to SerialOffset; the trailing
+// flags and dependency counts are covered by the entry size
+func (p *Package) readExport(entry []byte) (PackageExport, error) {
+	r := &PackageReader{pkg: p, data: entry}
+	export := PackageExport{}
+
+	var err error
+	if export.ClassIndex, err = r.ReadInt32(); err != nil {
+		return export, err
+	}
+	if export.SuperIndex, err = r.ReadInt32(); err != nil {
+		return export, err
+	}
+	if p.Header.FileVersion >= ue4VersionTemplateIndexInExports {
+		if export.TemplateIndex, err = r.ReadInt32(); err != nil {
+			return export, err
+		}
+	}
+	if export.OuterIndex, err = r.ReadInt32(); err != nil {
+		return export, err
+	}
+	if export.ObjectName, err = r.ReadFName(); err != nil {
+		return export, err
+	}
+	if export.ObjectFlags, err = r.ReadUint32(); err != nil {
+		return export, err
+	}
+
+	if p.Header.FileVersion >= ue4Version64BitExportSerialSizes {
+		if export.SerialSize, err = r.ReadInt64(); err != nil {
+			return export, err
+		}
+		export.SerialOffset, err = r.ReadInt64()
+		return export, err
+	}
+
+	size, err := r.ReadInt32()
+	if err != nil {
+		return export, err
+	}
+	offset, err := r.ReadInt32()
+	export.SerialSize, export.SerialOffset = int64(size), int64(offset)
+	return export, err
+}
+
+// tableEntries slices a fixed-stride table out of the package content
+func (p *Package) tableEntries(offset, count int32, stride int, table string) ([][]byte, error) {
+	if count == 0 {
+		return nil, nil
+	}
+	if offset <= 0 || count < 0 || int64(offset)+int64(count)*int64(stride) > int64(len(p.content)) {
+		return nil, fmt.Errorf("invalid %s table: %d entries at offset %d", table, count, offset)
+	}
+
+	entries := make([][]byte, count)
+	for i := range entries {
+		start := int(offset) + i*stride
+		entries[i] = p.content[start : start+stride]
+	}
+	return entries, nil
+}
+
+func (p *Package) name(index int32) string {
+	if index < 0 || int(index) >= len(p.Names) {
+		return ""
+	}
+	return p.Names[index]
+}
+
+// fname renders an FName; non-zero numbers are stored off by one (Node_3 is number 4)
+func (p *Package) fname(index, number int32) string {
+	name := p.name(index)
+	if number > 0 {
+		return fmt.Sprintf("%s_%d", name, number-1)
+	}
+	return name
+}
+
+// PackageReader reads serialized values from an export's data
+type PackageReader struct {
+	pkg  *Package
+	data []byte
+	pos  int
+}
+
+// Remaining returns the number of unread bytes
+func (r *PackageReader) Remaining() int {
+	return len(r.data) - r.pos
+}
+
+func (r *PackageReader) take(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, fmt.Errorf("unexpected end of export data at offset %d (need %d bytes)", r.pos, n)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// Skip advances past n bytes
+func (r *PackageReader) Skip(n int) error {
+	_, err := r.take(n)
+	return err
+}
+
+// ReadUint8 reads a single byte
+func (r *PackageReader) ReadUint8() (uint8, error) {
+	b, err := r.take(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// ReadInt32 reads a little-endian int32
+func (r *PackageReader) ReadInt32() (int32, error) {
+	b, err := r.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+// ReadUint32 reads a little-endian uint32
+func (r *PackageReader) ReadUint32() (uint32, error) {
+	b, err := r.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// ReadInt64 reads a little-endian int64
+func (r *PackageReader) ReadInt64() (int64, error) {
+	b, err := r.take(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(b)), nil
+}
+
+// ReadFloat32 reads a little-endian float
+func (r *PackageReader) ReadFloat32() (float32, error) {
+	v, err := r.ReadUint32()
+	return math.Float32frombits(v), err
+}
+
+// ReadFloat64 reads a little-endian double
+func (r *PackageReader) ReadFloat64() (float64, error) {
+	b, err := r.take(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+}
+
+// ReadBool reads a UBOOL, which archives serialize as 32 bits
+func (r *PackageReader) ReadBool() (bool, error) {
+	v, err := r.ReadUint32()
+	return v != 0, err
+}
+
+// ReadFName reads a name table index and instance number
+func (r *PackageReader) ReadFName() (string, error) {
+	index, err := r.ReadInt32()
+	if err != nil {
+		return "", err
+	}
+	number, err := r.ReadInt32()
+	if err != nil {
+		return "", err
+	}
+	if index < 0 || int(index) >= len(r.pkg.Names) {
+		return "", fmt.Errorf("name index %d out of range", index)
+	}
+	return r.pkg.fname(index, number), nil
+}
+
+// ReadFString reads a length-prefixed string; negative lengths are UTF-16
+func (r *PackageReader) ReadFString() (string, error) {
+	length, err := r.ReadInt32()
+	if err != nil {
+		return "", err
+	}
+	if length == 0 {
+		return "", nil
+	}
+	if length > maxSerializedStringLen || length < -maxSerializedStringLen {
+		return "", fmt.Errorf("string length %d out of range", length)
+	}
+
+	if length > 0 {
+		b, err := r.take(int(length))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\x00"), nil
+	}
+
+	b, err := r.take(int(-length) * 2)
+	if err != nil {
+		return "", err
+	}
+	units := make([]uint16, 0, -length)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, binary.LittleEndian.Uint16(b[i:]))
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\x00"), nil
+}
+
+// ReadGuid reads a 16-byte GUID as four uint32 components
+func (r *PackageReader) ReadGuid() (string, error) {
+	b, err := r.take(16)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%08X%08X%08X%08X",
+		binary.LittleEndian.Uint32(b[0:4]), binary.LittleEndian.Uint32(b[4:8]),
+		binary.LittleEndian.Uint32(b[8:12]), binary.LittleEndian.Uint32(b[12:16])), nil
+}
+
+// ReadObjectRef reads a package index and resolves it to an object name
+func (r *PackageReader) ReadObjectRef() (int32, string, error) {
+	index, err := r.ReadInt32()
+	if err != nil {
+		return 0, "", err
+	}
+	return index, r.pkg.ObjectName(index), nil
+}
+
+// ReadFText reads an FText, keeping only its source string
+func (r *PackageReader) ReadFText() (string, error) {
+	if _, err := r.ReadUint32(); err != nil { // flags
+		return "", err
+	}
+	historyType, err := r.ReadUint8()
+	if err != nil {
+		return "", err
+	}
+
+	switch int8(historyType) {
+	case -1: // None
+		hasCultureInvariant, err := r.ReadBool()
+		if err != nil || !hasCultureInvariant {
+			return "", err
+		}
+		return r.ReadFString()
+	case 0: // Base: namespace, key, source string
+		for i := 0; i < 2; i++ {
+			if _, err := r.ReadFString(); err != nil {
+				return "", err
+			}
+		}
+		return r.ReadFString()
+	}
+
+	return "", fmt.Errorf("unsupported text history type %d", int8(historyType))
+}
+
+// Property tag extension flag marking an overridable operation after the tag (UE5)
+const propertyExtensionOverridable = 0x02
+
+// Property tag flags of the complete type name layout (UE5.4+)
+const (
+	propertyTagHasArrayIndex      = 0x01
+	propertyTagHasPropertyGuid    = 0x02
+	propertyTagHasExtensions      = 0x04
+	propertyTagBoolTrue           = 0x10
+	maxPropertyTypeNameParameters = 64
+)
+
+// ReadTaggedProperties reads properties until the terminating None tag
+func (r *PackageReader) ReadTaggedProperties() ([]TaggedProperty, error) {
+	var properties []TaggedProperty
+
+	for {
+		name, err := r.ReadFName()
+		if err != nil {
+			return properties, err
+		}
+		if name == "None" {
+			return properties, nil
+		}
+
+		prop := TaggedProperty{Name: name}
+		var boolValue bool
+		if r.pkg.Header.FileVersionUE5 >= ue5VersionPropertyTagCompleteTypeName {
+			boolValue, err = r.readPropertyTag(&prop)
+		} else {
+			boolValue, err = r.readLegacyPropertyTag(&prop)
+		}
+		if err != nil {
+			return properties, err
+		}
+
+		if prop.Type == "BoolProperty" {
+			prop.Value = boolValue
+			properties = append(properties, prop)
+			continue
+		}
+
+		// Decode from a bounded sub-reader so a bad value can't desync the tag stream
+		valueData, err := r.take(int(prop.Size))
+		if err != nil {
+			return properties, err
+		}
+		value := &PackageReader{pkg: r.pkg, data: valueData}
+		prop.Value, _ = value.readPropertyValue(prop.Type, prop.StructName, prop.InnerType, int(prop.Size))
+
+		properties = append(properties, prop)
+	}
+}
+
+// readLegacyPropertyTag reads the rest of a tag whose type parameters follow the type name
+func (r *PackageReader) readLegacyPropertyTag(prop *TaggedProperty) (bool, error) {
+	var err error
+	if prop.Type, err = r.ReadFName(); err != nil {
+		return false, err
+	}
+	if prop.Size, err = r.ReadInt32(); err != nil {
+		return false, err
+	}
+	if prop.ArrayIndex, err = r.ReadInt32(); err != nil {
+		return false, err
+	}
+
+	var boolValue uint8
+	switch prop.Type {
+	case "StructProperty":
+		if prop.StructName, err = r.ReadFName(); err == nil {
+			err = r.Skip(16) // struct guid
+		}
+	case "BoolProperty":
+		boolValue, err = r.ReadUint8()
+	case "ByteProperty", "EnumProperty":
+		prop.StructName, err = r.ReadFName() // enum name
+	case "ArrayProperty", "SetProperty":
+		prop.InnerType, err = r.ReadFName()
+	case "MapProperty":
+		if prop.InnerType, err = r.ReadFName(); err == nil {
+			_, err = r.ReadFName() // value type
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	hasGuid, err := r.ReadUint8()
+	if err != nil {
+		return false, err
+	}
+	if hasGuid != 0 {
+		if err := r.Skip(16); err != nil {
+			return false, err
+		}
+	}
+
+	if r.pkg.Header.FileVersionUE5 >= ue5VersionPropertyTagExtension {
+		if err := r.skipPropertyExtensions(); err != nil {
+			return false, err
+		}
+	}
+	return boolValue != 0, nil
+}
+
+// readPropertyTag reads the rest of a tag that carries its complete type name, such as
+// ArrayProperty(StructProperty(Vector)), followed by a flags byte
+func (r *PackageReader) readPropertyTag(prop *TaggedProperty) (bool, error) {
+	typeName, err := r.readPropertyTypeName(0)
+	if err != nil {
+		return false, err
+	}
+	prop.Type = typeName.name
+
+	switch prop.Type {
+	case "StructProperty", "ByteProperty", "EnumProperty":
+		prop.StructName = typeName.parameter(0).name // struct or enum name
+	case "ArrayProperty", "SetProperty", "MapProperty":
+		inner := typeName.parameter(0)
+		prop.InnerType = inner.name
+		if inner.name == "StructProperty" {
+			prop.StructName = inner.parameter(0).name // element struct
+		}
+	}
+
+	if prop.Size, err = r.ReadInt32(); err != nil {
+		return false, err
+	}
+	flags, err := r.ReadUint8()
+	if err != nil {
+		return false, err
+	}
+	if flags&propertyTagHasArrayIndex != 0 {
+		if prop.ArrayIndex, err = r.ReadInt32(); err != nil {
+			return false, err
+		}
+	}
+	if flags&propertyTagHasPropertyGuid != 0 {
+		if err := r.Skip(16); err != nil {
+			return false, err
+		}
+	}
+	if flags&propertyTagHasExtensions != 0 {
+		if err := r.skipPropertyExtensions(); err != nil {
+			return false, err
+		}
+	}
+	return flags&propertyTagBoolTrue != 0, nil
+}
+
+// propertyTypeName is a node of a serialized FPropertyTypeName
+type propertyTypeName struct {
+	name       string
+	parameters []propertyTypeName
+}
+
+func (t propertyTypeName) parameter(i int) propertyTypeName {
+	if i < len(t.parameters) {
+		return t.parameters[i]
+	}
+	return propertyTypeName{}
+}
+
+// readPropertyTypeName reads a type name and its parameters, serialized depth first
+func (r *PackageReader) readPropertyTypeName(depth int) (propertyTypeName, error) {
+	var typeName propertyTypeName
+	if depth > maxPropertyTypeNameParameters {
+		return typeName, fmt.Errorf("property type name nested too deeply")
+	}
+
+	var err error
+	if typeName.name, err = r.ReadFName(); err != nil {
+		return typeName, err
+	}
+	count, err := r.ReadInt32()
+	if err != nil {
+		return typeName, err
+	}
+	if count < 0 || count > maxPropertyTypeNameParameters {
+		return typeName, fmt.Errorf("property type parameter count %d out of range", count)
+	}
+
+	for i := int32(0); i < count; i++ {
+		parameter, err := r.readPropertyTypeName(depth + 1)
+		if err != nil {
+			return typeName, err
+		}
+		typeName.parameters = append(typeName.parameters, parameter)
+	}
+	return typeName, nil
+}
+
+// skipPropertyExtensions skips the extension byte and the override operation it may flag
+func (r *PackageReader) skipPropertyExtensions() error {
+	extensions, err := r.ReadUint8()
+	if err != nil || extensions&propertyExtensionOverridable == 0 {
+		return err
+	}
+	return r.Skip(1 + 4) // operation, experimental overridable logic flag
+}
+
+// readPropertyValue decodes a single property value; unknown types yield nil
+func (r *PackageReader) readPropertyValue(propType, structName, innerType string, size int) (interface{}, error) {
+	switch propType {
+	case "IntProperty":
+		return r.ReadInt32()
+	case "UInt32Property":
+		return r.ReadUint32()
+	case "Int64Property":
+		return r.ReadInt64()
+	case "UInt64Property":
+		v, err := r.ReadInt64()
+		return uint64(v), err
+	case "FloatProperty":
+		return r.ReadFloat32()
+	case "DoubleProperty":
+		return r.ReadFloat64()
+	case "NameProperty":
+		return r.ReadFName()
+	case "StrProperty":
+		return r.ReadFString()
+	case "TextProperty":
+		return r.ReadFText()
+	case "ObjectProperty", "ClassProperty", "InterfaceProperty", "WeakObjectProperty", "LazyObjectProperty":
+		_, name, err := r.ReadObjectRef()
+		return name, err
+	case "SoftObjectProperty", "SoftClassProperty":
+		path, err := r.ReadFName()
+		if err != nil {
+			return nil, err
+		}
+		subPath, err := r.ReadFString()
+		if subPath != "" {
+			path += ":" + subPath
+		}
+		return path, err
+	case "ByteProperty":
+		if size == 1 {
+			return r.ReadUint8()
+		}
+		return r.ReadFName()
+	case "EnumProperty":
+		return r.ReadFName()
+	case "StructProperty":
+		return r.readStructValue(structName)
+	case "ArrayProperty":
+		return r.readArrayValue(innerType, structName)
+	}
+
+	return nil, nil
+}
+
+// ReadStruct decodes a struct value; native structs are read directly, others as tagged properties
+func (r *PackageReader) ReadStruct(structName string) (interface{}, error) {
+	return r.readStructValue(structName)
+}
+
+// readStructValue decodes common native structs and falls back to nested tagged properties
+func (r *PackageReader) readStructValue(structName string) (interface{}, error) {
+	switch structName {
+	case "EdGraphPinType":
+		return r.readPinType()
+	case "Guid":
+		return r.ReadGuid()
+	case "Vector", "Rotator":
+		// UE5 serializes these as doubles (large world coordinates)
+		values := make([]float64, 3)
+		for i := range values {
+			v, err := r.ReadFloat64()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	case "Vector2D":
+		values := make([]float64, 2)
+		for i := range values {
+			v, err := r.ReadFloat64()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	case "LinearColor":
+		values := make([]float32, 4)
+		for i := range values {
+			v, err := r.ReadFloat32()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	case "Color":
+		b, err := r.take(4)
+		if err != nil {
+			return nil, err
+		}
+		return []uint8{b[2], b[1], b[0], b[3]}, nil // stored BGRA
+	case "SimpleCurveKey":
+		return r.readCurveKey(false)
+	case "RichCurveKey":
+		return r.readCurveKey(true)
+	}
+
+	properties, err := r.ReadTaggedProperties()
+	if err != nil {
+		return nil, err
+	}
+	return PropertyMap(properties), nil
+}
+
+// readPinType reads a natively serialized FEdGraphPinType (UE5 editor layout)
+func (r *PackageReader) readPinType() (map[string]interface{}, error) {
+	pinType := make(map[string]interface{})
+
+	category, err := r.ReadFName()
+	if err != nil {
+		return nil, err
+	}
+	subCategory, err := r.ReadFName()
+	if err != nil {
+		return nil, err
+	}
+	_, subCategoryObject, err := r.ReadObjectRef()
+	if err != nil {
+		return nil, err
+	}
+	containerType, err := r.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+
+	pinType["PinCategory"] = category
+	pinType["PinSubCategory"] = subCategory
+	pinType["PinSubCategoryObject"] = subCategoryObject
+	pinType["ContainerType"] = containerType
+
+	if containerType == 3 { // map: terminal category, sub category, sub category object, 3 flags
+		valueCategory, err := r.ReadFName()
+		if err != nil {
+			return nil, err
+		}
+		pinType["PinValueType"] = valueCategory
+		if err := r.Skip(8 + 4 + 4*3); err != nil {
+			return nil, err
+		}
+	}
+
+	isReference, err := r.ReadBool()
+	if err != nil {
+		return nil, err
+	}
+	pinType["bIsReference"] = isReference
+
+	// bIsWeakPointer, member reference (parent, name, guid), bIsConst,
+	// bIsUObjectWrapper, bSerializeAsSinglePrecisionFloat
+	return pinType, r.Skip(4 + 4 + 8 + 16 + 4 + 4 + 4)
+}
+
+// readCurveKey reads a natively serialized FSimpleCurveKey or FRichCurveKey
+func (r *PackageReader) readCurveKey(rich bool) (map[string]interface{}, error) {
+	key := make(map[string]interface{})
+
+	if rich {
+		modes, err := r.take(3) // interp, tangent and tangent weight modes
+		if err != nil {
+			return nil, err
+		}
+		key["InterpMode"] = modes[0]
+		key["TangentMode"] = modes[1]
+		key["TangentWeightMode"] = modes[2]
+	}
+
+	fields := []string{"Time", "Value"}
+	if rich {
+		fields = append(fields, "ArriveTangent", "ArriveTangentWeight", "LeaveTangent", "LeaveTangentWeight")
+	}
+	for _, field := range fields {
+		v, err := r.ReadFloat32()
+		if err != nil {
+			return nil, err
+		}
+		key[field] = v
+	}
+
+	return key, nil
+}
+
+// readArrayValue decodes arrays of simple values and structs. structName is the element
+// struct when the tag carried it; older packages describe it with an inner tag instead.
+func (r *PackageReader) readArrayValue(innerType, structName string) (interface{}, error) {
+	count, err := r.ReadInt32()
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 || int(count) > r.Remaining() {
+		return nil, fmt.Errorf("array count %d out of range", count)
+	}
+
+	// Struct arrays carry a single inner tag describing the element struct
+	if innerType == "StructProperty" && r.pkg.Header.FileVersionUE5 < ue5VersionPropertyTagCompleteTypeName {
+		if _, err := r.ReadFName(); err != nil { // property name
+			return nil, err
+		}
+		if _, err := r.ReadFName(); err != nil { // StructProperty
+			return nil, err
+		}
+		if err := r.Skip(8); err != nil { // size + array index
+			return nil, err
+		}
+		if structName, err = r.ReadFName(); err != nil {
+			return nil, err
+		}
+		if err := r.Skip(17); err != nil { // struct guid + property guid flag
+			return nil, err
+		}
+		if r.pkg.Header.FileVersionUE5 >= ue5VersionPropertyTagExtension {
+			if err := r.skipPropertyExtensions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	values := make([]interface{}, 0, count)
+	for i := 0; i < int(count); i++ {
+		var value interface{}
+		var err error
+		switch innerType {
+		case "StructProperty":
+			value, err = r.readStructValue(structName)
+		case "BoolProperty":
+			var b uint8
+			b, err = r.ReadUint8()
+			value = b != 0
+		case "ByteProperty":
+			value, err = r.ReadUint8()
+		default:
+			value, err = r.readPropertyValue(innerType, "", "", 0)
+			if value == nil && err == nil {
+				return values, nil // element type we can't size
+			}
+		}
+		if err != nil {
+			return values, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// PropertyMap indexes tagged properties by name; static array elements get an [index] suffix
+func PropertyMap(properties []TaggedProperty) map[string]interface{} {
+	values := make(map[string]interface{}, len(properties))
+	for _, prop := range properties {
+		key := prop.Name
+		if prop.ArrayIndex > 0 {
+			key = fmt.Sprintf("%s[%d]", prop.Name, prop.ArrayIndex)
+		}
+		values[key] = prop.Value
+	}
+	return values
+}
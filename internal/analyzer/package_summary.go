@@ -0,0 +1,373 @@
+package analyzer
+
+import (
+	"fmt"
+)
+
+// packageFileTag is the magic number every .uasset/.umap summary starts with
+const packageFileTag = 0x9E2A83C1
+
+// Package flag the summary and import layout depend on
+const packageFlagFilterEditorOnly = 0x80000000
+
+// UE4 object versions (EUnrealEngineObjectUE4Version) that change the summary or table layout
+const (
+	ue4VersionOldestLoadable             = 214
+	ue4VersionLoadForEditorGame          = 365
+	ue4VersionSerializeTextInPackages    = 459
+	ue4VersionCookedAssetsInEditor       = 485
+	ue4VersionNameHashesSerialized       = 504
+	ue4VersionPreloadDependencies        = 507
+	ue4VersionTemplateIndexInExports     = 508
+	ue4Version64BitExportSerialSizes     = 511
+	ue4VersionPackageSummaryLocalization = 516
+	ue4VersionNonOuterPackageImport      = 520
+	ue4VersionLatest                     = 522
+)
+
+// UE5 object versions (EUnrealEngineObjectUE5Version) that change the summary or table layout
+const (
+	ue5VersionOptionalResources           = 1003
+	ue5VersionRemoveExportPackageGuid     = 1005
+	ue5VersionTrackExportIsInherited      = 1006
+	ue5VersionSoftObjectPathList          = 1008
+	ue5VersionScriptSerializationOffset   = 1010
+	ue5VersionPropertyTagExtension        = 1011
+	ue5VersionPropertyTagCompleteTypeName = 1012
+	ue5VersionMetadataSerializationOffset = 1014
+	ue5VersionPackageSavedHash            = 1016
+	ue5VersionLatest                      = 1017
+)
+
+// Oldest and newest legacy summary formats the reader understands: -2 is UE4.0,
+// -7 UE4.26, -8 UE5.0 and -9 the UE5 layout with the saved hash ahead of custom versions
+const (
+	oldestLegacyFileVersion = -2
+	newestLegacyFileVersion = -9
+)
+
+// Upper bounds on summary counts, guard against corrupt headers
+const (
+	maxCustomVersions = 4096
+	maxTableEntries   = 1 << 24
+)
+
+// PackageSummaryBytes is how many leading bytes of a package CheckPackage reads. Summaries
+// are a few kilobytes; even the largest custom version container fits well within this.
+const PackageSummaryBytes = 256 << 10
+
+// readPackageSummary deserializes FPackageFileSummary up to the object tables. Unversioned
+// (cooked) packages are read as the newest layout this reader knows.
+func readPackageSummary(content []byte) (*UAssetHeader, error) {
+	return parsePackageSummary(content, int64(len(content)))
+}
+
+// parsePackageSummary reads the summary from the leading bytes of a package of the given size
+func parsePackageSummary(content []byte, size int64) (*UAssetHeader, error) {
+	r := &PackageReader{data: content}
+	header := &UAssetHeader{}
+
+	if err := readSummaryFields(r, &header.Magic); err != nil {
+		return nil, fmt.Errorf("content too small for package summary: %w", err)
+	}
+	if header.Magic != packageFileTag {
+		return nil, fmt.Errorf("invalid UAsset magic number: 0x%x", header.Magic)
+	}
+
+	legacy, err := r.ReadInt32()
+	if err != nil {
+		return nil, err
+	}
+	if legacy > oldestLegacyFileVersion || legacy < newestLegacyFileVersion {
+		return nil, fmt.Errorf("unsupported legacy file version %d", legacy)
+	}
+	header.LegacyFileVersion = legacy
+
+	if legacy != -4 {
+		if _, err := r.ReadInt32(); err != nil { // LegacyUE3Version
+			return nil, err
+		}
+	}
+	if header.FileVersion, err = r.ReadInt32(); err != nil {
+		return nil, err
+	}
+	if legacy <= -8 {
+		if header.FileVersionUE5, err = r.ReadInt32(); err != nil {
+			return nil, err
+		}
+	}
+	if header.LicenseeVersion, err = r.ReadInt32(); err != nil {
+		return nil, err
+	}
+
+	if header.FileVersion == 0 && header.FileVersionUE5 == 0 && header.LicenseeVersion == 0 {
+		header.Unversioned = true
+		header.FileVersion = ue4VersionLatest
+		if legacy <= -8 {
+			header.FileVersionUE5 = ue5VersionLatest
+		}
+	}
+	if header.FileVersion < ue4VersionOldestLoadable {
+		return nil, fmt.Errorf("package version %d is older than the oldest loadable version", header.FileVersion)
+	}
+
+	if header.FileVersionUE5 >= ue5VersionPackageSavedHash {
+		if err := r.Skip(20); err != nil { // SavedHash
+			return nil, err
+		}
+		if header.TotalHeaderSize, err = r.ReadInt32(); err != nil {
+			return nil, err
+		}
+	}
+
+	if header.CustomVersionCount, err = skipCustomVersions(r, legacy); err != nil {
+		return nil, fmt.Errorf("failed to read custom versions: %w", err)
+	}
+
+	if header.FileVersionUE5 < ue5VersionPackageSavedHash {
+		if header.TotalHeaderSize, err = r.ReadInt32(); err != nil {
+			return nil, err
+		}
+	}
+	if header.FolderName, err = r.ReadFString(); err != nil {
+		return nil, fmt.Errorf("failed to read package name: %w", err)
+	}
+	if header.PackageFlags, err = r.ReadUint32(); err != nil {
+		return nil, err
+	}
+	if err := readSummaryFields(r, &header.NameCount, &header.NameOffset); err != nil {
+		return nil, err
+	}
+
+	if header.FileVersionUE5 >= ue5VersionSoftObjectPathList {
+		if err := readSummaryFields(r, &header.SoftObjectPathsCount, &header.SoftObjectPathsOffset); err != nil {
+			return nil, err
+		}
+	}
+	if !header.FilterEditorOnly() && header.FileVersion >= ue4VersionPackageSummaryLocalization {
+		if _, err := r.ReadFString(); err != nil { // LocalizationId
+			return nil, err
+		}
+	}
+	if header.FileVersion >= ue4VersionSerializeTextInPackages {
+		if err := r.Skip(8); err != nil { // GatherableTextDataCount, GatherableTextDataOffset
+			return nil, err
+		}
+	}
+	if header.FileVersionUE5 >= ue5VersionMetadataSerializationOffset {
+		if err := r.Skip(4); err != nil { // MetaDataOffset
+			return nil, err
+		}
+	}
+
+	if err := readSummaryFields(r,
+		&header.ExportCount, &header.ExportOffset,
+		&header.ImportCount, &header.ImportOffset,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := header.validate(size); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// CheckPackage is the upload gate for .uasset/.umap content. Every package must carry the
+// package magic; versioned packages in a layout this reader knows must also have a summary
+// whose tables fit the file. Unversioned (cooked) packages and packages saved by a newer
+// engine only get the magic check, so a reader that lags the engine never rejects good content.
+//
+// header holds the first PackageSummaryBytes of the package, or all of a smaller one, and
+// size is the size of the whole package.
+func (ua *UE5AssetAnalyzer) CheckPackage(header []byte, size int64) error {
+	r := &PackageReader{data: header}
+
+	var magic uint32
+	var legacy int32
+	if err := readSummaryFields(r, &magic, &legacy); err != nil || magic != packageFileTag {
+		return fmt.Errorf("missing package magic number")
+	}
+	if legacy > oldestLegacyFileVersion || legacy < newestLegacyFileVersion {
+		return nil
+	}
+
+	if legacy != -4 {
+		if err := r.Skip(4); err != nil { // LegacyUE3Version
+			return fmt.Errorf("content too small for package summary: %w", err)
+		}
+	}
+	var fileVersion, fileVersionUE5, licenseeVersion int32
+	if err := readSummaryFields(r, &fileVersion); err != nil {
+		return fmt.Errorf("content too small for package summary: %w", err)
+	}
+	if legacy <= -8 {
+		if err := readSummaryFields(r, &fileVersionUE5); err != nil {
+			return fmt.Errorf("content too small for package summary: %w", err)
+		}
+	}
+	if err := readSummaryFields(r, &licenseeVersion); err != nil {
+		return fmt.Errorf("content too small for package summary: %w", err)
+	}
+
+	unversioned := fileVersion == 0 && fileVersionUE5 == 0 && licenseeVersion == 0
+	if unversioned || fileVersion > ue4VersionLatest || fileVersionUE5 > ue5VersionLatest {
+		return nil
+	}
+
+	_, err := parsePackageSummary(header, size)
+	return err
+}
+
+// readSummaryFields reads consecutive 32-bit summary fields
+func readSummaryFields(r *PackageReader, fields ...interface{}) error {
+	for _, field := range fields {
+		switch f := field.(type) {
+		case *int32:
+			v, err := r.ReadInt32()
+			if err != nil {
+				return err
+			}
+			*f = v
+		case *uint32:
+			v, err := r.ReadUint32()
+			if err != nil {
+				return err
+			}
+			*f = v
+		}
+	}
+	return nil
+}
+
+// skipCustomVersions skips the custom version container, whose layout depends on the legacy version
+func skipCustomVersions(r *PackageReader, legacy int32) (int32, error) {
+	count, err := r.ReadInt32()
+	if err != nil {
+		return 0, err
+	}
+	if count < 0 || count > maxCustomVersions {
+		return 0, fmt.Errorf("custom version count %d out of range", count)
+	}
+
+	for i := int32(0); i < count; i++ {
+		switch {
+		case legacy == -2: // enum tag and version
+			err = r.Skip(8)
+		case legacy >= -5: // guid, version and friendly name
+			if err = r.Skip(20); err == nil {
+				_, err = r.ReadFString()
+			}
+		default: // guid and version
+			err = r.Skip(20)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// validate checks the table counts and offsets against the package size
+func (h *UAssetHeader) validate(size int64) error {
+	if h.TotalHeaderSize <= 0 || int64(h.TotalHeaderSize) > size {
+		return fmt.Errorf("total header size %d out of range for %d bytes", h.TotalHeaderSize, size)
+	}
+
+	tables := []struct {
+		name          string
+		count, offset int32
+	}{
+		{"name", h.NameCount, h.NameOffset},
+		{"import", h.ImportCount, h.ImportOffset},
+		{"export", h.ExportCount, h.ExportOffset},
+	}
+	for _, table := range tables {
+		if table.count < 0 || table.count > maxTableEntries {
+			return fmt.Errorf("%s count %d out of range", table.name, table.count)
+		}
+		if table.count > 0 && (table.offset <= 0 || table.offset >= h.TotalHeaderSize) {
+			return fmt.Errorf("%s table offset %d outside the %d byte header", table.name, table.offset, h.TotalHeaderSize)
+		}
+	}
+	return nil
+}
+
+// FilterEditorOnly reports whether editor-only data was stripped when the package was saved
+func (h *UAssetHeader) FilterEditorOnly() bool {
+	return h.PackageFlags&packageFlagFilterEditorOnly != 0
+}
+
+// importEntrySize returns the serialized size of an FObjectImport
+func (h *UAssetHeader) importEntrySize() int {
+	size := 8 + 8 + 4 + 8 // ClassPackage, ClassName, OuterIndex, ObjectName
+	if !h.FilterEditorOnly() && h.FileVersion >= ue4VersionNonOuterPackageImport {
+		size += 8 // PackageName
+	}
+	if h.FileVersionUE5 >= ue5VersionOptionalResources {
+		size += 4 // bImportOptional
+	}
+	return size
+}
+
+// exportEntrySize returns the serialized size of an FObjectExport
+func (h *UAssetHeader) exportEntrySize() int {
+	size := 4 + 4 // ClassIndex, SuperIndex
+	if h.FileVersion >= ue4VersionTemplateIndexInExports {
+		size += 4
+	}
+	size += 4 + 8 + 4 // OuterIndex, ObjectName, ObjectFlags
+	if h.FileVersion >= ue4Version64BitExportSerialSizes {
+		size += 16
+	} else {
+		size += 8
+	}
+	size += 12 // bForcedExport, bNotForClient, bNotForServer
+	if h.FileVersionUE5 < ue5VersionRemoveExportPackageGuid {
+		size += 16
+	}
+	if h.FileVersionUE5 >= ue5VersionTrackExportIsInherited {
+		size += 4
+	}
+	size += 4 // PackageFlags
+	if h.FileVersion >= ue4VersionLoadForEditorGame {
+		size += 4
+	}
+	if h.FileVersion >= ue4VersionCookedAssetsInEditor {
+		size += 4
+	}
+	if h.FileVersionUE5 >= ue5VersionOptionalResources {
+		size += 4 // bGeneratePublicHash
+	}
+	if h.FileVersion >= ue4VersionPreloadDependencies {
+		size += 20
+	}
+	if h.FileVersionUE5 >= ue5VersionScriptSerializationOffset {
+		size += 16
+	}
+	return size
+}
+
+// readNameTable reads the name map; entries carry two 16-bit hashes on newer versions
+func readNameTable(content []byte, header *UAssetHeader) ([]string, error) {
+	if header.NameCount == 0 {
+		return nil, nil
+	}
+
+	r := &PackageReader{data: content, pos: int(header.NameOffset)}
+	names := make([]string, header.NameCount)
+	for i := range names {
+		name, err := r.ReadFString()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read name %d: %w", i, err)
+		}
+		names[i] = name
+
+		if header.FileVersion >= ue4VersionNameHashesSerialized {
+			if err := r.Skip(4); err != nil {
+				return nil, fmt.Errorf("failed to read name %d hash: %w", i, err)
+			}
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,182 @@
+package analyzer
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// ProjectType identifies the engine a project is built with
+type ProjectType string
+
+const (
+	ProjectTypeUnreal ProjectType = "unreal"
+	ProjectTypeUnity  ProjectType = "unity"
+	ProjectTypeGodot  ProjectType = "godot"
+)
+
+// Asset issue kinds reported while indexing a project tree
+const (
+	IssueMissingMeta = "missing_meta" // Unity asset has no .meta committed alongside it
+	IssueInvalidID   = "invalid_id"   // identity file (.meta, .uid) assigns no parseable ID
+	IssueIDCollision = "id_collision" // two assets claim the same engine ID
+)
+
+// ProjectAnalyzer is the engine-specific analysis behind dependency graphs, impact
+// analysis and reference validation. Assets are keyed by graph name: the UE package
+// name, the Unity asset path or the Godot res:// path.
+type ProjectAnalyzer interface {
+	ProjectType() ProjectType
+
+	// IsAsset reports whether a file is a node in the dependency graph
+	IsAsset(filePath string) bool
+
+	// AssetFor returns the graph name of the asset a file is or belongs to, such as
+	// the package of a .uexp or the asset a Unity .meta describes
+	AssetFor(filePath string) (string, bool)
+
+	// ReferenceName normalizes a dependency target to a graph name
+	ReferenceName(target string) string
+
+	// IsLevel reports whether an asset is a level or scene players load directly
+	IsLevel(filePath string) bool
+
+	// AnalyzeAsset extracts metadata and dependencies from a file
+	AnalyzeAsset(filePath string, content []byte) (*AssetInfo, error)
+
+	// References returns the hard references of a file that make up graph edges
+	References(filePath string, content []byte) ([]AssetDependency, error)
+
+	// IsIdentityFile reports whether a file assigns stable IDs to its asset
+	IsIdentityFile(filePath string) bool
+
+	// Identify returns the IDs an identity file assigns to its asset (Unity GUID, Godot UID)
+	Identify(filePath string, content []byte) ([]string, error)
+}
+
+// CompanionChecker is implemented by analyzers whose engine requires companion files
+type CompanionChecker interface {
+	// MissingCompanions reports assets in a tree without their required companion files
+	MissingCompanions(paths []string) []AssetIssue
+}
+
+// ContentRooter is implemented by analyzers whose references can point outside the project,
+// such as UE packages importing engine plugin content
+type ContentRooter interface {
+	// ContentRoots returns the reference prefixes a tree's own assets live under
+	ContentRoots(paths []string) []string
+}
+
+// AssetIssue describes a problem found while indexing a project tree
+type AssetIssue struct {
+	Kind      string `json:"kind"`
+	AssetPath string `json:"asset_path"`
+	ID        string `json:"id,omitempty"`
+	Other     string `json:"other,omitempty"` // the other asset claiming a colliding ID
+}
+
+// DetectProjectType infers the engine of a project from its file paths
+func DetectProjectType(paths []string) ProjectType {
+	if GodotProjectRoot(paths) != "" {
+		return ProjectTypeGodot
+	}
+	if IsUnityProject(paths) {
+		return ProjectTypeUnity
+	}
+	return ProjectTypeUnreal
+}
+
+// GodotProjectRoot returns the directory holding the outermost project.godot, "." for the
+// repository root, or "" when the tree has none
+func GodotProjectRoot(paths []string) string {
+	depth := func(dir string) int {
+		if dir == "." {
+			return 0
+		}
+		return strings.Count(dir, "/") + 1
+	}
+
+	root := ""
+	for _, p := range paths {
+		if path.Base(p) != "project.godot" {
+			continue
+		}
+		if dir := path.Dir(p); root == "" || depth(dir) < depth(root) {
+			root = dir
+		}
+	}
+	return root
+}
+
+// ReferenceIndex records which assets and engine IDs exist in a project tree
+type ReferenceIndex struct {
+	analyzer ProjectAnalyzer
+	assets   map[string]string // graph name -> file path
+	ids      map[string]string // engine ID -> graph name
+	issues   []AssetIssue
+}
+
+// NewReferenceIndex creates an empty index for a project analyzer
+func NewReferenceIndex(analyzer ProjectAnalyzer) *ReferenceIndex {
+	return &ReferenceIndex{
+		analyzer: analyzer,
+		assets:   make(map[string]string),
+		ids:      make(map[string]string),
+	}
+}
+
+// AddAsset records an asset node
+func (idx *ReferenceIndex) AddAsset(name, filePath string) {
+	idx.assets[name] = filePath
+}
+
+// AddIDs records the IDs an asset claims, reporting IDs another asset already holds
+func (idx *ReferenceIndex) AddIDs(name string, ids []string) {
+	for _, id := range ids {
+		if owner, exists := idx.ids[id]; exists && owner != name {
+			idx.issues = append(idx.issues, AssetIssue{Kind: IssueIDCollision, AssetPath: name, ID: id, Other: owner})
+			continue
+		}
+		idx.ids[id] = name
+	}
+}
+
+// AddInvalid records an identity file that couldn't be parsed
+func (idx *ReferenceIndex) AddInvalid(name string) {
+	idx.issues = append(idx.issues, AssetIssue{Kind: IssueInvalidID, AssetPath: name})
+}
+
+// Has reports whether an asset exists
+func (idx *ReferenceIndex) Has(name string) bool {
+	_, ok := idx.assets[name]
+	return ok
+}
+
+// FilePath returns the file an asset was indexed from
+func (idx *ReferenceIndex) FilePath(name string) string {
+	return idx.assets[name]
+}
+
+// Issues returns invalid identity files and ID collisions, ordered by asset
+func (idx *ReferenceIndex) Issues() []AssetIssue {
+	issues := append([]AssetIssue(nil), idx.issues...)
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].AssetPath < issues[j].AssetPath })
+	return issues
+}
+
+// Resolve returns the graph name a dependency points at. Engine IDs win over paths,
+// matching how Unity and Godot load references.
+func (idx *ReferenceIndex) Resolve(dep AssetDependency) (string, bool) {
+	if dep.TargetID != "" {
+		if name, ok := idx.ids[dep.TargetID]; ok {
+			return name, true
+		}
+	}
+	if idx.Has(dep.TargetAsset) {
+		return dep.TargetAsset, true
+	}
+	if name := idx.analyzer.ReferenceName(dep.TargetAsset); idx.Has(name) {
+		return name, true
+	}
+	return "", false
+}
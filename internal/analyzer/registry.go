@@ -0,0 +1,237 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+)
+
+// HeaderSize is how many leading bytes Lookup needs to recognize a format by magic bytes
+const HeaderSize = 64
+
+// FileFormat declares a file format: the paths and leading bytes that identify it and the
+// analysis it provides. Analyze, Check, CheckHeader and Diff are optional; a format without
+// them only contributes its asset type and binary flag.
+type FileFormat struct {
+	Name       string
+	Engine     ProjectType // engine the format belongs to, empty for interchange formats
+	Extensions []string    // lower-case, including the dot
+	Magic      [][]byte    // leading bytes that identify the format whatever its extension
+	AssetType  AssetType   // reported when Analyze is missing or can't tell
+	Binary     bool
+
+	// VersionMetadata marks source formats whose analyzed properties (dimensions, mesh
+	// counts) are kept on every file version so history can show how they changed
+	VersionMetadata bool
+
+	// Analyze extracts the asset type, dependencies and properties of a file
+	Analyze func(filePath string, content []byte) (*AssetInfo, error)
+
+	// Check returns the integrity problems of a file, nil when it's sound
+	Check func(filePath string, content []byte) []string
+
+	// CheckHeader is Check for formats whose soundness shows in a bounded header. It gets
+	// the first HeaderBytes of a file and the file's size, so large files are checked
+	// without reading them whole. It takes precedence over Check.
+	CheckHeader func(filePath string, header []byte, size int64) []string
+	HeaderBytes int
+
+	// Diff describes how a file changed between two versions, one change per line. Without
+	// it, formats that Analyze are compared by their version metadata or dependencies.
+	Diff func(filePath string, from, to []byte) ([]string, error)
+}
+
+// Registry dispatches files to the format that handles them
+type Registry struct {
+	mu          sync.RWMutex
+	formats     []*FileFormat
+	byExtension map[string]*FileFormat
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		byExtension: make(map[string]*FileFormat),
+	}
+}
+
+var defaultRegistry = sync.OnceValue(func() *Registry {
+	registry := NewRegistry()
+	registerBuiltinFormats(registry)
+	return registry
+})
+
+// DefaultRegistry returns the shared registry holding the built-in formats
+func DefaultRegistry() *Registry {
+	return defaultRegistry()
+}
+
+// Register adds a format. A later format takes over extensions an earlier one declared.
+func (r *Registry) Register(format *FileFormat) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.formats = append(r.formats, format)
+	for _, ext := range format.Extensions {
+		r.byExtension[strings.ToLower(ext)] = format
+	}
+}
+
+// Formats returns the registered formats in registration order
+func (r *Registry) Formats() []*FileFormat {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*FileFormat(nil), r.formats...)
+}
+
+// Lookup returns the format of a file by extension, falling back to the magic bytes of
+// header when the extension is unknown. header may be nil; it returns nil for unknown files.
+func (r *Registry) Lookup(filePath string, header []byte) *FileFormat {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if format, ok := r.byExtension[formatExtension(filePath)]; ok {
+		return format
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	for _, format := range r.formats {
+		for _, magic := range format.Magic {
+			if bytes.HasPrefix(header, magic) {
+				return format
+			}
+		}
+	}
+	return nil
+}
+
+// AssetType returns the asset type a file's format declares, AssetTypeUnknown when none
+func (r *Registry) AssetType(filePath string) AssetType {
+	if format := r.Lookup(filePath, nil); format != nil && format.AssetType != "" {
+		return format.AssetType
+	}
+	return AssetTypeUnknown
+}
+
+// IsBinary reports whether a file should be treated as binary
+func (r *Registry) IsBinary(filePath string) bool {
+	format := r.Lookup(filePath, nil)
+	return format != nil && format.Binary
+}
+
+// IsEngineFile reports whether a file belongs to an engine's own formats
+func (r *Registry) IsEngineFile(filePath string, engine ProjectType) bool {
+	format := r.Lookup(filePath, nil)
+	return format != nil && format.Engine == engine
+}
+
+// Analyze runs the analysis of a file's format. It returns nil without an error when
+// no registered format analyzes the file.
+func (r *Registry) Analyze(filePath string, content []byte) (*AssetInfo, error) {
+	format := r.Lookup(filePath, leadingBytes(content))
+	if format == nil || format.Analyze == nil {
+		return nil, nil
+	}
+
+	info, err := format.Analyze(filePath, content)
+	if err != nil {
+		return nil, fmt.Errorf("%s analysis failed: %w", format.Name, err)
+	}
+	if (info.AssetType == "" || info.AssetType == AssetTypeUnknown) && format.AssetType != "" {
+		info.AssetType = format.AssetType
+	}
+	return info, nil
+}
+
+// VersionMetadata returns the properties kept on a file version for source formats such as
+// textures and FBX, nil for other files
+func (r *Registry) VersionMetadata(filePath string, content []byte) (map[string]interface{}, error) {
+	format := r.Lookup(filePath, leadingBytes(content))
+	if format == nil || !format.VersionMetadata || format.Analyze == nil {
+		return nil, nil
+	}
+
+	info, err := format.Analyze(filePath, content)
+	if err != nil {
+		return nil, fmt.Errorf("%s analysis failed: %w", format.Name, err)
+	}
+	return info.Properties, nil
+}
+
+// HasVersionMetadata reports whether a file's format keeps metadata on file versions
+func (r *Registry) HasVersionMetadata(filePath string) bool {
+	format := r.Lookup(filePath, nil)
+	return format != nil && format.VersionMetadata
+}
+
+// Analyzes reports whether a file's format has an analysis, recognizing the format by
+// extension or by header, the file's leading bytes
+func (r *Registry) Analyzes(filePath string, header []byte) bool {
+	format := r.Lookup(filePath, header)
+	return format != nil && format.Analyze != nil
+}
+
+// Check runs the integrity checks of a file's format and returns the problems found
+func (r *Registry) Check(filePath string, content []byte) []string {
+	format := r.Lookup(filePath, leadingBytes(content))
+	switch {
+	case format == nil:
+		return nil
+	case format.CheckHeader != nil:
+		header := content
+		if len(header) > format.HeaderBytes {
+			header = header[:format.HeaderBytes]
+		}
+		return format.CheckHeader(filePath, header, int64(len(content)))
+	case format.Check != nil:
+		return format.Check(filePath, content)
+	}
+	return nil
+}
+
+// CheckStream runs the integrity checks of a file's format on content of the given size
+// read from content. Formats that check a header only get their header read; the others
+// are read whole.
+func (r *Registry) CheckStream(filePath string, content io.Reader, size int64) ([]string, error) {
+	buffered := bufio.NewReaderSize(content, HeaderSize)
+	magic, err := buffered.Peek(HeaderSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	format := r.Lookup(filePath, magic)
+	switch {
+	case format == nil:
+		return nil, nil
+	case format.CheckHeader != nil:
+		header := make([]byte, format.HeaderBytes)
+		n, err := io.ReadFull(buffered, header)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return nil, err
+		}
+		return format.CheckHeader(filePath, header[:n], size), nil
+	case format.Check != nil:
+		whole, err := io.ReadAll(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return format.Check(filePath, whole), nil
+	}
+	return nil, nil
+}
+
+func formatExtension(filePath string) string {
+	return strings.ToLower(path.Ext(filePath))
+}
+
+func leadingBytes(content []byte) []byte {
+	if len(content) > HeaderSize {
+		return content[:HeaderSize]
+	}
+	return content
+}
@@ -0,0 +1,488 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// textureInfo is the metadata read from a source image header
+type textureInfo struct {
+	width        int
+	height       int
+	channels     int
+	bitDepth     int // bits per channel
+	hasAlpha     bool
+	colorMode    string
+	colorProfile string // embedded ICC profile description, "sRGB" for a PNG sRGB chunk
+}
+
+// properties returns the metadata in the shape kept on file versions
+func (t *textureInfo) properties() map[string]interface{} {
+	props := map[string]interface{}{
+		"width":     t.width,
+		"height":    t.height,
+		"channels":  t.channels,
+		"bit_depth": t.bitDepth,
+		"has_alpha": t.hasAlpha,
+	}
+	if t.colorMode != "" {
+		props["color_mode"] = t.colorMode
+	}
+	if t.colorProfile != "" {
+		props["color_profile"] = t.colorProfile
+	}
+	return props
+}
+
+// textureAnalyzer adapts an image header parser to FileFormat.Analyze
+func textureAnalyzer(parse func([]byte) (*textureInfo, error)) func(string, []byte) (*AssetInfo, error) {
+	return func(filePath string, content []byte) (*AssetInfo, error) {
+		texture, err := parse(content)
+		if err != nil {
+			return nil, err
+		}
+		info := newFormatAssetInfo(filePath, AssetTypeTexture2D)
+		info.Properties = texture.properties()
+		return info, nil
+	}
+}
+
+// textureCheck adapts an image header parser to FileFormat.Check
+func textureCheck(parse func([]byte) (*textureInfo, error)) func(string, []byte) []string {
+	return func(filePath string, content []byte) []string {
+		texture, err := parse(content)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		if texture.width == 0 || texture.height == 0 {
+			return []string{"image has zero width or height"}
+		}
+		return nil
+	}
+}
+
+// parsePNG reads the IHDR chunk and the color chunks that precede the image data
+func parsePNG(content []byte) (*textureInfo, error) {
+	if !bytes.HasPrefix(content, pngMagic) {
+		return nil, fmt.Errorf("missing PNG signature")
+	}
+	if len(content) < 33 || string(content[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("missing PNG IHDR chunk")
+	}
+
+	texture := &textureInfo{
+		width:    int(binary.BigEndian.Uint32(content[16:])),
+		height:   int(binary.BigEndian.Uint32(content[20:])),
+		bitDepth: int(content[24]),
+	}
+	switch content[25] {
+	case 0:
+		texture.channels, texture.colorMode = 1, "grayscale"
+	case 2:
+		texture.channels, texture.colorMode = 3, "rgb"
+	case 3:
+		texture.channels, texture.colorMode = 3, "indexed"
+	case 4:
+		texture.channels, texture.colorMode, texture.hasAlpha = 2, "grayscale", true
+	case 6:
+		texture.channels, texture.colorMode, texture.hasAlpha = 4, "rgb", true
+	default:
+		return nil, fmt.Errorf("invalid PNG color type %d", content[25])
+	}
+
+	for offset := 8; offset+8 <= len(content); {
+		length := int(binary.BigEndian.Uint32(content[offset:]))
+		chunkType := string(content[offset+4 : offset+8])
+		data := offset + 8
+		if data+length > len(content) {
+			return nil, fmt.Errorf("PNG chunk %s overruns the file", chunkType)
+		}
+
+		switch chunkType {
+		case "iCCP":
+			if end := bytes.IndexByte(content[data:data+length], 0); end > 0 {
+				texture.colorProfile = string(content[data : data+end])
+			}
+		case "sRGB":
+			if texture.colorProfile == "" {
+				texture.colorProfile = "sRGB"
+			}
+		case "tRNS":
+			texture.hasAlpha = true
+		case "IDAT", "IEND":
+			return texture, nil
+		}
+		offset = data + length + 4 // skip the CRC
+	}
+	return texture, nil
+}
+
+// parseJPEG reads the frame header and any embedded ICC profile of a JPEG
+func parseJPEG(content []byte) (*textureInfo, error) {
+	if !bytes.HasPrefix(content, jpegMagic) {
+		return nil, fmt.Errorf("missing JPEG SOI marker")
+	}
+
+	var texture *textureInfo
+	var icc []byte
+	for offset := 2; offset+4 <= len(content); {
+		if content[offset] != 0xFF {
+			return nil, fmt.Errorf("invalid JPEG marker at offset %d", offset)
+		}
+		marker := content[offset+1]
+		if marker == 0xFF { // fill byte
+			offset++
+			continue
+		}
+		if marker == 0xD9 || marker == 0xDA { // end of image, start of scan
+			break
+		}
+		length := int(binary.BigEndian.Uint16(content[offset+2:]))
+		segment := offset + 4
+		if length < 2 || segment+length-2 > len(content) {
+			return nil, fmt.Errorf("JPEG segment overruns the file")
+		}
+		data := content[segment : segment+length-2]
+
+		switch {
+		case marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC:
+			if len(data) < 6 {
+				return nil, fmt.Errorf("truncated JPEG frame header")
+			}
+			texture = &textureInfo{
+				bitDepth: int(data[0]),
+				height:   int(binary.BigEndian.Uint16(data[1:])),
+				width:    int(binary.BigEndian.Uint16(data[3:])),
+				channels: int(data[5]),
+			}
+		case marker == 0xE2 && bytes.HasPrefix(data, []byte("ICC_PROFILE\x00")) && len(data) > 14:
+			icc = append(icc, data[14:]...)
+		}
+		offset = segment + length - 2
+	}
+
+	if texture == nil {
+		return nil, fmt.Errorf("missing JPEG frame header")
+	}
+	switch texture.channels {
+	case 1:
+		texture.colorMode = "grayscale"
+	case 3:
+		texture.colorMode = "ycbcr"
+	case 4:
+		texture.colorMode = "cmyk"
+	}
+	texture.colorProfile = iccDescription(icc)
+	return texture, nil
+}
+
+// parseTGA reads the 18-byte Truevision TGA header
+func parseTGA(content []byte) (*textureInfo, error) {
+	if len(content) < 18 {
+		return nil, fmt.Errorf("truncated TGA header")
+	}
+
+	imageType := content[2]
+	pixelDepth := int(content[16])
+	alphaBits := int(content[17] & 0x0F)
+	texture := &textureInfo{
+		width:    int(binary.LittleEndian.Uint16(content[12:])),
+		height:   int(binary.LittleEndian.Uint16(content[14:])),
+		hasAlpha: alphaBits > 0,
+	}
+
+	switch imageType {
+	case 1, 9:
+		texture.channels, texture.bitDepth, texture.colorMode = 3, 8, "indexed"
+	case 2, 10:
+		texture.colorMode = "rgb"
+		switch pixelDepth {
+		case 32:
+			texture.channels, texture.bitDepth = 4, 8
+		case 24:
+			texture.channels, texture.bitDepth = 3, 8
+		case 15, 16:
+			texture.channels, texture.bitDepth = 3, 5
+			if texture.hasAlpha {
+				texture.channels = 4
+			}
+		default:
+			return nil, fmt.Errorf("invalid TGA pixel depth %d", pixelDepth)
+		}
+	case 3, 11:
+		texture.channels, texture.bitDepth, texture.colorMode = 1, pixelDepth, "grayscale"
+		if pixelDepth == 16 {
+			texture.channels, texture.bitDepth = 2, 8
+		}
+	default:
+		return nil, fmt.Errorf("unsupported TGA image type %d", imageType)
+	}
+	if texture.channels == 4 || texture.channels == 2 {
+		texture.hasAlpha = true
+	}
+
+	if 18+int(content[0]) > len(content) {
+		return nil, fmt.Errorf("truncated TGA image ID")
+	}
+	return texture, nil
+}
+
+// parseEXR reads the channel list and data window from the header of an OpenEXR image
+func parseEXR(content []byte) (*textureInfo, error) {
+	if !bytes.HasPrefix(content, exrMagic) {
+		return nil, fmt.Errorf("missing OpenEXR magic number")
+	}
+
+	texture := &textureInfo{colorMode: "linear"}
+	foundWindow := false
+	offset := 8
+	for {
+		name, next, ok := readCString(content, offset)
+		if !ok {
+			return nil, fmt.Errorf("truncated OpenEXR header")
+		}
+		if name == "" {
+			break
+		}
+		attrType, next, ok := readCString(content, next)
+		if !ok || next+4 > len(content) {
+			return nil, fmt.Errorf("truncated OpenEXR header")
+		}
+		size := int(binary.LittleEndian.Uint32(content[next:]))
+		value := next + 4
+		if size < 0 || value+size > len(content) {
+			return nil, fmt.Errorf("OpenEXR attribute %s overruns the file", name)
+		}
+		data := content[value : value+size]
+
+		switch {
+		case name == "channels" && attrType == "chlist":
+			texture.channels, texture.bitDepth, texture.hasAlpha = exrChannels(data)
+		case name == "dataWindow" && attrType == "box2i" && len(data) >= 16:
+			xMin := int32(binary.LittleEndian.Uint32(data[0:]))
+			yMin := int32(binary.LittleEndian.Uint32(data[4:]))
+			xMax := int32(binary.LittleEndian.Uint32(data[8:]))
+			yMax := int32(binary.LittleEndian.Uint32(data[12:]))
+			texture.width = int(xMax - xMin + 1)
+			texture.height = int(yMax - yMin + 1)
+			foundWindow = true
+		case name == "chromaticities":
+			texture.colorProfile = "custom chromaticities"
+		}
+		offset = value + size
+	}
+
+	if !foundWindow {
+		return nil, fmt.Errorf("OpenEXR header has no dataWindow")
+	}
+	return texture, nil
+}
+
+// exrChannels counts the channels of a chlist attribute and returns the widest pixel type
+func exrChannels(data []byte) (int, int, bool) {
+	channels, bitDepth, hasAlpha := 0, 0, false
+	for offset := 0; ; {
+		name, next, ok := readCString(data, offset)
+		if !ok || name == "" || next+16 > len(data) {
+			break
+		}
+		channels++
+		if name == "A" || strings.HasSuffix(name, ".A") {
+			hasAlpha = true
+		}
+		depth := 32 // UINT and FLOAT
+		if binary.LittleEndian.Uint32(data[next:]) == 1 {
+			depth = 16 // HALF
+		}
+		if depth > bitDepth {
+			bitDepth = depth
+		}
+		offset = next + 16
+	}
+	return channels, bitDepth, hasAlpha
+}
+
+// psdHeader is the fixed 26-byte header of a PSD/PSB file
+type psdHeader struct {
+	version   int
+	channels  int
+	height    int
+	width     int
+	depth     int
+	colorMode int
+}
+
+var psdColorModes = map[int]string{
+	0: "bitmap", 1: "grayscale", 2: "indexed", 3: "rgb", 4: "cmyk", 7: "multichannel", 8: "duotone", 9: "lab",
+}
+
+// Photoshop image resource holding the document's ICC profile
+const psdResourceICCProfile = 0x040F
+
+func parsePSDHeader(content []byte) (*psdHeader, error) {
+	if !bytes.HasPrefix(content, psdMagic) {
+		return nil, fmt.Errorf("missing 8BPS signature")
+	}
+	if len(content) < 26 {
+		return nil, fmt.Errorf("truncated PSD header")
+	}
+	return &psdHeader{
+		version:   int(binary.BigEndian.Uint16(content[4:])),
+		channels:  int(binary.BigEndian.Uint16(content[12:])),
+		height:    int(binary.BigEndian.Uint32(content[14:])),
+		width:     int(binary.BigEndian.Uint32(content[18:])),
+		depth:     int(binary.BigEndian.Uint16(content[22:])),
+		colorMode: int(binary.BigEndian.Uint16(content[24:])),
+	}, nil
+}
+
+// psdICCProfile returns the ICC profile in the image resources section of a PSD, if any
+func psdICCProfile(content []byte) []byte {
+	offset := 26
+	if offset+4 > len(content) {
+		return nil
+	}
+	offset += 4 + int(binary.BigEndian.Uint32(content[offset:])) // color mode data
+	if offset+4 > len(content) {
+		return nil
+	}
+	end := offset + 4 + int(binary.BigEndian.Uint32(content[offset:]))
+	if end > len(content) {
+		end = len(content)
+	}
+
+	for offset += 4; offset+7 <= end; {
+		id := int(binary.BigEndian.Uint16(content[offset+4:]))
+		nameLen := int(content[offset+6])
+		offset += 6 + (nameLen+2)&^1 // pascal string padded to even length
+		if offset+4 > end {
+			return nil
+		}
+		size := int(binary.BigEndian.Uint32(content[offset:]))
+		data := offset + 4
+		if data+size > end {
+			return nil
+		}
+		if id == psdResourceICCProfile {
+			return content[data : data+size]
+		}
+		offset = data + (size+1)&^1
+	}
+	return nil
+}
+
+// analyzePSD reads the dimensions, color format and profile of a Photoshop document
+func analyzePSD(filePath string, content []byte) (*AssetInfo, error) {
+	header, err := parsePSDHeader(content)
+	if err != nil {
+		return nil, err
+	}
+
+	texture := &textureInfo{
+		width:        header.width,
+		height:       header.height,
+		channels:     header.channels,
+		bitDepth:     header.depth,
+		colorMode:    psdColorModes[header.colorMode],
+		colorProfile: iccDescription(psdICCProfile(content)),
+	}
+	switch texture.colorMode {
+	case "rgb", "lab":
+		texture.hasAlpha = header.channels > 3
+	case "cmyk":
+		texture.hasAlpha = header.channels > 4
+	case "grayscale":
+		texture.hasAlpha = header.channels > 1
+	}
+
+	info := newFormatAssetInfo(filePath, AssetTypeTexture2D)
+	info.Properties = texture.properties()
+	info.Properties["large_document"] = header.version == 2
+	return info, nil
+}
+
+func checkPSD(filePath string, content []byte) []string {
+	header, err := parsePSDHeader(content)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	if header.version != 1 && header.version != 2 {
+		problems = append(problems, fmt.Sprintf("unsupported PSD version %d", header.version))
+	}
+	if header.width == 0 || header.height == 0 {
+		problems = append(problems, "PSD has zero width or height")
+	}
+	if header.channels < 1 || header.channels > 56 {
+		problems = append(problems, fmt.Sprintf("invalid PSD channel count %d", header.channels))
+	}
+	return problems
+}
+
+// iccDescription returns the description tag of an ICC profile, "" when absent or unreadable
+func iccDescription(profile []byte) string {
+	if len(profile) < 132 {
+		return ""
+	}
+
+	count := int(binary.BigEndian.Uint32(profile[128:]))
+	for i := 0; i < count; i++ {
+		entry := 132 + i*12
+		if entry+12 > len(profile) {
+			return ""
+		}
+		if string(profile[entry:entry+4]) != "desc" {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(profile[entry+4:]))
+		size := int(binary.BigEndian.Uint32(profile[entry+8:]))
+		if offset+size > len(profile) || size < 12 {
+			return ""
+		}
+		return decodeICCText(profile[offset : offset+size])
+	}
+	return ""
+}
+
+// decodeICCText decodes a textDescriptionType (ICC v2) or multiLocalizedUnicodeType (ICC v4) tag
+func decodeICCText(tag []byte) string {
+	switch string(tag[:4]) {
+	case "desc":
+		length := int(binary.BigEndian.Uint32(tag[8:]))
+		if 12+length > len(tag) {
+			return ""
+		}
+		return strings.TrimRight(string(tag[12:12+length]), "\x00")
+	case "mluc":
+		if len(tag) < 28 || binary.BigEndian.Uint32(tag[8:]) == 0 {
+			return ""
+		}
+		length := int(binary.BigEndian.Uint32(tag[20:]))
+		offset := int(binary.BigEndian.Uint32(tag[24:]))
+		if offset+length > len(tag) {
+			return ""
+		}
+		units := make([]uint16, length/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(tag[offset+i*2:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	}
+	return ""
+}
+
+// readCString reads a NUL-terminated string, returning the offset after the terminator
+func readCString(data []byte, offset int) (string, int, bool) {
+	if offset >= len(data) {
+		return "", offset, false
+	}
+	end := bytes.IndexByte(data[offset:], 0)
+	if end < 0 {
+		return "", offset, false
+	}
+	return string(data[offset : offset+end]), offset + end + 1, true
+}
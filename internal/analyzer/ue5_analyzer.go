@@ -1,8 +1,6 @@
 package analyzer
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
 	"path/filepath"
 	"regexp"
@@ -62,24 +60,34 @@ type AssetInfo struct {
 type AssetDependency struct {
 	SourceAsset    string         `json:"source_asset"`
 	TargetAsset    string         `json:"target_asset"`
+	TargetID       string         `json:"target_id,omitempty"` // Engine ID of the target (Unity GUID, Godot UID)
 	DependencyType DependencyType `json:"dependency_type"`
 	PropertyName   string         `json:"property_name,omitempty"`
 	IsCircular     bool           `json:"is_circular"`
 	Weight         float64        `json:"weight"`
 }
 
-// UAssetHeader represents the header of a .uasset file
+// UAssetHeader represents the header of a .uasset file: the FPackageFileSummary fields
+// up to the object tables
 type UAssetHeader struct {
-	Magic           uint32
-	FileVersion     int32
-	LicenseeVersion int32
-	PackageFlags    uint32
-	NameCount       int32
-	NameOffset      int32
-	ExportCount     int32
-	ExportOffset    int32
-	ImportCount     int32
-	ImportOffset    int32
+	Magic                 uint32
+	LegacyFileVersion     int32
+	FileVersion           int32 // UE4 object version
+	FileVersionUE5        int32 // UE5 object version, 0 before UE5
+	LicenseeVersion       int32
+	Unversioned           bool // cooked without versions; read as the newest known layout
+	CustomVersionCount    int32
+	TotalHeaderSize       int32
+	FolderName            string
+	PackageFlags          uint32
+	NameCount             int32
+	NameOffset            int32
+	SoftObjectPathsCount  int32
+	SoftObjectPathsOffset int32
+	ExportCount           int32
+	ExportOffset          int32
+	ImportCount           int32
+	ImportOffset          int32
 }
 
 // NewUE5AssetAnalyzer creates a new UE5 asset analyzer
@@ -140,7 +148,8 @@ func (ua *UE5AssetAnalyzer) ValidateAssetIntegrity(assetInfo *AssetInfo, availab
 	var missingDependencies []string
 
 	for _, dep := range assetInfo.Dependencies {
-		if !availableAssets[dep.TargetAsset] {
+		// References may name the package or an object inside it
+		if !availableAssets[dep.TargetAsset] && !availableAssets[ua.PackageNameFromReference(dep.TargetAsset)] {
 			missingDependencies = append(missingDependencies, dep.TargetAsset)
 		}
 	}
@@ -148,6 +157,110 @@ func (ua *UE5AssetAnalyzer) ValidateAssetIntegrity(assetInfo *AssetInfo, availab
 	return missingDependencies
 }
 
+// PackageNameForFile returns the package name other assets use to reference a file
+func (ua *UE5AssetAnalyzer) PackageNameForFile(filePath string) string {
+	return ua.extractPackageName(filePath)
+}
+
+// PackageNameFromReference strips the object name from an object path
+// /Game/Characters/Hero.Hero_C -> /Game/Characters/Hero
+func (ua *UE5AssetAnalyzer) PackageNameFromReference(reference string) string {
+	return packageNameFromReference(ua.normalizeAssetPath(reference))
+}
+
+// ProjectType implements ProjectAnalyzer
+func (ua *UE5AssetAnalyzer) ProjectType() ProjectType {
+	return ProjectTypeUnreal
+}
+
+// IsAsset reports whether a path is a referenceable package
+func (ua *UE5AssetAnalyzer) IsAsset(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return ext == ".uasset" || ext == ".umap"
+}
+
+// AssetFor returns the package a package file or its companion .uexp/.ubulk/.uptnl belongs to
+func (ua *UE5AssetAnalyzer) AssetFor(filePath string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".uasset", ".umap", ".uexp", ".ubulk", ".uptnl":
+		return ua.PackageNameForFile(filePath), true
+	}
+	return "", false
+}
+
+// ReferenceName implements ProjectAnalyzer
+func (ua *UE5AssetAnalyzer) ReferenceName(target string) string {
+	return ua.PackageNameFromReference(target)
+}
+
+// IsLevel reports whether a package is a map
+func (ua *UE5AssetAnalyzer) IsLevel(filePath string) bool {
+	return strings.EqualFold(filepath.Ext(filePath), ".umap")
+}
+
+// References returns the hard references of a package to content packages (/Game and plugin
+// mounts), one per target
+func (ua *UE5AssetAnalyzer) References(filePath string, content []byte) ([]AssetDependency, error) {
+	assetInfo, err := ua.AnalyzeAsset(filePath, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze %s: %w", filePath, err)
+	}
+
+	seen := make(map[string]bool)
+	deps := make([]AssetDependency, 0, len(assetInfo.Dependencies))
+	for _, dep := range assetInfo.Dependencies {
+		if dep.DependencyType != DependencyHard || !isContentPackage(dep.TargetAsset) || seen[dep.TargetAsset] {
+			continue
+		}
+		seen[dep.TargetAsset] = true
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+// ContentRoots returns the mount points a project's packages live under: /Game for Content/
+// and /<Plugin> for each project plugin with content
+func (ua *UE5AssetAnalyzer) ContentRoots(paths []string) []string {
+	seen := map[string]bool{"/Game/": true}
+	roots := []string{"/Game/"}
+	for _, filePath := range paths {
+		filePath = strings.ReplaceAll(filePath, "\\", "/")
+		idx := strings.Index(filePath, "/Content/")
+		if idx < 0 || !strings.HasPrefix(filePath, "Plugins/") {
+			continue
+		}
+		root := "/" + filepath.Base(filePath[:idx]) + "/"
+		if !seen[root] {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// IsIdentityFile is always false: packages are referenced by path
+func (ua *UE5AssetAnalyzer) IsIdentityFile(filePath string) bool {
+	return false
+}
+
+// Identify implements ProjectAnalyzer; UE packages carry no separate IDs
+func (ua *UE5AssetAnalyzer) Identify(filePath string, content []byte) ([]string, error) {
+	return nil, nil
+}
+
+func packageNameFromReference(reference string) string {
+	// Sub-object paths use ':' after the object name
+	if idx := strings.Index(reference, ":"); idx >= 0 {
+		reference = reference[:idx]
+	}
+
+	if idx := strings.LastIndex(reference, "."); idx > strings.LastIndex(reference, "/") {
+		reference = reference[:idx]
+	}
+
+	return reference
+}
+
 // CalculateComplexity estimates the complexity of an asset
 func (ua *UE5AssetAnalyzer) CalculateComplexity(assetInfo *AssetInfo) int {
 	complexity := 0
@@ -184,23 +297,15 @@ func (ua *UE5AssetAnalyzer) analyzeUAsset(assetInfo *AssetInfo, content []byte)
 		return assetInfo, fmt.Errorf("content too small to be a valid .uasset file")
 	}
 
-	// Parse UAsset header
-	header, err := ua.parseUAssetHeader(content)
-	if err != nil {
-		return assetInfo, fmt.Errorf("failed to parse UAsset header: %w", err)
-	}
-
-	// Extract names table for string references
-	names, err := ua.extractNamesTable(content, header)
+	// Parse the package summary, name and object tables
+	pkg, err := ua.ParsePackage(content)
 	if err != nil {
-		return assetInfo, fmt.Errorf("failed to extract names table: %w", err)
+		return assetInfo, err
 	}
+	names := pkg.Names
 
 	// Extract imports (external dependencies)
-	imports, err := ua.extractImports(content, header, names)
-	if err != nil {
-		return assetInfo, fmt.Errorf("failed to extract imports: %w", err)
-	}
+	imports := ua.extractImports(pkg)
 
 	// Convert imports to dependencies
 	for _, imp := range imports {
@@ -246,8 +351,18 @@ func (ua *UE5AssetAnalyzer) analyzeUMap(assetInfo *AssetInfo, content []byte) (*
 	// Extract level-specific information
 	// UMaps contain references to all actors and their assets
 
-	// Find asset references in the level data
-	assetRefs := ua.extractAssetReferencesFromLevel(content)
+	// Find asset references in the level data: the import table when the package
+	// parses, otherwise any /Game paths in the raw data
+	var assetRefs []string
+	if pkg, err := ua.ParsePackage(content); err == nil {
+		for _, imp := range ua.extractImports(pkg) {
+			if ua.isAssetReference(imp) {
+				assetRefs = append(assetRefs, imp)
+			}
+		}
+	} else {
+		assetRefs = ua.extractAssetReferencesFromLevel(content)
+	}
 
 	for _, ref := range assetRefs {
 		dependency := AssetDependency{
@@ -284,142 +399,23 @@ func (ua *UE5AssetAnalyzer) analyzeGenericAsset(assetInfo *AssetInfo, content []
 }
 
 func (ua *UE5AssetAnalyzer) parseUAssetHeader(content []byte) (*UAssetHeader, error) {
-	if len(content) < 32 {
-		return nil, fmt.Errorf("content too small for UAsset header")
-	}
-
-	header := &UAssetHeader{}
-	reader := bytes.NewReader(content)
-
-	if err := binary.Read(reader, binary.LittleEndian, &header.Magic); err != nil {
-		return nil, err
-	}
-
-	// Verify UE5 magic number
-	if header.Magic != 0x9E2A83C1 {
-		return nil, fmt.Errorf("invalid UAsset magic number: 0x%x", header.Magic)
-	}
-
-	// Read remaining header fields
-	if err := binary.Read(reader, binary.LittleEndian, &header.FileVersion); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(reader, binary.LittleEndian, &header.LicenseeVersion); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(reader, binary.LittleEndian, &header.PackageFlags); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(reader, binary.LittleEndian, &header.NameCount); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(reader, binary.LittleEndian, &header.NameOffset); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(reader, binary.LittleEndian, &header.ExportCount); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(reader, binary.LittleEndian, &header.ExportOffset); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(reader, binary.LittleEndian, &header.ImportCount); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(reader, binary.LittleEndian, &header.ImportOffset); err != nil {
-		return nil, err
-	}
-
-	return header, nil
+	return readPackageSummary(content)
 }
 
 func (ua *UE5AssetAnalyzer) extractNamesTable(content []byte, header *UAssetHeader) ([]string, error) {
-	if int(header.NameOffset) >= len(content) {
-		return nil, fmt.Errorf("invalid name offset")
-	}
-
-	names := make([]string, header.NameCount)
-	offset := int(header.NameOffset)
-
-	for i := 0; i < int(header.NameCount); i++ {
-		if offset+8 >= len(content) {
-			break
-		}
-
-		// Read string length
-		reader := bytes.NewReader(content[offset:])
-		var nameLen int32
-		if err := binary.Read(reader, binary.LittleEndian, &nameLen); err != nil {
-			break
-		}
-		offset += 4
-
-		if nameLen <= 0 || int(nameLen) > 1000 { // Reasonable limit
-			offset += 4 // Skip hash
-			continue
-		}
-
-		// Read string data
-		if offset+int(nameLen) > len(content) {
-			break
-		}
-
-		nameBytes := content[offset : offset+int(nameLen)-1] // -1 to exclude null terminator
-		names[i] = string(nameBytes)
-		offset += int(nameLen)
-
-		// Skip hash (4 bytes)
-		offset += 4
-	}
-
-	return names, nil
+	return readNameTable(content, header)
 }
 
-func (ua *UE5AssetAnalyzer) extractImports(content []byte, header *UAssetHeader, names []string) ([]string, error) {
-	if int(header.ImportOffset) >= len(content) {
-		return nil, fmt.Errorf("invalid import offset")
-	}
-
+// extractImports returns the packages a package imports; objects inside them are
+// reached through the package import they are outered to
+func (ua *UE5AssetAnalyzer) extractImports(pkg *Package) []string {
 	var imports []string
-	offset := int(header.ImportOffset)
-
-	for i := 0; i < int(header.ImportCount); i++ {
-		if offset+20 >= len(content) { // Minimum import entry size
-			break
+	for _, imp := range pkg.Imports {
+		if imp.ClassName == "Package" && imp.OuterIndex == 0 && imp.ObjectName != "" {
+			imports = append(imports, imp.ObjectName)
 		}
-
-		reader := bytes.NewReader(content[offset:])
-
-		// Read import entry structure (simplified)
-		var classPackage, className, packageName, objectName int32
-
-		if err := binary.Read(reader, binary.LittleEndian, &classPackage); err != nil {
-			break
-		}
-		if err := binary.Read(reader, binary.LittleEndian, &className); err != nil {
-			break
-		}
-		if err := binary.Read(reader, binary.LittleEndian, &packageName); err != nil {
-			break
-		}
-		if err := binary.Read(reader, binary.LittleEndian, &objectName); err != nil {
-			break
-		}
-
-		// Build import path from name indices
-		if packageName >= 0 && int(packageName) < len(names) && objectName >= 0 && int(objectName) < len(names) {
-			packageStr := names[packageName]
-			objectStr := names[objectName]
-
-			if packageStr != "" && objectStr != "" {
-				importPath := fmt.Sprintf("%s.%s", packageStr, objectStr)
-				imports = append(imports, importPath)
-			}
-		}
-
-		offset += 20 // Move to next import entry
 	}
-
-	return imports, nil
+	return imports
 }
 
 func (ua *UE5AssetAnalyzer) extractSoftReferences(content []byte) []string {
@@ -519,6 +515,14 @@ func (ua *UE5AssetAnalyzer) extractPackageName(filePath string) string {
 	// Normalize path separators
 	packagePath = strings.ReplaceAll(packagePath, "\\", "/")
 
+	// Content/ is mounted as /Game, plugin content as /<PluginName>
+	if strings.HasPrefix(packagePath, "Content/") {
+		packagePath = strings.TrimPrefix(packagePath, "Content/")
+	} else if idx := strings.Index(packagePath, "/Content/"); idx >= 0 && strings.HasPrefix(packagePath, "Plugins/") {
+		pluginName := filepath.Base(packagePath[:idx])
+		packagePath = "/" + pluginName + "/" + packagePath[idx+len("/Content/"):]
+	}
+
 	// Ensure it starts with /Game if it's a content asset
 	if !strings.HasPrefix(packagePath, "/") {
 		packagePath = "/Game/" + packagePath
@@ -550,9 +554,32 @@ func (ua *UE5AssetAnalyzer) normalizeAssetPath(assetPath string) string {
 	return assetPath
 }
 
+// engineMounts are package roots that never hold project content
+var engineMounts = map[string]bool{
+	"Script": true,
+	"Engine": true,
+	"Temp":   true,
+	"Memory": true,
+	"Config": true,
+}
+
+// isContentPackage reports whether a reference names a package under a content mount
+// such as /Game/ or a plugin's /<PluginName>/, rather than native script or engine packages
+func isContentPackage(reference string) bool {
+	if !strings.HasPrefix(reference, "/") {
+		return false
+	}
+	mount := reference[1:]
+	idx := strings.Index(mount, "/")
+	if idx <= 0 || idx == len(mount)-1 {
+		return false
+	}
+	return !engineMounts[mount[:idx]]
+}
+
 func (ua *UE5AssetAnalyzer) isAssetReference(reference string) bool {
 	// Check if the reference looks like an asset path
-	if strings.Contains(reference, "/Game/") {
+	if isContentPackage(reference) {
 		return true
 	}
 
@@ -0,0 +1,398 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Unity asset types without a UE counterpart
+const (
+	AssetTypePrefab             AssetType = "Prefab"
+	AssetTypeAnimatorController AssetType = "AnimatorController"
+	AssetTypeScript             AssetType = "Script"
+	AssetTypeShader             AssetType = "Shader"
+	AssetTypeFolder             AssetType = "Folder"
+)
+
+// UnityAssetAnalyzer analyzes Unity .meta files and YAML-serialized assets
+type UnityAssetAnalyzer struct {
+	extensionTypes map[string]AssetType
+}
+
+// UnityMeta is the parsed content of a .meta file
+type UnityMeta struct {
+	GUID              string `json:"guid"`
+	FileFormatVersion int    `json:"file_format_version"`
+	Importer          string `json:"importer"`
+	FolderAsset       bool   `json:"folder_asset"`
+}
+
+var (
+	unityReferencePattern = regexp.MustCompile(`\{\s*fileID:\s*(-?\d+)\s*,\s*guid:\s*([0-9a-fA-F]{32})\s*,\s*type:\s*(\d+)\s*\}`)
+	unityAssetGUIDPattern = regexp.MustCompile(`m_AssetGUID:\s*([0-9a-fA-F]{32})`)
+	unityDocumentPattern  = regexp.MustCompile(`^--- !u!(\d+) &(-?\d+)`)
+	unityKeyPattern       = regexp.MustCompile(`^\s*(?:-\s+)?([A-Za-z_][A-Za-z0-9_]*):`)
+	unityGUIDPattern      = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+)
+
+// GUIDs of Unity's built-in resources, which never have a .meta in the project
+var unityBuiltinGUIDs = map[string]bool{
+	"00000000000000000000000000000000": true,
+	"0000000000000000e000000000000000": true,
+	"0000000000000000f000000000000000": true,
+}
+
+// NewUnityAssetAnalyzer creates a new Unity asset analyzer
+func NewUnityAssetAnalyzer() *UnityAssetAnalyzer {
+	analyzer := &UnityAssetAnalyzer{
+		extensionTypes: make(map[string]AssetType),
+	}
+
+	analyzer.initializeExtensionTypes()
+
+	return analyzer
+}
+
+// AnalyzeAsset analyzes a Unity asset or .meta file and extracts its GUID references.
+// Dependency targets are "guid:<guid>" until resolved against a ReferenceIndex.
+func (ua *UnityAssetAnalyzer) AnalyzeAsset(filePath string, content []byte) (*AssetInfo, error) {
+	assetPath := strings.TrimSuffix(filePath, ".meta")
+	assetInfo := &AssetInfo{
+		FilePath:     filePath,
+		AssetName:    strings.TrimSuffix(path.Base(assetPath), path.Ext(assetPath)),
+		AssetType:    ua.determineAssetType(assetPath),
+		PackageName:  assetPath,
+		Properties:   make(map[string]interface{}),
+		Dependencies: make([]AssetDependency, 0),
+	}
+
+	// Importer settings can reference other assets (remapped materials, default script references)
+	if strings.HasSuffix(filePath, ".meta") {
+		meta, err := ua.ParseMeta(content)
+		if err != nil {
+			return assetInfo, err
+		}
+		assetInfo.Properties["guid"] = meta.GUID
+		assetInfo.Properties["importer"] = meta.Importer
+		if meta.FolderAsset {
+			assetInfo.AssetType = AssetTypeFolder
+		}
+		assetInfo.Dependencies = ua.extractReferences(assetPath, content, meta.GUID)
+		return assetInfo, nil
+	}
+
+	if !ua.IsSerializedAsset(filePath) {
+		return assetInfo, nil
+	}
+
+	if !bytes.HasPrefix(content, []byte("%YAML")) {
+		// Binary serialization (Asset Serialization Mode other than Force Text) isn't parsed
+		assetInfo.Properties["serialization"] = "binary"
+		return assetInfo, nil
+	}
+	assetInfo.Properties["serialization"] = "text"
+
+	classCounts, objectCount := ua.countObjects(content)
+	assetInfo.Properties["object_count"] = objectCount
+	assetInfo.Properties["classes"] = classCounts
+
+	assetInfo.Dependencies = ua.extractReferences(assetPath, content, "")
+	assetInfo.Complexity = objectCount/10 + len(assetInfo.Dependencies)*2
+
+	return assetInfo, nil
+}
+
+// ParseMeta reads the GUID and importer of a .meta file
+func (ua *UnityAssetAnalyzer) ParseMeta(content []byte) (*UnityMeta, error) {
+	meta := &UnityMeta{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "guid:"):
+			meta.GUID = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "guid:")))
+		case strings.HasPrefix(line, "fileFormatVersion:"):
+			fmt.Sscanf(strings.TrimSpace(strings.TrimPrefix(line, "fileFormatVersion:")), "%d", &meta.FileFormatVersion)
+		case strings.HasPrefix(line, "folderAsset:"):
+			meta.FolderAsset = strings.TrimSpace(strings.TrimPrefix(line, "folderAsset:")) == "yes"
+		case meta.Importer == "" && strings.HasSuffix(line, "Importer:") && !strings.HasPrefix(line, " "):
+			meta.Importer = strings.TrimSuffix(line, ":")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read .meta: %w", err)
+	}
+
+	if !unityGUIDPattern.MatchString(meta.GUID) {
+		return nil, fmt.Errorf(".meta has no valid guid")
+	}
+	return meta, nil
+}
+
+// IsSerializedAsset reports whether Unity serializes a file type as YAML in text mode
+func (ua *UnityAssetAnalyzer) IsSerializedAsset(filePath string) bool {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".unity", ".prefab", ".mat", ".asset", ".anim", ".controller", ".overridecontroller",
+		".physicmaterial", ".physicsmaterial2d", ".mask", ".playable", ".signal", ".lighting",
+		".rendertexture", ".cubemap", ".flare", ".guiskin", ".fontsettings", ".mixer",
+		".spriteatlas", ".terrainlayer", ".brush", ".preset", ".shadervariants", ".giparams":
+		return true
+	}
+	return false
+}
+
+// ProjectType implements ProjectAnalyzer
+func (ua *UnityAssetAnalyzer) ProjectType() ProjectType {
+	return ProjectTypeUnity
+}
+
+// IsAsset reports whether a file is an asset Unity tracks with a .meta
+func (ua *UnityAssetAnalyzer) IsAsset(filePath string) bool {
+	return RequiresMeta(filePath)
+}
+
+// AssetFor returns the asset path of an asset or of the asset a .meta describes
+func (ua *UnityAssetAnalyzer) AssetFor(filePath string) (string, bool) {
+	assetPath := strings.TrimSuffix(filePath, ".meta")
+	return assetPath, RequiresMeta(assetPath)
+}
+
+// ReferenceName implements ProjectAnalyzer; references resolve through GUIDs
+func (ua *UnityAssetAnalyzer) ReferenceName(target string) string {
+	return target
+}
+
+// IsLevel reports whether an asset is a scene
+func (ua *UnityAssetAnalyzer) IsLevel(filePath string) bool {
+	return strings.EqualFold(path.Ext(filePath), ".unity")
+}
+
+// References returns the hard GUID references of a serialized asset or .meta
+func (ua *UnityAssetAnalyzer) References(filePath string, content []byte) ([]AssetDependency, error) {
+	if !strings.HasSuffix(filePath, ".meta") && !ua.IsSerializedAsset(filePath) {
+		return nil, nil
+	}
+
+	assetInfo, err := ua.AnalyzeAsset(filePath, content)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := make([]AssetDependency, 0, len(assetInfo.Dependencies))
+	for _, dep := range assetInfo.Dependencies {
+		if dep.DependencyType == DependencyHard {
+			deps = append(deps, dep)
+		}
+	}
+	return deps, nil
+}
+
+// IsIdentityFile reports whether a file is the .meta of a tracked asset or folder
+func (ua *UnityAssetAnalyzer) IsIdentityFile(filePath string) bool {
+	return strings.HasSuffix(filePath, ".meta") && RequiresMeta(strings.TrimSuffix(filePath, ".meta"))
+}
+
+// Identify returns the "guid:<guid>" ID a .meta assigns
+func (ua *UnityAssetAnalyzer) Identify(filePath string, content []byte) ([]string, error) {
+	meta, err := ua.ParseMeta(content)
+	if err != nil {
+		return nil, err
+	}
+	return []string{"guid:" + meta.GUID}, nil
+}
+
+// MissingCompanions reports assets and folders committed without a .meta
+func (ua *UnityAssetAnalyzer) MissingCompanions(paths []string) []AssetIssue {
+	return ValidateMetaFiles(paths)
+}
+
+// IsUnityProject reports whether a project tree is a Unity project
+func IsUnityProject(paths []string) bool {
+	for _, p := range paths {
+		if p == "ProjectSettings/ProjectVersion.txt" || (strings.HasPrefix(p, "Assets/") && strings.HasSuffix(p, ".meta")) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresMeta reports whether Unity tracks a path with a .meta file
+func RequiresMeta(filePath string) bool {
+	if !strings.HasPrefix(filePath, "Assets/") || strings.HasSuffix(filePath, ".meta") {
+		return false
+	}
+	for _, part := range strings.Split(filePath, "/") {
+		if isUnityIgnoredName(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// isUnityIgnoredName matches the names Unity's asset database skips
+func isUnityIgnoredName(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") || lower == "cvs" || strings.HasSuffix(lower, ".tmp")
+}
+
+// MetaOwners returns every asset path, including folders, that needs a .meta for the given file
+func MetaOwners(filePath string) []string {
+	if !RequiresMeta(filePath) {
+		return nil
+	}
+
+	owners := []string{filePath}
+	for dir := path.Dir(filePath); dir != "Assets" && dir != "."; dir = path.Dir(dir) {
+		owners = append(owners, dir)
+	}
+	return owners
+}
+
+// ValidateMetaFiles checks that every asset in a tree has a .meta alongside it
+func ValidateMetaFiles(paths []string) []AssetIssue {
+	present := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		present[p] = true
+	}
+
+	seen := make(map[string]bool)
+	var issues []AssetIssue
+	for _, p := range paths {
+		for _, owner := range MetaOwners(p) {
+			if seen[owner] {
+				continue
+			}
+			seen[owner] = true
+			if !present[owner+".meta"] {
+				issues = append(issues, AssetIssue{Kind: IssueMissingMeta, AssetPath: owner})
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].AssetPath < issues[j].AssetPath })
+	return issues
+}
+
+// extractReferences collects {fileID, guid, type} references and Addressables GUIDs,
+// one dependency per target GUID. References to selfGUID and built-in resources are skipped.
+func (ua *UnityAssetAnalyzer) extractReferences(assetPath string, content []byte, selfGUID string) []AssetDependency {
+	deps := make([]AssetDependency, 0)
+	seen := make(map[string]int)
+
+	add := func(guid string, depType DependencyType, weight float64, property string) {
+		guid = strings.ToLower(guid)
+		if guid == selfGUID || unityBuiltinGUIDs[guid] {
+			return
+		}
+		if i, ok := seen[guid]; ok {
+			// A hard reference outranks a soft one to the same asset
+			if depType == DependencyHard && deps[i].DependencyType != DependencyHard {
+				deps[i].DependencyType = depType
+				deps[i].Weight = weight
+				deps[i].PropertyName = property
+			}
+			return
+		}
+		seen[guid] = len(deps)
+		deps = append(deps, AssetDependency{
+			SourceAsset:    assetPath,
+			TargetAsset:    "guid:" + guid,
+			TargetID:       "guid:" + guid,
+			DependencyType: depType,
+			PropertyName:   property,
+			Weight:         weight,
+		})
+	}
+
+	lastKey := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		key := ""
+		if match := unityKeyPattern.FindStringSubmatch(line); match != nil {
+			key = match[1]
+		}
+
+		// List items ("- {fileID: ...}") belong to the key above them
+		property := key
+		if property == "" || strings.HasPrefix(strings.TrimSpace(line), "- {") {
+			property = lastKey
+		}
+
+		for _, match := range unityReferencePattern.FindAllStringSubmatch(line, -1) {
+			add(match[2], DependencyHard, 1.0, property)
+		}
+		// Addressables AssetReference fields load on demand
+		for _, match := range unityAssetGUIDPattern.FindAllStringSubmatch(line, -1) {
+			add(match[1], DependencySoft, 0.5, property)
+		}
+
+		if key != "" {
+			lastKey = key
+		}
+	}
+
+	return deps
+}
+
+// countObjects counts the serialized objects in a YAML asset by class ID
+func (ua *UnityAssetAnalyzer) countObjects(content []byte) (map[string]int, int) {
+	classCounts := make(map[string]int)
+	total := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	expectClass := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if unityDocumentPattern.MatchString(line) {
+			total++
+			expectClass = true
+			continue
+		}
+		if expectClass {
+			// The line after a document header names the object's class
+			classCounts[strings.TrimSuffix(strings.TrimSpace(line), ":")]++
+			expectClass = false
+		}
+	}
+
+	return classCounts, total
+}
+
+func (ua *UnityAssetAnalyzer) determineAssetType(assetPath string) AssetType {
+	if assetType, ok := ua.extensionTypes[strings.ToLower(path.Ext(assetPath))]; ok {
+		return assetType
+	}
+	return AssetTypeUnknown
+}
+
+func (ua *UnityAssetAnalyzer) initializeExtensionTypes() {
+	types := map[AssetType][]string{
+		AssetTypeLevel:              {".unity"},
+		AssetTypePrefab:             {".prefab"},
+		AssetTypeMaterial:           {".mat"},
+		AssetTypeDataAsset:          {".asset"},
+		AssetTypeAnimation:          {".anim"},
+		AssetTypeAnimatorController: {".controller", ".overridecontroller"},
+		AssetTypeScript:             {".cs"},
+		AssetTypeShader:             {".shader", ".shadergraph", ".hlsl", ".cginc"},
+		AssetTypeTexture2D:          {".png", ".tga", ".psd", ".jpg", ".jpeg", ".exr", ".tif", ".tiff"},
+		AssetTypeStaticMesh:         {".fbx", ".obj", ".blend"},
+		AssetTypeSound:              {".wav", ".ogg", ".mp3", ".aiff"},
+	}
+
+	for assetType, extensions := range types {
+		for _, ext := range extensions {
+			ua.extensionTypes[ext] = assetType
+		}
+	}
+}
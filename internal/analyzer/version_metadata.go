@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Counted FBX contents, in the order changes are reported
+var versionCounts = []struct {
+	key   string
+	label string
+}{
+	{"node_count", "nodes"},
+	{"mesh_count", "meshes"},
+	{"material_count", "materials"},
+	{"bone_count", "bones"},
+}
+
+// SummarizeVersionMetadata describes the version metadata of a file in one line, such as
+// "4096x4096 (4K) rgb 8-bit with alpha, sRGB" or "12 nodes, 3 meshes, 2 materials, skeleton Hips"
+func SummarizeVersionMetadata(meta map[string]interface{}) string {
+	if len(meta) == 0 {
+		return ""
+	}
+
+	var parts []string
+	if width, ok := metaInt(meta, "width"); ok {
+		height, _ := metaInt(meta, "height")
+		texture := fmt.Sprintf("%dx%d (%s)", width, height, resolutionLabel(width, height))
+		if mode := metaString(meta, "color_mode"); mode != "" {
+			texture += " " + mode
+		}
+		if depth, ok := metaInt(meta, "bit_depth"); ok && depth > 0 {
+			texture += fmt.Sprintf(" %d-bit", depth)
+		}
+		if alpha, _ := meta["has_alpha"].(bool); alpha {
+			texture += " with alpha"
+		}
+		parts = append(parts, texture)
+		if profile := metaString(meta, "color_profile"); profile != "" {
+			parts = append(parts, profile)
+		}
+	}
+
+	for _, count := range versionCounts {
+		if n, ok := metaInt(meta, count.key); ok && n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, count.label))
+		}
+	}
+	if skeletons := metaStrings(meta, "skeletons"); len(skeletons) > 0 {
+		parts = append(parts, "skeleton "+strings.Join(skeletons, ", "))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// DescribeVersionChanges lists how the version metadata of a file changed between two
+// versions, such as "texture grew from 2K to 8K (2048x2048 → 8192x8192)". It returns nil
+// for a first version.
+func DescribeVersionChanges(from, to map[string]interface{}) []string {
+	if len(from) == 0 || len(to) == 0 {
+		return nil
+	}
+
+	var changes []string
+
+	fromWidth, hasWidth := metaInt(from, "width")
+	toWidth, _ := metaInt(to, "width")
+	fromHeight, _ := metaInt(from, "height")
+	toHeight, _ := metaInt(to, "height")
+	if hasWidth && (fromWidth != toWidth || fromHeight != toHeight) {
+		fromLabel, toLabel := resolutionLabel(fromWidth, fromHeight), resolutionLabel(toWidth, toHeight)
+		dims := fmt.Sprintf("%dx%d → %dx%d", fromWidth, fromHeight, toWidth, toHeight)
+		switch {
+		case fromLabel == toLabel:
+			changes = append(changes, "texture resized "+dims)
+		case toWidth*toHeight > fromWidth*fromHeight:
+			changes = append(changes, fmt.Sprintf("texture grew from %s to %s (%s)", fromLabel, toLabel, dims))
+		default:
+			changes = append(changes, fmt.Sprintf("texture shrank from %s to %s (%s)", fromLabel, toLabel, dims))
+		}
+	}
+
+	for _, key := range []string{"channels", "bit_depth"} {
+		if a, ok := metaInt(from, key); ok {
+			if b, _ := metaInt(to, key); a != b {
+				changes = append(changes, fmt.Sprintf("%s %d → %d", strings.ReplaceAll(key, "_", " "), a, b))
+			}
+		}
+	}
+
+	fromAlpha, hasAlpha := from["has_alpha"].(bool)
+	if toAlpha, _ := to["has_alpha"].(bool); hasAlpha && fromAlpha != toAlpha {
+		if toAlpha {
+			changes = append(changes, "alpha channel added")
+		} else {
+			changes = append(changes, "alpha channel removed")
+		}
+	}
+
+	fromProfile, toProfile := metaString(from, "color_profile"), metaString(to, "color_profile")
+	switch {
+	case fromProfile == toProfile:
+	case fromProfile == "":
+		changes = append(changes, "color profile added: "+toProfile)
+	case toProfile == "":
+		changes = append(changes, "color profile removed: "+fromProfile)
+	default:
+		changes = append(changes, fmt.Sprintf("color profile %s → %s", fromProfile, toProfile))
+	}
+
+	for _, count := range versionCounts {
+		if a, ok := metaInt(from, count.key); ok {
+			if b, _ := metaInt(to, count.key); a != b {
+				changes = append(changes, fmt.Sprintf("%s %d → %d", count.label, a, b))
+			}
+		}
+	}
+
+	fromSkeletons := make(map[string]bool)
+	for _, name := range metaStrings(from, "skeletons") {
+		fromSkeletons[name] = true
+	}
+	for _, name := range metaStrings(to, "skeletons") {
+		if !fromSkeletons[name] {
+			changes = append(changes, "skeleton added: "+name)
+		}
+		delete(fromSkeletons, name)
+	}
+	for _, name := range metaStrings(from, "skeletons") {
+		if fromSkeletons[name] {
+			changes = append(changes, "skeleton removed: "+name)
+		}
+	}
+
+	return changes
+}
+
+// resolutionLabel names a texture size by its longest side, "2K" for 2048
+func resolutionLabel(width, height int) string {
+	longest := max(width, height)
+	if longest >= 1024 && longest%1024 == 0 {
+		return fmt.Sprintf("%dK", longest/1024)
+	}
+	return fmt.Sprintf("%d", longest)
+}
+
+// metaInt reads a number from metadata that may have been through a JSON round trip
+func metaInt(meta map[string]interface{}, key string) (int, bool) {
+	switch v := meta[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
+
+func metaString(meta map[string]interface{}, key string) string {
+	s, _ := meta[key].(string)
+	return s
+}
+
+func metaStrings(meta map[string]interface{}, key string) []string {
+	switch v := meta[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
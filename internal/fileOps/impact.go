@@ -0,0 +1,134 @@
+package fileops
+
+import (
+	"sort"
+
+	"github.com/Telerallc/gamedev-vcs/internal/state"
+)
+
+// ImpactedAsset is an asset that transitively references part of a change set
+type ImpactedAsset struct {
+	FilePath      string   `json:"file_path"`
+	PackageName   string   `json:"package_name"`
+	IsLevel       bool     `json:"is_level"`
+	Owner         string   `json:"owner,omitempty"`
+	LockedBy      string   `json:"locked_by,omitempty"`
+	ChangedAssets []string `json:"changed_assets"` // changed assets this asset reaches
+}
+
+// LevelImpact groups the impacted assets loaded by a single map
+type LevelImpact struct {
+	Level         string   `json:"level"`
+	Owner         string   `json:"owner,omitempty"`
+	ChangedAssets []string `json:"changed_assets"`
+	Referencers   []string `json:"referencers"` // impacted non-level assets the map references
+}
+
+// ImpactReport summarizes which assets need re-testing before a change set is committed
+type ImpactReport struct {
+	ChangedAssets  []string            `json:"changed_assets"`
+	Referencers    []ImpactedAsset     `json:"referencers"`
+	Levels         []LevelImpact       `json:"levels"`
+	ByOwner        map[string][]string `json:"by_owner"`
+	LockedByOthers []ImpactedAsset     `json:"locked_by_others"`
+}
+
+// AnalyzeImpact computes the transitive referencers of changedPaths across a project tree.
+// owners maps file paths to their last modifier; locks held by userID are not reported.
+func (fo *FileOperations) AnalyzeImpact(tree map[string]string, sizes map[string]int64, changedPaths []string, owners map[string]string, locks []state.FileLock, userID string) *ImpactReport {
+	report := &ImpactReport{
+		ChangedAssets:  make([]string, 0),
+		Referencers:    make([]ImpactedAsset, 0),
+		Levels:         make([]LevelImpact, 0),
+		ByOwner:        make(map[string][]string),
+		LockedByOthers: make([]ImpactedAsset, 0),
+	}
+
+	pa := fo.projectAnalyzer(tree)
+	graph := fo.BuildDependencyGraph(tree, sizes)
+
+	// Collect referencers per changed asset so each can report what it reaches
+	reaches := make(map[string][]string)
+	changedPackages := make(map[string]bool)
+	for _, path := range changedPaths {
+		packageName, ok := pa.AssetFor(path)
+		if !ok || !pa.IsAsset(path) {
+			continue
+		}
+		report.ChangedAssets = append(report.ChangedAssets, path)
+
+		changedPackages[packageName] = true
+		for _, referencer := range graph.Referencers(packageName) {
+			reaches[referencer] = append(reaches[referencer], path)
+		}
+	}
+	sort.Strings(report.ChangedAssets)
+
+	lockOwners := make(map[string]string)
+	for _, lock := range locks {
+		if lock.UserID != userID {
+			lockOwners[lock.FilePath] = lock.UserName
+		}
+	}
+
+	impacted := make(map[string]bool)
+	for packageName := range reaches {
+		if changedPackages[packageName] {
+			continue // part of the change set itself
+		}
+		impacted[packageName] = true
+	}
+
+	for _, packageName := range sortedKeys(impacted) {
+		node, _ := graph.Node(packageName)
+		asset := ImpactedAsset{
+			FilePath:      node.FilePath,
+			PackageName:   packageName,
+			IsLevel:       pa.IsLevel(node.FilePath),
+			Owner:         owners[node.FilePath],
+			LockedBy:      lockOwners[node.FilePath],
+			ChangedAssets: reaches[packageName],
+		}
+		sort.Strings(asset.ChangedAssets)
+
+		report.Referencers = append(report.Referencers, asset)
+		if asset.Owner != "" {
+			report.ByOwner[asset.Owner] = append(report.ByOwner[asset.Owner], asset.FilePath)
+		}
+		if asset.LockedBy != "" {
+			report.LockedByOthers = append(report.LockedByOthers, asset)
+		}
+	}
+
+	// Group impacted assets under the levels that reach them, following the same hard and
+	// soft references that made them referencers
+	for _, asset := range report.Referencers {
+		if !asset.IsLevel {
+			continue
+		}
+
+		level := LevelImpact{
+			Level:         asset.FilePath,
+			Owner:         asset.Owner,
+			ChangedAssets: asset.ChangedAssets,
+			Referencers:   make([]string, 0),
+		}
+		for _, packageName := range graph.Reachable(asset.PackageName) {
+			if node, ok := graph.Node(packageName); ok && impacted[packageName] && packageName != asset.PackageName && !pa.IsLevel(node.FilePath) {
+				level.Referencers = append(level.Referencers, node.FilePath)
+			}
+		}
+		report.Levels = append(report.Levels, level)
+	}
+
+	return report
+}
+
+func sortedKeys[V any](set map[string]V) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
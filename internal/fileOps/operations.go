@@ -1,9 +1,9 @@
 package fileops
 
 import (
+	"bufio"
 	"fmt"
 	"io"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -28,15 +28,22 @@ type FileIndexEntry struct {
 }
 
 type FileOperations struct {
-	storage      *storage.ContentStore
-	stateManager *state.StateManager
-	analytics    *analytics.AnalyticsClient
-	analyzer     *analyzer.UE5AssetAnalyzer
+	storage       *storage.ContentStore
+	stateManager  *state.StateManager
+	analytics     *analytics.AnalyticsClient
+	analyzer      *analyzer.UE5AssetAnalyzer
+	unityAnalyzer *analyzer.UnityAssetAnalyzer
+	formats       *analyzer.Registry // per-file analysis and integrity checks for uploads
 
 	// PHASE 1.5: Git-style components
 	objectStore *storage.GitStyleObjectStore
 	fileIndex   *storage.FileIndex
 	commitStore *storage.GitStyleCommitStore // NEW
+
+	// Hard references and engine IDs per content hash, used by pre-receive validation
+	referenceCache *lruCache[[]analyzer.AssetDependency]
+	identityCache  *lruCache[[]string]               // engine IDs per identity file, nil when invalid
+	metadataCache  *lruCache[map[string]interface{}] // source metadata per file type and content hash
 }
 
 // UploadRequest represents a file upload request
@@ -50,6 +57,7 @@ type UploadRequest struct {
 	Metadata      map[string]string `json:"metadata"`
 	CommitHash    string            `json:"commit_hash,omitempty"`
 	CommitMessage string            `json:"commit_message,omitempty"`
+	DeclaredHash  string            `json:"declared_hash,omitempty"` // Hash computed by the client, verified on ingest
 }
 
 // UploadResult represents the result of a file upload
@@ -129,15 +137,22 @@ type ObjectUploadResult struct {
 	Error      error  `json:"error,omitempty"`
 	Skipped    bool   `json:"skipped"`
 	SkipReason string `json:"skip_reason,omitempty"`
+	AssetType  string `json:"asset_type,omitempty"`
 }
 
 // NewFileOperations creates a new file operations coordinator
 func NewFileOperations(storage *storage.ContentStore, stateManager *state.StateManager, analytics *analytics.AnalyticsClient, objectStore *storage.GitStyleObjectStore, fileIndex *storage.FileIndex) *FileOperations {
 	fo := &FileOperations{
-		storage:      storage,
-		stateManager: stateManager,
-		analytics:    analytics,
-		analyzer:     analyzer.NewUE5AssetAnalyzer(),
+		storage:       storage,
+		stateManager:  stateManager,
+		analytics:     analytics,
+		analyzer:      analyzer.NewUE5AssetAnalyzer(),
+		unityAnalyzer: analyzer.NewUnityAssetAnalyzer(),
+		formats:       analyzer.DefaultRegistry(),
+
+		referenceCache: newLRUCache[[]analyzer.AssetDependency](referenceCacheSize),
+		identityCache:  newLRUCache[[]string](identityCacheSize),
+		metadataCache:  newLRUCache[map[string]interface{}](metadataCacheSize),
 	}
 
 	// Initialize Git-style components
@@ -186,6 +201,11 @@ func (fo *FileOperations) UploadFile(req *UploadRequest) (*UploadResult, error)
 		return nil, fmt.Errorf("failed to read content: %w", err)
 	}
 
+	// Verify before the content reaches the live store
+	if actualHash, reasons := fo.verifyUpload(req.FilePath, req.DeclaredHash, content); len(reasons) > 0 {
+		return nil, fo.quarantineUpload(req, content, actualHash, reasons)
+	}
+
 	// Store content in content-addressable storage
 	fmt.Printf("DEBUG: About to store content for file: %s\n", req.FilePath)
 	fileStats, err := fo.storage.Store(strings.NewReader(string(content)), req.Metadata)
@@ -207,22 +227,10 @@ func (fo *FileOperations) UploadFile(req *UploadRequest) (*UploadResult, error)
 		FilePath:    req.FilePath,
 	}
 
-	// Analyze asset if it's a UE5 file
-	if fo.isUE5Asset(req.FilePath) {
-		assetInfo, err := fo.analyzer.AnalyzeAsset(req.FilePath, content)
-		if err == nil {
-			result.AssetInfo = assetInfo
-			result.Dependencies = assetInfo.Dependencies
-
-			// Record asset dependencies in analytics
-			if len(assetInfo.Dependencies) > 0 {
-				analyticsDeps := fo.convertToAnalyticsDependencies(assetInfo.Dependencies, req.CommitHash)
-				if err := fo.analytics.RecordAssetDependencies(analyticsDeps); err != nil {
-					// Log error but don't fail the upload
-					fmt.Printf("Failed to record dependencies: %v\n", err)
-				}
-			}
-		}
+	// Analyze asset with whichever registered format handles it
+	if assetInfo := fo.analyzeUpload(req.FilePath, content, fileStats.Hash, req.CommitHash); assetInfo != nil {
+		result.AssetInfo = assetInfo
+		result.Dependencies = assetInfo.Dependencies
 	}
 
 	// Record file change in analytics
@@ -235,7 +243,7 @@ func (fo *FileOperations) UploadFile(req *UploadRequest) (*UploadResult, error)
 			FileSizeBytes:  uint64(fileStats.Size),
 			Author:         req.UserName,
 			CommitTime:     time.Now(),
-			AssetType:      string(fo.getAssetType(req.FilePath)),
+			AssetType:      string(fo.assetType(req.FilePath, result.AssetInfo)),
 			SyncDurationMS: 0, // TODO: Track actual sync duration
 		}
 
@@ -245,6 +253,9 @@ func (fo *FileOperations) UploadFile(req *UploadRequest) (*UploadResult, error)
 			fileChange.BlueprintType = result.AssetInfo.BlueprintType
 			fileChange.UE5PackagePath = result.AssetInfo.PackageName
 		}
+		if meta := fo.VersionMetadata(req.FilePath, fileStats.Hash); meta != nil {
+			fileChange.SourceMetadata = flattenMetadata(meta)
+		}
 
 		if err := fo.analytics.RecordFileChanges([]analytics.FileChange{*fileChange}); err != nil {
 			fmt.Printf("Failed to record file change: %v\n", err)
@@ -338,22 +349,20 @@ func (fo *FileOperations) UploadChunk(req *ChunkUploadRequest) error {
 
 // FinalizeChunkedUpload assembles chunks into final file
 func (fo *FileOperations) FinalizeChunkedUpload(sessionID string, totalChunks int, metadata map[string]string, req *UploadRequest) (*UploadResult, error) {
-	// Assemble chunks
-	fileStats, err := fo.storage.AssembleChunks(sessionID, totalChunks, metadata)
+	// Assemble chunks outside the live store and verify them before they go in
+	staged, err := fo.storage.StageChunks(sessionID, totalChunks)
 	if err != nil {
 		return nil, fmt.Errorf("failed to assemble chunks: %w", err)
 	}
+	defer staged.Discard()
 
-	// Get assembled content for analysis
-	content, _, err := fo.storage.Get(fileStats.Hash)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get assembled content: %w", err)
+	if err := fo.verifyStaged(req, staged); err != nil {
+		return nil, err
 	}
-	defer content.Close()
 
-	contentBytes, err := io.ReadAll(content)
+	fileStats, err := staged.Commit(metadata)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read assembled content: %w", err)
+		return nil, fmt.Errorf("failed to store assembled content: %w", err)
 	}
 
 	result := &UploadResult{
@@ -362,13 +371,10 @@ func (fo *FileOperations) FinalizeChunkedUpload(sessionID string, totalChunks in
 		FilePath:    req.FilePath,
 	}
 
-	// Analyze if UE5 asset
-	if fo.isUE5Asset(req.FilePath) {
-		assetInfo, err := fo.analyzer.AnalyzeAsset(req.FilePath, contentBytes)
-		if err == nil {
-			result.AssetInfo = assetInfo
-			result.Dependencies = assetInfo.Dependencies
-		}
+	// Analyze the assembled asset
+	if assetInfo := fo.analyzeStored(req.FilePath, fileStats.Hash, req.CommitHash); assetInfo != nil {
+		result.AssetInfo = assetInfo
+		result.Dependencies = assetInfo.Dependencies
 	}
 
 	// Record analytics
@@ -381,7 +387,10 @@ func (fo *FileOperations) FinalizeChunkedUpload(sessionID string, totalChunks in
 			FileSizeBytes: uint64(fileStats.Size),
 			Author:        req.UserName,
 			CommitTime:    time.Now(),
-			AssetType:     string(fo.getAssetType(req.FilePath)),
+			AssetType:     string(fo.assetType(req.FilePath, result.AssetInfo)),
+		}
+		if meta := fo.VersionMetadata(req.FilePath, fileStats.Hash); meta != nil {
+			fileChange.SourceMetadata = flattenMetadata(meta)
 		}
 
 		fo.analytics.RecordFileChanges([]analytics.FileChange{*fileChange})
@@ -394,6 +403,40 @@ func (fo *FileOperations) FinalizeChunkedUpload(sessionID string, totalChunks in
 	return result, nil
 }
 
+// StoreObject streams one object into the store. The content is hashed and checked on its
+// way in and only reaches the live store once it matches req.DeclaredHash and passes the
+// checks of its format; otherwise it's quarantined.
+func (fo *FileOperations) StoreObject(req *UploadRequest, content io.Reader) (*UploadResult, error) {
+	staged, err := fo.storage.Stage(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive object: %w", err)
+	}
+	defer staged.Discard()
+
+	if err := fo.verifyStaged(req, staged); err != nil {
+		return nil, err
+	}
+
+	fileStats, err := staged.Commit(map[string]string{
+		"file_path":  req.FilePath,
+		"session_id": req.SessionID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store object: %w", err)
+	}
+
+	result := &UploadResult{
+		ContentHash: fileStats.Hash,
+		Size:        fileStats.Size,
+		FilePath:    req.FilePath,
+	}
+	if assetInfo := fo.analyzeStored(req.FilePath, fileStats.Hash, req.CommitHash); assetInfo != nil {
+		result.AssetInfo = assetInfo
+		result.Dependencies = assetInfo.Dependencies
+	}
+	return result, nil
+}
+
 // LockFile acquires an exclusive lock on a file
 func (fo *FileOperations) LockFile(req *LockRequest) (*state.FileLock, error) {
 	lock, err := fo.stateManager.LockFile(req.ProjectID, req.FilePath, req.UserID, req.UserName, req.SessionID)
@@ -486,36 +529,57 @@ func (fo *FileOperations) RecordCommit(commit *analytics.Commit, fileChanges []a
 
 // Helper methods
 
-func (fo *FileOperations) isUE5Asset(filePath string) bool {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	ue5Extensions := []string{".uasset", ".umap", ".uexp", ".ubulk"}
+// analyzeUpload runs the registered format analysis of uploaded content and records the
+// dependencies it finds. It returns nil when no format analyzes the file or analysis fails.
+func (fo *FileOperations) analyzeUpload(filePath string, content []byte, contentHash, commitHash string) *analyzer.AssetInfo {
+	assetInfo, err := fo.formats.Analyze(filePath, content)
+	if err != nil || assetInfo == nil {
+		return nil
+	}
 
-	for _, ue5Ext := range ue5Extensions {
-		if ext == ue5Ext {
-			return true
+	if fo.formats.HasVersionMetadata(filePath) {
+		fo.cacheVersionMetadata(filePath, contentHash, assetInfo.Properties)
+	}
+
+	// Record asset dependencies in analytics
+	if len(assetInfo.Dependencies) > 0 {
+		analyticsDeps := fo.convertToAnalyticsDependencies(assetInfo.Dependencies, commitHash)
+		if err := fo.analytics.RecordAssetDependencies(analyticsDeps); err != nil {
+			// Log error but don't fail the upload
+			fmt.Printf("Failed to record dependencies: %v\n", err)
 		}
 	}
 
-	return false
+	return assetInfo
 }
 
-func (fo *FileOperations) getAssetType(filePath string) analyzer.AssetType {
-	ext := strings.ToLower(filepath.Ext(filePath))
+// analyzeStored analyzes content already in the store, reading it only when its format
+// has an analysis
+func (fo *FileOperations) analyzeStored(filePath, contentHash, commitHash string) *analyzer.AssetInfo {
+	reader, _, err := fo.storage.Get(contentHash)
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+
+	buffered := bufio.NewReaderSize(reader, analyzer.HeaderSize)
+	header, _ := buffered.Peek(analyzer.HeaderSize)
+	if !fo.formats.Analyzes(filePath, header) {
+		return nil
+	}
+	content, err := io.ReadAll(buffered)
+	if err != nil {
+		return nil
+	}
+	return fo.analyzeUpload(filePath, content, contentHash, commitHash)
+}
 
-	switch ext {
-	case ".umap":
-		return analyzer.AssetTypeLevel
-	case ".uasset":
-		return analyzer.AssetTypeUnknown // Would need content analysis
-	case ".fbx":
-		return analyzer.AssetTypeStaticMesh
-	case ".png", ".jpg", ".jpeg", ".tga":
-		return analyzer.AssetTypeTexture2D
-	case ".wav", ".mp3":
-		return analyzer.AssetTypeSound
-	default:
-		return analyzer.AssetTypeUnknown
+// assetType prefers the analyzed type of a file over the one its format declares
+func (fo *FileOperations) assetType(filePath string, assetInfo *analyzer.AssetInfo) analyzer.AssetType {
+	if assetInfo != nil && assetInfo.AssetType != "" && assetInfo.AssetType != analyzer.AssetTypeUnknown {
+		return assetInfo.AssetType
 	}
+	return fo.formats.AssetType(filePath)
 }
 
 func (fo *FileOperations) determineChangeType(filePath string) string {
@@ -0,0 +1,110 @@
+package fileops
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Telerallc/gamedev-vcs/internal/state"
+	"github.com/Telerallc/gamedev-vcs/internal/storage"
+)
+
+// QuarantinedUploadError reports uploaded content that failed verification and was quarantined
+type QuarantinedUploadError struct {
+	Record *storage.QuarantineRecord
+}
+
+func (e *QuarantinedUploadError) Error() string {
+	return fmt.Sprintf("upload of %s was quarantined (%s): %s", e.Record.FilePath, e.Record.ID, strings.Join(e.Record.Reasons, "; "))
+}
+
+// verifyUpload checks uploaded content against the hash the client declared and runs the
+// integrity checks of its registered format. It returns the actual content hash and the
+// reasons the content failed, if any.
+func (fo *FileOperations) verifyUpload(filePath, declaredHash string, content []byte) (string, []string) {
+	actualHash := fmt.Sprintf("%x", sha256.Sum256(content))
+	return actualHash, fo.checkUpload(filePath, declaredHash, actualHash, content)
+}
+
+// checkUpload verifies content whose hash was already computed while it streamed in
+func (fo *FileOperations) checkUpload(filePath, declaredHash, actualHash string, content []byte) []string {
+	return append(checkDeclaredHash(declaredHash, actualHash), fo.formats.Check(filePath, content)...)
+}
+
+// checkDeclaredHash reports content whose hash isn't the one the client declared
+func checkDeclaredHash(declaredHash, actualHash string) []string {
+	if declaredHash != "" && !strings.EqualFold(declaredHash, actualHash) {
+		return []string{fmt.Sprintf("content hash %s doesn't match declared hash %s", actualHash, declaredHash)}
+	}
+	return nil
+}
+
+// verifyStaged checks staged content before it's committed to the live store, quarantining
+// it on failure. The content was hashed as it was staged, and format checks only read as
+// much of it as they need.
+func (fo *FileOperations) verifyStaged(req *UploadRequest, staged *storage.StagedContent) error {
+	reader, err := staged.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read staged content: %w", err)
+	}
+	problems, err := fo.formats.CheckStream(req.FilePath, reader, staged.Size)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read staged content: %w", err)
+	}
+
+	if reasons := append(checkDeclaredHash(req.DeclaredHash, staged.Hash), problems...); len(reasons) > 0 {
+		record := fo.quarantineRecord(req, staged.Hash, reasons)
+		if err := staged.Quarantine(record); err != nil {
+			return fmt.Errorf("upload failed verification and could not be quarantined: %w", err)
+		}
+		return fo.reportQuarantine(req, record)
+	}
+	return nil
+}
+
+// quarantineUpload moves failing content to the quarantine area and tells the uploader
+func (fo *FileOperations) quarantineUpload(req *UploadRequest, content []byte, actualHash string, reasons []string) error {
+	record := fo.quarantineRecord(req, actualHash, reasons)
+	if err := fo.storage.Quarantine(content, record); err != nil {
+		return fmt.Errorf("upload failed verification and could not be quarantined: %w", err)
+	}
+	return fo.reportQuarantine(req, record)
+}
+
+func (fo *FileOperations) quarantineRecord(req *UploadRequest, actualHash string, reasons []string) *storage.QuarantineRecord {
+	return &storage.QuarantineRecord{
+		ProjectID:    req.ProjectID,
+		FilePath:     req.FilePath,
+		DeclaredHash: req.DeclaredHash,
+		ActualHash:   actualHash,
+		Reasons:      reasons,
+		UserID:       req.UserID,
+		UserName:     req.UserName,
+		SessionID:    req.SessionID,
+	}
+}
+
+// reportQuarantine tells collaborators about quarantined content and returns the
+// uploader's error
+func (fo *FileOperations) reportQuarantine(req *UploadRequest, record *storage.QuarantineRecord) error {
+	fo.stateManager.PublishEvent(&state.CollaborationEvent{
+		EventID:   fmt.Sprintf("quarantine_%s", record.ID),
+		Type:      state.EventUploadQuarantined,
+		UserID:    req.UserID,
+		UserName:  req.UserName,
+		ProjectID: req.ProjectID,
+		FilePath:  req.FilePath,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"quarantine_id": record.ID,
+			"declared_hash": record.DeclaredHash,
+			"actual_hash":   record.ActualHash,
+			"reasons":       record.Reasons,
+			"file_size":     record.Size,
+		},
+	})
+
+	return &QuarantinedUploadError{Record: record}
+}
@@ -0,0 +1,472 @@
+package fileops
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Telerallc/gamedev-vcs/internal/analyzer"
+)
+
+// Reference violation reasons
+const (
+	ViolationMissingTarget = "missing_target" // changed asset references an asset that doesn't exist
+	ViolationDeletedTarget = "deleted_target" // deleted asset is still referenced by a remaining asset
+	ViolationMissingMeta   = "missing_meta"   // Unity asset has no .meta committed alongside it
+	ViolationInvalidID     = "invalid_id"     // Unity .meta or Godot .uid assigns no parseable ID
+	ViolationIDCollision   = "id_collision"   // two assets claim the same Unity GUID or Godot UID
+)
+
+// ReferenceViolation describes a hard reference that would be broken by a push
+type ReferenceViolation struct {
+	SourceAsset string `json:"source_asset"`
+	TargetAsset string `json:"target_asset"`
+	Reason      string `json:"reason"`
+}
+
+// ReferenceCheckResult summarizes a pre-receive reference validation
+type ReferenceCheckResult struct {
+	ProjectType   analyzer.ProjectType `json:"project_type"`
+	CheckedAssets int                  `json:"checked_assets"`
+	Violations    []ReferenceViolation `json:"violations"`
+	Unverified    []string             `json:"unverified,omitempty"` // changed assets whose content isn't on the server
+}
+
+// HasViolations reports whether any broken references were found
+func (r *ReferenceCheckResult) HasViolations() bool {
+	return r != nil && len(r.Violations) > 0
+}
+
+// ValidateReferences checks the hard references of a change set against the post-push tree.
+// currentTree and changes map file paths to content hashes; deletions lists removed paths.
+func (fo *FileOperations) ValidateReferences(currentTree, changes map[string]string, deletions []string) *ReferenceCheckResult {
+	result := &ReferenceCheckResult{
+		Violations: make([]ReferenceViolation, 0),
+	}
+
+	// Build the tree as it will look once the push lands
+	postTree := make(map[string]string, len(currentTree)+len(changes))
+	for path, hash := range currentTree {
+		postTree[path] = hash
+	}
+	for path, hash := range changes {
+		postTree[path] = hash
+	}
+	for _, path := range deletions {
+		delete(postTree, path)
+	}
+
+	pa := fo.projectAnalyzer(postTree)
+	result.ProjectType = pa.ProjectType()
+	postIndex, postFiles := fo.indexTree(pa, postTree)
+	inProject := projectReferenceFilter(pa, currentTree, postTree)
+
+	// Assets the change set touches, including those whose identity file changed or was deleted
+	changedAssets := make(map[string]bool)
+	var identityPaths []string
+	for path := range changes {
+		if name, ok := pa.AssetFor(path); ok {
+			changedAssets[name] = true
+			if pa.IsIdentityFile(path) {
+				identityPaths = append(identityPaths, path)
+			}
+		}
+	}
+	for _, path := range deletions {
+		if name, ok := pa.AssetFor(path); ok && pa.IsIdentityFile(path) {
+			changedAssets[name] = true
+			identityPaths = append(identityPaths, path)
+		}
+	}
+
+	// Only report tree problems the change set touches, so old damage doesn't block unrelated pushes
+	touched := func(name string) bool {
+		if changedAssets[name] {
+			return true
+		}
+		for changed := range changedAssets {
+			if strings.HasPrefix(changed, name+"/") {
+				return true // a folder is touched when anything inside it changed
+			}
+		}
+		return false
+	}
+
+	if checker, ok := pa.(analyzer.CompanionChecker); ok {
+		for _, issue := range checker.MissingCompanions(sortedPaths(postTree)) {
+			if touched(issue.AssetPath) {
+				result.Violations = append(result.Violations, ReferenceViolation{
+					SourceAsset: issue.AssetPath,
+					TargetAsset: issue.AssetPath + ".meta",
+					Reason:      ViolationMissingMeta,
+				})
+			}
+		}
+	}
+
+	for _, issue := range postIndex.Issues() {
+		if !touched(issue.AssetPath) && (issue.Other == "" || !touched(issue.Other)) {
+			continue
+		}
+		violation := ReferenceViolation{SourceAsset: issue.AssetPath, TargetAsset: issue.AssetPath, Reason: ViolationInvalidID}
+		if issue.Kind == analyzer.IssueIDCollision {
+			violation = ReferenceViolation{SourceAsset: issue.AssetPath, TargetAsset: issue.Other, Reason: ViolationIDCollision}
+		}
+		result.Violations = append(result.Violations, violation)
+	}
+
+	// Changed assets may only reference assets that exist after the push
+	for _, name := range sortedKeys(changedAssets) {
+		if !postIndex.Has(name) {
+			continue
+		}
+
+		deps, err := fo.assetReferences(pa, postTree, postFiles[name])
+		if err != nil {
+			result.Unverified = append(result.Unverified, postIndex.FilePath(name))
+			continue
+		}
+		result.CheckedAssets++
+
+		for _, dep := range deps {
+			if !inProject(dep) {
+				continue
+			}
+			if _, ok := postIndex.Resolve(dep); !ok {
+				result.Violations = append(result.Violations, ReferenceViolation{
+					SourceAsset: postIndex.FilePath(name),
+					TargetAsset: referenceTarget(dep),
+					Reason:      ViolationMissingTarget,
+				})
+			}
+		}
+	}
+
+	// Deleted assets and IDs that went away must not be referenced by anything that remains
+	lost := fo.lostTargets(pa, currentTree, postTree, postIndex, deletions, identityPaths)
+	if len(lost) == 0 {
+		return result
+	}
+	currentIndex, _ := fo.indexTree(pa, currentTree)
+
+	for _, name := range sortedKeys(postFiles) {
+		if changedAssets[name] || !postIndex.Has(name) {
+			continue // changed assets were validated above
+		}
+
+		deps, err := fo.assetReferences(pa, postTree, postFiles[name])
+		if err != nil {
+			continue
+		}
+
+		for _, dep := range deps {
+			if !inProject(dep) {
+				continue
+			}
+			target, existed := currentIndex.Resolve(dep)
+			if !existed || !lost[target] {
+				continue
+			}
+			if _, exists := postIndex.Resolve(dep); !exists {
+				result.Violations = append(result.Violations, ReferenceViolation{
+					SourceAsset: postIndex.FilePath(name),
+					TargetAsset: currentIndex.FilePath(target),
+					Reason:      ViolationDeletedTarget,
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// lostTargets returns the assets remaining assets could have referenced before the push
+// but can't resolve afterwards: deleted assets, and assets whose identity file stopped
+// assigning an ID nothing else in the post-push tree assigns. Identity files that were
+// edited without changing their IDs lose nothing, so they don't trigger a scan.
+func (fo *FileOperations) lostTargets(pa analyzer.ProjectAnalyzer, currentTree, postTree map[string]string, postIndex *analyzer.ReferenceIndex, deletions, identityPaths []string) map[string]bool {
+	lost := make(map[string]bool)
+	for _, path := range deletions {
+		if _, tracked := currentTree[path]; !tracked {
+			continue
+		}
+		if name, ok := pa.AssetFor(path); ok && pa.IsAsset(path) && !postIndex.Has(name) {
+			lost[name] = true
+		}
+	}
+
+	for _, path := range identityPaths {
+		hash, existed := currentTree[path]
+		if !existed {
+			continue
+		}
+		before, known := fo.identify(pa, path, hash)
+		if !known || len(before) == 0 {
+			continue
+		}
+
+		after := make(map[string]bool)
+		if hash, exists := postTree[path]; exists {
+			ids, _ := fo.identify(pa, path, hash)
+			for _, id := range ids {
+				after[id] = true
+			}
+		}
+
+		name, _ := pa.AssetFor(path)
+		for _, id := range before {
+			if after[id] {
+				continue
+			}
+			if _, reassigned := postIndex.Resolve(analyzer.AssetDependency{TargetID: id}); !reassigned {
+				lost[name] = true
+			}
+		}
+	}
+
+	return lost
+}
+
+// BuildDependencyGraph analyzes every asset in a project tree into a hard-reference graph.
+// tree maps file paths to content hashes and sizes maps file paths to byte sizes; companion
+// files (.uexp/.ubulk, .meta, .import) count toward their asset's size.
+func (fo *FileOperations) BuildDependencyGraph(tree map[string]string, sizes map[string]int64) *analyzer.DependencyGraph {
+	pa := fo.projectAnalyzer(tree)
+	index, files := fo.indexTree(pa, tree)
+
+	assetSizes := make(map[string]int64)
+	for path, size := range sizes {
+		if name, ok := pa.AssetFor(path); ok {
+			assetSizes[name] += size
+		}
+	}
+
+	inProject := projectReferenceFilter(pa, tree)
+	graph := analyzer.NewDependencyGraph()
+	for _, name := range sortedKeys(files) {
+		if !index.Has(name) {
+			continue
+		}
+
+		// Assets we can't analyze still count as nodes so references to them resolve
+		deps, err := fo.assetReferences(pa, tree, files[name])
+		if err != nil {
+			deps = nil
+		}
+
+		// ID references resolve to the asset's graph name; path references the graph resolves itself
+		projectDeps := make([]analyzer.AssetDependency, 0, len(deps))
+		for _, dep := range deps {
+			if !inProject(dep) {
+				continue
+			}
+			if target, ok := index.Resolve(dep); ok && dep.TargetID != "" {
+				dep.TargetAsset = target
+			}
+			projectDeps = append(projectDeps, dep)
+		}
+		deps = projectDeps
+
+		graph.AddAsset(name, index.FilePath(name), assetSizes[name], deps)
+	}
+
+	return graph
+}
+
+// projectReferenceFilter reports whether a dependency targets content the project itself holds.
+// Engines whose references can leave the project (UE engine plugins) are limited to the content
+// roots of the given trees; everything else counts.
+func projectReferenceFilter(pa analyzer.ProjectAnalyzer, trees ...map[string]string) func(analyzer.AssetDependency) bool {
+	rooter, ok := pa.(analyzer.ContentRooter)
+	if !ok {
+		return func(analyzer.AssetDependency) bool { return true }
+	}
+
+	var paths []string
+	for _, tree := range trees {
+		for path := range tree {
+			paths = append(paths, path)
+		}
+	}
+	roots := rooter.ContentRoots(paths)
+
+	return func(dep analyzer.AssetDependency) bool {
+		if dep.TargetAsset == "" {
+			return true
+		}
+		for _, root := range roots {
+			if strings.HasPrefix(dep.TargetAsset, root) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// projectAnalyzer selects the analyzer for a project tree by its project type
+func (fo *FileOperations) projectAnalyzer(tree map[string]string) analyzer.ProjectAnalyzer {
+	paths := sortedPaths(tree)
+	switch analyzer.DetectProjectType(paths) {
+	case analyzer.ProjectTypeUnity:
+		return fo.unityAnalyzer
+	case analyzer.ProjectTypeGodot:
+		return analyzer.NewGodotAssetAnalyzer(analyzer.GodotProjectRoot(paths))
+	}
+	return fo.analyzer
+}
+
+// indexTree records the assets and engine IDs of a tree, and groups the files that make
+// up each asset (the asset itself plus identity and companion files) by graph name
+func (fo *FileOperations) indexTree(pa analyzer.ProjectAnalyzer, tree map[string]string) (*analyzer.ReferenceIndex, map[string][]string) {
+	index := analyzer.NewReferenceIndex(pa)
+	files := make(map[string][]string)
+
+	for _, path := range sortedPaths(tree) {
+		name, ok := pa.AssetFor(path)
+		if !ok {
+			continue
+		}
+		files[name] = append(files[name], path)
+
+		if pa.IsAsset(path) {
+			index.AddAsset(name, path)
+		}
+		if pa.IsIdentityFile(path) {
+			ids, known := fo.identify(pa, path, tree[path])
+			switch {
+			case !known:
+				// content isn't on the server; its IDs can't be checked yet
+			case ids == nil:
+				index.AddInvalid(name)
+			default:
+				index.AddIDs(name, ids)
+			}
+		}
+	}
+
+	return index, files
+}
+
+// identify returns the IDs an identity file assigns, cached by content hash. known is false
+// when the content can't be read; a nil slice means the file is invalid.
+func (fo *FileOperations) identify(pa analyzer.ProjectAnalyzer, filePath, contentHash string) ([]string, bool) {
+	cacheKey := referenceCacheKey(pa, filePath, contentHash)
+
+	ids, cached := fo.identityCache.Get(cacheKey)
+	if cached {
+		return ids, true
+	}
+
+	content, err := fo.readObject(contentHash)
+	if err != nil {
+		return nil, false
+	}
+
+	ids, err = pa.Identify(filePath, content)
+	if err != nil {
+		ids = nil
+	} else if ids == nil {
+		ids = []string{}
+	}
+
+	fo.identityCache.Add(cacheKey, ids)
+
+	return ids, true
+}
+
+// assetReferences collects the hard references of every file making up an asset. Only a
+// failure to analyze the asset file itself is an error.
+func (fo *FileOperations) assetReferences(pa analyzer.ProjectAnalyzer, tree map[string]string, paths []string) ([]analyzer.AssetDependency, error) {
+	var deps []analyzer.AssetDependency
+	for _, path := range paths {
+		if !pa.IsAsset(path) && !pa.IsIdentityFile(path) {
+			continue
+		}
+
+		fileDeps, err := fo.fileReferences(pa, path, tree[path])
+		if err != nil {
+			if pa.IsAsset(path) {
+				return nil, err
+			}
+			continue
+		}
+		deps = append(deps, fileDeps...)
+	}
+	return deps, nil
+}
+
+// fileReferences returns the hard references of a stored file, cached by content hash
+func (fo *FileOperations) fileReferences(pa analyzer.ProjectAnalyzer, filePath, contentHash string) ([]analyzer.AssetDependency, error) {
+	cacheKey := referenceCacheKey(pa, filePath, contentHash)
+
+	deps, cached := fo.referenceCache.Get(cacheKey)
+	if cached {
+		return deps, nil
+	}
+
+	content, err := fo.readObject(contentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	deps, err = pa.References(filePath, content)
+	if err != nil {
+		return nil, err
+	}
+
+	fo.referenceCache.Add(cacheKey, deps)
+
+	return deps, nil
+}
+
+// referenceCacheKey keys analysis results by engine and file type as well as content, since
+// the same bytes analyze differently as a Unity .meta and a Godot resource
+func referenceCacheKey(pa analyzer.ProjectAnalyzer, filePath, contentHash string) string {
+	return fmt.Sprintf("%s:%s:%s", pa.ProjectType(), strings.ToLower(filepath.Ext(filePath)), contentHash)
+}
+
+// referenceTarget names a dependency target for reports, preferring its path
+func referenceTarget(dep analyzer.AssetDependency) string {
+	if dep.TargetAsset != "" {
+		return dep.TargetAsset
+	}
+	return dep.TargetID
+}
+
+// readObject loads object content from the content store, falling back to the Git-style store
+func (fo *FileOperations) readObject(contentHash string) ([]byte, error) {
+	if fo.storage != nil && fo.storage.Exists(contentHash) {
+		reader, _, err := fo.storage.Get(contentHash)
+		if err == nil {
+			defer reader.Close()
+			return io.ReadAll(reader)
+		}
+	}
+
+	if fo.objectStore != nil {
+		reader, _, err := fo.objectStore.Get(contentHash)
+		if err == nil {
+			defer reader.Close()
+			return io.ReadAll(reader)
+		}
+	}
+
+	return nil, fmt.Errorf("object %s not found", contentHash)
+}
+
+// isPackageFile reports whether a path is a referenceable UE package
+func isPackageFile(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return ext == ".uasset" || ext == ".umap"
+}
+
+func sortedPaths(tree map[string]string) []string {
+	paths := make([]string, 0, len(tree))
+	for path := range tree {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,72 @@
+package fileops
+
+import (
+	"fmt"
+
+	"github.com/Telerallc/gamedev-vcs/internal/analyzer"
+	"github.com/Telerallc/gamedev-vcs/internal/integrity"
+)
+
+// DiffBlueprint produces a semantic diff between two stored revisions of a Blueprint.
+// An empty hash stands for a revision in which the file doesn't exist.
+func (fo *FileOperations) DiffBlueprint(filePath, fromHash, toHash string) (*integrity.BlueprintDiff, error) {
+	if !isPackageFile(filePath) {
+		return nil, fmt.Errorf("%s is not a Blueprint package", filePath)
+	}
+
+	fromContent, toContent, err := fo.readRevisions(fromHash, toHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return integrity.NewBlueprintTracker().DiffBlueprints(fromContent, toContent)
+}
+
+// DiffDataTable produces a row-level diff between two stored revisions of a DataTable or CurveTable.
+// An empty hash stands for a revision in which the file doesn't exist.
+func (fo *FileOperations) DiffDataTable(filePath, fromHash, toHash string) (*analyzer.DataTableDiff, error) {
+	if !isPackageFile(filePath) {
+		return nil, fmt.Errorf("%s is not a table asset", filePath)
+	}
+
+	fromContent, toContent, err := fo.readRevisions(fromHash, toHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to *analyzer.DataTable
+	if len(fromContent) > 0 {
+		if from, err = fo.analyzer.DecodeDataTable(fromContent); err != nil {
+			return nil, fmt.Errorf("failed to decode old revision: %w", err)
+		}
+	}
+	if len(toContent) > 0 {
+		if to, err = fo.analyzer.DecodeDataTable(toContent); err != nil {
+			return nil, fmt.Errorf("failed to decode new revision: %w", err)
+		}
+	}
+
+	return analyzer.DiffDataTables(from, to), nil
+}
+
+// readRevisions loads the content of two stored revisions, leaving absent sides empty
+func (fo *FileOperations) readRevisions(fromHash, toHash string) ([]byte, []byte, error) {
+	var fromContent, toContent []byte
+	var err error
+
+	if fromHash != "" {
+		if fromContent, err = fo.readObject(fromHash); err != nil {
+			return nil, nil, fmt.Errorf("failed to read old revision: %w", err)
+		}
+	}
+	if toHash == fromHash {
+		return fromContent, fromContent, nil
+	}
+	if toHash != "" {
+		if toContent, err = fo.readObject(toHash); err != nil {
+			return nil, nil, fmt.Errorf("failed to read new revision: %w", err)
+		}
+	}
+
+	return fromContent, toContent, nil
+}
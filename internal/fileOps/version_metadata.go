@@ -0,0 +1,61 @@
+package fileops
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VersionMetadata returns the source metadata kept on a file version, such as texture
+// dimensions or FBX mesh counts, cached by content hash. It returns nil for formats that
+// keep none and for content that can't be read or analyzed.
+func (fo *FileOperations) VersionMetadata(filePath, contentHash string) map[string]interface{} {
+	if !fo.formats.HasVersionMetadata(filePath) {
+		return nil
+	}
+	cacheKey := versionMetadataKey(filePath, contentHash)
+
+	meta, cached := fo.metadataCache.Get(cacheKey)
+	if cached {
+		return meta
+	}
+
+	content, err := fo.readObject(contentHash)
+	if err != nil {
+		return nil
+	}
+	meta, err = fo.formats.VersionMetadata(filePath, content)
+	if err != nil {
+		fmt.Printf("Warning: failed to read metadata of %s: %v\n", filePath, err)
+		meta = nil
+	}
+
+	fo.cacheVersionMetadata(filePath, contentHash, meta)
+	return meta
+}
+
+// cacheVersionMetadata remembers metadata analyzed at upload so commits don't re-read the content
+func (fo *FileOperations) cacheVersionMetadata(filePath, contentHash string, meta map[string]interface{}) {
+	fo.metadataCache.Add(versionMetadataKey(filePath, contentHash), meta)
+}
+
+func versionMetadataKey(filePath, contentHash string) string {
+	return strings.ToLower(filepath.Ext(filePath)) + ":" + contentHash
+}
+
+// flattenMetadata converts version metadata to the string map analytics stores
+func flattenMetadata(meta map[string]interface{}) map[string]string {
+	flat := make(map[string]string, len(meta))
+	for key, value := range meta {
+		switch v := value.(type) {
+		case []string:
+			sorted := append([]string(nil), v...)
+			sort.Strings(sorted)
+			flat[key] = strings.Join(sorted, ",")
+		default:
+			flat[key] = fmt.Sprint(v)
+		}
+	}
+	return flat
+}
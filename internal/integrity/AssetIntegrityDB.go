@@ -0,0 +1,341 @@
+// Persistent asset integrity database
+// Stores integrity records per asset revision and an append-only check history under .vcs/integrity
+
+package integrity
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Number of checks kept inline on a record; the full history lives in the check log
+const maxRecordChecks = 50
+
+// Index journal entries are folded into index.json once they outnumber the indexed
+// assets, and never before this many, so updates stay append-only between compactions
+const minJournalCompaction = 1024
+
+// AssetIntegrityDB manages integrity records
+//
+// Layout:
+//
+//	index.json                        snapshot of the current state of every asset
+//	index.jsonl                       IntegrityIndexEntry updates since the snapshot
+//	records/<path key>/<hash>.json    AssetIntegrityRecord per asset revision
+//	history/<path key>.jsonl          one HealthSample per integrity check
+type AssetIntegrityDB struct {
+	dbPath  string
+	mu      sync.RWMutex
+	index   map[string]*IntegrityIndexEntry
+	journal int // entries appended to index.jsonl since the last snapshot
+}
+
+// IntegrityIndexEntry is the current integrity state of an asset
+type IntegrityIndexEntry struct {
+	AssetPath    string          `json:"asset_path"`
+	ContentHash  string          `json:"content_hash"`
+	Status       IntegrityStatus `json:"status"`
+	HealthScore  float64         `json:"health_score"`
+	LastChecked  time.Time       `json:"last_checked"`
+	LastRecovery string          `json:"last_recovery,omitempty"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// HealthSample is a single integrity check result in an asset's history
+type HealthSample struct {
+	Timestamp   time.Time        `json:"timestamp"`
+	ContentHash string           `json:"content_hash"`
+	Status      IntegrityStatus  `json:"status"`
+	HealthScore float64          `json:"health_score"`
+	Checks      []IntegrityCheck `json:"checks"`
+	Recovery    []string         `json:"recovery,omitempty"`
+}
+
+// NewAssetIntegrityDB creates a new integrity database
+func NewAssetIntegrityDB(dbPath string) (*AssetIntegrityDB, error) {
+	for _, dir := range []string{dbPath, filepath.Join(dbPath, "records"), filepath.Join(dbPath, "history")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	db := &AssetIntegrityDB{
+		dbPath: dbPath,
+		index:  make(map[string]*IntegrityIndexEntry),
+	}
+	if err := db.loadIndex(); err != nil {
+		return nil, fmt.Errorf("failed to load integrity index: %w", err)
+	}
+
+	return db, nil
+}
+
+// StoreRecord saves a record under its path and content hash and makes it the asset's current revision
+func (aidb *AssetIntegrityDB) StoreRecord(record *AssetIntegrityRecord) error {
+	aidb.mu.Lock()
+	defer aidb.mu.Unlock()
+
+	if len(record.IntegrityChecks) > maxRecordChecks {
+		record.IntegrityChecks = record.IntegrityChecks[len(record.IntegrityChecks)-maxRecordChecks:]
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode integrity record: %w", err)
+	}
+	if err := writeFileAtomic(aidb.recordPath(record.AssetPath, record.ContentHash), data); err != nil {
+		return fmt.Errorf("failed to write integrity record: %w", err)
+	}
+
+	entry, exists := aidb.index[record.AssetPath]
+	if !exists || entry.ContentHash != record.ContentHash {
+		// A new revision hasn't been checked yet
+		entry = &IntegrityIndexEntry{AssetPath: record.AssetPath, Status: IntegrityUnknown}
+		aidb.index[record.AssetPath] = entry
+	}
+	entry.ContentHash = record.ContentHash
+	entry.HealthScore = record.HealthScore
+	entry.UpdatedAt = time.Now()
+
+	return aidb.updateIndex(entry)
+}
+
+// GetRecord returns the current record of an asset
+func (aidb *AssetIntegrityDB) GetRecord(assetPath string) (*AssetIntegrityRecord, error) {
+	aidb.mu.RLock()
+	entry, exists := aidb.index[assetPath]
+	aidb.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("no integrity record for %s", assetPath)
+	}
+	return aidb.GetRecordAt(assetPath, entry.ContentHash)
+}
+
+// GetRecordAt returns the record of a specific revision of an asset
+func (aidb *AssetIntegrityDB) GetRecordAt(assetPath, contentHash string) (*AssetIntegrityRecord, error) {
+	data, err := os.ReadFile(aidb.recordPath(assetPath, contentHash))
+	if err != nil {
+		return nil, fmt.Errorf("no integrity record for %s at %s", assetPath, contentHash)
+	}
+
+	var record AssetIntegrityRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("corrupt integrity record for %s: %w", assetPath, err)
+	}
+	return &record, nil
+}
+
+// RecordCheck appends a check result to the asset's history and updates its current status.
+// An asset that was recovered after the check is considered valid again.
+func (aidb *AssetIntegrityDB) RecordCheck(record *AssetIntegrityRecord, result *IntegrityCheckResult) error {
+	aidb.mu.Lock()
+	defer aidb.mu.Unlock()
+
+	sample := HealthSample{
+		Timestamp:   result.Timestamp,
+		ContentHash: record.ContentHash,
+		Status:      result.OverallStatus,
+		HealthScore: record.HealthScore,
+		Checks:      result.Checks,
+		Recovery:    result.RecoveryActions,
+	}
+
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("failed to encode check result: %w", err)
+	}
+
+	file, err := os.OpenFile(aidb.historyPath(record.AssetPath), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open check history: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append check history: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	entry, exists := aidb.index[record.AssetPath]
+	if !exists {
+		entry = &IntegrityIndexEntry{AssetPath: record.AssetPath}
+		aidb.index[record.AssetPath] = entry
+	}
+	entry.ContentHash = record.ContentHash
+	entry.Status = result.OverallStatus
+	entry.HealthScore = record.HealthScore
+	entry.LastChecked = result.Timestamp
+	entry.UpdatedAt = time.Now()
+	if len(result.RecoveryActions) > 0 {
+		entry.Status = IntegrityValid
+		entry.LastRecovery = result.RecoveryActions[len(result.RecoveryActions)-1]
+	}
+
+	return aidb.updateIndex(entry)
+}
+
+// HealthTrend returns the most recent check results of an asset, oldest first.
+// A limit of zero returns the full history.
+func (aidb *AssetIntegrityDB) HealthTrend(assetPath string, limit int) ([]HealthSample, error) {
+	aidb.mu.RLock()
+	defer aidb.mu.RUnlock()
+
+	file, err := os.Open(aidb.historyPath(assetPath))
+	if os.IsNotExist(err) {
+		return []HealthSample{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open check history: %w", err)
+	}
+	defer file.Close()
+
+	samples := make([]HealthSample, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var sample HealthSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			continue // skip a torn trailing write
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read check history: %w", err)
+	}
+
+	if limit > 0 && len(samples) > limit {
+		samples = samples[len(samples)-limit:]
+	}
+	return samples, nil
+}
+
+// AssetsWithStatus lists assets whose current status matches, sorted by path
+func (aidb *AssetIntegrityDB) AssetsWithStatus(status IntegrityStatus) []IntegrityIndexEntry {
+	entries := make([]IntegrityIndexEntry, 0)
+	for _, entry := range aidb.ListAssets() {
+		if entry.Status == status {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// ListAssets returns the current state of every tracked asset, sorted by path
+func (aidb *AssetIntegrityDB) ListAssets() []IntegrityIndexEntry {
+	aidb.mu.RLock()
+	defer aidb.mu.RUnlock()
+
+	entries := make([]IntegrityIndexEntry, 0, len(aidb.index))
+	for _, entry := range aidb.index {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].AssetPath < entries[j].AssetPath })
+	return entries
+}
+
+// loadIndex reads the index snapshot and replays the journal written since
+func (aidb *AssetIntegrityDB) loadIndex() error {
+	data, err := os.ReadFile(aidb.snapshotPath())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &aidb.index); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(aidb.journalPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry IntegrityIndexEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // skip a torn trailing write
+		}
+		aidb.index[entry.AssetPath] = &entry
+		aidb.journal++
+	}
+	return scanner.Err()
+}
+
+// updateIndex appends an asset's new state to the journal, folding the journal into the
+// snapshot once it has grown past the index itself
+func (aidb *AssetIntegrityDB) updateIndex(entry *IntegrityIndexEntry) error {
+	if aidb.journal >= max(minJournalCompaction, len(aidb.index)) {
+		return aidb.saveIndex()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(aidb.journalPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open index journal: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append index journal: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	aidb.journal++
+	return nil
+}
+
+// saveIndex writes a full snapshot and truncates the journal it supersedes
+func (aidb *AssetIntegrityDB) saveIndex() error {
+	data, err := json.MarshalIndent(aidb.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(aidb.snapshotPath(), data); err != nil {
+		return err
+	}
+	if err := os.Remove(aidb.journalPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	aidb.journal = 0
+	return nil
+}
+
+func (aidb *AssetIntegrityDB) snapshotPath() string {
+	return filepath.Join(aidb.dbPath, "index.json")
+}
+
+func (aidb *AssetIntegrityDB) journalPath() string {
+	return filepath.Join(aidb.dbPath, "index.jsonl")
+}
+
+func (aidb *AssetIntegrityDB) recordPath(assetPath, contentHash string) string {
+	return filepath.Join(aidb.dbPath, "records", pathKey(assetPath), contentHash+".json")
+}
+
+func (aidb *AssetIntegrityDB) historyPath(assetPath string) string {
+	return filepath.Join(aidb.dbPath, "history", pathKey(assetPath)+".jsonl")
+}
+
+// pathKey maps an asset path to a filesystem-safe key
+func pathKey(assetPath string) string {
+	hash := sha256.Sum256([]byte(filepath.ToSlash(assetPath)))
+	return fmt.Sprintf("%x", hash[:16])
+}
@@ -0,0 +1,226 @@
+// Asset recovery from the object store and commit history
+// Restores corrupted assets to their last healthy committed revision
+
+package integrity
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AssetObjectStore reads stored object content by content hash
+type AssetObjectStore interface {
+	ReadObject(hash string) ([]byte, error)
+}
+
+// AssetHistory lists the committed revisions of an asset, newest first
+type AssetHistory interface {
+	AssetRevisions(assetPath string) ([]AssetRevision, error)
+}
+
+// RecoveryRecorder records completed recoveries in the corruption log
+type RecoveryRecorder interface {
+	RecordRecovery(recovery AssetRecovery) error
+}
+
+// AssetRevision is a committed revision of an asset
+type AssetRevision struct {
+	CommitID    string    `json:"commit_id"`
+	ContentHash string    `json:"content_hash"`
+	Size        int64     `json:"size"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// AssetRecovery describes an asset restored into the working directory
+type AssetRecovery struct {
+	AssetPath    string        `json:"asset_path"`
+	Method       string        `json:"method"`
+	CommitID     string        `json:"commit_id,omitempty"`
+	VersionID    string        `json:"version_id,omitempty"`
+	PreviousHash string        `json:"previous_hash"`
+	RestoredHash string        `json:"restored_hash"`
+	Timestamp    time.Time     `json:"timestamp"`
+	RecoveryTime time.Duration `json:"recovery_time"`
+}
+
+// Recovery methods
+const (
+	RecoveryRestoredFromBackup = "restored_from_backup"
+	RecoveryRevertedToLastGood = "reverted_to_last_good"
+)
+
+// SetRecoverySources connects the version manager to the object store, commit history and corruption log
+func (avm *AssetVersionManager) SetRecoverySources(objects AssetObjectStore, history AssetHistory, recorder RecoveryRecorder) {
+	avm.objects = objects
+	avm.history = history
+	avm.recorder = recorder
+}
+
+// RestoreFromBackup restores a backup version of an asset into the working directory
+func (avm *AssetVersionManager) RestoreFromBackup(record *AssetIntegrityRecord, backup BackupVersion) (*AssetRecovery, error) {
+	if avm.objects == nil {
+		return nil, fmt.Errorf("no object store configured for recovery")
+	}
+	if !backup.IsHealthy || avm.knownBad(record)[backup.ContentHash] {
+		return nil, fmt.Errorf("backup %s is not healthy", backup.VersionID)
+	}
+
+	startTime := time.Now()
+	content, err := avm.readHealthyRevision(record.AssetPath, backup.ContentHash)
+	if err != nil {
+		return nil, fmt.Errorf("backup %s is unusable: %w", backup.VersionID, err)
+	}
+
+	recovery := &AssetRecovery{
+		AssetPath:    record.AssetPath,
+		Method:       RecoveryRestoredFromBackup,
+		VersionID:    backup.VersionID,
+		RestoredHash: backup.ContentHash,
+	}
+	return recovery, avm.restore(recovery, content, startTime)
+}
+
+// RevertToLastKnownGood restores the most recent committed revision of an asset that
+// passes integrity checks and isn't recorded as corrupt
+func (avm *AssetVersionManager) RevertToLastKnownGood(record *AssetIntegrityRecord) (*AssetRecovery, error) {
+	if avm.objects == nil || avm.history == nil {
+		return nil, fmt.Errorf("no commit history configured for recovery")
+	}
+
+	startTime := time.Now()
+	revisions, err := avm.history.AssetRevisions(record.AssetPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", record.AssetPath, err)
+	}
+
+	bad := avm.knownBad(record)
+	for _, revision := range revisions {
+		if bad[revision.ContentHash] {
+			continue
+		}
+
+		content, err := avm.readHealthyRevision(record.AssetPath, revision.ContentHash)
+		if err != nil {
+			continue // missing or damaged object, try an older revision
+		}
+
+		recovery := &AssetRecovery{
+			AssetPath:    record.AssetPath,
+			Method:       RecoveryRevertedToLastGood,
+			CommitID:     revision.CommitID,
+			RestoredHash: revision.ContentHash,
+		}
+		return recovery, avm.restore(recovery, content, startTime)
+	}
+
+	return nil, fmt.Errorf("no healthy committed revision of %s found", record.AssetPath)
+}
+
+// knownBad collects content hashes the integrity record has seen corrupted,
+// including the current working copy
+func (avm *AssetVersionManager) knownBad(record *AssetIntegrityRecord) map[string]bool {
+	bad := make(map[string]bool)
+	for _, check := range record.IntegrityChecks {
+		if check.Status == IntegrityCorrupted && check.ActualHash != "" {
+			bad[check.ActualHash] = true
+		}
+	}
+	for _, backup := range record.BackupVersions {
+		if !backup.IsHealthy {
+			bad[backup.ContentHash] = true
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(avm.projectPath, record.AssetPath)); err == nil {
+		if hash := hashContent(content); hash != record.ContentHash {
+			bad[hash] = true
+		}
+	}
+
+	return bad
+}
+
+// readHealthyRevision reads a stored revision and verifies its hash and package structure
+func (avm *AssetVersionManager) readHealthyRevision(assetPath, contentHash string) ([]byte, error) {
+	content, err := avm.objects.ReadObject(contentHash)
+	if err != nil {
+		return nil, err
+	}
+	if actual := hashContent(content); actual != contentHash {
+		return nil, fmt.Errorf("object %s is corrupted (hash %s)", contentHash, actual)
+	}
+
+	switch strings.ToLower(filepath.Ext(assetPath)) {
+	case ".uasset", ".umap":
+		if _, err := avm.analyzer.ParsePackage(content); err != nil {
+			return nil, fmt.Errorf("object %s is not a valid package: %w", contentHash, err)
+		}
+	}
+
+	return content, nil
+}
+
+// restore atomically replaces the working copy and records the recovery
+func (avm *AssetVersionManager) restore(recovery *AssetRecovery, content []byte, startTime time.Time) error {
+	fullPath := filepath.Join(avm.projectPath, recovery.AssetPath)
+	if previous, err := os.ReadFile(fullPath); err == nil {
+		recovery.PreviousHash = hashContent(previous)
+	}
+
+	if err := writeFileAtomic(fullPath, content); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", recovery.AssetPath, err)
+	}
+
+	recovery.Timestamp = time.Now()
+	recovery.RecoveryTime = time.Since(startTime)
+
+	if avm.recorder != nil {
+		if err := avm.recorder.RecordRecovery(*recovery); err != nil {
+			return fmt.Errorf("restored %s but failed to record recovery: %w", recovery.AssetPath, err)
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes content to a temporary file beside path and renames it into place
+func writeFileAtomic(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempPath := temp.Name()
+
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+func hashContent(content []byte) string {
+	hash := sha256.Sum256(content)
+	return fmt.Sprintf("%x", hash)
+}
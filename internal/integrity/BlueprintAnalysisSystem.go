@@ -53,6 +53,8 @@ type BlueprintAnalysis struct {
 // BlueprintNode represents a node in the Blueprint graph
 type BlueprintNode struct {
 	NodeID            string                 `json:"node_id"`
+	NodeGuid          string                 `json:"node_guid,omitempty"`
+	GraphName         string                 `json:"graph_name,omitempty"`
 	NodeType          string                 `json:"node_type"`
 	NodeClass         string                 `json:"node_class"`
 	FunctionName      string                 `json:"function_name"`
@@ -67,6 +69,7 @@ type BlueprintNode struct {
 // BlueprintFunction represents a custom function in the Blueprint
 type BlueprintFunction struct {
 	FunctionName   string              `json:"function_name"`
+	FunctionGuid   string              `json:"function_guid,omitempty"`
 	FunctionType   string              `json:"function_type"`
 	Parameters     []FunctionParameter `json:"parameters"`
 	ReturnType     string              `json:"return_type"`
@@ -83,6 +86,7 @@ type BlueprintFunction struct {
 // BlueprintVariable represents a variable in the Blueprint
 type BlueprintVariable struct {
 	VariableName    string      `json:"variable_name"`
+	VariableGuid    string      `json:"variable_guid,omitempty"`
 	VariableType    string      `json:"variable_type"`
 	DefaultValue    interface{} `json:"default_value"`
 	IsPublic        bool        `json:"is_public"`
@@ -254,23 +258,34 @@ func (bt *BlueprintTracker) AnalyzeBlueprint(content []byte) (*BlueprintAnalysis
 		RecommendedActions: []string{},
 	}
 
+	// Parse the package tables the graph is deserialized from
+	pkg, err := bt.analyzer.ParsePackage(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Blueprint package: %w", err)
+	}
+
+	graph, err := bt.extractGraph(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract Blueprint graph: %w", err)
+	}
+
 	// Parse Blueprint header and metadata
-	if err := bt.parseBlueprintHeader(content, analysis); err != nil {
+	if err := bt.parseBlueprintHeader(content, graph, analysis); err != nil {
 		return nil, fmt.Errorf("failed to parse Blueprint header: %w", err)
 	}
 
-	// Extract and analyze Blueprint logic graph
-	if err := bt.analyzeLogicGraph(content, analysis); err != nil {
+	// Analyze Blueprint logic graph
+	if err := bt.analyzeLogicGraph(graph, analysis); err != nil {
 		return nil, fmt.Errorf("failed to analyze logic graph: %w", err)
 	}
 
 	// Analyze functions and events
-	if err := bt.analyzeFunctions(content, analysis); err != nil {
+	if err := bt.analyzeFunctions(graph, analysis); err != nil {
 		return nil, fmt.Errorf("failed to analyze functions: %w", err)
 	}
 
 	// Analyze variables
-	if err := bt.analyzeVariables(content, analysis); err != nil {
+	if err := bt.analyzeVariables(graph, analysis); err != nil {
 		return nil, fmt.Errorf("failed to analyze variables: %w", err)
 	}
 
@@ -295,17 +310,11 @@ func (bt *BlueprintTracker) AnalyzeBlueprint(content []byte) (*BlueprintAnalysis
 }
 
 // parseBlueprintHeader extracts basic Blueprint information
-func (bt *BlueprintTracker) parseBlueprintHeader(content []byte, analysis *BlueprintAnalysis) error {
-	contentStr := string(content)
+func (bt *BlueprintTracker) parseBlueprintHeader(content []byte, graph *blueprintGraph, analysis *BlueprintAnalysis) error {
+	analysis.BlueprintClass = graph.generatedClass
 
-	// Extract Blueprint class information
-	if match := regexp.MustCompile(`BlueprintGeneratedClass\s*['"](.*?)['"]`).FindStringSubmatch(contentStr); len(match) > 1 {
-		analysis.BlueprintClass = match[1]
-	}
-
-	// Extract parent class
-	if match := regexp.MustCompile(`ParentClass\s*['"](.*?)['"]`).FindStringSubmatch(contentStr); len(match) > 1 {
-		analysis.ParentClass = match[1]
+	if parentClass, ok := graph.blueprint["ParentClass"].(string); ok {
+		analysis.ParentClass = parentClass
 	}
 
 	// Determine Blueprint type
@@ -317,72 +326,37 @@ func (bt *BlueprintTracker) parseBlueprintHeader(content []byte, analysis *Bluep
 	return nil
 }
 
-// analyzeLogicGraph extracts and analyzes the Blueprint node graph
-func (bt *BlueprintTracker) analyzeLogicGraph(content []byte, analysis *BlueprintAnalysis) error {
-	// Extract node information from Blueprint data
-	nodes, err := bt.extractNodes(content)
-	if err != nil {
-		return err
-	}
-
-	analysis.LogicNodes = nodes
-	analysis.NodeCount = len(nodes)
+// analyzeLogicGraph analyzes the deserialized Blueprint node graph
+func (bt *BlueprintTracker) analyzeLogicGraph(graph *blueprintGraph, analysis *BlueprintAnalysis) error {
+	analysis.LogicNodes = graph.nodes
+	analysis.NodeCount = len(graph.nodes)
 
 	// Analyze node connections and flow
-	bt.analyzeNodeConnections(nodes, analysis)
+	bt.analyzeNodeConnections(graph, analysis)
 
 	// Detect logic issues
-	bt.detectLogicIssues(nodes, analysis)
+	bt.detectLogicIssues(graph.nodes, analysis)
 
 	return nil
 }
 
-// extractNodes parses Blueprint content to extract node information
-func (bt *BlueprintTracker) extractNodes(content []byte) ([]BlueprintNode, error) {
-	var nodes []BlueprintNode
-	contentStr := string(content)
-
-	// Look for node patterns in the Blueprint data
-	nodePattern := regexp.MustCompile(`K2Node_(\w+)`)
-	matches := nodePattern.FindAllStringSubmatch(contentStr, -1)
-
-	for i, match := range matches {
-		if len(match) > 1 {
-			node := BlueprintNode{
-				NodeID:      fmt.Sprintf("node_%d", i),
-				NodeType:    match[1],
-				NodeClass:   fmt.Sprintf("K2Node_%s", match[1]),
-				IsValid:     true,
-				Connections: []NodeConnection{},
-				Properties:  make(map[string]interface{}),
-			}
-
-			// Analyze node type for performance impact
-			node.PerformanceImpact = bt.analyzeNodePerformance(node.NodeType)
-
-			nodes = append(nodes, node)
-		}
-	}
-
-	return nodes, nil
-}
-
-// analyzeNodeConnections traces connections between nodes
-func (bt *BlueprintTracker) analyzeNodeConnections(nodes []BlueprintNode, analysis *BlueprintAnalysis) {
-	// Build connection graph
-	connectionMap := make(map[string][]string)
+// analyzeNodeConnections traces exec flow between nodes
+func (bt *BlueprintTracker) analyzeNodeConnections(graph *blueprintGraph, analysis *BlueprintAnalysis) {
+	connectionMap := graph.execConnectionMap()
 
-	for _, node := range nodes {
-		for _, conn := range node.Connections {
-			connectionMap[node.NodeID] = append(connectionMap[node.NodeID], conn.TargetNodeID)
+	// Latent nodes yield a frame, so exec cycles through them don't hang the game thread
+	loopMap := make(map[string][]string, len(connectionMap))
+	for _, node := range graph.nodes {
+		if targets, ok := connectionMap[node.NodeID]; ok && !graph.isLatent(node) {
+			loopMap[node.NodeID] = targets
 		}
 	}
 
 	// Detect infinite loops
-	analysis.HasInfiniteLoops = bt.detectInfiniteLoops(connectionMap)
+	analysis.HasInfiniteLoops = bt.detectInfiniteLoops(loopMap)
 
 	// Detect unreachable code
-	analysis.HasUnreachableCode = bt.detectUnreachableCode(connectionMap)
+	analysis.HasUnreachableCode = bt.detectUnreachableCode(connectionMap, graph.entryNodes())
 
 	// Calculate logic complexity
 	analysis.LogicComplexity = bt.calculateLogicComplexity(connectionMap)
@@ -391,6 +365,35 @@ func (bt *BlueprintTracker) analyzeNodeConnections(nodes []BlueprintNode, analys
 // detectLogicIssues identifies various logic problems
 func (bt *BlueprintTracker) detectLogicIssues(nodes []BlueprintNode, analysis *BlueprintAnalysis) {
 	for _, node := range nodes {
+		// Check for nodes that failed to deserialize or link to missing nodes
+		if !node.IsValid {
+			analysis.HasLogicCorruption = true
+			analysis.PotentialIssues = append(analysis.PotentialIssues, BlueprintIssue{
+				IssueID:        fmt.Sprintf("invalid_node_%s", node.NodeID),
+				IssueType:      IssueLogicError,
+				Severity:       BlueprintSeverityError,
+				Description:    fmt.Sprintf("Node %s could not be read: %s", node.NodeID, node.ErrorMessage),
+				Location:       node.NodeID,
+				NodeID:         node.NodeID,
+				RecommendedFix: "Restore from backup or recreate the node",
+				AutoFixable:    false,
+			})
+		}
+		for _, conn := range node.Connections {
+			if !conn.IsValid {
+				analysis.PotentialIssues = append(analysis.PotentialIssues, BlueprintIssue{
+					IssueID:        fmt.Sprintf("broken_link_%s_%s", node.NodeID, conn.PinName),
+					IssueType:      IssueLogicError,
+					Severity:       BlueprintSeverityError,
+					Description:    fmt.Sprintf("Pin %s on %s links to a missing node", conn.PinName, node.NodeID),
+					Location:       node.NodeID,
+					NodeID:         node.NodeID,
+					RecommendedFix: "Reconnect or remove the dangling pin link",
+					AutoFixable:    false,
+				})
+			}
+		}
+
 		// Check for deprecated nodes
 		if bt.isDeprecatedNode(node.NodeType) {
 			issue := BlueprintIssue{
@@ -424,72 +427,172 @@ func (bt *BlueprintTracker) detectLogicIssues(nodes []BlueprintNode, analysis *B
 	}
 }
 
-// analyzeFunctions extracts and analyzes Blueprint functions
-func (bt *BlueprintTracker) analyzeFunctions(content []byte, analysis *BlueprintAnalysis) error {
-	contentStr := string(content)
+// analyzeFunctions extracts Blueprint functions and events from the graph
+func (bt *BlueprintTracker) analyzeFunctions(graph *blueprintGraph, analysis *BlueprintAnalysis) error {
+	callCounts := make(map[string]int)
+	for _, node := range graph.nodes {
+		if node.NodeClass == "K2Node_CallFunction" && node.FunctionName != "" {
+			callCounts[node.FunctionName]++
+		}
+	}
+
+	for _, graphName := range graph.graphNames("FunctionGraphs") {
+		nodes := graph.nodesInGraph(graphName)
+		function := BlueprintFunction{
+			FunctionName:   graphName,
+			FunctionGuid:   graph.graphGuids[graphName],
+			FunctionType:   "UserDefined",
+			Parameters:     []FunctionParameter{},
+			AccessModifier: "Public",
+			NodeCount:      len(nodes),
+			CallCount:      callCounts[graphName],
+			HasSideEffects: true,
+		}
 
-	// Extract function definitions
-	functionPattern := regexp.MustCompile(`UserDefinedFunction\s*['"](.*?)['"]`)
-	matches := functionPattern.FindAllStringSubmatch(contentStr, -1)
+		for _, node := range nodes {
+			switch node.NodeClass {
+			case "K2Node_FunctionEntry":
+				flags := propertyUint(node.Properties, "ExtraFlags")
+				function.IsPure = flags&funcBlueprintPure != 0
+				function.HasSideEffects = !function.IsPure
+				switch {
+				case flags&funcPrivate != 0:
+					function.AccessModifier = "Private"
+				case flags&funcProtected != 0:
+					function.AccessModifier = "Protected"
+				}
 
-	for _, match := range matches {
-		if len(match) > 1 {
-			function := BlueprintFunction{
-				FunctionName:   match[1],
-				FunctionType:   "UserDefined",
-				Parameters:     []FunctionParameter{},
-				AccessModifier: "Public",
-				NodeCount:      bt.countFunctionNodes(contentStr, match[1]),
+				// Entry outputs are the function's parameters
+				for _, pin := range graph.pins[node.NodeID] {
+					if pin.Direction == pinDirectionOut && pin.Category != pinCategoryExec {
+						function.Parameters = append(function.Parameters, FunctionParameter{
+							ParameterName: pin.PinName,
+							ParameterType: pin.Category,
+						})
+					}
+				}
+			case "K2Node_FunctionResult":
+				for _, pin := range graph.pins[node.NodeID] {
+					if pin.Direction != pinDirectionOut && pin.Category != pinCategoryExec && function.ReturnType == "" {
+						function.ReturnType = pin.Category
+					}
+				}
+			case "K2Node_CallFunction":
+				if node.FunctionName == graphName {
+					function.IsRecursive = true
+				}
 			}
+		}
+
+		// Calculate function complexity
+		function.Complexity = bt.calculateFunctionComplexity(function)
+
+		analysis.Functions = append(analysis.Functions, function)
+	}
 
-			// Calculate function complexity
-			function.Complexity = bt.calculateFunctionComplexity(function)
+	for _, node := range graph.nodes {
+		if !entryNodeClasses[node.NodeClass] || node.NodeClass == "K2Node_FunctionEntry" || node.NodeClass == "K2Node_Tunnel" {
+			continue
+		}
 
-			// Check if function is recursive
-			function.IsRecursive = bt.detectRecursion(contentStr, match[1])
+		event := BlueprintEvent{
+			EventName:     node.FunctionName,
+			EventType:     node.NodeType,
+			TriggerType:   "Native",
+			Parameters:    []string{},
+			IsCustomEvent: node.NodeClass == "K2Node_CustomEvent",
+			IsNetworked:   propertyUint(node.Properties, "FunctionFlags")&funcNet != 0,
+			CallFrequency: "OnDemand",
+		}
+		if event.IsCustomEvent {
+			event.TriggerType = "Custom"
+		}
+		if strings.Contains(node.NodeClass, "Input") {
+			event.TriggerType = "Input"
+		}
+		if event.EventName == "ReceiveTick" {
+			event.CallFrequency = "PerFrame"
+			event.PerformanceRisk = "high"
+		}
 
-			analysis.Functions = append(analysis.Functions, function)
+		for _, pin := range graph.pins[node.NodeID] {
+			if pin.Direction == pinDirectionOut && pin.Category != pinCategoryExec {
+				event.Parameters = append(event.Parameters, pin.PinName)
+			}
 		}
+
+		analysis.Events = append(analysis.Events, event)
 	}
 
 	analysis.FunctionCount = len(analysis.Functions)
+	analysis.EventCount = len(analysis.Events)
 	return nil
 }
 
-// analyzeVariables extracts and analyzes Blueprint variables
-func (bt *BlueprintTracker) analyzeVariables(content []byte, analysis *BlueprintAnalysis) error {
-	contentStr := string(content)
+// analyzeVariables extracts Blueprint member variables and counts their get/set nodes
+func (bt *BlueprintTracker) analyzeVariables(graph *blueprintGraph, analysis *BlueprintAnalysis) error {
+	usage := make(map[string]int)
+	for _, node := range graph.nodes {
+		if strings.HasPrefix(node.NodeClass, "K2Node_VariableGet") || strings.HasPrefix(node.NodeClass, "K2Node_VariableSet") {
+			usage[node.FunctionName]++
+		}
+	}
 
-	// Extract variable definitions
-	variablePattern := regexp.MustCompile(`VariableName\s*['"](.*?)['"]`)
-	matches := variablePattern.FindAllStringSubmatch(contentStr, -1)
+	descriptions, _ := graph.blueprint["NewVariables"].([]interface{})
+	for _, value := range descriptions {
+		description, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
 
-	for _, match := range matches {
-		if len(match) > 1 {
-			variable := BlueprintVariable{
-				VariableName: match[1],
-				VariableType: bt.extractVariableType(contentStr, match[1]),
-				IsPublic:     bt.isPublicVariable(contentStr, match[1]),
-				UsageCount:   bt.countVariableUsage(contentStr, match[1]),
-			}
+		name, _ := description["VarName"].(string)
+		if name == "" {
+			continue
+		}
 
-			// Mark unused variables
-			variable.IsUnused = variable.UsageCount == 0
-
-			if variable.IsUnused {
-				issue := BlueprintIssue{
-					IssueID:        fmt.Sprintf("unused_var_%s", variable.VariableName),
-					IssueType:      IssueUnusedVariable,
-					Severity:       BlueprintSeverityInfo,
-					Description:    fmt.Sprintf("Variable %s is unused", variable.VariableName),
-					RecommendedFix: "Remove unused variable",
-					AutoFixable:    true,
-				}
-				analysis.PotentialIssues = append(analysis.PotentialIssues, issue)
+		flags := propertyUint(description, "PropertyFlags")
+		variable := BlueprintVariable{
+			VariableName:    name,
+			VariableGuid:    propertyString(description, "VarGuid"),
+			VariableType:    "Unknown",
+			DefaultValue:    description["DefaultValue"],
+			IsEditable:      flags&cpfEdit != 0,
+			ReplicationMode: "None",
+			UsageCount:      usage[name],
+		}
+		variable.IsPublic = (variable.IsEditable && flags&cpfDisableEditOnInstance == 0) || flags&cpfExposeOnSpawn != 0
+
+		if varType, ok := description["VarType"].(map[string]interface{}); ok {
+			if category, ok := varType["PinCategory"].(string); ok && category != "" {
+				variable.VariableType = category
 			}
+		}
+		if category, ok := description["Category"].(string); ok {
+			variable.Category = category
+		}
+		switch {
+		case flags&cpfRepNotify != 0:
+			variable.ReplicationMode = "RepNotify"
+		case flags&cpfNet != 0:
+			variable.ReplicationMode = "Replicated"
+		}
 
-			analysis.Variables = append(analysis.Variables, variable)
+		// Mark unused variables
+		variable.IsUnused = variable.UsageCount == 0
+
+		if variable.IsUnused {
+			issue := BlueprintIssue{
+				IssueID:        fmt.Sprintf("unused_var_%s", variable.VariableName),
+				IssueType:      IssueUnusedVariable,
+				Severity:       BlueprintSeverityInfo,
+				Description:    fmt.Sprintf("Variable %s is unused", variable.VariableName),
+				RecommendedFix: "Remove unused variable",
+				AutoFixable:    true,
+			}
+			analysis.PotentialIssues = append(analysis.PotentialIssues, issue)
 		}
+
+		analysis.Variables = append(analysis.Variables, variable)
 	}
 
 	analysis.VariableCount = len(analysis.Variables)
@@ -676,24 +779,14 @@ func (bt *BlueprintTracker) hasCycleDFS(node string, graph map[string][]string,
 	return false
 }
 
-func (bt *BlueprintTracker) detectUnreachableCode(connectionMap map[string][]string) bool {
-	// Find entry points (nodes with no incoming connections)
-	hasIncoming := make(map[string]bool)
-	for _, targets := range connectionMap {
-		for _, target := range targets {
-			hasIncoming[target] = true
-		}
-	}
-
-	// Start DFS from entry points
+func (bt *BlueprintTracker) detectUnreachableCode(connectionMap map[string][]string, entryPoints []string) bool {
+	// Walk exec flow from events and function entries
 	reachable := make(map[string]bool)
-	for node := range connectionMap {
-		if !hasIncoming[node] {
-			bt.markReachableDFS(node, connectionMap, reachable)
-		}
+	for _, entry := range entryPoints {
+		bt.markReachableDFS(entry, connectionMap, reachable)
 	}
 
-	// Check if any nodes are unreachable
+	// Any exec node not reached can never run
 	for node := range connectionMap {
 		if !reachable[node] {
 			return true
@@ -713,15 +806,18 @@ func (bt *BlueprintTracker) markReachableDFS(node string, graph map[string][]str
 }
 
 func (bt *BlueprintTracker) calculateLogicComplexity(connectionMap map[string][]string) int {
-	// Simplified cyclomatic complexity calculation
+	if len(connectionMap) == 0 {
+		return 1
+	}
+
 	edges := 0
 	for _, targets := range connectionMap {
 		edges += len(targets)
 	}
 	nodes := len(connectionMap)
 
-	// Cyclomatic complexity = E - N + 2P (P = connected components, simplified to 1)
-	complexity := edges - nodes + 2
+	// Cyclomatic complexity = E - N + 2P, P = weakly connected components (one per entry chain)
+	complexity := edges - nodes + 2*bt.countComponents(connectionMap)
 	if complexity < 1 {
 		complexity = 1
 	}
@@ -729,6 +825,29 @@ func (bt *BlueprintTracker) calculateLogicComplexity(connectionMap map[string][]
 	return complexity
 }
 
+// countComponents counts weakly connected components of a connection graph
+func (bt *BlueprintTracker) countComponents(connectionMap map[string][]string) int {
+	undirected := make(map[string][]string)
+	for node, targets := range connectionMap {
+		for _, target := range targets {
+			undirected[node] = append(undirected[node], target)
+			undirected[target] = append(undirected[target], node)
+		}
+	}
+
+	visited := make(map[string]bool)
+	components := 0
+	for node := range connectionMap {
+		if visited[node] {
+			continue
+		}
+		components++
+		bt.markReachableDFS(node, undirected, visited)
+	}
+
+	return components
+}
+
 func (bt *BlueprintTracker) isDeprecatedNode(nodeType string) bool {
 	deprecatedNodes := []string{"OldFunction", "DeprecatedNode", "LegacyCall"}
 	for _, deprecated := range deprecatedNodes {
@@ -739,13 +858,6 @@ func (bt *BlueprintTracker) isDeprecatedNode(nodeType string) bool {
 	return false
 }
 
-func (bt *BlueprintTracker) countFunctionNodes(content, functionName string) int {
-	// Count nodes in a specific function (simplified)
-	pattern := fmt.Sprintf(`%s.*?K2Node`, regexp.QuoteMeta(functionName))
-	matches := regexp.MustCompile(pattern).FindAllString(content, -1)
-	return len(matches)
-}
-
 func (bt *BlueprintTracker) calculateFunctionComplexity(function BlueprintFunction) int {
 	// Base complexity
 	complexity := 1
@@ -764,33 +876,6 @@ func (bt *BlueprintTracker) calculateFunctionComplexity(function BlueprintFuncti
 	return complexity
 }
 
-func (bt *BlueprintTracker) detectRecursion(content, functionName string) bool {
-	// Look for function calling itself
-	pattern := fmt.Sprintf(`%s.*?CallFunction.*?%s`, regexp.QuoteMeta(functionName), regexp.QuoteMeta(functionName))
-	matched, _ := regexp.MatchString(pattern, content)
-	return matched
-}
-
-func (bt *BlueprintTracker) extractVariableType(content, varName string) string {
-	pattern := fmt.Sprintf(`%s.*?VariableType.*?["'](.*?)["']`, regexp.QuoteMeta(varName))
-	matches := regexp.MustCompile(pattern).FindStringSubmatch(content)
-	if len(matches) > 1 {
-		return matches[1]
-	}
-	return "Unknown"
-}
-
-func (bt *BlueprintTracker) isPublicVariable(content, varName string) bool {
-	pattern := fmt.Sprintf(`%s.*?bExposeOnSpawn.*?true`, regexp.QuoteMeta(varName))
-	matched, _ := regexp.MatchString(pattern, content)
-	return matched
-}
-
-func (bt *BlueprintTracker) countVariableUsage(content, varName string) int {
-	matches := regexp.MustCompile(regexp.QuoteMeta(varName)).FindAllString(content, -1)
-	return len(matches) - 1 // Subtract 1 for the declaration
-}
-
 func (bt *BlueprintTracker) validateDependency(dep *BlueprintDependency) {
 	// Simplified validation - check if file exists
 	if _, err := os.Stat(dep.DependencyPath); os.IsNotExist(err) {
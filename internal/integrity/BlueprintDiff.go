@@ -0,0 +1,380 @@
+// Semantic Blueprint diff
+// Compares two analyzed Blueprint revisions member by member and graph by graph
+
+package integrity
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Member change kinds
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeRenamed  = "renamed"
+	ChangeModified = "modified"
+)
+
+// BlueprintDiff is a semantic diff between two revisions of a Blueprint
+type BlueprintDiff struct {
+	FromClass   string         `json:"from_class"`
+	ToClass     string         `json:"to_class"`
+	ParentClass *ValueChange   `json:"parent_class,omitempty"`
+	Variables   []MemberChange `json:"variables"`
+	Functions   []MemberChange `json:"functions"`
+	Events      []MemberChange `json:"events"`
+	Graphs      []GraphDiff    `json:"graphs"`
+}
+
+// MemberChange describes an added, removed, renamed or modified variable, function or event
+type MemberChange struct {
+	Change  string        `json:"change"`
+	Name    string        `json:"name"`
+	OldName string        `json:"old_name,omitempty"`
+	Details []ValueChange `json:"details,omitempty"`
+}
+
+// ValueChange is a single field that differs between revisions
+type ValueChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// GraphDiff lists node and wiring changes within one graph
+type GraphDiff struct {
+	Graph        string       `json:"graph"`
+	AddedNodes   []NodeChange `json:"added_nodes"`
+	RemovedNodes []NodeChange `json:"removed_nodes"`
+	Rewired      []PinChange  `json:"rewired"`
+}
+
+// NodeChange identifies a node that was added or removed
+type NodeChange struct {
+	NodeID       string `json:"node_id"`
+	NodeClass    string `json:"node_class"`
+	FunctionName string `json:"function_name,omitempty"`
+}
+
+// PinChange is a pin link that was made or broken
+type PinChange struct {
+	NodeID     string `json:"node_id"`
+	PinName    string `json:"pin_name"`
+	TargetNode string `json:"target_node"`
+	Change     string `json:"change"` // added or removed
+}
+
+// HasChanges reports whether the two revisions differ semantically
+func (d *BlueprintDiff) HasChanges() bool {
+	return d.ParentClass != nil || len(d.Variables) > 0 || len(d.Functions) > 0 ||
+		len(d.Events) > 0 || len(d.Graphs) > 0
+}
+
+// DiffBlueprints analyzes two Blueprint revisions and compares them. Either side may be
+// empty to represent an added or deleted Blueprint.
+func (bt *BlueprintTracker) DiffBlueprints(fromContent, toContent []byte) (*BlueprintDiff, error) {
+	from, err := bt.analyzeRevision(fromContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze old revision: %w", err)
+	}
+	to, err := bt.analyzeRevision(toContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze new revision: %w", err)
+	}
+
+	diff := &BlueprintDiff{
+		FromClass: from.BlueprintClass,
+		ToClass:   to.BlueprintClass,
+		Variables: bt.diffVariables(from.Variables, to.Variables),
+		Functions: bt.diffFunctions(from.Functions, to.Functions),
+		Events:    bt.diffEvents(from.Events, to.Events),
+		Graphs:    bt.diffGraphs(from.LogicNodes, to.LogicNodes),
+	}
+
+	if from.ParentClass != to.ParentClass && len(fromContent) > 0 && len(toContent) > 0 {
+		diff.ParentClass = &ValueChange{Field: "parent_class", From: from.ParentClass, To: to.ParentClass}
+	}
+
+	return diff, nil
+}
+
+func (bt *BlueprintTracker) analyzeRevision(content []byte) (*BlueprintAnalysis, error) {
+	if len(content) == 0 {
+		return &BlueprintAnalysis{}, nil
+	}
+	return bt.AnalyzeBlueprint(content)
+}
+
+func (bt *BlueprintTracker) diffVariables(from, to []BlueprintVariable) []MemberChange {
+	keyOf := func(v BlueprintVariable) string { return memberKey(v.VariableGuid, v.VariableName) }
+
+	oldVars := make(map[string]BlueprintVariable, len(from))
+	for _, v := range from {
+		oldVars[keyOf(v)] = v
+	}
+
+	changes := []MemberChange{}
+	seen := make(map[string]bool)
+	for _, newVar := range to {
+		key := keyOf(newVar)
+		seen[key] = true
+
+		oldVar, existed := oldVars[key]
+		if !existed {
+			changes = append(changes, MemberChange{Change: ChangeAdded, Name: newVar.VariableName})
+			continue
+		}
+
+		var details []ValueChange
+		details = appendChange(details, "type", oldVar.VariableType, newVar.VariableType)
+		details = appendChange(details, "default_value", fmt.Sprint(oldVar.DefaultValue), fmt.Sprint(newVar.DefaultValue))
+		details = appendChange(details, "category", oldVar.Category, newVar.Category)
+		details = appendChange(details, "public", oldVar.IsPublic, newVar.IsPublic)
+		details = appendChange(details, "replication", oldVar.ReplicationMode, newVar.ReplicationMode)
+
+		if change, ok := memberChange(oldVar.VariableName, newVar.VariableName, details); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	for _, oldVar := range from {
+		if !seen[keyOf(oldVar)] {
+			changes = append(changes, MemberChange{Change: ChangeRemoved, Name: oldVar.VariableName})
+		}
+	}
+
+	return changes
+}
+
+func (bt *BlueprintTracker) diffFunctions(from, to []BlueprintFunction) []MemberChange {
+	keyOf := func(f BlueprintFunction) string { return memberKey(f.FunctionGuid, f.FunctionName) }
+
+	oldFuncs := make(map[string]BlueprintFunction, len(from))
+	for _, f := range from {
+		oldFuncs[keyOf(f)] = f
+	}
+
+	changes := []MemberChange{}
+	seen := make(map[string]bool)
+	for _, newFunc := range to {
+		key := keyOf(newFunc)
+		seen[key] = true
+
+		oldFunc, existed := oldFuncs[key]
+		if !existed {
+			changes = append(changes, MemberChange{Change: ChangeAdded, Name: newFunc.FunctionName})
+			continue
+		}
+
+		var details []ValueChange
+		details = appendChange(details, "parameters", parameterSignature(oldFunc.Parameters), parameterSignature(newFunc.Parameters))
+		details = appendChange(details, "return_type", oldFunc.ReturnType, newFunc.ReturnType)
+		details = appendChange(details, "access", oldFunc.AccessModifier, newFunc.AccessModifier)
+		details = appendChange(details, "pure", oldFunc.IsPure, newFunc.IsPure)
+		details = appendChange(details, "node_count", oldFunc.NodeCount, newFunc.NodeCount)
+
+		if change, ok := memberChange(oldFunc.FunctionName, newFunc.FunctionName, details); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	for _, oldFunc := range from {
+		if !seen[keyOf(oldFunc)] {
+			changes = append(changes, MemberChange{Change: ChangeRemoved, Name: oldFunc.FunctionName})
+		}
+	}
+
+	return changes
+}
+
+func (bt *BlueprintTracker) diffEvents(from, to []BlueprintEvent) []MemberChange {
+	oldEvents := make(map[string]BlueprintEvent, len(from))
+	for _, e := range from {
+		oldEvents[e.EventName] = e
+	}
+
+	changes := []MemberChange{}
+	seen := make(map[string]bool)
+	for _, newEvent := range to {
+		seen[newEvent.EventName] = true
+
+		oldEvent, existed := oldEvents[newEvent.EventName]
+		if !existed {
+			changes = append(changes, MemberChange{Change: ChangeAdded, Name: newEvent.EventName})
+			continue
+		}
+
+		var details []ValueChange
+		details = appendChange(details, "parameters", fmt.Sprint(oldEvent.Parameters), fmt.Sprint(newEvent.Parameters))
+		details = appendChange(details, "networked", oldEvent.IsNetworked, newEvent.IsNetworked)
+
+		if change, ok := memberChange(oldEvent.EventName, newEvent.EventName, details); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	for _, oldEvent := range from {
+		if !seen[oldEvent.EventName] {
+			changes = append(changes, MemberChange{Change: ChangeRemoved, Name: oldEvent.EventName})
+		}
+	}
+
+	return changes
+}
+
+// diffGraphs compares nodes and pin links per graph, matching nodes by NodeGuid
+func (bt *BlueprintTracker) diffGraphs(from, to []BlueprintNode) []GraphDiff {
+	oldNodes, oldIdentity := indexNodes(from)
+	newNodes, newIdentity := indexNodes(to)
+
+	graphs := make(map[string]*GraphDiff)
+	graphFor := func(name string) *GraphDiff {
+		if graph, ok := graphs[name]; ok {
+			return graph
+		}
+		graph := &GraphDiff{Graph: name, AddedNodes: []NodeChange{}, RemovedNodes: []NodeChange{}, Rewired: []PinChange{}}
+		graphs[name] = graph
+		return graph
+	}
+
+	for _, key := range sortedNodeKeys(newNodes) {
+		newNode := newNodes[key]
+		oldNode, existed := oldNodes[key]
+		if !existed {
+			graph := graphFor(newNode.GraphName)
+			graph.AddedNodes = append(graph.AddedNodes, nodeChange(newNode))
+			continue
+		}
+
+		// Compare links by target identity so renumbered node names don't show as rewires
+		oldLinks := nodeLinks(oldNode, oldIdentity)
+		newLinks := nodeLinks(newNode, newIdentity)
+		for _, link := range sortedLinkKeys(newLinks) {
+			if !oldLinks[link].made {
+				graph := graphFor(newNode.GraphName)
+				graph.Rewired = append(graph.Rewired, newLinks[link].change(newNode.NodeID, ChangeAdded))
+			}
+		}
+		for _, link := range sortedLinkKeys(oldLinks) {
+			if !newLinks[link].made {
+				graph := graphFor(newNode.GraphName)
+				graph.Rewired = append(graph.Rewired, oldLinks[link].change(newNode.NodeID, ChangeRemoved))
+			}
+		}
+	}
+
+	for _, key := range sortedNodeKeys(oldNodes) {
+		if _, exists := newNodes[key]; !exists {
+			oldNode := oldNodes[key]
+			graph := graphFor(oldNode.GraphName)
+			graph.RemovedNodes = append(graph.RemovedNodes, nodeChange(oldNode))
+		}
+	}
+
+	names := make([]string, 0, len(graphs))
+	for name := range graphs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]GraphDiff, 0, len(names))
+	for _, name := range names {
+		result = append(result, *graphs[name])
+	}
+	return result
+}
+
+type nodeLink struct {
+	made       bool
+	pinName    string
+	targetNode string
+}
+
+func (l nodeLink) change(nodeID, change string) PinChange {
+	return PinChange{NodeID: nodeID, PinName: l.pinName, TargetNode: l.targetNode, Change: change}
+}
+
+// nodeLinks keys a node's outgoing links by pin name and target node identity
+func nodeLinks(node BlueprintNode, identity map[string]string) map[string]nodeLink {
+	links := make(map[string]nodeLink, len(node.Connections))
+	for _, conn := range node.Connections {
+		target := identity[conn.TargetNodeID]
+		if target == "" {
+			target = conn.TargetNodeID
+		}
+		links[conn.PinName+"->"+target] = nodeLink{made: true, pinName: conn.PinName, targetNode: conn.TargetNodeID}
+	}
+	return links
+}
+
+// indexNodes keys nodes by identity and maps node IDs to that identity
+func indexNodes(nodes []BlueprintNode) (map[string]BlueprintNode, map[string]string) {
+	index := make(map[string]BlueprintNode, len(nodes))
+	identity := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		key := memberKey(node.NodeGuid, node.NodeID)
+		index[key] = node
+		identity[node.NodeID] = key
+	}
+	return index, identity
+}
+
+func nodeChange(node BlueprintNode) NodeChange {
+	return NodeChange{NodeID: node.NodeID, NodeClass: node.NodeClass, FunctionName: node.FunctionName}
+}
+
+// memberKey prefers a stable GUID and falls back to the name
+func memberKey(guid, name string) string {
+	if guid != "" && guid != "00000000000000000000000000000000" {
+		return guid
+	}
+	return "name:" + name
+}
+
+// memberChange turns a matched pair into a renamed/modified change, if anything differs
+func memberChange(oldName, newName string, details []ValueChange) (MemberChange, bool) {
+	if oldName != newName {
+		return MemberChange{Change: ChangeRenamed, Name: newName, OldName: oldName, Details: details}, true
+	}
+	if len(details) > 0 {
+		return MemberChange{Change: ChangeModified, Name: newName, Details: details}, true
+	}
+	return MemberChange{}, false
+}
+
+func appendChange(details []ValueChange, field string, from, to interface{}) []ValueChange {
+	if from != to {
+		details = append(details, ValueChange{Field: field, From: from, To: to})
+	}
+	return details
+}
+
+func parameterSignature(params []FunctionParameter) string {
+	signature := ""
+	for i, param := range params {
+		if i > 0 {
+			signature += ", "
+		}
+		signature += param.ParameterName + ": " + param.ParameterType
+	}
+	return signature
+}
+
+func sortedNodeKeys(nodes map[string]BlueprintNode) []string {
+	keys := make([]string, 0, len(nodes))
+	for key := range nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedLinkKeys(links map[string]nodeLink) []string {
+	keys := make([]string, 0, len(links))
+	for key := range links {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
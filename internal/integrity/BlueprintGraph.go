@@ -0,0 +1,485 @@
+// Blueprint graph extraction
+// Deserializes UEdGraph/UEdGraphNode exports and their pin links into BlueprintNode graphs
+
+package integrity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Telerallc/gamedev-vcs/internal/analyzer"
+)
+
+// Pin categories and directions as serialized by UEdGraphPin
+const (
+	pinCategoryExec = "exec"
+	pinDirectionOut = 1
+)
+
+// Function flags stored in K2Node_FunctionEntry.ExtraFlags
+const (
+	funcBlueprintPure = 0x10000000
+	funcPublic        = 0x00020000
+	funcPrivate       = 0x00040000
+	funcProtected     = 0x00080000
+	funcNet           = 0x00000040
+)
+
+// Property flags stored in BPVariableDescription.PropertyFlags
+const (
+	cpfEdit                  = 0x0000000000000001
+	cpfNet                   = 0x0000000000000020
+	cpfDisableEditOnInstance = 0x0000000000010000
+	cpfRepNotify             = 0x0000000100000000
+	cpfExposeOnSpawn         = 0x0001000000000000
+)
+
+// Node classes that start execution within a graph
+var entryNodeClasses = map[string]bool{
+	"K2Node_Event":                true,
+	"K2Node_CustomEvent":          true,
+	"K2Node_ComponentBoundEvent":  true,
+	"K2Node_ActorBoundEvent":      true,
+	"K2Node_FunctionEntry":        true,
+	"K2Node_Tunnel":               true,
+	"K2Node_InputAction":          true,
+	"K2Node_InputAxisEvent":       true,
+	"K2Node_InputKey":             true,
+	"K2Node_InputTouch":           true,
+	"K2Node_EnhancedInputAction":  true,
+	"K2Node_GeneratedBoundEvent":  true,
+	"K2Node_InputActionEvent":     true,
+	"K2Node_InputVectorAxisEvent": true,
+}
+
+// Functions that suspend execution, so an exec cycle through them is not an infinite loop
+var latentFunctions = map[string]bool{
+	"Delay":               true,
+	"RetriggerableDelay":  true,
+	"DelayUntilNextTick":  true,
+	"MoveComponentTo":     true,
+	"LoadAsset":           true,
+	"LoadClassAsset":      true,
+	"AsyncLoadAsset":      true,
+	"DelayUntilNextFrame": true,
+}
+
+// blueprintGraph is the deserialized node graph of a Blueprint package
+type blueprintGraph struct {
+	pkg            *analyzer.Package
+	nodes          []BlueprintNode
+	pins           map[string][]graphPin  // node ID -> pins
+	graphOf        map[string]string      // node ID -> owning graph name
+	graphGuids     map[string]string      // graph name -> GraphGuid
+	blueprint      map[string]interface{} // Blueprint export properties
+	generatedClass string
+}
+
+type graphPin struct {
+	PinID     string
+	PinName   string
+	Direction uint8
+	Category  string
+	LinkedTo  []pinLink
+}
+
+type pinLink struct {
+	OwningNode int32
+	PinID      string
+}
+
+// extractGraph deserializes every graph node export of a Blueprint package
+func (bt *BlueprintTracker) extractGraph(pkg *analyzer.Package) (*blueprintGraph, error) {
+	graph := &blueprintGraph{
+		pkg:        pkg,
+		nodes:      []BlueprintNode{},
+		pins:       make(map[string][]graphPin),
+		graphOf:    make(map[string]string),
+		graphGuids: make(map[string]string),
+		blueprint:  make(map[string]interface{}),
+	}
+
+	nodeExports := make(map[int32]bool)
+	for i := range pkg.Exports {
+		export := &pkg.Exports[i]
+		class := pkg.ExportClass(export)
+
+		switch {
+		case isGraphNodeClass(class):
+			nodeExports[int32(export.Index)] = true
+		case strings.HasSuffix(class, "Blueprint"):
+			properties, err := bt.readExportProperties(pkg, export)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s properties: %w", export.ObjectName, err)
+			}
+			graph.blueprint = analyzer.PropertyMap(properties)
+		case strings.HasSuffix(class, "GeneratedClass"):
+			graph.generatedClass = export.ObjectName
+		case class == "EdGraph":
+			// GraphGuid survives renames, so diffs can track renamed functions
+			if properties, err := bt.readExportProperties(pkg, export); err == nil {
+				graph.graphGuids[export.ObjectName] = propertyString(analyzer.PropertyMap(properties), "GraphGuid")
+			}
+		}
+	}
+
+	for i := range pkg.Exports {
+		export := &pkg.Exports[i]
+		if !nodeExports[int32(export.Index)] {
+			continue
+		}
+
+		node, pins := bt.readGraphNode(pkg, export, nodeExports)
+		node.GraphName = pkg.ObjectName(export.OuterIndex)
+		graph.nodes = append(graph.nodes, node)
+		graph.pins[node.NodeID] = pins
+		graph.graphOf[node.NodeID] = node.GraphName
+	}
+
+	return graph, nil
+}
+
+// readGraphNode decodes a node's tagged properties and pins into a BlueprintNode
+func (bt *BlueprintTracker) readGraphNode(pkg *analyzer.Package, export *analyzer.PackageExport, nodeExports map[int32]bool) (BlueprintNode, []graphPin) {
+	class := pkg.ExportClass(export)
+	node := BlueprintNode{
+		NodeID:      export.ObjectName,
+		NodeType:    strings.TrimPrefix(class, "K2Node_"),
+		NodeClass:   class,
+		Connections: []NodeConnection{},
+		Properties:  make(map[string]interface{}),
+		IsValid:     true,
+	}
+	node.PerformanceImpact = bt.analyzeNodePerformance(node.NodeType)
+
+	reader, err := pkg.ExportReader(export)
+	if err != nil {
+		node.IsValid = false
+		node.ErrorMessage = err.Error()
+		return node, nil
+	}
+
+	properties, err := reader.ReadTaggedProperties()
+	node.Properties = analyzer.PropertyMap(properties)
+	if err != nil {
+		node.IsValid = false
+		node.ErrorMessage = fmt.Sprintf("failed to read node properties: %v", err)
+		return node, nil
+	}
+
+	node.FunctionName = nodeFunctionName(node.Properties)
+	node.NodeGuid = propertyString(node.Properties, "NodeGuid")
+	if x, ok := node.Properties["NodePosX"].(int32); ok {
+		node.Position.X = float64(x)
+	}
+	if y, ok := node.Properties["NodePosY"].(int32); ok {
+		node.Position.Y = float64(y)
+	}
+
+	// UObject trails its properties with an optional lazy object GUID
+	hasGuid, err := reader.ReadBool()
+	if err == nil && hasGuid {
+		err = reader.Skip(16)
+	}
+	if err != nil {
+		node.ErrorMessage = fmt.Sprintf("failed to read object guid: %v", err)
+		return node, nil
+	}
+
+	pins, err := readPinArray(reader)
+	if err != nil {
+		// Properties are still usable; the node just contributes no links
+		node.ErrorMessage = fmt.Sprintf("failed to read pins: %v", err)
+		return node, pins
+	}
+
+	for _, pin := range pins {
+		if pin.Direction != pinDirectionOut {
+			continue
+		}
+
+		connectionType := "data"
+		if pin.Category == pinCategoryExec {
+			connectionType = pinCategoryExec
+		}
+
+		for _, link := range pin.LinkedTo {
+			target := pkg.ObjectName(link.OwningNode)
+			node.Connections = append(node.Connections, NodeConnection{
+				TargetNodeID:   target,
+				ConnectionType: connectionType,
+				PinName:        pin.PinName,
+				IsValid:        target != "" && nodeExports[link.OwningNode],
+			})
+		}
+	}
+
+	return node, pins
+}
+
+func (bt *BlueprintTracker) readExportProperties(pkg *analyzer.Package, export *analyzer.PackageExport) ([]analyzer.TaggedProperty, error) {
+	reader, err := pkg.ExportReader(export)
+	if err != nil {
+		return nil, err
+	}
+	return reader.ReadTaggedProperties()
+}
+
+// readPinArray reads the pins a UEdGraphNode serializes after its properties
+func readPinArray(reader *analyzer.PackageReader) ([]graphPin, error) {
+	count, err := reader.ReadInt32()
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 || int(count) > reader.Remaining() {
+		return nil, fmt.Errorf("pin count %d out of range", count)
+	}
+
+	pins := make([]graphPin, 0, count)
+	for i := 0; i < int(count); i++ {
+		isNull, err := reader.ReadBool()
+		if err != nil {
+			return pins, err
+		}
+		if isNull {
+			continue
+		}
+
+		// Owning node reference and pin GUID precede the full pin
+		if err := reader.Skip(4 + 16); err != nil {
+			return pins, err
+		}
+
+		pin, err := readPin(reader)
+		if err != nil {
+			return pins, fmt.Errorf("pin %d: %w", i, err)
+		}
+		pins = append(pins, pin)
+	}
+
+	return pins, nil
+}
+
+// readPin reads a UEdGraphPin in the UE5 editor layout
+func readPin(reader *analyzer.PackageReader) (graphPin, error) {
+	var pin graphPin
+	var err error
+
+	if _, _, err = reader.ReadObjectRef(); err != nil { // owning node
+		return pin, err
+	}
+	if pin.PinID, err = reader.ReadGuid(); err != nil {
+		return pin, err
+	}
+	if pin.PinName, err = reader.ReadFName(); err != nil {
+		return pin, err
+	}
+	if _, err = reader.ReadFText(); err != nil { // friendly name
+		return pin, err
+	}
+	if _, err = reader.ReadFString(); err != nil { // tooltip
+		return pin, err
+	}
+	if pin.Direction, err = reader.ReadUint8(); err != nil {
+		return pin, err
+	}
+	if pin.Category, err = readPinType(reader); err != nil {
+		return pin, err
+	}
+	if err = skipStrings(reader, 2); err != nil { // default and autogenerated default value
+		return pin, err
+	}
+	if _, _, err = reader.ReadObjectRef(); err != nil { // default object
+		return pin, err
+	}
+	if _, err = reader.ReadFText(); err != nil { // default text value
+		return pin, err
+	}
+
+	if pin.LinkedTo, err = readPinLinks(reader); err != nil {
+		return pin, err
+	}
+	if _, err = readPinLinks(reader); err != nil { // sub pins
+		return pin, err
+	}
+	for i := 0; i < 2; i++ { // parent pin, reference pass-through connection
+		if _, err = readPinLink(reader); err != nil {
+			return pin, err
+		}
+	}
+
+	// Persistent GUID and bitfield
+	return pin, reader.Skip(16 + 4)
+}
+
+// readPinType reads an FEdGraphPinType and returns its category
+func readPinType(reader *analyzer.PackageReader) (string, error) {
+	value, err := reader.ReadStruct("EdGraphPinType")
+	if err != nil {
+		return "", err
+	}
+	pinType, _ := value.(map[string]interface{})
+	category, _ := pinType["PinCategory"].(string)
+	return category, nil
+}
+
+func readPinLinks(reader *analyzer.PackageReader) ([]pinLink, error) {
+	count, err := reader.ReadInt32()
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 || int(count) > reader.Remaining() {
+		return nil, fmt.Errorf("pin link count %d out of range", count)
+	}
+
+	links := make([]pinLink, 0, count)
+	for i := 0; i < int(count); i++ {
+		link, err := readPinLink(reader)
+		if err != nil {
+			return links, err
+		}
+		if link != nil {
+			links = append(links, *link)
+		}
+	}
+	return links, nil
+}
+
+// readPinLink reads a reference to a pin on another node (owning node + pin GUID)
+func readPinLink(reader *analyzer.PackageReader) (*pinLink, error) {
+	isNull, err := reader.ReadBool()
+	if err != nil || isNull {
+		return nil, err
+	}
+
+	owningNode, _, err := reader.ReadObjectRef()
+	if err != nil {
+		return nil, err
+	}
+	pinID, err := reader.ReadGuid()
+	if err != nil {
+		return nil, err
+	}
+	return &pinLink{OwningNode: owningNode, PinID: pinID}, nil
+}
+
+func skipStrings(reader *analyzer.PackageReader, count int) error {
+	for i := 0; i < count; i++ {
+		if _, err := reader.ReadFString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execConnectionMap returns the exec flow graph, with every exec node present as a key
+func (g *blueprintGraph) execConnectionMap() map[string][]string {
+	connectionMap := make(map[string][]string)
+	for _, node := range g.nodes {
+		if !g.hasExecPins(node.NodeID) {
+			continue
+		}
+		connectionMap[node.NodeID] = []string{}
+		for _, conn := range node.Connections {
+			if conn.ConnectionType == pinCategoryExec && conn.IsValid {
+				connectionMap[node.NodeID] = append(connectionMap[node.NodeID], conn.TargetNodeID)
+			}
+		}
+	}
+	return connectionMap
+}
+
+// entryNodes returns the events and function entries that start execution
+func (g *blueprintGraph) entryNodes() []string {
+	var entries []string
+	for _, node := range g.nodes {
+		if entryNodeClasses[node.NodeClass] {
+			entries = append(entries, node.NodeID)
+		}
+	}
+	return entries
+}
+
+func (g *blueprintGraph) hasExecPins(nodeID string) bool {
+	for _, pin := range g.pins[nodeID] {
+		if pin.Category == pinCategoryExec {
+			return true
+		}
+	}
+	return false
+}
+
+// isLatent reports whether a node suspends execution (delays, timelines, async tasks)
+func (g *blueprintGraph) isLatent(node BlueprintNode) bool {
+	if latentFunctions[node.FunctionName] {
+		return true
+	}
+	return node.NodeClass == "K2Node_Timeline" ||
+		strings.Contains(node.NodeClass, "Async") ||
+		strings.Contains(node.NodeClass, "Latent")
+}
+
+// graphNames returns the graph export names listed in a Blueprint array property
+func (g *blueprintGraph) graphNames(property string) []string {
+	values, _ := g.blueprint[property].([]interface{})
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		if name, ok := value.(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// nodesInGraph returns the nodes owned by a graph
+func (g *blueprintGraph) nodesInGraph(graphName string) []BlueprintNode {
+	var nodes []BlueprintNode
+	for _, node := range g.nodes {
+		if g.graphOf[node.NodeID] == graphName {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func isGraphNodeClass(class string) bool {
+	return strings.HasPrefix(class, "K2Node_") || strings.HasPrefix(class, "EdGraphNode_")
+}
+
+// nodeFunctionName returns the function, event or variable a node refers to
+func nodeFunctionName(properties map[string]interface{}) string {
+	if name, ok := properties["CustomFunctionName"].(string); ok && name != "" {
+		return name
+	}
+
+	for _, key := range []string{"FunctionReference", "EventReference", "VariableReference", "DelegateReference"} {
+		if ref, ok := properties[key].(map[string]interface{}); ok {
+			if name, ok := ref["MemberName"].(string); ok && name != "" {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// propertyString returns a string-typed property value
+func propertyString(properties map[string]interface{}, key string) string {
+	value, _ := properties[key].(string)
+	return value
+}
+
+// propertyUint returns an integer-typed property value as uint64
+func propertyUint(properties map[string]interface{}, key string) uint64 {
+	switch v := properties[key].(type) {
+	case int32:
+		return uint64(uint32(v))
+	case uint32:
+		return uint64(v)
+	case int64:
+		return uint64(v)
+	case uint64:
+		return v
+	}
+	return 0
+}
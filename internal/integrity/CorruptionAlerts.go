@@ -0,0 +1,327 @@
+// Corruption alert routing
+// Delivers corruption events to named sinks according to per-severity routes
+
+package integrity
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Headers set on outgoing corruption webhooks
+const (
+	WebhookSignatureHeader = "X-VCS-Signature"
+	WebhookEventHeader     = "X-VCS-Event"
+	WebhookTimestampHeader = "X-VCS-Timestamp"
+)
+
+// CorruptionAlertSystem manages corruption alerts
+type CorruptionAlertSystem struct {
+	mu     sync.Mutex
+	alerts []CorruptionEvent
+	sinks  map[string]AlertSink
+	routes []AlertRoute
+}
+
+// AlertSink delivers a corruption event to one destination
+type AlertSink interface {
+	Deliver(event CorruptionEvent) error
+}
+
+// AlertSinkFunc adapts a function to an AlertSink
+type AlertSinkFunc func(event CorruptionEvent) error
+
+// Deliver calls f(event)
+func (f AlertSinkFunc) Deliver(event CorruptionEvent) error {
+	return f(event)
+}
+
+// AlertRoute sends events at or above MinSeverity to a sink. When Paths is set, at
+// least one affected asset must match one of the glob patterns ("**" matches any depth).
+type AlertRoute struct {
+	Sink        string             `json:"sink"`
+	MinSeverity CorruptionSeverity `json:"min_severity"`
+	Paths       []string           `json:"paths,omitempty"`
+}
+
+// NewCorruptionAlertSystem creates a new corruption alert system
+func NewCorruptionAlertSystem() *CorruptionAlertSystem {
+	return &CorruptionAlertSystem{
+		alerts: []CorruptionEvent{},
+		sinks:  make(map[string]AlertSink),
+	}
+}
+
+// AddSink registers a named sink
+func (cas *CorruptionAlertSystem) AddSink(name string, sink AlertSink) {
+	cas.mu.Lock()
+	defer cas.mu.Unlock()
+	cas.sinks[name] = sink
+}
+
+// AddRoute routes matching events to a registered sink
+func (cas *CorruptionAlertSystem) AddRoute(route AlertRoute) {
+	cas.mu.Lock()
+	defer cas.mu.Unlock()
+	cas.routes = append(cas.routes, route)
+}
+
+// SendAlert delivers an event to every sink routed for it, once per sink, and records
+// the sinks that accepted it in TeamNotified. It fails only if no routed sink accepted it.
+func (cas *CorruptionAlertSystem) SendAlert(event *CorruptionEvent) error {
+	cas.mu.Lock()
+	var targets []string
+	seen := make(map[string]bool)
+	for _, route := range cas.routes {
+		if !seen[route.Sink] && route.matches(event) {
+			seen[route.Sink] = true
+			targets = append(targets, route.Sink)
+		}
+	}
+	sinks := make(map[string]AlertSink, len(targets))
+	for _, name := range targets {
+		sinks[name] = cas.sinks[name]
+	}
+	cas.mu.Unlock()
+
+	var failures []string
+	for _, name := range targets {
+		sink := sinks[name]
+		if sink == nil {
+			failures = append(failures, fmt.Sprintf("%s: no such sink", name))
+			continue
+		}
+		if err := sink.Deliver(*event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		event.TeamNotified = append(event.TeamNotified, name)
+	}
+
+	cas.mu.Lock()
+	cas.alerts = append(cas.alerts, *event)
+	cas.mu.Unlock()
+
+	if len(targets) > 0 && len(event.TeamNotified) == 0 {
+		return fmt.Errorf("alert delivery failed: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Alerts returns the events sent so far
+func (cas *CorruptionAlertSystem) Alerts() []CorruptionEvent {
+	cas.mu.Lock()
+	defer cas.mu.Unlock()
+	return append([]CorruptionEvent{}, cas.alerts...)
+}
+
+func (route AlertRoute) matches(event *CorruptionEvent) bool {
+	if SeverityRank(event.Severity) < SeverityRank(route.MinSeverity) {
+		return false
+	}
+	if len(route.Paths) == 0 {
+		return true
+	}
+
+	for _, asset := range event.AffectedAssets {
+		for _, pattern := range route.Paths {
+			if matchAssetPattern(pattern, asset) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SeverityRank orders severities from low (1) to critical (4); unknown severities rank 0
+func SeverityRank(severity CorruptionSeverity) int {
+	switch severity {
+	case CorruptionSeverityLow:
+		return 1
+	case CorruptionSeverityMedium:
+		return 2
+	case CorruptionSeverityHigh:
+		return 3
+	case CorruptionSeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+// matchAssetPattern matches a slash-separated glob where "**" spans directories
+// and a pattern without a slash matches the file name anywhere
+func matchAssetPattern(pattern, assetPath string) bool {
+	assetPath = strings.TrimPrefix(assetPath, "/")
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(assetPath))
+		return matched
+	}
+
+	if prefix, suffix, ok := strings.Cut(pattern, "**"); ok {
+		if !strings.HasPrefix(assetPath, prefix) {
+			return false
+		}
+		suffix = strings.TrimPrefix(suffix, "/")
+		if suffix == "" {
+			return true
+		}
+		matched, _ := path.Match(suffix, path.Base(assetPath))
+		return matched
+	}
+
+	matched, _ := path.Match(pattern, assetPath)
+	return matched
+}
+
+// WebhookSink posts corruption events as JSON, signed with HMAC-SHA256 when a secret is set
+type WebhookSink struct {
+	URL        string
+	Secret     string
+	ProjectID  string
+	httpClient *http.Client
+}
+
+// webhookPayload is the body of a corruption webhook
+type webhookPayload struct {
+	Event     string          `json:"event"`
+	ProjectID string          `json:"project_id,omitempty"`
+	SentAt    time.Time       `json:"sent_at"`
+	Alert     CorruptionEvent `json:"alert"`
+}
+
+// NewWebhookSink creates a webhook sink for a project. The URL must pass ValidateWebhookURL,
+// and deliveries only connect to public addresses, whatever the host name resolves to.
+func NewWebhookSink(webhookURL, secret, projectID string) (*WebhookSink, error) {
+	if err := ValidateWebhookURL(webhookURL); err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	return &WebhookSink{
+		URL:       webhookURL,
+		Secret:    secret,
+		ProjectID: projectID,
+		httpClient: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// A redirect could leave https; receivers have to be configured with their final URL
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// ValidateWebhookURL accepts https URLs whose host isn't a loopback, private, link-local
+// or otherwise internal address, so alert settings can't aim the server at its own network
+func ValidateWebhookURL(webhookURL string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if parsed.Scheme != "https" {
+		return fmt.Errorf("webhook URL must use https")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host == "" {
+		return fmt.Errorf("webhook URL has no host")
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("webhook URL must not point at localhost")
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return fmt.Errorf("webhook URL must not point at internal address %s", host)
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// Deliver posts the event, retrying once on network errors and server errors
+func (ws *WebhookSink) Deliver(event CorruptionEvent) error {
+	sentAt := time.Now().UTC()
+	body, err := json.Marshal(webhookPayload{
+		Event:     "corruption_detected",
+		ProjectID: ws.ProjectID,
+		SentAt:    sentAt,
+		Alert:     event,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt > 0 {
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		req, err := http.NewRequest("POST", ws.URL, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(WebhookEventHeader, "corruption_detected")
+		timestamp := fmt.Sprintf("%d", sentAt.Unix())
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		if ws.Secret != "" {
+			req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(ws.Secret, timestamp, body))
+		}
+
+		resp, err := ws.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("webhook request failed: %w", err)
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		if resp.StatusCode < 500 {
+			break // the receiver rejected it; retrying won't help
+		}
+	}
+
+	return lastErr
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of "<timestamp>.<body>", for senders and
+// receivers. Signing the X-VCS-Timestamp value lets receivers reject replayed deliveries.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
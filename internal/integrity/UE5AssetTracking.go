@@ -12,21 +12,17 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
-)
-
-// AssetIntegrityDB manages integrity records
-type AssetIntegrityDB struct {
-	dbPath string
-}
 
-// CorruptionAlertSystem manages corruption alerts
-type CorruptionAlertSystem struct {
-	alerts []CorruptionEvent
-}
+	"github.com/Telerallc/gamedev-vcs/internal/analyzer"
+)
 
 // AssetVersionManager manages asset versions
 type AssetVersionManager struct {
 	projectPath string
+	objects     AssetObjectStore
+	history     AssetHistory
+	recorder    RecoveryRecorder
+	analyzer    *analyzer.UE5AssetAnalyzer
 }
 
 // UE5Analyzer analyzes UE5 assets
@@ -193,22 +189,12 @@ const (
 	CorruptionSeverityCritical CorruptionSeverity = "critical"
 )
 
-// NewAssetIntegrityDB creates a new integrity database
-func NewAssetIntegrityDB(dbPath string) (*AssetIntegrityDB, error) {
-	if err := os.MkdirAll(dbPath, 0755); err != nil {
-		return nil, err
-	}
-	return &AssetIntegrityDB{dbPath: dbPath}, nil
-}
-
-// NewCorruptionAlertSystem creates a new corruption alert system
-func NewCorruptionAlertSystem() *CorruptionAlertSystem {
-	return &CorruptionAlertSystem{alerts: []CorruptionEvent{}}
-}
-
 // NewAssetVersionManager creates a new asset version manager
 func NewAssetVersionManager(projectPath string) *AssetVersionManager {
-	return &AssetVersionManager{projectPath: projectPath}
+	return &AssetVersionManager{
+		projectPath: projectPath,
+		analyzer:    analyzer.NewUE5AssetAnalyzer(),
+	}
 }
 
 // NewUE5AssetTracker creates a new asset tracking system
@@ -322,16 +308,35 @@ func (uat *UE5AssetTracker) VerifyAssetIntegrity(assetPath string, userID string
 		}
 	}
 
+	record.IntegrityChecks = append(record.IntegrityChecks, result.Checks...)
+
 	// If corruption detected, trigger alerts and recovery
 	if result.CorruptionDetected {
 		if err := uat.handleCorruption(record, result); err != nil {
 			return nil, fmt.Errorf("failed to handle corruption: %w", err)
 		}
+
+		latest := record.CorruptionHistory[len(record.CorruptionHistory)-1]
+		if latest.AutoRecovered {
+			result.RecoveryActions = append(result.RecoveryActions, latest.RecoveryMethod)
+		}
+	} else if err := uat.integrityDB.StoreRecord(record); err != nil {
+		return nil, fmt.Errorf("failed to store integrity record: %w", err)
+	}
+
+	// Keep the check in the asset's health history
+	if err := uat.integrityDB.RecordCheck(record, result); err != nil {
+		return nil, fmt.Errorf("failed to record integrity check: %w", err)
 	}
 
 	return result, nil
 }
 
+// GetRecord returns the stored integrity record of an asset
+func (uat *UE5AssetTracker) GetRecord(assetPath string) (*AssetIntegrityRecord, error) {
+	return uat.integrityDB.GetRecord(assetPath)
+}
+
 // checkContentIntegrity verifies the overall file content
 func (uat *UE5AssetTracker) checkContentIntegrity(record *AssetIntegrityRecord, userID string) IntegrityCheck {
 	check := IntegrityCheck{
@@ -492,7 +497,7 @@ func (uat *UE5AssetTracker) handleCorruption(record *AssetIntegrityRecord, check
 	event.Severity = uat.determineSeverity(record, checkResult)
 
 	// Alert team based on severity
-	if err := uat.corruptionAlert.SendAlert(event); err != nil {
+	if err := uat.corruptionAlert.SendAlert(&event); err != nil {
 		return fmt.Errorf("failed to send corruption alert: %w", err)
 	}
 
@@ -565,15 +570,17 @@ func (uat *UE5AssetTracker) attemptAutoRecovery(record *AssetIntegrityRecord) (b
 	if len(record.BackupVersions) > 0 {
 		latestBackup := record.BackupVersions[len(record.BackupVersions)-1]
 		if latestBackup.IsHealthy {
-			if err := uat.versionManager.RestoreFromBackup(record.AssetPath, latestBackup.VersionID); err == nil {
-				return true, "restored_from_backup"
+			if recovery, err := uat.versionManager.RestoreFromBackup(record, latestBackup); err == nil {
+				uat.applyRecovery(record, recovery)
+				return true, recovery.Method
 			}
 		}
 	}
 
 	// Try to revert to last known good version from version control
-	if err := uat.versionManager.RevertToLastKnownGood(record.AssetPath); err == nil {
-		return true, "reverted_to_last_good"
+	if recovery, err := uat.versionManager.RevertToLastKnownGood(record); err == nil {
+		uat.applyRecovery(record, recovery)
+		return true, recovery.Method
 	}
 
 	// For Blueprints, try structural repair
@@ -586,6 +593,48 @@ func (uat *UE5AssetTracker) attemptAutoRecovery(record *AssetIntegrityRecord) (b
 	return false, "manual_intervention_required"
 }
 
+// ReportCorruptionTo sends every corruption the tracker detects to a sink, such as one
+// that reports it to the server so the project's own alert routes apply
+func (uat *UE5AssetTracker) ReportCorruptionTo(name string, sink AlertSink) {
+	uat.corruptionAlert.AddSink(name, sink)
+	uat.corruptionAlert.AddRoute(AlertRoute{Sink: name, MinSeverity: CorruptionSeverityLow})
+}
+
+// SetRecoverySources connects auto-recovery to the object store, commit history and corruption log
+func (uat *UE5AssetTracker) SetRecoverySources(objects AssetObjectStore, history AssetHistory, recorder RecoveryRecorder) {
+	uat.versionManager.SetRecoverySources(objects, history, recorder)
+}
+
+// RevertToLastKnownGood restores an asset to its most recent healthy committed revision
+func (uat *UE5AssetTracker) RevertToLastKnownGood(assetPath string) (*AssetRecovery, error) {
+	record, err := uat.integrityDB.GetRecord(assetPath)
+	if err != nil {
+		record = &AssetIntegrityRecord{AssetPath: assetPath}
+	}
+
+	recovery, err := uat.versionManager.RevertToLastKnownGood(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.ContentHash != "" {
+		uat.applyRecovery(record, recovery)
+		if err := uat.integrityDB.StoreRecord(record); err != nil {
+			return recovery, fmt.Errorf("failed to update integrity record: %w", err)
+		}
+	}
+	return recovery, nil
+}
+
+// applyRecovery points an integrity record at the restored revision
+func (uat *UE5AssetTracker) applyRecovery(record *AssetIntegrityRecord, recovery *AssetRecovery) {
+	record.ContentHash = recovery.RestoredHash
+	if fileInfo, err := os.Stat(filepath.Join(uat.projectPath, record.AssetPath)); err == nil {
+		record.FileSize = fileInfo.Size()
+		record.LastModified = fileInfo.ModTime()
+	}
+}
+
 // Helper methods
 
 func (uat *UE5AssetTracker) calculateContentHash(content []byte) string {
@@ -594,15 +643,7 @@ func (uat *UE5AssetTracker) calculateContentHash(content []byte) string {
 }
 
 func (uat *UE5AssetTracker) isUE5Asset(assetPath string) bool {
-	ext := strings.ToLower(filepath.Ext(assetPath))
-	ue5Extensions := []string{".uasset", ".umap", ".uexp", ".ubulk"}
-
-	for _, ue5Ext := range ue5Extensions {
-		if ext == ue5Ext {
-			return true
-		}
-	}
-	return false
+	return analyzer.DefaultRegistry().IsEngineFile(assetPath, analyzer.ProjectTypeUnreal)
 }
 
 func (uat *UE5AssetTracker) analyzeUE5Asset(record *AssetIntegrityRecord, content []byte) error {
@@ -725,35 +766,6 @@ type IntegrityCheckResult struct {
 	RecoveryActions    []string         `json:"recovery_actions"`
 }
 
-// AssetIntegrityDB methods
-func (aidb *AssetIntegrityDB) StoreRecord(record *AssetIntegrityRecord) error {
-	// Implementation would store record to database
-	return nil
-}
-
-func (aidb *AssetIntegrityDB) GetRecord(assetPath string) (*AssetIntegrityRecord, error) {
-	// Implementation would retrieve record from database
-	return nil, fmt.Errorf("not implemented")
-}
-
-// CorruptionAlertSystem methods
-func (cas *CorruptionAlertSystem) SendAlert(event CorruptionEvent) error {
-	// Implementation would send alert
-	cas.alerts = append(cas.alerts, event)
-	return nil
-}
-
-// AssetVersionManager methods
-func (avm *AssetVersionManager) RestoreFromBackup(assetPath, versionID string) error {
-	// Implementation would restore from backup
-	return nil
-}
-
-func (avm *AssetVersionManager) RevertToLastKnownGood(assetPath string) error {
-	// Implementation would revert to last known good version
-	return nil
-}
-
 // UE5Analyzer methods
 func (ua *UE5Analyzer) AnalyzeAsset(assetPath string, content []byte) (*AssetInfo, error) {
 	// Implementation would analyze UE5 asset
@@ -72,14 +72,16 @@ const (
 type EventType string
 
 const (
-	EventFileLocked       EventType = "file_locked"
-	EventFileUnlocked     EventType = "file_unlocked"
-	EventFileModified     EventType = "file_modified"
-	EventUserJoined       EventType = "user_joined"
-	EventUserLeft         EventType = "user_left"
-	EventUserIdle         EventType = "user_idle"
-	EventConflictDetected EventType = "conflict_detected"
-	EventCommitCreated    EventType = "commit_created"
+	EventFileLocked         EventType = "file_locked"
+	EventFileUnlocked       EventType = "file_unlocked"
+	EventFileModified       EventType = "file_modified"
+	EventUserJoined         EventType = "user_joined"
+	EventUserLeft           EventType = "user_left"
+	EventUserIdle           EventType = "user_idle"
+	EventConflictDetected   EventType = "conflict_detected"
+	EventCommitCreated      EventType = "commit_created"
+	EventCorruptionDetected EventType = "corruption_detected"
+	EventUploadQuarantined  EventType = "upload_quarantined"
 )
 
 // Redis key patterns
@@ -92,37 +92,68 @@ func NewContentStore(basePath string) (*ContentStore, error) {
 	return cs, nil
 }
 
+// StagedContent is content written and hashed into the temp area but not yet part of the
+// live store, so it can be verified first and then committed or discarded
+type StagedContent struct {
+	Hash string
+	Size int64
+
+	cs   *ContentStore
+	path string
+}
+
 // Store saves content and returns its hash, enabling deduplication
 func (cs *ContentStore) Store(reader io.Reader, metadata map[string]string) (*FileStats, error) {
-	// Create temporary file for atomic operation
+	staged, err := cs.Stage(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer staged.Discard()
+
+	return staged.Commit(metadata)
+}
+
+// Stage streams content into the temp area, hashing it on the way
+func (cs *ContentStore) Stage(reader io.Reader) (*StagedContent, error) {
 	tempFile, err := os.CreateTemp(cs.tempPath, "upload-*")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp file: %w", err)
 	}
-	defer os.Remove(tempFile.Name())
 	defer tempFile.Close()
 
 	// Hash while writing to temp file
 	hasher := sha256.New()
-	multiWriter := io.MultiWriter(hasher, tempFile)
-
-	size, err := io.Copy(multiWriter, reader)
+	size, err := io.Copy(io.MultiWriter(hasher, tempFile), reader)
 	if err != nil {
+		os.Remove(tempFile.Name())
 		return nil, fmt.Errorf("failed to write content: %w", err)
 	}
-
 	if size == 0 {
+		os.Remove(tempFile.Name())
 		return nil, fmt.Errorf("cannot store empty content")
 	}
 
-	hash := fmt.Sprintf("%x", hasher.Sum(nil))
-	finalPath := cs.getContentPath(hash)
+	return &StagedContent{
+		Hash: fmt.Sprintf("%x", hasher.Sum(nil)),
+		Size: size,
+		cs:   cs,
+		path: tempFile.Name(),
+	}, nil
+}
 
-	fmt.Printf("DEBUG: Storing content with hash: %s, size: %d, path: %s\n", hash, size, finalPath)
+// Open reads the staged content back, e.g. for format checks
+func (sc *StagedContent) Open() (io.ReadCloser, error) {
+	return os.Open(sc.path)
+}
+
+// Commit moves the staged content into the live store
+func (sc *StagedContent) Commit(metadata map[string]string) (*FileStats, error) {
+	cs := sc.cs
+	hash, size := sc.Hash, sc.Size
+	finalPath := cs.getContentPath(hash)
 
 	// Check if content already exists (deduplication)
 	if _, err := os.Stat(finalPath); err == nil {
-		fmt.Printf("DEBUG: Content already exists, updating stats\n")
 		cs.updateStats(hash, size, metadata)
 		stats := cs.getStats(hash)
 		if stats == nil {
@@ -137,7 +168,7 @@ func (cs *ContentStore) Store(reader io.Reader, metadata map[string]string) (*Fi
 	}
 
 	// Atomic move from temp to final location
-	if err := os.Rename(tempFile.Name(), finalPath); err != nil {
+	if err := os.Rename(sc.path, finalPath); err != nil {
 		return nil, fmt.Errorf("failed to move content to final location: %w", err)
 	}
 
@@ -151,12 +182,15 @@ func (cs *ContentStore) Store(reader io.Reader, metadata map[string]string) (*Fi
 		ContentType:  detectContentType(metadata),
 	}
 
-	fmt.Printf("DEBUG: Created stats: %+v\n", stats)
-
 	cs.setStats(hash, stats)
 	return stats, nil
 }
 
+// Discard removes the staged content if it wasn't committed
+func (sc *StagedContent) Discard() {
+	os.Remove(sc.path)
+}
+
 // Get retrieves content by hash
 func (cs *ContentStore) Get(hash string) (io.ReadCloser, *FileStats, error) {
 	if !cs.isValidHash(hash) {
@@ -255,73 +289,37 @@ func (cs *ContentStore) StoreChunk(chunkData []byte, chunkIndex int, totalChunks
 
 // AssembleChunks combines chunks into final content
 func (cs *ContentStore) AssembleChunks(sessionID string, totalChunks int, metadata map[string]string) (*FileStats, error) {
-	chunksDir := filepath.Join(cs.tempPath, "chunks", sessionID)
-
-	// Create temporary file for assembly
-	tempFile, err := os.CreateTemp(cs.tempPath, "assembled-*")
+	staged, err := cs.StageChunks(sessionID, totalChunks)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create assembly temp file: %w", err)
+		return nil, err
 	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
+	defer staged.Discard()
 
-	hasher := sha256.New()
-	multiWriter := io.MultiWriter(hasher, tempFile)
+	return staged.Commit(metadata)
+}
 
-	var totalSize int64
+// StageChunks streams a session's chunks, in order, into one staged file and removes the chunks
+func (cs *ContentStore) StageChunks(sessionID string, totalChunks int) (*StagedContent, error) {
+	chunksDir := filepath.Join(cs.tempPath, "chunks", sessionID)
 
-	// Read and concatenate chunks in order
+	readers := make([]io.Reader, 0, totalChunks)
 	for i := 0; i < totalChunks; i++ {
-		chunkPath := filepath.Join(chunksDir, fmt.Sprintf("chunk-%d", i))
-		chunkData, err := os.ReadFile(chunkPath)
+		chunk, err := os.Open(filepath.Join(chunksDir, fmt.Sprintf("chunk-%d", i)))
 		if err != nil {
 			return nil, fmt.Errorf("failed to read chunk %d: %w", i, err)
 		}
-
-		if _, err := multiWriter.Write(chunkData); err != nil {
-			return nil, fmt.Errorf("failed to write chunk %d to assembly: %w", i, err)
-		}
-
-		totalSize += int64(len(chunkData))
-	}
-
-	// Calculate final hash
-	hash := fmt.Sprintf("%x", hasher.Sum(nil))
-	finalPath := cs.getContentPath(hash)
-
-	// Check for existing content
-	if _, err := os.Stat(finalPath); err == nil {
-		// Content already exists, clean up chunks
-		os.RemoveAll(chunksDir)
-		cs.updateStats(hash, totalSize, metadata)
-		return cs.getStats(hash), nil
-	}
-
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(finalPath), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create content directory: %w", err)
+		defer chunk.Close()
+		readers = append(readers, chunk)
 	}
 
-	// Move assembled file to final location
-	if err := os.Rename(tempFile.Name(), finalPath); err != nil {
-		return nil, fmt.Errorf("failed to move assembled content: %w", err)
+	staged, err := cs.Stage(io.MultiReader(readers...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to assemble chunks: %w", err)
 	}
 
 	// Clean up chunks
 	os.RemoveAll(chunksDir)
-
-	// Create stats
-	stats := &FileStats{
-		Hash:         hash,
-		Size:         totalSize,
-		CreatedAt:    time.Now(),
-		LastAccessed: time.Now(),
-		RefCount:     1,
-		ContentType:  detectContentType(metadata),
-	}
-
-	cs.setStats(hash, stats)
-	return stats, nil
+	return staged, nil
 }
 
 // Delete removes content if no longer referenced
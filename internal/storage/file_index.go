@@ -28,6 +28,11 @@ type IndexEntry struct {
 	Staged    bool      `json:"staged"`
 }
 
+// IsDeletion reports whether the entry stages the file's deletion
+func (e *IndexEntry) IsDeletion() bool {
+	return e.Hash == DeletedHash
+}
+
 // FileIndex manages a Git-style file index with stat optimization
 type FileIndex struct {
 	entries   map[string]*IndexEntry
@@ -240,6 +245,25 @@ func (idx *FileIndex) BatchUpdateEntries(updates map[string]string) error {
 	return nil
 }
 
+// StageDeletion stages the removal of a file that no longer exists in the working copy
+func (idx *FileIndex) StageDeletion(filePath string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	now := time.Now()
+	entry := &IndexEntry{
+		Path:      filePath,
+		Hash:      DeletedHash,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Staged:    true,
+	}
+	if existing, exists := idx.entries[filePath]; exists {
+		entry.CreatedAt = existing.CreatedAt
+	}
+	idx.entries[filePath] = entry
+}
+
 // RemoveEntry removes an entry from the index
 func (idx *FileIndex) RemoveEntry(filePath string) {
 	idx.mu.Lock()
@@ -653,12 +677,14 @@ func (idx *FileIndex) CompareWithFileSystem(filePaths []string) (map[string]stri
 	results := make(map[string]string)
 
 	for _, filePath := range filePaths {
+		// NeedsUpdate reports a missing file as changed rather than failing
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			results[filePath] = "deleted"
+			continue
+		}
+
 		needsUpdate, err := idx.NeedsUpdate(filePath)
 		if err != nil {
-			if os.IsNotExist(err) {
-				results[filePath] = "deleted"
-				continue
-			}
 			return nil, fmt.Errorf("failed to check %s: %w", filePath, err)
 		}
 
@@ -5,6 +5,7 @@ import (
 	"compress/zlib"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -118,6 +119,84 @@ func (s *GitStyleObjectStore) Store(content io.Reader, metadata map[string]strin
 	}, nil
 }
 
+// ErrHashMismatch is returned when content doesn't hash to the object it's stored as
+var ErrHashMismatch = errors.New("content doesn't match its hash")
+
+// StoreFile stores a file's content as the object it's expected to be, compressing it
+// straight from disk and hashing it on the way; content that doesn't match the hash is
+// discarded with an error wrapping ErrHashMismatch. Only the final rename holds the
+// store's lock, so large files can be stored side by side.
+func (s *GitStyleObjectStore) StoreFile(filePath, hash string) (*ObjectInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	objectPath := s.getObjectPath(hash)
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create object directory: %w", err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(objectPath), filepath.Base(objectPath)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary object: %w", err)
+	}
+	tempPath := temp.Name()
+	defer os.Remove(tempPath) // No-op once the object is renamed into place
+
+	hasher := sha256.New()
+	writer := zlib.NewWriter(temp)
+	_, err = fmt.Fprintf(writer, "blob %d\x00", stat.Size())
+	if err == nil {
+		var copied int64
+		copied, err = io.Copy(writer, io.TeeReader(file, hasher))
+		if err == nil && copied != stat.Size() {
+			err = fmt.Errorf("%s changed while it was stored", filePath)
+		}
+	}
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write object: %w", err)
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != hash {
+		return nil, fmt.Errorf("%w: %s", ErrHashMismatch, hash)
+	}
+
+	tempStat, err := os.Stat(tempPath)
+	if err != nil {
+		return nil, err
+	}
+	info := &ObjectInfo{
+		Hash:           hash,
+		Size:           stat.Size(),
+		CompressedSize: tempStat.Size(),
+		StoredAt:       time.Now(),
+		ObjectPath:     objectPath,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if exists, err := s.exists(hash); err != nil {
+		return nil, fmt.Errorf("failed to check object existence: %w", err)
+	} else if exists {
+		return info, nil
+	}
+	if err := os.Rename(tempPath, objectPath); err != nil {
+		return nil, fmt.Errorf("failed to finalize object: %w", err)
+	}
+	return info, nil
+}
+
 // Get retrieves content by hash
 func (s *GitStyleObjectStore) Get(hash string) (io.ReadCloser, *ObjectInfo, error) {
 	s.mu.RLock()
@@ -177,6 +256,17 @@ func (s *GitStyleObjectStore) Get(hash string) (io.ReadCloser, *ObjectInfo, erro
 	return contentReader, info, nil
 }
 
+// ReadObject returns the full content of an object
+func (s *GitStyleObjectStore) ReadObject(hash string) ([]byte, error) {
+	reader, _, err := s.Get(hash)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
 // Exists checks if an object exists by hash
 func (s *GitStyleObjectStore) Exists(hash string) (bool, error) {
 	s.mu.RLock()
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// QuarantineRecord describes uploaded content held back from the live store
+type QuarantineRecord struct {
+	ID            string    `json:"id"`
+	ProjectID     string    `json:"project_id"`
+	FilePath      string    `json:"file_path"`
+	DeclaredHash  string    `json:"declared_hash,omitempty"`
+	ActualHash    string    `json:"actual_hash"`
+	Size          int64     `json:"size"`
+	Reasons       []string  `json:"reasons"`
+	UserID        string    `json:"user_id"`
+	UserName      string    `json:"user_name"`
+	SessionID     string    `json:"session_id,omitempty"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// Quarantine stores content under quarantine/<id>/ instead of the live object store
+func (cs *ContentStore) Quarantine(content []byte, record *QuarantineRecord) error {
+	return cs.quarantine(record, int64(len(content)), func(path string) error {
+		return os.WriteFile(path, content, 0644)
+	})
+}
+
+// Quarantine moves staged content under quarantine/<id>/ instead of the live object store
+func (sc *StagedContent) Quarantine(record *QuarantineRecord) error {
+	return sc.cs.quarantine(record, sc.Size, func(path string) error {
+		return os.Rename(sc.path, path)
+	})
+}
+
+// quarantine writes a quarantine record next to the content that store puts in place
+func (cs *ContentStore) quarantine(record *QuarantineRecord, size int64, store func(path string) error) error {
+	if record.ID == "" {
+		record.ID = fmt.Sprintf("q_%d", time.Now().UnixNano())
+	}
+	if record.QuarantinedAt.IsZero() {
+		record.QuarantinedAt = time.Now()
+	}
+	record.Size = size
+
+	dir := cs.quarantinePath(record.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	if err := store(filepath.Join(dir, "content")); err != nil {
+		return fmt.Errorf("failed to write quarantined content: %w", err)
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode quarantine record: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "record.json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write quarantine record: %w", err)
+	}
+
+	return nil
+}
+
+// ListQuarantined returns quarantined uploads, newest first. An empty projectID lists all projects.
+func (cs *ContentStore) ListQuarantined(projectID string) ([]*QuarantineRecord, error) {
+	entries, err := os.ReadDir(filepath.Join(cs.basePath, "quarantine"))
+	if os.IsNotExist(err) {
+		return []*QuarantineRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine: %w", err)
+	}
+
+	records := make([]*QuarantineRecord, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		record, err := cs.GetQuarantined(entry.Name())
+		if err != nil {
+			continue
+		}
+		if projectID == "" || record.ProjectID == projectID {
+			records = append(records, record)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].QuarantinedAt.After(records[j].QuarantinedAt) })
+	return records, nil
+}
+
+// GetQuarantined returns the record of a quarantined upload
+func (cs *ContentStore) GetQuarantined(id string) (*QuarantineRecord, error) {
+	if !isQuarantineID(id) {
+		return nil, fmt.Errorf("invalid quarantine id: %s", id)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cs.quarantinePath(id), "record.json"))
+	if err != nil {
+		return nil, fmt.Errorf("quarantined upload not found: %s", id)
+	}
+
+	var record QuarantineRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("corrupt quarantine record %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+// ReadQuarantined returns the content of a quarantined upload
+func (cs *ContentStore) ReadQuarantined(id string) ([]byte, error) {
+	if !isQuarantineID(id) {
+		return nil, fmt.Errorf("invalid quarantine id: %s", id)
+	}
+	return os.ReadFile(filepath.Join(cs.quarantinePath(id), "content"))
+}
+
+// ReleaseQuarantined moves a quarantined upload into the live store under the hash of its actual content
+func (cs *ContentStore) ReleaseQuarantined(id string) (*FileStats, error) {
+	record, err := cs.GetQuarantined(id)
+	if err != nil {
+		return nil, err
+	}
+	content, err := cs.ReadQuarantined(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantined content: %w", err)
+	}
+
+	stats, err := cs.Store(bytes.NewReader(content), map[string]string{
+		"file_path":            record.FilePath,
+		"released_from":        id,
+		"released_at":          time.Now().Format(time.RFC3339),
+		"quarantine_reasons":   strings.Join(record.Reasons, "; "),
+		"quarantine_uploader":  record.UserID,
+		"quarantine_timestamp": record.QuarantinedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store released content: %w", err)
+	}
+
+	if err := os.RemoveAll(cs.quarantinePath(id)); err != nil {
+		return stats, fmt.Errorf("released %s but failed to remove it from quarantine: %w", id, err)
+	}
+	return stats, nil
+}
+
+// PurgeQuarantined permanently deletes a quarantined upload
+func (cs *ContentStore) PurgeQuarantined(id string) error {
+	if _, err := cs.GetQuarantined(id); err != nil {
+		return err
+	}
+	return os.RemoveAll(cs.quarantinePath(id))
+}
+
+func (cs *ContentStore) quarantinePath(id string) string {
+	return filepath.Join(cs.basePath, "quarantine", id)
+}
+
+// isQuarantineID rejects ids that could escape the quarantine directory
+func isQuarantineID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\`)
+}
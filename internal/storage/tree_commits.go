@@ -10,15 +10,19 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Telerallc/gamedev-vcs/internal/integrity"
 )
 
 // GitStyleCommitStore manages Git-style commits with tree objects
 type GitStyleCommitStore struct {
-	basePath    string
-	objectStore *GitStyleObjectStore
-	fileIndex   *FileIndex
+	basePath      string
+	objectStore   *GitStyleObjectStore
+	fileIndex     *FileIndex
+	remoteHistory integrity.AssetHistory
 }
 
 // TreeEntry represents a file in a tree object
@@ -29,6 +33,18 @@ type TreeEntry struct {
 	Size int64  `json:"size"` // File size
 }
 
+// Trees only hold the files a commit changed, so a deleted file is recorded as an entry
+// with DeletedMode and DeletedHash that hides older versions of the path
+const DeletedMode = "000000"
+
+// DeletedHash is the content hash of deletion entries in trees and the index
+var DeletedHash = strings.Repeat("0", sha256.Size*2)
+
+// IsDeletion reports whether the entry records the file's deletion
+func (e TreeEntry) IsDeletion() bool {
+	return e.Mode == DeletedMode
+}
+
 // TreeObject represents a Git-style tree (directory listing)
 type TreeObject struct {
 	Entries   []TreeEntry `json:"entries"`
@@ -71,6 +87,12 @@ func NewGitStyleCommitStore(basePath string, objectStore *GitStyleObjectStore, f
 	}
 }
 
+// SetRemoteHistory lets AssetRevisions continue with the server's history of a file
+// where local history ends at the clone base
+func (cs *GitStyleCommitStore) SetRemoteHistory(history integrity.AssetHistory) {
+	cs.remoteHistory = history
+}
+
 // CreateCommit creates a Git-style commit from current index state
 func (cs *GitStyleCommitStore) CreateCommit(commit *CommitObject) (*CommitResult, error) {
 	// Get staged files from index
@@ -86,6 +108,7 @@ func (cs *GitStyleCommitStore) CreateCommit(commit *CommitObject) (*CommitResult
 	}
 
 	// Convert index entries to tree entries
+	var deleted []string
 	for path, entry := range stagedEntries {
 		treeEntry := TreeEntry{
 			Mode: "100644", // Regular file mode
@@ -93,6 +116,10 @@ func (cs *GitStyleCommitStore) CreateCommit(commit *CommitObject) (*CommitResult
 			Hash: entry.Hash,
 			Size: entry.Size,
 		}
+		if entry.IsDeletion() {
+			treeEntry.Mode = DeletedMode
+			deleted = append(deleted, path)
+		}
 		treeObject.Entries = append(treeObject.Entries, treeEntry)
 	}
 
@@ -122,8 +149,11 @@ func (cs *GitStyleCommitStore) CreateCommit(commit *CommitObject) (*CommitResult
 		return nil, fmt.Errorf("failed to update branch ref: %w", err)
 	}
 
-	// Mark files as committed (unstage them)
+	// Mark files as committed (unstage them); deleted files leave the index
 	cs.fileIndex.MarkUnstaged(getFilePathsFromEntries(stagedEntries))
+	for _, path := range deleted {
+		cs.fileIndex.RemoveEntry(path)
+	}
 
 	// Save updated index
 	if err := cs.fileIndex.Save(); err != nil {
@@ -143,32 +173,49 @@ func (cs *GitStyleCommitStore) CreateCommit(commit *CommitObject) (*CommitResult
 	}, nil
 }
 
-// storeTreeObject stores a tree object and returns its hash
-func (cs *GitStyleCommitStore) storeTreeObject(tree *TreeObject) (string, error) {
-	// Serialize tree object in Git-style binary format
+// encodeTree serializes tree entries in Git-style binary format and returns them with
+// their hash. Entries must be sorted by name.
+func encodeTree(entries []TreeEntry) ([]byte, string, error) {
 	var buf bytes.Buffer
 
 	// Write tree header
-	header := fmt.Sprintf("tree %d\x00", len(tree.Entries))
+	header := fmt.Sprintf("tree %d\x00", len(entries))
 	buf.WriteString(header)
 
 	// Write sorted entries in binary format
-	for _, entry := range tree.Entries {
+	for _, entry := range entries {
 		// Format: mode<space>name<null>hash_bytes
 		fmt.Fprintf(&buf, "%s %s\x00", entry.Mode, entry.Name)
 
 		// Write hash as binary (20 bytes for SHA-1, 32 for SHA-256)
 		hashBytes, err := hex.DecodeString(entry.Hash)
 		if err != nil {
-			return "", fmt.Errorf("invalid hash for %s: %w", entry.Name, err)
+			return nil, "", fmt.Errorf("invalid hash for %s: %w", entry.Name, err)
 		}
 		buf.Write(hashBytes)
 	}
 
-	// Calculate tree hash
 	hasher := sha256.New()
 	hasher.Write(buf.Bytes())
-	treeHash := hex.EncodeToString(hasher.Sum(nil))
+	return buf.Bytes(), hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// TreeHash returns the hash a tree with these entries is stored under, so a server can
+// check a pushed tree against the files pushed with it
+func TreeHash(entries []TreeEntry) (string, error) {
+	sorted := append([]TreeEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	_, hash, err := encodeTree(sorted)
+	return hash, err
+}
+
+// storeTreeObject stores a tree object and returns its hash
+func (cs *GitStyleCommitStore) storeTreeObject(tree *TreeObject) (string, error) {
+	// Serialize tree object in Git-style binary format
+	data, treeHash, err := encodeTree(tree.Entries)
+	if err != nil {
+		return "", err
+	}
 
 	// Store as compressed object
 	objectPath := cs.getObjectPath(treeHash)
@@ -179,7 +226,7 @@ func (cs *GitStyleCommitStore) storeTreeObject(tree *TreeObject) (string, error)
 	// Compress and store
 	var compressed bytes.Buffer
 	writer := zlib.NewWriter(&compressed)
-	writer.Write(buf.Bytes())
+	writer.Write(data)
 	writer.Close()
 
 	// Atomic write
@@ -279,10 +326,10 @@ func (cs *GitStyleCommitStore) updateBranchRef(branch, commitHash string) error
 		return fmt.Errorf("failed to update branch ref: %w", err)
 	}
 
-	// Update HEAD if this is the current branch
+	// A symbolic HEAD follows the branch ref; only a detached HEAD on this branch is moved
 	headPath := filepath.Join(cs.basePath, "HEAD")
 	currentBranch, err := cs.getCurrentBranch()
-	if err == nil && currentBranch == branch {
+	if err == nil && currentBranch == branch && !cs.hasSymbolicHead() {
 		headTempPath := headPath + ".tmp"
 		if err := os.WriteFile(headTempPath, []byte(commitHash+"\n"), 0644); err == nil {
 			os.Rename(headTempPath, headPath)
@@ -398,6 +445,187 @@ func (cs *GitStyleCommitStore) ListCommits(branch string, limit int) ([]*CommitO
 	return commits, nil
 }
 
+// HasCommit reports whether a commit object exists in the local store
+func (cs *GitStyleCommitStore) HasCommit(commitHash string) bool {
+	_, err := os.Stat(cs.getObjectPath(commitHash))
+	return err == nil
+}
+
+// CommitsSince returns the local commits reachable from head that aren't in known,
+// parents before children. The walk stops at known commits and at commits that only
+// exist on the server, such as the commit a clone started from.
+func (cs *GitStyleCommitStore) CommitsSince(head string, known map[string]bool) ([]string, error) {
+	var ordered []string
+	visited := make(map[string]bool)
+
+	var visit func(commitHash string) error
+	visit = func(commitHash string) error {
+		if commitHash == "" || visited[commitHash] || known[commitHash] || !cs.HasCommit(commitHash) {
+			return nil
+		}
+		visited[commitHash] = true
+
+		commit, err := cs.GetCommit(commitHash)
+		if err != nil {
+			return err
+		}
+		for _, parent := range commit.Parents {
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		ordered = append(ordered, commitHash)
+		return nil
+	}
+
+	if err := visit(head); err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
+
+// ReplayCommit stores a copy of a commit on new parents, keeping its tree, author,
+// timestamp and message, and returns the copy's hash
+func (cs *GitStyleCommitStore) ReplayCommit(commitHash string, parents []string) (string, error) {
+	commit, err := cs.GetCommit(commitHash)
+	if err != nil {
+		return "", err
+	}
+	commit.Parents = parents
+	return cs.storeCommitObject(commit)
+}
+
+// SetBranchHead points a branch at a commit
+func (cs *GitStyleCommitStore) SetBranchHead(branch, commitHash string) error {
+	return cs.updateBranchRef(branch, commitHash)
+}
+
+// CheckoutHead points HEAD at a branch, or detaches it at commitHash when branch is empty
+func (cs *GitStyleCommitStore) CheckoutHead(branch, commitHash string) error {
+	head := commitHash
+	if branch != "" {
+		head = "ref: refs/heads/" + branch
+	}
+
+	headPath := filepath.Join(cs.basePath, "HEAD")
+	tempPath := headPath + ".tmp"
+	if err := os.WriteFile(tempPath, []byte(head+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write HEAD: %w", err)
+	}
+	if err := os.Rename(tempPath, headPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	return nil
+}
+
+// MarkShallow records a commit that local history builds on but whose objects only exist
+// on the server, so fsck doesn't report it missing
+func (cs *GitStyleCommitStore) MarkShallow(commitHash string) error {
+	shallow := ShallowCommits(cs.basePath)
+	if shallow[commitHash] {
+		return nil
+	}
+
+	file, err := os.OpenFile(filepath.Join(cs.basePath, "shallow"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open shallow file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintln(file, commitHash); err != nil {
+		return fmt.Errorf("failed to record shallow commit: %w", err)
+	}
+	return nil
+}
+
+// ShallowCommits returns the server commits recorded as history boundaries of a .vcs directory
+func ShallowCommits(vcsPath string) map[string]bool {
+	shallow := make(map[string]bool)
+	data, err := os.ReadFile(filepath.Join(vcsPath, "shallow"))
+	if err != nil {
+		return shallow
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			shallow[line] = true
+		}
+	}
+	return shallow
+}
+
+// AssetRevisions lists the commits on the current branch that changed a file, newest
+// first. Local history of a shallow or partial clone stops at the clone base, so older
+// revisions come from the server's history of the file when one is set.
+func (cs *GitStyleCommitStore) AssetRevisions(filePath string) ([]integrity.AssetRevision, error) {
+	branch, err := cs.getCurrentBranch()
+	if err != nil {
+		branch = "main"
+	}
+
+	commitHash, err := cs.GetBranchHead(branch)
+	if err != nil && cs.remoteHistory == nil {
+		return nil, err
+	}
+
+	filePath = filepath.ToSlash(filePath)
+	var revisions []integrity.AssetRevision
+	visited := make(map[string]bool)
+	cutOff := err != nil
+
+	// Commit trees hold the files changed by each commit, so every match is a revision
+	for commitHash != "" && !visited[commitHash] {
+		visited[commitHash] = true
+
+		commit, err := cs.GetCommit(commitHash)
+		if err != nil {
+			delete(visited, commitHash) // history continues on the server, starting here
+			cutOff = true
+			break
+		}
+
+		if tree, err := cs.GetTree(commit.Tree); err == nil {
+			for _, entry := range tree.Entries {
+				if filepath.ToSlash(entry.Name) == filePath {
+					if entry.IsDeletion() {
+						break // a deleted file has no content to recover
+					}
+					revisions = append(revisions, integrity.AssetRevision{
+						CommitID:    commitHash,
+						ContentHash: entry.Hash,
+						Size:        entry.Size,
+						Timestamp:   commit.Timestamp,
+					})
+					break
+				}
+			}
+		}
+
+		commitHash = ""
+		if len(commit.Parents) > 0 {
+			commitHash = commit.Parents[0]
+		}
+	}
+
+	if !cutOff || cs.remoteHistory == nil {
+		return revisions, nil
+	}
+
+	remote, err := cs.remoteHistory.AssetRevisions(filePath)
+	if err != nil {
+		if len(revisions) == 0 {
+			return nil, fmt.Errorf("failed to read server history: %w", err)
+		}
+		return revisions, nil // the local revisions are still worth trying
+	}
+	for _, revision := range remote {
+		if !visited[revision.CommitID] {
+			revisions = append(revisions, revision)
+		}
+	}
+	return revisions, nil
+}
+
 // Helper methods
 
 func (cs *GitStyleCommitStore) getObjectPath(hash string) string {
@@ -422,6 +650,11 @@ func (cs *GitStyleCommitStore) getCurrentBranch() (string, error) {
 	return "main", nil // Default branch
 }
 
+func (cs *GitStyleCommitStore) hasSymbolicHead() bool {
+	data, err := os.ReadFile(filepath.Join(cs.basePath, "HEAD"))
+	return err == nil && strings.HasPrefix(string(data), "ref: ")
+}
+
 func (cs *GitStyleCommitStore) calculateTreeSize(tree *TreeObject) int64 {
 	var total int64
 	for _, entry := range tree.Entries {
@@ -456,7 +689,7 @@ func (cs *GitStyleCommitStore) parseCommitData(data string) (*CommitObject, erro
 			commit.Parents = append(commit.Parents, value)
 		case "author":
 			// Parse: "Name <email> timestamp timezone"
-			commit.Author = strings.Split(value, " <")[0]
+			commit.Author, commit.AuthorID, commit.Timestamp = parseSignature(value)
 		case "committer":
 			commit.Committer = strings.Split(value, " <")[0]
 		case "project":
@@ -469,12 +702,26 @@ func (cs *GitStyleCommitStore) parseCommitData(data string) (*CommitObject, erro
 	}
 
 	if messageStart > 0 && messageStart < len(lines) {
-		commit.Message = strings.Join(lines[messageStart:], "\n")
+		commit.Message = strings.TrimSuffix(strings.Join(lines[messageStart:], "\n"), "\n")
 	}
 
 	return commit, nil
 }
 
+// parseSignature splits an author line into name, ID and timestamp
+func parseSignature(value string) (string, string, time.Time) {
+	name, rest, _ := strings.Cut(value, " <")
+	id, rest, _ := strings.Cut(rest, "> ")
+
+	var timestamp time.Time
+	if fields := strings.Fields(rest); len(fields) > 0 {
+		if unix, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			timestamp = time.Unix(unix, 0)
+		}
+	}
+	return name, id, timestamp
+}
+
 func (cs *GitStyleCommitStore) parseTreeData(data []byte) (*TreeObject, error) {
 	// Parse binary tree format
 	tree := &TreeObject{
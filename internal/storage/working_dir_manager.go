@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Telerallc/gamedev-vcs/internal/analyzer"
 	"github.com/Telerallc/gamedev-vcs/internal/integrity"
 )
 
@@ -20,6 +21,7 @@ import (
 type WorkingDirectoryManager struct {
 	projectPath      string
 	assetTracker     *integrity.UE5AssetTracker
+	formats          *analyzer.Registry
 	integrityChecker *IntegrityChecker
 	corruptionLog    *CorruptionLog
 	autoRepair       bool
@@ -85,8 +87,31 @@ type FileIntegrityStatus struct {
 	Recommendations []string                   `json:"recommendations"`
 }
 
-// NewWorkingDirectoryManager creates an enhanced working directory manager
-func NewWorkingDirectoryManager(projectPath string) (*WorkingDirectoryManager, error) {
+// AssetRemote is the server a working copy was cloned from: the history of a file older
+// than the clone, and the objects of revisions that were never downloaded
+type AssetRemote interface {
+	integrity.AssetHistory
+	integrity.AssetObjectStore
+}
+
+// recoveryObjects reads objects from the local store and falls back to the server for
+// revisions a shallow or partial clone doesn't hold
+type recoveryObjects struct {
+	local  *GitStyleObjectStore
+	remote integrity.AssetObjectStore
+}
+
+func (r *recoveryObjects) ReadObject(hash string) ([]byte, error) {
+	content, err := r.local.ReadObject(hash)
+	if err == nil || r.remote == nil {
+		return content, err
+	}
+	return r.remote.ReadObject(hash)
+}
+
+// NewWorkingDirectoryManager creates an enhanced working directory manager. Recovery
+// reaches past local history through remote when it isn't nil.
+func NewWorkingDirectoryManager(projectPath string, remote AssetRemote) (*WorkingDirectoryManager, error) {
 	// Initialize UE5 asset tracker
 	assetTracker, err := integrity.NewUE5AssetTracker(projectPath)
 	if err != nil {
@@ -121,6 +146,7 @@ func NewWorkingDirectoryManager(projectPath string) (*WorkingDirectoryManager, e
 	enhanced := &WorkingDirectoryManager{
 		projectPath:      projectPath,
 		assetTracker:     assetTracker,
+		formats:          analyzer.DefaultRegistry(),
 		integrityChecker: integrityChecker,
 		corruptionLog:    corruptionLog,
 		autoRepair:       true, // Enable auto-repair by default
@@ -130,6 +156,20 @@ func NewWorkingDirectoryManager(projectPath string) (*WorkingDirectoryManager, e
 	// Load existing corruption log
 	enhanced.corruptionLog.Load()
 
+	// Recover corrupted assets from the local object store and commit history
+	vcsPath := filepath.Join(projectPath, ".vcs")
+	objectStore, err := NewGitStyleObjectStore(vcsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object store: %w", err)
+	}
+	commitStore := NewGitStyleCommitStore(vcsPath, objectStore, nil)
+	objects := &recoveryObjects{local: objectStore}
+	if remote != nil {
+		commitStore.SetRemoteHistory(remote)
+		objects.remote = remote
+	}
+	assetTracker.SetRecoverySources(objects, commitStore, corruptionLog)
+
 	// Start background integrity monitoring
 	go enhanced.startBackgroundIntegrityCheck()
 
@@ -402,6 +442,13 @@ func (ewdm *WorkingDirectoryManager) performIntegrityCheck(filePath string) (*Fi
 		}
 	}
 
+	// Checks declared by the file's registered format
+	if ewdm.integrityChecker.checksEnabled["structural"] {
+		if err := ewdm.performFormatChecks(filePath, result); err != nil {
+			fmt.Printf("Format integrity check warning for %s: %v\n", filePath, err)
+		}
+	}
+
 	// UE5-specific checks
 	if ewdm.isUE5Asset(filePath) {
 		if err := ewdm.performUE5SpecificChecks(filePath, result); err != nil {
@@ -445,6 +492,31 @@ func (ewdm *WorkingDirectoryManager) performUE5SpecificChecks(filePath string, r
 	return nil
 }
 
+// performFormatChecks runs the integrity checks the analyzer registry declares for a file's format
+func (ewdm *WorkingDirectoryManager) performFormatChecks(filePath string, result *FileIntegrityStatus) error {
+	format := ewdm.formats.Lookup(filePath, nil)
+	if format == nil || format.Check == nil {
+		return nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(ewdm.projectPath, filePath))
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range ewdm.formats.Check(filePath, content) {
+		result.Issues = append(result.Issues, integrity.BlueprintIssue{
+			IssueID:        fmt.Sprintf("%s_check_%d", format.Name, len(result.Issues)),
+			IssueType:      integrity.IssueLogicError,
+			Severity:       integrity.BlueprintSeverityError,
+			Description:    fmt.Sprintf("%s check failed: %s", format.Name, problem),
+			RecommendedFix: "Restore from backup or re-export from the source tool",
+		})
+	}
+
+	return nil
+}
+
 // attemptRepair attempts to repair a corrupted file
 func (ewdm *WorkingDirectoryManager) attemptRepair(filePath string, integrityStatus *FileIntegrityStatus) error {
 	startTime := time.Now()
@@ -500,17 +572,25 @@ func (ewdm *WorkingDirectoryManager) attemptBlueprintRepair(filePath string) err
 	return nil
 }
 
-// restoreFromBackup restores a file from the most recent backup
+// RestoreLastKnownGood restores a file to its most recent healthy committed revision
+func (ewdm *WorkingDirectoryManager) RestoreLastKnownGood(filePath string) (*integrity.AssetRecovery, error) {
+	return ewdm.assetTracker.RevertToLastKnownGood(filepath.ToSlash(filePath))
+}
+
+// restoreFromBackup restores a file to its most recent healthy committed revision
 func (ewdm *WorkingDirectoryManager) restoreFromBackup(filePath string) error {
-	// This would integrate with your backup system
-	// For now, we'll simulate a successful restore
 	fmt.Printf("🔄 Restoring %s from backup...\n", filePath)
 
-	// In a real implementation, this would:
-	// 1. Find the most recent healthy backup
-	// 2. Copy the backup to the working directory
-	// 3. Update the file index
+	recovery, err := ewdm.RestoreLastKnownGood(filePath)
+	if err != nil {
+		return err
+	}
 
+	commitID := recovery.CommitID
+	if len(commitID) > 8 {
+		commitID = commitID[:8]
+	}
+	fmt.Printf("📦 Restored %s from commit %s\n", filePath, commitID)
 	return nil
 }
 
@@ -580,15 +660,7 @@ func (ewdm *WorkingDirectoryManager) performBackgroundIntegrityCheck() {
 // Helper methods
 
 func (ewdm *WorkingDirectoryManager) isUE5Asset(filePath string) bool {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	ue5Extensions := []string{".uasset", ".umap", ".uexp", ".ubulk"}
-
-	for _, ue5Ext := range ue5Extensions {
-		if ext == ue5Ext {
-			return true
-		}
-	}
-	return false
+	return ewdm.formats.IsEngineFile(filePath, analyzer.ProjectTypeUnreal)
 }
 
 func (ewdm *WorkingDirectoryManager) checkContentIntegrity(filePath string, result *FileIntegrityStatus) error {
@@ -736,10 +808,14 @@ func (ewdm *WorkingDirectoryManager) mapIntegrityToSeverity(status integrity.Int
 	}
 }
 
+// ReportCorruptionTo sends corruption found by integrity checks to a sink
+func (ewdm *WorkingDirectoryManager) ReportCorruptionTo(name string, sink integrity.AlertSink) {
+	ewdm.assetTracker.ReportCorruptionTo(name, sink)
+}
+
 // getAssetRecord gets asset record from tracker
 func (ewdm *WorkingDirectoryManager) getAssetRecord(assetPath string) (*integrity.AssetIntegrityRecord, error) {
-	// Implementation would get record from asset tracker
-	return nil, fmt.Errorf("not implemented")
+	return ewdm.assetTracker.GetRecord(assetPath)
 }
 
 // Result structures
@@ -793,6 +869,24 @@ func (cl *CorruptionLog) Save() error {
 	return os.WriteFile(cl.logPath, data, 0644)
 }
 
+// RecordRecovery logs an asset restored by the version manager
+func (cl *CorruptionLog) RecordRecovery(recovery integrity.AssetRecovery) error {
+	cl.events = append(cl.events, CorruptionLogEntry{
+		Timestamp:      recovery.Timestamp,
+		AssetPath:      recovery.AssetPath,
+		CorruptionType: integrity.CorruptionContent,
+		Severity:       integrity.CorruptionSeverityMedium,
+		DetectedBy:     "version_manager",
+		Description:    fmt.Sprintf("Restored revision %s (%s)", recovery.RestoredHash, recovery.Method),
+		AutoFixed:      true,
+		FixMethod:      recovery.Method,
+		PreviousHash:   recovery.RestoredHash,
+		CorruptedHash:  recovery.PreviousHash,
+		RecoveryTime:   recovery.RecoveryTime,
+	})
+	return cl.Save()
+}
+
 func (cl *CorruptionLog) GetAssetHistory(assetPath string) []CorruptionLogEntry {
 	var history []CorruptionLogEntry
 	for _, event := range cl.events {
@@ -8,10 +8,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Telerallc/gamedev-vcs/internal/analyzer"
 	"github.com/Telerallc/gamedev-vcs/models"
 	"gorm.io/gorm"
 )
 
+// deletedFileMode marks tree entries of deleted files. Files without a content hash are
+// recorded as deletions, so a commit can remove paths as well as change them.
+const deletedFileMode = "000000"
+
 // CommitService handles version control operations
 type CommitService struct {
 	db *gorm.DB
@@ -34,6 +39,9 @@ func (cs *CommitService) CreateCommit(projectID, authorID, message string, files
 			Mode:        "100644", // Regular file
 			Type:        "file",
 		}
+		if file.ContentHash == "" {
+			treeFiles[i].Mode, treeFiles[i].Type = deletedFileMode, "deleted"
+		}
 	}
 
 	// Calculate tree hash
@@ -82,6 +90,7 @@ func (cs *CommitService) CreateCommit(projectID, authorID, message string, files
 				CommitID:    commitHash,
 				Size:        file.Size,
 				MimeType:    file.MimeType,
+				Metadata:    file.Metadata,
 				CreatedAt:   time.Now(),
 			}
 
@@ -101,6 +110,75 @@ func (cs *CommitService) CreateCommit(projectID, authorID, message string, files
 	return createdCommit, nil
 }
 
+// ImportCommit stores a commit that was created offline by a client, keeping its ID, tree
+// hash, parents and timestamp so later pushes and pulls recognize it. The caller checks
+// the tree hash against the files. Importing a commit the project already has is a
+// no-op; a commit ID another project uses is ErrForeignCommit.
+func (cs *CommitService) ImportCommit(commit *models.Commit, files []models.File) error {
+	return cs.db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.Commit
+		if err := tx.Select("id", "project_id").Where("id = ?", commit.ID).Limit(1).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to look up commit: %w", err)
+		}
+		if len(existing) > 0 {
+			if existing[0].ProjectID != commit.ProjectID {
+				return fmt.Errorf("%w: %s", ErrForeignCommit, commit.ID)
+			}
+			return nil
+		}
+
+		treeFiles := make([]models.CommitTreeFile, len(files))
+		for i, file := range files {
+			treeFiles[i] = models.CommitTreeFile{
+				Path:        file.Path,
+				ContentHash: file.ContentHash,
+				Size:        file.Size,
+				Mode:        "100644",
+				Type:        "file",
+			}
+			if file.ContentHash == "" {
+				treeFiles[i].Mode, treeFiles[i].Type = deletedFileMode, "deleted"
+			}
+		}
+
+		// Identical trees from different commits of a project share one row
+		commitTree := &models.CommitTree{
+			ID:        commit.TreeHash,
+			ProjectID: commit.ProjectID,
+			CommitID:  commit.ID,
+			Files:     treeFiles,
+			CreatedAt: commit.CreatedAt,
+		}
+		if err := tx.Where("id = ? AND project_id = ?", commit.TreeHash, commit.ProjectID).FirstOrCreate(commitTree).Error; err != nil {
+			return fmt.Errorf("failed to create commit tree: %w", err)
+		}
+
+		if err := tx.Create(commit).Error; err != nil {
+			return fmt.Errorf("failed to create commit: %w", err)
+		}
+
+		for _, file := range files {
+			fileVersion := &models.FileVersion{
+				ID:          fmt.Sprintf("%s:%s", commit.ID, file.Path),
+				ProjectID:   commit.ProjectID,
+				Path:        file.Path,
+				ContentHash: file.ContentHash,
+				CommitID:    commit.ID,
+				Size:        file.Size,
+				MimeType:    file.MimeType,
+				Metadata:    file.Metadata,
+				CreatedAt:   commit.CreatedAt,
+			}
+
+			if err := tx.Create(fileVersion).Error; err != nil {
+				return fmt.Errorf("failed to create file version: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
 // GetCommitHistory retrieves commit history for a project/branch
 func (cs *CommitService) GetCommitHistory(projectID string, branchName string, limit int) ([]models.Commit, error) {
 	// Get the branch to find the HEAD commit
@@ -128,7 +206,7 @@ func (cs *CommitService) GetCommitHistory(projectID string, branchName string, l
 		visitedCommits[commitID] = true
 
 		var commit models.Commit
-		if err := cs.db.Preload("Author").Where("id = ?", commitID).First(&commit).Error; err != nil {
+		if err := cs.db.Preload("Author").Where("id = ? AND project_id = ?", commitID, projectID).First(&commit).Error; err != nil {
 			continue // Skip missing commits
 		}
 
@@ -150,7 +228,7 @@ func (cs *CommitService) GetCommitByID(commitID string) (*models.Commit, error)
 
 	// Get commit tree separately
 	var tree models.CommitTree
-	if err := cs.db.Where("id = ?", commit.TreeHash).First(&tree).Error; err != nil {
+	if err := cs.db.Where("id = ? AND project_id = ?", commit.TreeHash, commit.ProjectID).First(&tree).Error; err != nil {
 		// Tree not found - this is okay for now
 		tree = models.CommitTree{Files: []models.CommitTreeFile{}}
 	}
@@ -167,21 +245,126 @@ func (cs *CommitService) GetFileAtCommit(commitID, filePath string) (*models.Fil
 	return &fileVersion, nil
 }
 
-// GetFileHistory retrieves the history of a specific file
+// GetFileAsOfCommit retrieves the version of a file visible at a commit, walking back
+// along first parents to the most recent commit that changed the file. A merge commit
+// records its changes against its first parent, so that chain decides what's visible.
+func (cs *CommitService) GetFileAsOfCommit(commitID, filePath string) (*models.FileVersion, error) {
+	visitedCommits := make(map[string]bool)
+	projectID := ""
+
+	for currentID := commitID; currentID != "" && !visitedCommits[currentID]; {
+		visitedCommits[currentID] = true
+
+		var commit models.Commit
+		if err := cs.db.Where("id = ?", currentID).First(&commit).Error; err != nil {
+			if currentID == commitID {
+				return nil, fmt.Errorf("commit not found: %w", err)
+			}
+			break // History is cut off at a missing ancestor
+		}
+		if projectID == "" {
+			projectID = commit.ProjectID
+		} else if commit.ProjectID != projectID {
+			break // Parents never lead into another project's history
+		}
+
+		var fileVersion models.FileVersion
+		if err := cs.db.Where("commit_id = ? AND path = ?", currentID, filePath).First(&fileVersion).Error; err == nil {
+			if fileVersion.IsDeletion() {
+				return nil, fmt.Errorf("file %s was deleted by commit %s", filePath, currentID)
+			}
+			return &fileVersion, nil
+		}
+
+		currentID = firstParent(&commit)
+	}
+
+	return nil, fmt.Errorf("file %s not found at commit %s", filePath, commitID)
+}
+
+// GetTreeAtCommit retrieves every file visible at a commit, sorted by path. Trees only
+// hold the files each commit changed, so the newest version of each path along the
+// commit's first-parent chain wins, and paths whose newest version is a deletion are
+// left out. Merge commits record their changes against their first parent.
+func (cs *CommitService) GetTreeAtCommit(commitID string) ([]models.FileVersion, error) {
+	files := make(map[string]models.FileVersion)
+	visitedCommits := make(map[string]bool)
+	projectID := ""
+
+	for currentID := commitID; currentID != "" && !visitedCommits[currentID]; {
+		visitedCommits[currentID] = true
+
+		var commit models.Commit
+		if err := cs.db.Where("id = ?", currentID).First(&commit).Error; err != nil {
+			if currentID == commitID {
+				return nil, fmt.Errorf("commit not found: %w", err)
+			}
+			break // History is cut off at a missing ancestor
+		}
+		if projectID == "" {
+			projectID = commit.ProjectID
+		} else if commit.ProjectID != projectID {
+			break // Parents never lead into another project's history
+		}
+
+		var versions []models.FileVersion
+		if err := cs.db.Where("commit_id = ?", currentID).Find(&versions).Error; err != nil {
+			return nil, fmt.Errorf("failed to get files of commit %s: %w", currentID, err)
+		}
+		for _, version := range versions {
+			if _, seen := files[version.Path]; !seen {
+				files[version.Path] = version
+			}
+		}
+
+		currentID = firstParent(&commit)
+	}
+
+	tree := make([]models.FileVersion, 0, len(files))
+	for _, file := range files {
+		if !file.IsDeletion() {
+			tree = append(tree, file)
+		}
+	}
+	sort.Slice(tree, func(i, j int) bool {
+		return tree[i].Path < tree[j].Path
+	})
+
+	return tree, nil
+}
+
+// firstParent returns the parent a commit's changes are recorded against
+func firstParent(commit *models.Commit) string {
+	if len(commit.ParentIDs) == 0 {
+		return ""
+	}
+	return commit.ParentIDs[0]
+}
+
+// GetFileHistory retrieves the history of a specific file, newest first, with how each
+// version's source metadata changed from the version before it
 func (cs *CommitService) GetFileHistory(projectID, filePath string, limit int) ([]models.FileVersion, error) {
 	var fileVersions []models.FileVersion
-	
+
+	// One extra version gives the oldest returned version something to compare against
 	err := cs.db.
 		Preload("Commit.Author").
 		Where("project_id = ? AND path = ?", projectID, filePath).
 		Order("created_at DESC").
-		Limit(limit).
+		Limit(limit + 1).
 		Find(&fileVersions).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file history: %w", err)
 	}
 
+	for i := 0; i+1 < len(fileVersions); i++ {
+		fileVersions[i].Changes = analyzer.DescribeVersionChanges(fileVersions[i+1].Metadata, fileVersions[i].Metadata)
+	}
+	if len(fileVersions) > limit {
+		fileVersions = fileVersions[:limit]
+	}
+
 	return fileVersions, nil
 }
 
@@ -220,6 +403,34 @@ func (cs *CommitService) UpdateBranchHead(projectID, branchName, commitID string
 	return cs.db.Save(ref).Error
 }
 
+// MoveBranchHead moves a branch from one commit to another, failing with ErrBranchMoved
+// when the branch no longer points where the caller last saw it
+func (cs *CommitService) MoveBranchHead(projectID, branchName, from, to string) error {
+	result := cs.db.Model(&models.Branch{}).
+		Where("project_id = ? AND name = ? AND last_commit = ?", projectID, branchName, from).
+		Update("last_commit", to)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update branch: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrBranchMoved
+	}
+
+	ref := &models.Ref{
+		ID:        fmt.Sprintf("refs/heads/%s:%s", branchName, projectID),
+		ProjectID: projectID,
+		Name:      fmt.Sprintf("refs/heads/%s", branchName),
+		Type:      "branch",
+		CommitID:  to,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := cs.db.Save(ref).Error; err != nil {
+		return fmt.Errorf("failed to update branch ref: %w", err)
+	}
+	return nil
+}
+
 // DiffCommits compares two commits and returns the differences
 func (cs *CommitService) DiffCommits(fromCommitID, toCommitID string) (*CommitDiff, error) {
 	fromCommit, err := cs.GetCommitByID(fromCommitID)
@@ -234,8 +445,8 @@ func (cs *CommitService) DiffCommits(fromCommitID, toCommitID string) (*CommitDi
 
 	// Get commit trees
 	var fromTree, toTree models.CommitTree
-	cs.db.Where("id = ?", fromCommit.TreeHash).First(&fromTree)
-	cs.db.Where("id = ?", toCommit.TreeHash).First(&toTree)
+	cs.db.Where("id = ? AND project_id = ?", fromCommit.TreeHash, fromCommit.ProjectID).First(&fromTree)
+	cs.db.Where("id = ? AND project_id = ?", toCommit.TreeHash, toCommit.ProjectID).First(&toTree)
 
 	// Create file maps for comparison
 	fromFiles := make(map[string]models.CommitTreeFile)
@@ -0,0 +1,361 @@
+package version
+
+import (
+	"container/heap"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Telerallc/gamedev-vcs/models"
+)
+
+// maxHistoryScan bounds how many commits one history page examines, so a filter that
+// rarely matches returns a cursor instead of walking the whole project in one request
+const maxHistoryScan = 5000
+
+// historyBatchSize is how many commits the walk loads per query
+const historyBatchSize = 500
+
+// HistoryQuery selects commits for a log. Empty fields don't filter.
+type HistoryQuery struct {
+	Branch string    // Branch whose head the walk starts from
+	From   []string  // Commits to start from instead of the branch head
+	Author string    // Substring of the author's name, username, email or ID
+	Since  time.Time // Only commits made at or after this time
+	Until  time.Time // Only commits made at or before this time
+	Grep   string    // Substring of the commit message
+	Paths  []string  // Only commits that changed one of these files or folders
+	Limit  int       // Maximum number of commits to return
+	Cursor string    // Where a previous page stopped
+}
+
+// HistoryPage is one page of a history query, newest commits first
+type HistoryPage struct {
+	Commits    []models.Commit `json:"commits"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// QueryHistory walks the history from the query's starting commits, newest first, and
+// returns the commits matching its filters. Merges are followed through all parents.
+// NextCursor is set when the walk stopped before running out of history.
+func (cs *CommitService) QueryHistory(projectID string, query HistoryQuery) (*HistoryPage, error) {
+	start := query.From
+	var horizon *historyPosition
+	if query.Cursor != "" {
+		cursor, err := decodeHistoryCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		start, horizon = cursor.frontier, &cursor.horizon
+	} else if len(start) == 0 {
+		var branch models.Branch
+		if err := cs.db.Where("project_id = ? AND name = ?", projectID, query.Branch).First(&branch).Error; err != nil {
+			return nil, fmt.Errorf("branch not found: %w", err)
+		}
+		if branch.LastCommit == "" {
+			return &HistoryPage{Commits: []models.Commit{}}, nil
+		}
+		start = []string{branch.LastCommit}
+	}
+
+	loader := &historyLoader{
+		cs:        cs,
+		projectID: projectID,
+		withPaths: len(query.Paths) > 0,
+		commits:   make(map[string]*models.Commit),
+		paths:     make(map[string][]string),
+	}
+	if horizon != nil {
+		loader.last = *horizon
+	}
+	if err := loader.loadStart(start); err != nil {
+		return nil, err
+	}
+
+	page := &HistoryPage{Commits: []models.Commit{}}
+	queue := &commitQueue{}
+	queued := make(map[string]bool)
+	push := func(commitID string) error {
+		if queued[commitID] {
+			return nil
+		}
+		queued[commitID] = true
+
+		commit, err := loader.get(commitID)
+		if err != nil || commit == nil {
+			return err // Skip missing commits
+		}
+		heap.Push(queue, *commit)
+		return nil
+	}
+	for _, commitID := range start {
+		if err := push(commitID); err != nil {
+			return nil, err
+		}
+	}
+
+	scanned := 0
+	for queue.Len() > 0 {
+		if len(page.Commits) >= query.Limit || scanned >= maxHistoryScan {
+			page.NextCursor = encodeHistoryCursor(queue.ids(), loader.last)
+			break
+		}
+
+		commit := heap.Pop(queue).(models.Commit)
+		scanned++
+		loader.last = historyPosition{Time: commit.CreatedAt, ID: commit.ID}
+		for _, parentID := range commit.ParentIDs {
+			if err := push(parentID); err != nil {
+				return nil, err
+			}
+		}
+
+		// An earlier page already examined everything ordered before its horizon; walking
+		// through it again is only needed to reach what lies behind it
+		if horizon != nil && !horizon.before(commit.CreatedAt, commit.ID) {
+			continue
+		}
+		if matchesHistoryQuery(&commit, loader.paths[commit.ID], query) {
+			page.Commits = append(page.Commits, commit)
+		}
+	}
+
+	return page, nil
+}
+
+// historyLoader loads the commits a history walk visits in batches: the project's commits
+// are read newest first, historyBatchSize at a time, so a walk down a branch costs one
+// query per batch instead of one per commit. Commits outside the loaded range (clock skew
+// or other branches) are fetched one by one.
+type historyLoader struct {
+	cs        *CommitService
+	projectID string
+	withPaths bool
+
+	commits map[string]*models.Commit
+	paths   map[string][]string // Files each loaded commit changed, when filtering by path
+	next    *historyPosition    // Where the next batch starts; nil once history is exhausted
+	last    historyPosition     // The last commit the walk examined
+}
+
+// loadStart loads the walk's starting commits and positions the batches behind the newest one
+func (l *historyLoader) loadStart(start []string) error {
+	var commits []models.Commit
+	if err := l.cs.db.Preload("Author").Where("id IN ? AND project_id = ?", start, l.projectID).Find(&commits).Error; err != nil {
+		return fmt.Errorf("failed to load commits: %w", err)
+	}
+	if err := l.add(commits); err != nil {
+		return err
+	}
+
+	for _, commit := range commits {
+		if l.next == nil || l.next.before(commit.CreatedAt, commit.ID) {
+			l.next = &historyPosition{Time: commit.CreatedAt, ID: commit.ID}
+		}
+	}
+	return nil
+}
+
+// get returns a commit of the project, loading the next batch when it isn't loaded yet.
+// A nil commit means it doesn't exist.
+func (l *historyLoader) get(commitID string) (*models.Commit, error) {
+	if commit, ok := l.commits[commitID]; ok {
+		return commit, nil
+	}
+
+	// Parents are usually in the next batch; anything else is fetched on its own
+	if l.next != nil {
+		if err := l.loadBatch(); err != nil {
+			return nil, err
+		}
+		if commit, ok := l.commits[commitID]; ok {
+			return commit, nil
+		}
+	}
+
+	var commits []models.Commit
+	if err := l.cs.db.Preload("Author").Where("id = ? AND project_id = ?", commitID, l.projectID).Find(&commits).Error; err != nil {
+		return nil, fmt.Errorf("failed to load commit %s: %w", commitID, err)
+	}
+	if err := l.add(commits); err != nil {
+		return nil, err
+	}
+	return l.commits[commitID], nil
+}
+
+// loadBatch loads the next historyBatchSize commits of the project, newest first
+func (l *historyLoader) loadBatch() error {
+	var commits []models.Commit
+	err := l.cs.db.Preload("Author").
+		Where("project_id = ? AND (created_at < ? OR (created_at = ? AND id > ?))", l.projectID, l.next.Time, l.next.Time, l.next.ID).
+		Order("created_at DESC, id ASC").
+		Limit(historyBatchSize).
+		Find(&commits).Error
+	if err != nil {
+		return fmt.Errorf("failed to load commits: %w", err)
+	}
+
+	if len(commits) < historyBatchSize {
+		l.next = nil
+	} else {
+		oldest := commits[len(commits)-1]
+		l.next = &historyPosition{Time: oldest.CreatedAt, ID: oldest.ID}
+	}
+	return l.add(commits)
+}
+
+// add records loaded commits and, when filtering by path, the files they changed
+func (l *historyLoader) add(commits []models.Commit) error {
+	ids := make([]string, 0, len(commits))
+	for i := range commits {
+		if _, loaded := l.commits[commits[i].ID]; loaded {
+			continue
+		}
+		l.commits[commits[i].ID] = &commits[i]
+		ids = append(ids, commits[i].ID)
+	}
+	if !l.withPaths || len(ids) == 0 {
+		return nil
+	}
+
+	var changed []struct {
+		CommitID string
+		Path     string
+	}
+	if err := l.cs.db.Model(&models.FileVersion{}).Select("commit_id, path").Where("commit_id IN ?", ids).Find(&changed).Error; err != nil {
+		return fmt.Errorf("failed to load changed files: %w", err)
+	}
+	for _, file := range changed {
+		l.paths[file.CommitID] = append(l.paths[file.CommitID], file.Path)
+	}
+	return nil
+}
+
+func matchesHistoryQuery(commit *models.Commit, changed []string, query HistoryQuery) bool {
+	if !query.Since.IsZero() && commit.CreatedAt.Before(query.Since) {
+		return false
+	}
+	if !query.Until.IsZero() && commit.CreatedAt.After(query.Until) {
+		return false
+	}
+	if query.Grep != "" && !containsFold(commit.Message, query.Grep) {
+		return false
+	}
+	if query.Author != "" {
+		author := commit.Author
+		if !containsFold(author.Name, query.Author) && !containsFold(author.Username, query.Author) &&
+			!containsFold(author.Email, query.Author) && commit.AuthorID != query.Author {
+			return false
+		}
+	}
+	if len(query.Paths) == 0 {
+		return true
+	}
+
+	for _, path := range changed {
+		if MatchesPathFilter(path, query.Paths) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesPathFilter reports whether a file is one of paths or inside one of them.
+// A path ending in a slash, or naming a folder, matches everything below it.
+func MatchesPathFilter(filePath string, paths []string) bool {
+	for _, path := range paths {
+		path = strings.TrimSuffix(path, "/")
+		if path == "" || path == "." || filePath == path || strings.HasPrefix(filePath, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// historyPosition is a place in the walk's newest-first order
+type historyPosition struct {
+	Time time.Time
+	ID   string
+}
+
+// before reports whether the position comes before a commit in newest-first order
+func (p historyPosition) before(createdAt time.Time, id string) bool {
+	if !p.Time.Equal(createdAt) {
+		return p.Time.After(createdAt)
+	}
+	return p.ID < id
+}
+
+// historyCursor is where a page stopped: the commits queued but not yet examined, and the
+// last commit examined. Commits ordered before it were examined by earlier pages.
+type historyCursor struct {
+	frontier []string
+	horizon  historyPosition
+}
+
+func encodeHistoryCursor(frontier []string, horizon historyPosition) string {
+	data := fmt.Sprintf("%d:%s|%s", horizon.Time.UnixNano(), horizon.ID, strings.Join(frontier, ","))
+	return base64.RawURLEncoding.EncodeToString([]byte(data))
+}
+
+func decodeHistoryCursor(cursor string) (*historyCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	position, frontier, ok := strings.Cut(string(data), "|")
+	if !ok || frontier == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	nanos, id, ok := strings.Cut(position, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &historyCursor{
+		frontier: strings.Split(frontier, ","),
+		horizon:  historyPosition{Time: time.Unix(0, unixNano), ID: id},
+	}, nil
+}
+
+// commitQueue orders commits newest first, breaking ties by ID so pages are stable
+type commitQueue []models.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	if !q[i].CreatedAt.Equal(q[j].CreatedAt) {
+		return q[i].CreatedAt.After(q[j].CreatedAt)
+	}
+	return q[i].ID < q[j].ID
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(models.Commit)) }
+
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return commit
+}
+
+func (q commitQueue) ids() []string {
+	ids := make([]string, len(q))
+	for i, commit := range q {
+		ids[i] = commit.ID
+	}
+	return ids
+}
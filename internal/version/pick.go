@@ -0,0 +1,237 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Telerallc/gamedev-vcs/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrMergeCommit is returned when asked to revert or cherry-pick a merge
+	ErrMergeCommit = errors.New("merge commits can't be reverted or cherry-picked")
+	// ErrNothingToPick is returned when a revert or cherry-pick wouldn't change any file
+	ErrNothingToPick = errors.New("nothing to apply")
+	// ErrBranchMoved is returned when a branch got new commits after a pick or push was checked
+	ErrBranchMoved = errors.New("the branch moved while the change was being applied")
+	// ErrForeignCommit is returned when an imported commit's ID belongs to another project
+	ErrForeignCommit = errors.New("commit ID is used by another project")
+)
+
+// PickConflict is a file a revert or cherry-pick can't apply because the target branch
+// changed it independently
+type PickConflict struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"` // Content hash the change was made against
+	Actual   string `json:"actual"`   // Content hash on the branch; empty when it's missing
+}
+
+// PickPlan is what reverting or cherry-picking a commit onto a branch would commit
+type PickPlan struct {
+	Source    models.Commit  `json:"source"`
+	Branch    string         `json:"branch"`
+	Head      string         `json:"head"`
+	Message   string         `json:"message"`
+	Files     []models.File  `json:"files"`
+	Conflicts []PickConflict `json:"conflicts,omitempty"`
+}
+
+// HasConflicts reports whether the plan can't be applied as is
+func (p *PickPlan) HasConflicts() bool {
+	return len(p.Conflicts) > 0
+}
+
+// pickedFile is one file a commit changed, with its version before and after the commit
+type pickedFile struct {
+	before  *models.FileVersion // nil when the commit added the file
+	after   models.FileVersion  // a deletion when the commit deleted the file
+	current *models.FileVersion // Version on the target branch; nil when it's missing
+}
+
+// PlanRevert works out the commit that undoes commitID on a branch: every file the
+// commit changed goes back to its version in the commit's parent, files it added are
+// deleted and files it deleted come back. A file the branch changed again since is a
+// conflict.
+func (cs *CommitService) PlanRevert(projectID, commitID, branch string) (*PickPlan, error) {
+	plan, files, err := cs.planPick(projectID, commitID, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		current := versionHash(file.current)
+		if current == versionHash(file.before) {
+			continue // Already back to the old version
+		}
+		if current != file.after.ContentHash {
+			plan.Conflicts = append(plan.Conflicts, PickConflict{Path: file.after.Path, Expected: file.after.ContentHash, Actual: current})
+			continue
+		}
+		if file.before == nil {
+			plan.Files = append(plan.Files, deletedFile(projectID, branch, file.after.Path))
+			continue
+		}
+		plan.Files = append(plan.Files, fileFromVersion(projectID, branch, file.before))
+	}
+
+	subject := strings.SplitN(strings.TrimSpace(plan.Source.Message), "\n", 2)[0]
+	plan.Message = fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.", subject, plan.Source.ID)
+	return plan, cs.checkPickable(plan)
+}
+
+// PlanCherryPick works out the commit that applies commitID's changes to a branch.
+// A file whose version on the branch differs from its version in the commit's parent
+// is a conflict.
+func (cs *CommitService) PlanCherryPick(projectID, commitID, branch string) (*PickPlan, error) {
+	plan, files, err := cs.planPick(projectID, commitID, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		current := versionHash(file.current)
+		if current == file.after.ContentHash {
+			continue // The branch already has this version
+		}
+		if current != versionHash(file.before) {
+			plan.Conflicts = append(plan.Conflicts, PickConflict{Path: file.after.Path, Expected: versionHash(file.before), Actual: current})
+			continue
+		}
+		plan.Files = append(plan.Files, fileFromVersion(projectID, branch, &file.after))
+	}
+
+	plan.Message = fmt.Sprintf("%s\n\n(cherry picked from commit %s)", strings.TrimSpace(plan.Source.Message), plan.Source.ID)
+	return plan, cs.checkPickable(plan)
+}
+
+// ApplyPick commits a conflict-free plan on top of its branch head and moves the branch.
+// The commit and the branch move happen in one transaction, and the branch only moves if
+// it still points at the plan's head; otherwise nothing is committed and ErrBranchMoved
+// is returned.
+func (cs *CommitService) ApplyPick(projectID, authorID string, plan *PickPlan) (*models.Commit, error) {
+	if plan.HasConflicts() {
+		return nil, fmt.Errorf("%d files conflict", len(plan.Conflicts))
+	}
+
+	var parents []string
+	if plan.Head != "" {
+		parents = []string{plan.Head}
+	}
+
+	var commit *models.Commit
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		txService := NewCommitService(tx)
+		var err error
+		commit, err = txService.CreateCommit(projectID, authorID, plan.Message, plan.Files, parents)
+		if err != nil {
+			return err
+		}
+		return txService.MoveBranchHead(projectID, plan.Branch, plan.Head, commit.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+// planPick loads a commit, the files it changed and their versions before it and on the
+// target branch
+func (cs *CommitService) planPick(projectID, commitID, branchName string) (*PickPlan, []pickedFile, error) {
+	var source models.Commit
+	if err := cs.db.Preload("Author").Where("id = ? AND project_id = ?", commitID, projectID).First(&source).Error; err != nil {
+		return nil, nil, fmt.Errorf("commit not found: %w", err)
+	}
+	if len(source.ParentIDs) > 1 {
+		return nil, nil, ErrMergeCommit
+	}
+
+	var branch models.Branch
+	if err := cs.db.Where("project_id = ? AND name = ?", projectID, branchName).First(&branch).Error; err != nil {
+		return nil, nil, fmt.Errorf("branch not found: %w", err)
+	}
+
+	var changed []models.FileVersion
+	if err := cs.db.Where("commit_id = ?", source.ID).Find(&changed).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load files of commit: %w", err)
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Path < changed[j].Path })
+
+	// Both trees are loaded once; a file missing from one is absent or deleted there
+	var before, current map[string]*models.FileVersion
+	var err error
+	if len(source.ParentIDs) == 1 {
+		if before, err = cs.treeByPath(source.ParentIDs[0]); err != nil {
+			return nil, nil, err
+		}
+	}
+	if branch.LastCommit != "" {
+		if current, err = cs.treeByPath(branch.LastCommit); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	files := make([]pickedFile, 0, len(changed))
+	for _, version := range changed {
+		files = append(files, pickedFile{
+			before:  before[version.Path],
+			after:   version,
+			current: current[version.Path],
+		})
+	}
+
+	plan := &PickPlan{
+		Source: source,
+		Branch: branchName,
+		Head:   branch.LastCommit,
+		Files:  []models.File{},
+	}
+	return plan, files, nil
+}
+
+// treeByPath returns the files of a commit's snapshot by path
+func (cs *CommitService) treeByPath(commitID string) (map[string]*models.FileVersion, error) {
+	tree, err := cs.GetTreeAtCommit(commitID)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*models.FileVersion, len(tree))
+	for i := range tree {
+		files[tree[i].Path] = &tree[i]
+	}
+	return files, nil
+}
+
+func (cs *CommitService) checkPickable(plan *PickPlan) error {
+	if len(plan.Files) > 0 || plan.HasConflicts() {
+		return nil
+	}
+	return fmt.Errorf("%w: %s already has these changes", ErrNothingToPick, plan.Branch)
+}
+
+// versionHash returns a version's content hash, empty when the file is missing or deleted
+func versionHash(version *models.FileVersion) string {
+	if version == nil {
+		return ""
+	}
+	return version.ContentHash
+}
+
+func fileFromVersion(projectID, branch string, version *models.FileVersion) models.File {
+	return models.File{
+		ProjectID:   projectID,
+		Path:        version.Path,
+		ContentHash: version.ContentHash,
+		Size:        version.Size,
+		MimeType:    version.MimeType,
+		Metadata:    version.Metadata,
+		Branch:      branch,
+	}
+}
+
+// deletedFile is a file a commit deletes: a path without content
+func deletedFile(projectID, branch, path string) models.File {
+	return models.File{ProjectID: projectID, Path: path, Branch: branch}
+}
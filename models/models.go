@@ -154,6 +154,26 @@ type Project struct {
 	Stats *ProjectStats `json:"stats,omitempty" gorm:"-"`
 }
 
+// ProjectSecret is a credential of a project integration, such as the signing secret of a
+// corruption alert webhook. It's kept out of Project.Settings, which every member can read.
+type ProjectSecret struct {
+	ProjectID string    `json:"project_id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"primaryKey"` // e.g. "webhook:alerts"
+	Value     string    `json:"-"`
+	UpdatedBy string    `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookSecretName names the secret of a corruption alert webhook; unnamed webhooks are
+// known by their 1-based position in the project's settings
+func WebhookSecretName(name string, position int) string {
+	if name == "" {
+		name = fmt.Sprintf("webhook_%d", position)
+	}
+	return "webhook:" + name
+}
+
 // ProjectMember represents project membership and permissions
 type ProjectMember struct {
 	ID        string    `json:"id" gorm:"primaryKey"`
@@ -190,6 +210,7 @@ type File struct {
 	ContentHash    string     `json:"content_hash"`
 	Size           int64      `json:"size"`
 	MimeType       string     `json:"mime_type"`
+	Metadata       JSON       `json:"metadata,omitempty" gorm:"type:jsonb"` // Source art metadata (texture size, FBX contents)
 	Branch         string     `json:"branch" gorm:"default:main"`
 	IsLocked       bool       `json:"is_locked" gorm:"default:false"`
 	LockedBy       *string    `json:"locked_by"`
@@ -220,8 +241,8 @@ type Commit struct {
 
 // CommitTree represents the file tree at a specific commit
 type CommitTree struct {
-	ID        string           `json:"id" gorm:"primaryKey"` // Tree hash
-	ProjectID string           `json:"project_id"`
+	ID        string           `json:"id" gorm:"primaryKey"`         // Tree hash
+	ProjectID string           `json:"project_id" gorm:"primaryKey"` // Projects with identical trees keep their own rows
 	CommitID  string           `json:"commit_id"`
 	Files     []CommitTreeFile `json:"files" gorm:"type:jsonb"`
 	CreatedAt time.Time        `json:"created_at"`
@@ -236,7 +257,13 @@ type CommitTreeFile struct {
 	ContentHash string `json:"content_hash"`
 	Size        int64  `json:"size"`
 	Mode        string `json:"mode"` // file permissions
-	Type        string `json:"type"` // file, directory, symlink
+	Type        string `json:"type"` // file, directory, symlink, or deleted
+}
+
+// IsDeletion reports whether the entry records the file's deletion. Trees hold the files
+// a commit changed, so a deletion hides the older versions of its path.
+func (f CommitTreeFile) IsDeletion() bool {
+	return f.Type == "deleted"
 }
 
 // Ref represents a git-like reference (branch HEAD, tags, etc.)
@@ -280,6 +307,8 @@ type FileVersion struct {
 	CommitID    string    `json:"commit_id"`
 	Size        int64     `json:"size"`
 	MimeType    string    `json:"mime_type"`
+	Metadata    JSON      `json:"metadata,omitempty" gorm:"type:jsonb"` // Source art metadata (texture size, FBX contents)
+	Changes     []string  `json:"changes,omitempty" gorm:"-"`           // How Metadata changed from the previous version
 	CreatedAt   time.Time `json:"created_at"`
 
 	// Relations
@@ -287,6 +316,23 @@ type FileVersion struct {
 	Commit  Commit  `json:"commit" gorm:"foreignKey:CommitID"`
 }
 
+// IsDeletion reports whether the version records the file's deletion rather than content
+func (fv FileVersion) IsDeletion() bool {
+	return fv.ContentHash == ""
+}
+
+// ProjectObject records that an object was uploaded to a project. The content store is
+// shared by every project, so an object existing there doesn't mean the project may use it.
+type ProjectObject struct {
+	ProjectID   string    `json:"project_id" gorm:"primaryKey"`
+	ContentHash string    `json:"content_hash" gorm:"primaryKey"`
+	UploadedBy  string    `json:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Relations
+	Project Project `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+}
+
 // ProjectStats represents computed project statistics
 type ProjectStats struct {
 	TotalFiles   int64  `json:"total_files"`
@@ -374,6 +420,94 @@ type FileEvent struct {
 	User    User    `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// CorruptionAlert is the server-side log of corruption events reported for a project
+type CorruptionAlert struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	ProjectID      string    `json:"project_id" gorm:"index"`
+	EventID        string    `json:"event_id" gorm:"uniqueIndex"`
+	Severity       string    `json:"severity" gorm:"index"` // low, medium, high, critical
+	CorruptionType string    `json:"corruption_type"`
+	AffectedAssets JSON      `json:"affected_assets" gorm:"type:jsonb"` // {"paths": [...]}
+	Details        JSON      `json:"details" gorm:"type:jsonb"`         // Root cause, detection method, recovery status
+	DeliveredTo    JSON      `json:"delivered_to" gorm:"type:jsonb"`    // {"sinks": [...]}
+	ReportedBy     string    `json:"reported_by" gorm:"index"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+
+	// Relations
+	Project Project `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+}
+
+// FsckRun is a server-side integrity check of one project, run in the background
+type FsckRun struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	ProjectID  string     `json:"project_id" gorm:"index"`
+	Status     string     `json:"status" gorm:"index"` // running, completed, failed
+	Healthy    bool       `json:"healthy"`
+	Reports    JSON       `json:"reports" gorm:"type:jsonb"` // {"content": ..., "history": ...}
+	Error      string     `json:"error,omitempty"`
+	StartedBy  string     `json:"started_by" gorm:"index"`
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	// Relations
+	Project Project `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+}
+
+// Shelf is a named snapshot of someone's uncommitted work, kept on the server so it can
+// be picked up again later or reviewed by someone else
+type Shelf struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	ProjectID  string     `json:"project_id" gorm:"index"`
+	UserID     string     `json:"user_id" gorm:"index"`
+	Name       string     `json:"name"`
+	Branch     string     `json:"branch"`
+	BaseCommit string     `json:"base_commit"` // Commit the work was based on
+	Files      ShelfFiles `json:"files" gorm:"type:jsonb"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relations
+	Project Project `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	User    User    `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// ShelfFile is one file of a shelf
+type ShelfFile struct {
+	Path        string `json:"path"`
+	ContentHash string `json:"content_hash,omitempty"` // Empty when the file was deleted
+	Size        int64  `json:"size"`
+	Staged      bool   `json:"staged"`
+	Deleted     bool   `json:"deleted,omitempty"`
+}
+
+// ShelfFiles is the JSONB list of files of a shelf
+type ShelfFiles []ShelfFile
+
+// Scan implements the sql.Scanner interface for ShelfFiles
+func (f *ShelfFiles) Scan(value interface{}) error {
+	if value == nil {
+		*f = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return fmt.Errorf("cannot scan %T into ShelfFiles", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for ShelfFiles
+func (f ShelfFiles) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	return json.Marshal(f)
+}
+
 // TableName methods for GORM
 func (User) TableName() string               { return "users" }
 func (Account) TableName() string            { return "accounts" }
@@ -392,3 +526,6 @@ func (CommitTree) TableName() string         { return "commit_trees" }
 func (Ref) TableName() string                { return "refs" }
 func (Tag) TableName() string                { return "tags" }
 func (FileVersion) TableName() string        { return "file_versions" }
+func (CorruptionAlert) TableName() string    { return "corruption_alerts" }
+func (FsckRun) TableName() string            { return "fsck_runs" }
+func (Shelf) TableName() string              { return "shelves" }